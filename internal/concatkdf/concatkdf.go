@@ -9,10 +9,21 @@ import (
 	"github.com/pkg/errors"
 )
 
+// MaxRounds is the maximum number of hash rounds that the KDF may
+// compute, as the round counter is a 32 bit integer. The total number
+// of bytes that can be read from a KDF is therefore limited to
+// MaxRounds times the size of the hash
+const MaxRounds = 1<<32 - 1
+
+// ErrOutputTooLong is returned by Read when the requested number of
+// bytes exceeds what the KDF is able to produce
+var ErrOutputTooLong = errors.New(`concatkdf: requested output exceeds the maximum length`)
+
 type KDF struct {
 	buf       []byte
 	hash      crypto.Hash
 	otherinfo []byte
+	round     uint64 // the round to compute next, starting from 1
 	z         []byte
 }
 
@@ -40,18 +51,29 @@ func New(hash crypto.Hash, alg, Z, apu, apv, pubinfo, privinfo []byte) *KDF {
 	return &KDF{
 		hash:      hash,
 		otherinfo: concat,
+		round:     1,
 		z:         Z,
 	}
 }
 
+// Read fills out with the derived key material. Consecutive calls
+// return consecutive portions of the same key stream. If out is longer
+// than the remaining key stream (see MaxRounds), ErrOutputTooLong is
+// returned without reading anything
 func (k *KDF) Read(out []byte) (int, error) {
-	var round uint32 = 1
+	if remaining := (MaxRounds-k.round+1)*uint64(k.hash.Size()) + uint64(len(k.buf)); uint64(len(out)) > remaining {
+		return 0, errors.Wrapf(ErrOutputTooLong, `requested %d bytes, but only %d bytes remain`, len(out), remaining)
+	}
+
+	var roundbuf [4]byte
 	h := k.hash.New()
 
 	for len(out) > len(k.buf) {
 		h.Reset()
 
-		if err := binary.Write(h, binary.BigEndian, round); err != nil {
+		// binary.Write would allocate for each round
+		binary.BigEndian.PutUint32(roundbuf[:], uint32(k.round))
+		if _, err := h.Write(roundbuf[:]); err != nil {
 			return 0, errors.Wrap(err, "failed to write round using kdf")
 		}
 		if _, err := h.Write(k.z); err != nil {
@@ -61,8 +83,8 @@ func (k *KDF) Read(out []byte) (int, error) {
 			return 0, errors.Wrap(err, "failed to write other info using kdf")
 		}
 
-		k.buf = append(k.buf, h.Sum(nil)...)
-		round++
+		k.buf = h.Sum(k.buf)
+		k.round++
 	}
 
 	n := copy(out, k.buf[:len(out)])
@@ -2,6 +2,7 @@ package concatkdf
 
 import (
 	"crypto"
+	"errors"
 	"testing"
 
 	"github.com/lestrrat-go/jwx/jwa"
@@ -40,3 +41,55 @@ func TestAppendix(t *testing.T) {
 		return
 	}
 }
+
+func TestRead(t *testing.T) {
+	newKDF := func() *KDF {
+		return New(crypto.SHA256, []byte(jwa.A256GCM.String()), []byte("shared secret"), nil, nil, []byte{0, 0, 1, 0}, nil)
+	}
+
+	t.Run("Consecutive reads", func(t *testing.T) {
+		expected := make([]byte, 100)
+		if _, err := newKDF().Read(expected); !assert.NoError(t, err, `kdf.Read should succeed`) {
+			return
+		}
+
+		// reads spanning multiple rounds should continue the key stream
+		kdf := newKDF()
+		out := make([]byte, 0, 100)
+		for _, size := range []int{10, 40, 50} {
+			buf := make([]byte, size)
+			if _, err := kdf.Read(buf); !assert.NoError(t, err, `kdf.Read should succeed`) {
+				return
+			}
+			out = append(out, buf...)
+		}
+		if !assert.Equal(t, expected, out, `key stream should match`) {
+			return
+		}
+	})
+	t.Run("Maximum length", func(t *testing.T) {
+		kdf := newKDF()
+		kdf.round = MaxRounds // pretend that all but the last round have been used
+
+		n, err := kdf.Read(make([]byte, crypto.SHA256.Size()+1))
+		if !assert.True(t, errors.Is(err, ErrOutputTooLong), `kdf.Read should fail with ErrOutputTooLong`) {
+			return
+		}
+		if !assert.Equal(t, 0, n, `nothing should be read`) {
+			return
+		}
+
+		n, err = kdf.Read(make([]byte, crypto.SHA256.Size()))
+		if !assert.NoError(t, err, `kdf.Read should succeed`) {
+			return
+		}
+		if !assert.Equal(t, crypto.SHA256.Size(), n, `the last round should be read`) {
+			return
+		}
+
+		_, err = kdf.Read(make([]byte, 1))
+		if !assert.True(t, errors.Is(err, ErrOutputTooLong), `kdf.Read should fail with ErrOutputTooLong`) {
+			return
+		}
+	})
+}
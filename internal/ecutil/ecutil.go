@@ -41,10 +41,9 @@ func ReleaseECPointBuffer(buf []byte) {
 	ecpointBufferPool.Put(&buf)
 }
 
-// AllocECPointBuffer allocates a buffer for the given point in the given
-// curve. This buffer should be released using the ReleaseECPointBuffer
-// function.
-func AllocECPointBuffer(v *big.Int, crv elliptic.Curve) []byte {
+// CurveSize returns the number of bytes required to represent a
+// coordinate (or a private key) of the given curve
+func CurveSize(crv elliptic.Curve) int {
 	// We need to create a buffer that fits the entire curve.
 	// If the curve size is 66, that fits in 9 bytes. If the curve
 	// size is 64, it fits in 8 bytes.
@@ -52,19 +51,32 @@ func AllocECPointBuffer(v *big.Int, crv elliptic.Curve) []byte {
 
 	// For most common cases we know before hand what the byte length
 	// is going to be. optimize
-	var inBytes int
 	switch bits {
 	case 224, 256, 384: // TODO: use constant?
-		inBytes = bits / 8
+		return bits / 8
 	case 521:
-		inBytes = ec521BufferSize
+		return ec521BufferSize
 	default:
-		inBytes = bits / 8
+		inBytes := bits / 8
 		if (bits % 8) != 0 {
 			inBytes++
 		}
+		return inBytes
 	}
+}
 
-	buf := getCrvFixedBuffer(inBytes)
+// AllocECPointBuffer allocates a buffer for the given point in the given
+// curve. This buffer should be released using the ReleaseECPointBuffer
+// function.
+func AllocECPointBuffer(v *big.Int, crv elliptic.Curve) []byte {
+	buf := getCrvFixedBuffer(CurveSize(crv))
 	return bigIntFillBytes(v, buf)
 }
+
+// EncodeCoordinate returns the big-endian representation of v, left
+// padded with zeros to the size of the curve. Unlike AllocECPointBuffer,
+// the returned buffer is not pooled, and may be retained by the caller.
+// v must be non-negative, and must fit in the size of the curve.
+func EncodeCoordinate(v *big.Int, crv elliptic.Curve) []byte {
+	return bigIntFillBytes(v, make([]byte, CurveSize(crv)))
+}
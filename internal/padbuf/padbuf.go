@@ -2,7 +2,10 @@
 // itself so that the buffer size aligns with an arbitrary block size.
 package padbuf
 
-import "errors"
+import (
+	"crypto/subtle"
+	"errors"
+)
 
 type PadBuffer []byte
 
@@ -37,25 +40,30 @@ func (pb PadBuffer) Resize(newlen int) PadBuffer {
 	return PadBuffer(buf)
 }
 
+// Unpad removes the PKCS#7 padding from the buffer. The padding is
+// checked in constant time, so that the time it takes to reject an
+// invalid padding does not reveal where the padding is invalid
 func (pb PadBuffer) Unpad(n int) (PadBuffer, error) {
-	rem := pb.Len() % n
-	if rem != 0 {
+	l := pb.Len()
+	if l == 0 || l%n != 0 {
 		return pb, errors.New("buffer should be multiple block size")
 	}
 
-	last := pb[pb.Len()-1]
+	padlen := int(pb[l-1])
 
-	count := 0
-	for i := pb.Len() - 1; i >= 0; i-- {
-		if pb[i] != last {
-			break
-		}
-		count++
+	// the padding length must be between 1 and n, and the last padlen
+	// bytes must all be equal to padlen. Instead of stopping at the
+	// first mismatch, all of the last n bytes are always examined
+	good := subtle.ConstantTimeLessOrEq(1, padlen) & subtle.ConstantTimeLessOrEq(padlen, n)
+	for i := 0; i < n; i++ {
+		inPadding := subtle.ConstantTimeLessOrEq(i+1, padlen)
+		matches := subtle.ConstantTimeByteEq(pb[l-1-i], byte(padlen))
+		good &= subtle.ConstantTimeSelect(inPadding, matches, 1)
 	}
 
-	if count != int(last) {
+	if good != 1 {
 		return pb, errors.New("invalid padding")
 	}
 
-	return pb[:pb.Len()-int(last)], nil
+	return pb[:l-padlen], nil
 }
@@ -27,3 +27,37 @@ func TestPadBuffer(t *testing.T) {
 		}
 	}
 }
+
+func TestUnpad(t *testing.T) {
+	t.Run("Content that looks like padding", func(t *testing.T) {
+		for _, last := range []byte{0x01, 0x02, 0x0f, 0x10} {
+			buf := make([]byte, 15)
+			for i := range buf {
+				buf[i] = last
+			}
+			pb := PadBuffer(buf).Pad(16)
+			unpadded, err := pb.Unpad(16)
+			if !assert.NoError(t, err, "Unpad should succeed") {
+				return
+			}
+			if !assert.Equal(t, buf, []byte(unpadded), "Unpad should return the original content") {
+				return
+			}
+		}
+	})
+	t.Run("Invalid padding", func(t *testing.T) {
+		testcases := map[string][]byte{
+			"empty":          {},
+			"not aligned":    make([]byte, 15),
+			"zero":           make([]byte, 16),
+			"too long":       append(make([]byte, 15), 17),
+			"mismatch":       append(make([]byte, 14), 3, 2),
+			"mismatch first": append(append(make([]byte, 12), 0x03), 0x04, 0x04, 0x04),
+		}
+		for name, buf := range testcases {
+			if _, err := PadBuffer(buf).Unpad(16); !assert.Error(t, err, "Unpad should fail (%s)", name) {
+				return
+			}
+		}
+	})
+}
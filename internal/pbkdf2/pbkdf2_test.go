@@ -0,0 +1,70 @@
+package pbkdf2_test
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/internal/pbkdf2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKey(t *testing.T) {
+	// Test vectors from https://tools.ietf.org/html/rfc6070, and
+	// the SHA-256 variants from https://stackoverflow.com/a/5136918
+	testcases := []struct {
+		hash     func() hash.Hash
+		password string
+		salt     string
+		iter     int
+		expected string
+	}{
+		{
+			hash:     sha1.New,
+			password: "password",
+			salt:     "salt",
+			iter:     1,
+			expected: "0c60c80f961f0e71f3a9b524af6012062fe037a6",
+		},
+		{
+			hash:     sha1.New,
+			password: "password",
+			salt:     "salt",
+			iter:     4096,
+			expected: "4b007901b765489abead49d926f721d065a429c1",
+		},
+		{
+			hash:     sha1.New,
+			password: "passwordPASSWORDpassword",
+			salt:     "saltSALTsaltSALTsaltSALTsaltSALTsalt",
+			iter:     4096,
+			expected: "3d2eec4fe41c849b80c8d83662c0e44a8b291a964cf2f07038",
+		},
+		{
+			hash:     sha256.New,
+			password: "password",
+			salt:     "salt",
+			iter:     1,
+			expected: "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b",
+		},
+		{
+			hash:     sha256.New,
+			password: "password",
+			salt:     "salt",
+			iter:     4096,
+			expected: "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a",
+		},
+	}
+
+	for _, tc := range testcases {
+		expected, err := hex.DecodeString(tc.expected)
+		if !assert.NoError(t, err, `hex.DecodeString should succeed`) {
+			return
+		}
+		if !assert.Equal(t, expected, pbkdf2.Key([]byte(tc.password), []byte(tc.salt), tc.iter, len(expected), tc.hash), `derived key should match`) {
+			return
+		}
+	}
+}
@@ -0,0 +1,251 @@
+// Package secp256k1 implements the secp256k1 elliptic curve as described
+// in https://www.secg.org/sec2-v2.pdf, section 2.4.1.
+//
+// The standard library only provides curves of the form y² = x³ - 3x + b,
+// and its generic implementation cannot be used for secp256k1, which is
+// of the form y² = x³ + 7. The arithmetic in this package is implemented
+// using math/big, and is NOT constant time: it exists for interoperability
+// with ecosystems that use this curve, and must only be used with public
+// values, i.e. to verify ES256K signatures. Operations involving private
+// keys, such as signing, key generation and ECDH, would leak the secret
+// scalars through timing, and are rejected by the packages that use
+// this curve.
+package secp256k1
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"sync"
+)
+
+// Name is the name of the curve, as used in the "crv" parameter of JWKs
+// (https://tools.ietf.org/html/rfc8812#section-3.1)
+const Name = `secp256k1`
+
+type curve struct {
+	params *elliptic.CurveParams
+}
+
+var initOnce sync.Once
+var secp256k1 curve
+
+func initCurve() {
+	params := &elliptic.CurveParams{Name: Name, BitSize: 256}
+	params.P, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F", 16)
+	params.N, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+	params.B = big.NewInt(7)
+	params.Gx, _ = new(big.Int).SetString("79BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798", 16)
+	params.Gy, _ = new(big.Int).SetString("483ADA7726A3C4655DA4FBFC0E1108A8FD17B448A68554199C47D08FFB10D4B8", 16)
+	secp256k1.params = params
+}
+
+// Curve returns an elliptic.Curve implementing secp256k1.
+// Multiple invocations of this function return the same value,
+// so it can be used for equality checks and switch statements.
+func Curve() elliptic.Curve {
+	initOnce.Do(initCurve)
+	return secp256k1
+}
+
+func (c curve) Params() *elliptic.CurveParams {
+	return c.params
+}
+
+// IsOnCurve reports whether the given (x,y) lies on the curve
+func (c curve) IsOnCurve(x, y *big.Int) bool {
+	p := c.params.P
+	if x.Sign() < 0 || x.Cmp(p) >= 0 || y.Sign() < 0 || y.Cmp(p) >= 0 {
+		return false
+	}
+
+	// y² = x³ + 7
+	y2 := new(big.Int).Mul(y, y)
+	y2.Mod(y2, p)
+
+	x3 := new(big.Int).Mul(x, x)
+	x3.Mul(x3, x)
+	x3.Add(x3, c.params.B)
+	x3.Mod(x3, p)
+
+	return x3.Cmp(y2) == 0
+}
+
+// jacobianPoint represents the point (x/z², y/z³). The point at
+// infinity is represented by z == 0
+type jacobianPoint struct {
+	x, y, z *big.Int
+}
+
+func (c curve) toJacobian(x, y *big.Int) *jacobianPoint {
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return &jacobianPoint{x: new(big.Int), y: new(big.Int), z: new(big.Int)}
+	}
+	return &jacobianPoint{x: new(big.Int).Set(x), y: new(big.Int).Set(y), z: big.NewInt(1)}
+}
+
+func (c curve) toAffine(pt *jacobianPoint) (*big.Int, *big.Int) {
+	if pt.z.Sign() == 0 {
+		return new(big.Int), new(big.Int)
+	}
+
+	p := c.params.P
+	zinv := new(big.Int).ModInverse(pt.z, p)
+	zinv2 := new(big.Int).Mul(zinv, zinv)
+	zinv2.Mod(zinv2, p)
+
+	x := new(big.Int).Mul(pt.x, zinv2)
+	x.Mod(x, p)
+
+	zinv2.Mul(zinv2, zinv)
+	y := new(big.Int).Mul(pt.y, zinv2)
+	y.Mod(y, p)
+	return x, y
+}
+
+// double computes 2*pt, using the "dbl-2009-l" formulas for a = 0
+func (c curve) double(pt *jacobianPoint) *jacobianPoint {
+	if pt.z.Sign() == 0 || pt.y.Sign() == 0 {
+		return &jacobianPoint{x: new(big.Int), y: new(big.Int), z: new(big.Int)}
+	}
+
+	p := c.params.P
+	a := new(big.Int).Mul(pt.x, pt.x)
+	a.Mod(a, p)
+	b := new(big.Int).Mul(pt.y, pt.y)
+	b.Mod(b, p)
+	cc := new(big.Int).Mul(b, b)
+	cc.Mod(cc, p)
+
+	// d = 2*((x+b)² - a - cc)
+	d := new(big.Int).Add(pt.x, b)
+	d.Mul(d, d)
+	d.Sub(d, a)
+	d.Sub(d, cc)
+	d.Lsh(d, 1)
+	d.Mod(d, p)
+
+	e := new(big.Int).Lsh(a, 1)
+	e.Add(e, a)
+	f := new(big.Int).Mul(e, e)
+
+	// x3 = f - 2*d
+	x3 := new(big.Int).Sub(f, new(big.Int).Lsh(d, 1))
+	x3.Mod(x3, p)
+
+	// y3 = e*(d - x3) - 8*cc
+	y3 := new(big.Int).Sub(d, x3)
+	y3.Mul(y3, e)
+	y3.Sub(y3, new(big.Int).Lsh(cc, 3))
+	y3.Mod(y3, p)
+
+	// z3 = 2*y*z
+	z3 := new(big.Int).Mul(pt.y, pt.z)
+	z3.Lsh(z3, 1)
+	z3.Mod(z3, p)
+
+	return &jacobianPoint{x: x3, y: y3, z: z3}
+}
+
+// add computes p1+p2, using the "add-2007-bl" formulas
+func (c curve) add(p1, p2 *jacobianPoint) *jacobianPoint {
+	if p1.z.Sign() == 0 {
+		return p2
+	}
+	if p2.z.Sign() == 0 {
+		return p1
+	}
+
+	p := c.params.P
+	z1z1 := new(big.Int).Mul(p1.z, p1.z)
+	z1z1.Mod(z1z1, p)
+	z2z2 := new(big.Int).Mul(p2.z, p2.z)
+	z2z2.Mod(z2z2, p)
+
+	u1 := new(big.Int).Mul(p1.x, z2z2)
+	u1.Mod(u1, p)
+	u2 := new(big.Int).Mul(p2.x, z1z1)
+	u2.Mod(u2, p)
+
+	s1 := new(big.Int).Mul(p1.y, p2.z)
+	s1.Mul(s1, z2z2)
+	s1.Mod(s1, p)
+	s2 := new(big.Int).Mul(p2.y, p1.z)
+	s2.Mul(s2, z1z1)
+	s2.Mod(s2, p)
+
+	h := new(big.Int).Sub(u2, u1)
+	h.Mod(h, p)
+	r := new(big.Int).Sub(s2, s1)
+	r.Mod(r, p)
+
+	if h.Sign() == 0 {
+		if r.Sign() == 0 {
+			return c.double(p1)
+		}
+		return &jacobianPoint{x: new(big.Int), y: new(big.Int), z: new(big.Int)}
+	}
+	r.Lsh(r, 1)
+
+	i := new(big.Int).Lsh(h, 1)
+	i.Mul(i, i)
+	i.Mod(i, p)
+	j := new(big.Int).Mul(h, i)
+	j.Mod(j, p)
+	v := new(big.Int).Mul(u1, i)
+	v.Mod(v, p)
+
+	// x3 = r² - j - 2*v
+	x3 := new(big.Int).Mul(r, r)
+	x3.Sub(x3, j)
+	x3.Sub(x3, new(big.Int).Lsh(v, 1))
+	x3.Mod(x3, p)
+
+	// y3 = r*(v - x3) - 2*s1*j
+	y3 := new(big.Int).Sub(v, x3)
+	y3.Mul(y3, r)
+	s1.Mul(s1, j)
+	s1.Lsh(s1, 1)
+	y3.Sub(y3, s1)
+	y3.Mod(y3, p)
+
+	// z3 = ((z1 + z2)² - z1z1 - z2z2)*h
+	z3 := new(big.Int).Add(p1.z, p2.z)
+	z3.Mul(z3, z3)
+	z3.Sub(z3, z1z1)
+	z3.Sub(z3, z2z2)
+	z3.Mul(z3, h)
+	z3.Mod(z3, p)
+
+	return &jacobianPoint{x: x3, y: y3, z: z3}
+}
+
+// Add returns the sum of (x1,y1) and (x2,y2)
+func (c curve) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	return c.toAffine(c.add(c.toJacobian(x1, y1), c.toJacobian(x2, y2)))
+}
+
+// Double returns 2*(x,y)
+func (c curve) Double(x1, y1 *big.Int) (*big.Int, *big.Int) {
+	return c.toAffine(c.double(c.toJacobian(x1, y1)))
+}
+
+// ScalarMult returns k*(x,y) where k is a number in big-endian form
+func (c curve) ScalarMult(x1, y1 *big.Int, k []byte) (*big.Int, *big.Int) {
+	base := c.toJacobian(x1, y1)
+	result := &jacobianPoint{x: new(big.Int), y: new(big.Int), z: new(big.Int)}
+	for _, b := range k {
+		for bit := 7; bit >= 0; bit-- {
+			result = c.double(result)
+			if (b>>uint(bit))&1 == 1 {
+				result = c.add(result, base)
+			}
+		}
+	}
+	return c.toAffine(result)
+}
+
+// ScalarBaseMult returns k*G, where G is the base point of the group
+// and k is an integer in big-endian form
+func (c curve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	return c.ScalarMult(c.params.Gx, c.params.Gy, k)
+}
@@ -0,0 +1,86 @@
+package secp256k1_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/internal/secp256k1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurve(t *testing.T) {
+	crv := secp256k1.Curve()
+	params := crv.Params()
+
+	if !assert.True(t, crv.IsOnCurve(params.Gx, params.Gy), `base point should be on the curve`) {
+		return
+	}
+
+	// Known multiples of the base point
+	testcases := []struct {
+		k    int64
+		x, y string
+	}{
+		{
+			k: 2,
+			x: "C6047F9441ED7D6D3045406E95C07CD85C778E4B8CEF3CA7ABAC09B95C709EE5",
+			y: "1AE168FEA63DC339A3C58419466CEAEEF7F632653266D0E1236431A950CFE52A",
+		},
+		{
+			k: 3,
+			x: "F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+			y: "388F7B0F632DE8140FE337E62A37F3566500A99934C2231B6CB9FD7584B8E672",
+		},
+	}
+	for _, tc := range testcases {
+		expectedX, _ := new(big.Int).SetString(tc.x, 16)
+		expectedY, _ := new(big.Int).SetString(tc.y, 16)
+
+		x, y := crv.ScalarBaseMult(big.NewInt(tc.k).Bytes())
+		if !assert.Equal(t, expectedX, x, `x should match for k = %d`, tc.k) {
+			return
+		}
+		if !assert.Equal(t, expectedY, y, `y should match for k = %d`, tc.k) {
+			return
+		}
+		if !assert.True(t, crv.IsOnCurve(x, y), `point should be on the curve for k = %d`, tc.k) {
+			return
+		}
+	}
+
+	x, y := crv.Double(params.Gx, params.Gy)
+	x, y = crv.Add(x, y, params.Gx, params.Gy)
+	x3, y3 := crv.ScalarBaseMult([]byte{3})
+	if !assert.True(t, x.Cmp(x3) == 0 && y.Cmp(y3) == 0, `2G + G should equal 3G`) {
+		return
+	}
+
+	x, y = crv.ScalarBaseMult(params.N.Bytes())
+	if !assert.True(t, x.Sign() == 0 && y.Sign() == 0, `N*G should be the point at infinity`) {
+		return
+	}
+}
+
+func TestECDSA(t *testing.T) {
+	key, err := ecdsa.GenerateKey(secp256k1.Curve(), rand.Reader)
+	if !assert.NoError(t, err, `ecdsa.GenerateKey should succeed`) {
+		return
+	}
+
+	digest := sha256.Sum256([]byte("Hello, World!"))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if !assert.NoError(t, err, `ecdsa.Sign should succeed`) {
+		return
+	}
+	if !assert.True(t, ecdsa.Verify(&key.PublicKey, digest[:], r, s), `ecdsa.Verify should succeed`) {
+		return
+	}
+
+	digest[0] ^= 0xff
+	if !assert.False(t, ecdsa.Verify(&key.PublicKey, digest[:], r, s), `ecdsa.Verify should fail for a different digest`) {
+		return
+	}
+}
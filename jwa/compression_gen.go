@@ -38,7 +38,7 @@ func (v *CompressionAlgorithm) Accept(value interface{}) error {
 	switch tmp {
 	case Deflate, NoCompress:
 	default:
-		return errors.Errorf(`invalid jwa.CompressionAlgorithm value`)
+		return errors.Errorf(`invalid jwa.CompressionAlgorithm value: %q`, tmp)
 	}
 
 	*v = tmp
@@ -49,3 +49,12 @@ func (v *CompressionAlgorithm) Accept(value interface{}) error {
 func (v CompressionAlgorithm) String() string {
 	return string(v)
 }
+
+// CompressionAlgorithms returns all of the supported values for CompressionAlgorithm.
+// A new slice is returned on every call, so it may be freely modified
+func CompressionAlgorithms() []CompressionAlgorithm {
+	return []CompressionAlgorithm{
+		Deflate,
+		NoCompress,
+	}
+}
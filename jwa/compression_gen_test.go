@@ -82,6 +82,16 @@ func TestCompressionAlgorithm(t *testing.T) {
 			return
 		}
 	})
+	t.Run(`list all supported values`, func(t *testing.T) {
+		t.Parallel()
+		expected := []jwa.CompressionAlgorithm{
+			jwa.Deflate,
+			jwa.NoCompress,
+		}
+		if !assert.Equal(t, expected, jwa.CompressionAlgorithms(), `list of values should match`) {
+			return
+		}
+	})
 	t.Run(`bail out on random integer value`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.CompressionAlgorithm
@@ -19,6 +19,8 @@ const (
 	A192GCM       ContentEncryptionAlgorithm = "A192GCM"       // AES-GCM (192)
 	A256CBC_HS512 ContentEncryptionAlgorithm = "A256CBC-HS512" // AES-CBC + HMAC-SHA512 (256)
 	A256GCM       ContentEncryptionAlgorithm = "A256GCM"       // AES-GCM (256)
+	C20P          ContentEncryptionAlgorithm = "C20P"          // ChaCha20-Poly1305
+	XC20P         ContentEncryptionAlgorithm = "XC20P"         // XChaCha20-Poly1305
 )
 
 // Accept is used when conversion from values given by
@@ -40,9 +42,9 @@ func (v *ContentEncryptionAlgorithm) Accept(value interface{}) error {
 		tmp = ContentEncryptionAlgorithm(s)
 	}
 	switch tmp {
-	case A128CBC_HS256, A128GCM, A192CBC_HS384, A192GCM, A256CBC_HS512, A256GCM:
+	case A128CBC_HS256, A128GCM, A192CBC_HS384, A192GCM, A256CBC_HS512, A256GCM, C20P, XC20P:
 	default:
-		return errors.Errorf(`invalid jwa.ContentEncryptionAlgorithm value`)
+		return errors.Errorf(`invalid jwa.ContentEncryptionAlgorithm value: %q`, tmp)
 	}
 
 	*v = tmp
@@ -53,3 +55,18 @@ func (v *ContentEncryptionAlgorithm) Accept(value interface{}) error {
 func (v ContentEncryptionAlgorithm) String() string {
 	return string(v)
 }
+
+// ContentEncryptionAlgorithms returns all of the supported values for ContentEncryptionAlgorithm.
+// A new slice is returned on every call, so it may be freely modified
+func ContentEncryptionAlgorithms() []ContentEncryptionAlgorithm {
+	return []ContentEncryptionAlgorithm{
+		A128CBC_HS256,
+		A128GCM,
+		A192CBC_HS384,
+		A192GCM,
+		A256CBC_HS512,
+		A256GCM,
+		C20P,
+		XC20P,
+	}
+}
@@ -3,20 +3,26 @@
 package jwa
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/pkg/errors"
 )
 
-//  EllipticCurveAlgorithm represents the algorithms used for EC keys
+// EllipticCurveAlgorithm represents the algorithms used for EC and OKP keys
 type EllipticCurveAlgorithm string
 
 // Supported values for EllipticCurveAlgorithm
 const (
+	Ed25519              EllipticCurveAlgorithm = "Ed25519" // Ed25519 signature algorithm key pairs (OKP)
+	Ed448                EllipticCurveAlgorithm = "Ed448"   // Ed448 signature algorithm key pairs (OKP)
 	InvalidEllipticCurve EllipticCurveAlgorithm = "P-invalid"
 	P256                 EllipticCurveAlgorithm = "P-256"
 	P384                 EllipticCurveAlgorithm = "P-384"
 	P521                 EllipticCurveAlgorithm = "P-521"
+	Secp256k1            EllipticCurveAlgorithm = "secp256k1" // SECG secp256k1 curve (https://tools.ietf.org/html/rfc8812#section-3.1)
+	X25519               EllipticCurveAlgorithm = "X25519"    // X25519 function key pairs (OKP)
+	X448                 EllipticCurveAlgorithm = "X448"      // X448 function key pairs (OKP)
 )
 
 // Accept is used when conversion from values given by
@@ -38,16 +44,41 @@ func (v *EllipticCurveAlgorithm) Accept(value interface{}) error {
 		tmp = EllipticCurveAlgorithm(s)
 	}
 	switch tmp {
-	case P256, P384, P521:
+	case Ed25519, Ed448, P256, P384, P521, Secp256k1, X25519, X448:
 	default:
-		return errors.Errorf(`invalid jwa.EllipticCurveAlgorithm value`)
+		return errors.Errorf(`invalid jwa.EllipticCurveAlgorithm value: %q`, tmp)
 	}
 
 	*v = tmp
 	return nil
 }
 
+// UnmarshalJSON decodes a JSON string into a EllipticCurveAlgorithm.
+// Unlike decoding into a plain string, invalid values are rejected
+func (v *EllipticCurveAlgorithm) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return errors.Wrap(err, `failed to decode jwa.EllipticCurveAlgorithm`)
+	}
+	return v.Accept(s)
+}
+
 // String returns the string representation of a EllipticCurveAlgorithm
 func (v EllipticCurveAlgorithm) String() string {
 	return string(v)
 }
+
+// EllipticCurveAlgorithms returns all of the supported values for EllipticCurveAlgorithm.
+// A new slice is returned on every call, so it may be freely modified
+func EllipticCurveAlgorithms() []EllipticCurveAlgorithm {
+	return []EllipticCurveAlgorithm{
+		Ed25519,
+		Ed448,
+		P256,
+		P384,
+		P521,
+		Secp256k1,
+		X25519,
+		X448,
+	}
+}
@@ -3,6 +3,7 @@
 package jwa_test
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/lestrrat-go/jwx/jwa"
@@ -10,6 +11,78 @@ import (
 )
 
 func TestEllipticCurveAlgorithm(t *testing.T) {
+	t.Run(`accept jwa constant Ed25519`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.Ed25519), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.Ed25519, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string Ed25519`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, dst.Accept("Ed25519"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.Ed25519, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for Ed25519`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "Ed25519"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.Ed25519, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for Ed25519`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "Ed25519", jwa.Ed25519.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant Ed448`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.Ed448), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.Ed448, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string Ed448`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, dst.Accept("Ed448"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.Ed448, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for Ed448`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "Ed448"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.Ed448, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for Ed448`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "Ed448", jwa.Ed448.String(), `stringified value matches`) {
+			return
+		}
+	})
 	t.Run(`accept jwa constant P256`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.EllipticCurveAlgorithm
@@ -118,6 +191,114 @@ func TestEllipticCurveAlgorithm(t *testing.T) {
 			return
 		}
 	})
+	t.Run(`accept jwa constant Secp256k1`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.Secp256k1), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.Secp256k1, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string secp256k1`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, dst.Accept("secp256k1"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.Secp256k1, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for secp256k1`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "secp256k1"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.Secp256k1, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for secp256k1`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "secp256k1", jwa.Secp256k1.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant X25519`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.X25519), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.X25519, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string X25519`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, dst.Accept("X25519"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.X25519, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for X25519`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "X25519"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.X25519, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for X25519`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "X25519", jwa.X25519.String(), `stringified value matches`) {
+			return
+		}
+	})
+	t.Run(`accept jwa constant X448`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.X448), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.X448, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string X448`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, dst.Accept("X448"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.X448, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for X448`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "X448"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.X448, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for X448`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "X448", jwa.X448.String(), `stringified value matches`) {
+			return
+		}
+	})
 	t.Run(`do not accept invalid constant InvalidEllipticCurve`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.EllipticCurveAlgorithm
@@ -125,6 +306,22 @@ func TestEllipticCurveAlgorithm(t *testing.T) {
 			return
 		}
 	})
+	t.Run(`list all supported values`, func(t *testing.T) {
+		t.Parallel()
+		expected := []jwa.EllipticCurveAlgorithm{
+			jwa.Ed25519,
+			jwa.Ed448,
+			jwa.P256,
+			jwa.P384,
+			jwa.P521,
+			jwa.Secp256k1,
+			jwa.X25519,
+			jwa.X448,
+		}
+		if !assert.Equal(t, expected, jwa.EllipticCurveAlgorithms(), `list of values should match`) {
+			return
+		}
+	})
 	t.Run(`bail out on random integer value`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.EllipticCurveAlgorithm
@@ -139,4 +336,102 @@ func TestEllipticCurveAlgorithm(t *testing.T) {
 			return
 		}
 	})
+	t.Run(`unmarshal JSON string Ed25519`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, json.Unmarshal([]byte("\"Ed25519\""), &dst), `json.Unmarshal is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.Ed25519, dst, `unmarshaled value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`unmarshal JSON string Ed448`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, json.Unmarshal([]byte("\"Ed448\""), &dst), `json.Unmarshal is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.Ed448, dst, `unmarshaled value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`unmarshal JSON string P-256`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, json.Unmarshal([]byte("\"P-256\""), &dst), `json.Unmarshal is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.P256, dst, `unmarshaled value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`unmarshal JSON string P-384`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, json.Unmarshal([]byte("\"P-384\""), &dst), `json.Unmarshal is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.P384, dst, `unmarshaled value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`unmarshal JSON string P-521`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, json.Unmarshal([]byte("\"P-521\""), &dst), `json.Unmarshal is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.P521, dst, `unmarshaled value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`unmarshal JSON string secp256k1`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, json.Unmarshal([]byte("\"secp256k1\""), &dst), `json.Unmarshal is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.Secp256k1, dst, `unmarshaled value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`unmarshal JSON string X25519`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, json.Unmarshal([]byte("\"X25519\""), &dst), `json.Unmarshal is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.X25519, dst, `unmarshaled value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`unmarshal JSON string X448`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.NoError(t, json.Unmarshal([]byte("\"X448\""), &dst), `json.Unmarshal is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.X448, dst, `unmarshaled value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`do not unmarshal invalid JSON string value`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		err := json.Unmarshal([]byte(`"totallyInvfalidValue"`), &dst)
+		if !assert.Error(t, err, `json.Unmarshal should fail`) {
+			return
+		}
+		if !assert.Contains(t, err.Error(), `totallyInvfalidValue`, `error should contain the invalid value`) {
+			return
+		}
+	})
+	t.Run(`do not unmarshal non-string JSON value`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.EllipticCurveAlgorithm
+		if !assert.Error(t, json.Unmarshal([]byte(`1`), &dst), `json.Unmarshal should fail`) {
+			return
+		}
+	})
 }
@@ -74,6 +74,16 @@ func _main() error {
 					value:   `A256GCM`,
 					comment: `AES-GCM (256)`,
 				},
+				{
+					name:    `C20P`,
+					value:   `C20P`,
+					comment: `ChaCha20-Poly1305`,
+				},
+				{
+					name:    `XC20P`,
+					value:   `XC20P`,
+					comment: `XChaCha20-Poly1305`,
+				},
 			},
 		},
 		{
@@ -102,12 +112,18 @@ func _main() error {
 					value:   `oct`,
 					comment: `Octet sequence (used to represent symmetric keys)`,
 				},
+				{
+					name:    `OKP`,
+					value:   `OKP`,
+					comment: `Octet key pair (used to represent Edwards/Montgomery curve keys)`,
+				},
 			},
 		},
 		{
 			name:     `EllipticCurveAlgorithm`,
-			comment:  ` EllipticCurveAlgorithm represents the algorithms used for EC keys`,
+			comment:  `EllipticCurveAlgorithm represents the algorithms used for EC and OKP keys`,
 			filename: `elliptic_gen.go`,
+			strict:   true,
 			elements: []element{
 				{
 					name:    `InvalidEllipticCurve`,
@@ -126,12 +142,38 @@ func _main() error {
 					name:  `P521`,
 					value: `P-521`,
 				},
+				{
+					name:    `Secp256k1`,
+					value:   `secp256k1`,
+					comment: `SECG secp256k1 curve (https://tools.ietf.org/html/rfc8812#section-3.1)`,
+				},
+				{
+					name:    `Ed25519`,
+					value:   `Ed25519`,
+					comment: `Ed25519 signature algorithm key pairs (OKP)`,
+				},
+				{
+					name:    `Ed448`,
+					value:   `Ed448`,
+					comment: `Ed448 signature algorithm key pairs (OKP)`,
+				},
+				{
+					name:    `X25519`,
+					value:   `X25519`,
+					comment: `X25519 function key pairs (OKP)`,
+				},
+				{
+					name:    `X448`,
+					value:   `X448`,
+					comment: `X448 function key pairs (OKP)`,
+				},
 			},
 		},
 		{
-			name:     `SignatureAlgorithm`,
-			comment:  `SignatureAlgorithm represents the various signature algorithms as described in https://tools.ietf.org/html/rfc7518#section-3.1`,
-			filename: `signature_gen.go`,
+			name:        `SignatureAlgorithm`,
+			comment:     `SignatureAlgorithm represents the various signature algorithms as described in https://tools.ietf.org/html/rfc7518#section-3.1`,
+			filename:    `signature_gen.go`,
+			listComment: "Note that the list includes NoSignature (\"none\"), which should be\nexcluded when building the list of algorithms accepted for verification",
 			elements: []element{
 				{
 					name:  `NoSignature`,
@@ -167,11 +209,21 @@ func _main() error {
 					value:   `RS512`,
 					comment: `RSASSA-PKCS-v1.5 using SHA-512`,
 				},
+				{
+					name:    `EdDSA`,
+					value:   `EdDSA`,
+					comment: `EdDSA signature algorithms`,
+				},
 				{
 					name:    `ES256`,
 					value:   `ES256`,
 					comment: `ECDSA using P-256 and SHA-256`,
 				},
+				{
+					name:    `ES256K`,
+					value:   `ES256K`,
+					comment: `ECDSA using secp256k1 and SHA-256`,
+				},
 				{
 					name:    `ES384`,
 					value:   `ES384`,
@@ -219,6 +271,16 @@ func _main() error {
 					value:   "RSA-OAEP-256",
 					comment: `RSA-OAEP-SHA256`,
 				},
+				{
+					name:    `RSA_OAEP_384`,
+					value:   "RSA-OAEP-384",
+					comment: `RSA-OAEP-SHA384`,
+				},
+				{
+					name:    `RSA_OAEP_512`,
+					value:   "RSA-OAEP-512",
+					comment: `RSA-OAEP-SHA512`,
+				},
 				{
 					name:    `A128KW`,
 					value:   "A128KW",
@@ -289,6 +351,11 @@ func _main() error {
 					value:   "PBES2-HS512+A256KW",
 					comment: `PBES2 + HMAC-SHA512 + AES key wrap (256)`,
 				},
+				{
+					name:    `ECMR`,
+					value:   "ECMR",
+					comment: `McCallum-Relyea key exchange`,
+				},
 			},
 		},
 	}
@@ -317,6 +384,12 @@ type typ struct {
 	comment  string
 	filename string
 	elements []element
+	// strict types get an UnmarshalJSON method, so that invalid
+	// values are rejected when JSON payloads are decoded
+	strict bool
+	// listComment is appended to the comment of the function that
+	// lists all of the supported values
+	listComment string
 }
 
 type element struct {
@@ -337,6 +410,9 @@ func (t typ) Generate() error {
 		"fmt",
 		"github.com/pkg/errors",
 	}
+	if t.strict {
+		pkgs = append(pkgs, "encoding/json")
+	}
 	for _, pkg := range pkgs {
 		fmt.Fprintf(&buf, "\n%s", strconv.Quote(pkg))
 	}
@@ -392,18 +468,46 @@ func (t typ) Generate() error {
 	}
 	fmt.Fprintf(&buf, ":")
 	fmt.Fprintf(&buf, "\ndefault:")
-	fmt.Fprintf(&buf, "\nreturn errors.Errorf(`invalid jwa.%s value`)", t.name)
+	fmt.Fprintf(&buf, "\nreturn errors.Errorf(`invalid jwa.%s value: %%q`, tmp)", t.name)
 	fmt.Fprintf(&buf, "\n}")
 
 	fmt.Fprintf(&buf, "\n\n*v = tmp")
 	fmt.Fprintf(&buf, "\nreturn nil")
 	fmt.Fprintf(&buf, "\n}") // func (v *%s) Accept(v interface{})
 
+	if t.strict {
+		fmt.Fprintf(&buf, "\n\n// UnmarshalJSON decodes a JSON string into a %s.", t.name)
+		fmt.Fprintf(&buf, "\n// Unlike decoding into a plain string, invalid values are rejected")
+		fmt.Fprintf(&buf, "\nfunc (v *%s) UnmarshalJSON(data []byte) error {", t.name)
+		fmt.Fprintf(&buf, "\nvar s string")
+		fmt.Fprintf(&buf, "\nif err := json.Unmarshal(data, &s); err != nil {")
+		fmt.Fprintf(&buf, "\nreturn errors.Wrap(err, `failed to decode jwa.%s`)", t.name)
+		fmt.Fprintf(&buf, "\n}")
+		fmt.Fprintf(&buf, "\nreturn v.Accept(s)")
+		fmt.Fprintf(&buf, "\n}")
+	}
+
 	fmt.Fprintf(&buf, "\n\n// String returns the string representation of a %s", t.name)
 	fmt.Fprintf(&buf, "\nfunc (v %s) String() string {", t.name)
 	fmt.Fprintf(&buf, "\nreturn string(v)")
 	fmt.Fprintf(&buf, "\n}")
 
+	fmt.Fprintf(&buf, "\n\n// %ss returns all of the supported values for %s.", t.name, t.name)
+	fmt.Fprintf(&buf, "\n// A new slice is returned on every call, so it may be freely modified")
+	if t.listComment != "" {
+		fmt.Fprintf(&buf, "\n//")
+		for _, line := range strings.Split(t.listComment, "\n") {
+			fmt.Fprintf(&buf, "\n// %s", line)
+		}
+	}
+	fmt.Fprintf(&buf, "\nfunc %ss() []%s {", t.name, t.name)
+	fmt.Fprintf(&buf, "\nreturn []%s{", t.name)
+	for _, e := range valids {
+		fmt.Fprintf(&buf, "\n%s,", e.name)
+	}
+	fmt.Fprintf(&buf, "\n}")
+	fmt.Fprintf(&buf, "\n}")
+
 	formatted, err := imports.Process("", buf.Bytes(), nil)
 	if err != nil {
 		os.Stdout.Write(buf.Bytes())
@@ -442,6 +546,9 @@ func (t typ) GenerateTest() error {
 		"github.com/lestrrat-go/jwx/jwa",
 		"github.com/stretchr/testify/assert",
 	}
+	if t.strict {
+		pkgs = append(pkgs, "encoding/json")
+	}
 	for _, pkg := range pkgs {
 		fmt.Fprintf(&buf, "\n%s", strconv.Quote(pkg))
 	}
@@ -500,6 +607,18 @@ func (t typ) GenerateTest() error {
 		fmt.Fprintf(&buf, "\n})")
 	}
 
+	fmt.Fprintf(&buf, "\nt.Run(`list all supported values`, func(t *testing.T) {")
+	fmt.Fprintf(&buf, "\nt.Parallel()")
+	fmt.Fprintf(&buf, "\nexpected := []jwa.%s{", t.name)
+	for _, e := range valids {
+		fmt.Fprintf(&buf, "\njwa.%s,", e.name)
+	}
+	fmt.Fprintf(&buf, "\n}")
+	fmt.Fprintf(&buf, "\nif !assert.Equal(t, expected, jwa.%ss(), `list of values should match`) {", t.name)
+	fmt.Fprintf(&buf, "\nreturn")
+	fmt.Fprintf(&buf, "\n}")
+	fmt.Fprintf(&buf, "\n})")
+
 	fmt.Fprintf(&buf, "\nt.Run(`bail out on random integer value`, func(t *testing.T) {")
 	fmt.Fprintf(&buf, "\nt.Parallel()")
 	fmt.Fprintf(&buf, "\nvar dst jwa.%s", t.name)
@@ -516,6 +635,41 @@ func (t typ) GenerateTest() error {
 	fmt.Fprintf(&buf, "\n}")
 	fmt.Fprintf(&buf, "\n})")
 
+	if t.strict {
+		for _, e := range valids {
+			fmt.Fprintf(&buf, "\nt.Run(`unmarshal JSON string %s`, func(t *testing.T) {", e.value)
+			fmt.Fprintf(&buf, "\nt.Parallel()")
+			fmt.Fprintf(&buf, "\nvar dst jwa.%s", t.name)
+			fmt.Fprintf(&buf, "\nif !assert.NoError(t, json.Unmarshal([]byte(%#v), &dst), `json.Unmarshal is successful`) {", strconv.Quote(e.value))
+			fmt.Fprintf(&buf, "\nreturn")
+			fmt.Fprintf(&buf, "\n}")
+			fmt.Fprintf(&buf, "\nif !assert.Equal(t, jwa.%s, dst, `unmarshaled value should be equal to constant`) {", e.name)
+			fmt.Fprintf(&buf, "\nreturn")
+			fmt.Fprintf(&buf, "\n}")
+			fmt.Fprintf(&buf, "\n})")
+		}
+
+		fmt.Fprintf(&buf, "\nt.Run(`do not unmarshal invalid JSON string value`, func(t *testing.T) {")
+		fmt.Fprintf(&buf, "\nt.Parallel()")
+		fmt.Fprintf(&buf, "\nvar dst jwa.%s", t.name)
+		fmt.Fprintf(&buf, "\nerr := json.Unmarshal([]byte(`\"totallyInvfalidValue\"`), &dst)")
+		fmt.Fprintf(&buf, "\nif !assert.Error(t, err, `json.Unmarshal should fail`) {")
+		fmt.Fprintf(&buf, "\nreturn")
+		fmt.Fprintf(&buf, "\n}")
+		fmt.Fprintf(&buf, "\nif !assert.Contains(t, err.Error(), `totallyInvfalidValue`, `error should contain the invalid value`) {")
+		fmt.Fprintf(&buf, "\nreturn")
+		fmt.Fprintf(&buf, "\n}")
+		fmt.Fprintf(&buf, "\n})")
+
+		fmt.Fprintf(&buf, "\nt.Run(`do not unmarshal non-string JSON value`, func(t *testing.T) {")
+		fmt.Fprintf(&buf, "\nt.Parallel()")
+		fmt.Fprintf(&buf, "\nvar dst jwa.%s", t.name)
+		fmt.Fprintf(&buf, "\nif !assert.Error(t, json.Unmarshal([]byte(`1`), &dst), `json.Unmarshal should fail`) {")
+		fmt.Fprintf(&buf, "\nreturn")
+		fmt.Fprintf(&buf, "\n}")
+		fmt.Fprintf(&buf, "\n})")
+	}
+
 	fmt.Fprintf(&buf, "\n}")
 
 	formatted, err := imports.Process("", buf.Bytes(), nil)
@@ -31,6 +31,8 @@ const (
 	RSA1_5             KeyEncryptionAlgorithm = "RSA1_5"             // RSA-PKCS1v1.5
 	RSA_OAEP           KeyEncryptionAlgorithm = "RSA-OAEP"           // RSA-OAEP-SHA1
 	RSA_OAEP_256       KeyEncryptionAlgorithm = "RSA-OAEP-256"       // RSA-OAEP-SHA256
+	RSA_OAEP_384       KeyEncryptionAlgorithm = "RSA-OAEP-384"       // RSA-OAEP-SHA384
+	RSA_OAEP_512       KeyEncryptionAlgorithm = "RSA-OAEP-512"       // RSA-OAEP-SHA512
 )
 
 // Accept is used when conversion from values given by
@@ -52,9 +54,9 @@ func (v *KeyEncryptionAlgorithm) Accept(value interface{}) error {
 		tmp = KeyEncryptionAlgorithm(s)
 	}
 	switch tmp {
-	case A128GCMKW, A128KW, A192GCMKW, A192KW, A256GCMKW, A256KW, DIRECT, ECDH_ES, ECDH_ES_A128KW, ECDH_ES_A192KW, ECDH_ES_A256KW, PBES2_HS256_A128KW, PBES2_HS384_A192KW, PBES2_HS512_A256KW, RSA1_5, RSA_OAEP, RSA_OAEP_256:
+	case A128GCMKW, A128KW, A192GCMKW, A192KW, A256GCMKW, A256KW, DIRECT, ECDH_ES, ECDH_ES_A128KW, ECDH_ES_A192KW, ECDH_ES_A256KW, ECMR, PBES2_HS256_A128KW, PBES2_HS384_A192KW, PBES2_HS512_A256KW, RSA1_5, RSA_OAEP, RSA_OAEP_256, RSA_OAEP_384, RSA_OAEP_512:
 	default:
-		return errors.Errorf(`invalid jwa.KeyEncryptionAlgorithm value`)
+		return errors.Errorf(`invalid jwa.KeyEncryptionAlgorithm value: %q`, tmp)
 	}
 
 	*v = tmp
@@ -65,3 +67,30 @@ func (v *KeyEncryptionAlgorithm) Accept(value interface{}) error {
 func (v KeyEncryptionAlgorithm) String() string {
 	return string(v)
 }
+
+// KeyEncryptionAlgorithms returns all of the supported values for KeyEncryptionAlgorithm.
+// A new slice is returned on every call, so it may be freely modified
+func KeyEncryptionAlgorithms() []KeyEncryptionAlgorithm {
+	return []KeyEncryptionAlgorithm{
+		A128GCMKW,
+		A128KW,
+		A192GCMKW,
+		A192KW,
+		A256GCMKW,
+		A256KW,
+		DIRECT,
+		ECDH_ES,
+		ECDH_ES_A128KW,
+		ECDH_ES_A192KW,
+		ECDH_ES_A256KW,
+		ECMR,
+		PBES2_HS256_A128KW,
+		PBES2_HS384_A192KW,
+		PBES2_HS512_A256KW,
+		RSA1_5,
+		RSA_OAEP,
+		RSA_OAEP_256,
+		RSA_OAEP_384,
+		RSA_OAEP_512,
+	}
+}
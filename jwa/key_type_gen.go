@@ -15,6 +15,7 @@ type KeyType string
 const (
 	EC             KeyType = "EC"  // Elliptic Curve
 	InvalidKeyType KeyType = ""    // Invalid KeyType
+	OKP            KeyType = "OKP" // Octet key pair (used to represent Edwards/Montgomery curve keys)
 	OctetSeq       KeyType = "oct" // Octet sequence (used to represent symmetric keys)
 	RSA            KeyType = "RSA" // RSA
 )
@@ -38,9 +39,9 @@ func (v *KeyType) Accept(value interface{}) error {
 		tmp = KeyType(s)
 	}
 	switch tmp {
-	case EC, OctetSeq, RSA:
+	case EC, OKP, OctetSeq, RSA:
 	default:
-		return errors.Errorf(`invalid jwa.KeyType value`)
+		return errors.Errorf(`invalid jwa.KeyType value: %q`, tmp)
 	}
 
 	*v = tmp
@@ -51,3 +52,14 @@ func (v *KeyType) Accept(value interface{}) error {
 func (v KeyType) String() string {
 	return string(v)
 }
+
+// KeyTypes returns all of the supported values for KeyType.
+// A new slice is returned on every call, so it may be freely modified
+func KeyTypes() []KeyType {
+	return []KeyType{
+		EC,
+		OKP,
+		OctetSeq,
+		RSA,
+	}
+}
@@ -46,6 +46,42 @@ func TestKeyType(t *testing.T) {
 			return
 		}
 	})
+	t.Run(`accept jwa constant OKP`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyType
+		if !assert.NoError(t, dst.Accept(jwa.OKP), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.OKP, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string OKP`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyType
+		if !assert.NoError(t, dst.Accept("OKP"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.OKP, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for OKP`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.KeyType
+		if !assert.NoError(t, dst.Accept(stringer{src: "OKP"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.OKP, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for OKP`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "OKP", jwa.OKP.String(), `stringified value matches`) {
+			return
+		}
+	})
 	t.Run(`accept jwa constant OctetSeq`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.KeyType
@@ -125,6 +161,18 @@ func TestKeyType(t *testing.T) {
 			return
 		}
 	})
+	t.Run(`list all supported values`, func(t *testing.T) {
+		t.Parallel()
+		expected := []jwa.KeyType{
+			jwa.EC,
+			jwa.OKP,
+			jwa.OctetSeq,
+			jwa.RSA,
+		}
+		if !assert.Equal(t, expected, jwa.KeyTypes(), `list of values should match`) {
+			return
+		}
+	})
 	t.Run(`bail out on random integer value`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.KeyType
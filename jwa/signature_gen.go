@@ -13,12 +13,14 @@ type SignatureAlgorithm string
 
 // Supported values for SignatureAlgorithm
 const (
-	ES256       SignatureAlgorithm = "ES256" // ECDSA using P-256 and SHA-256
-	ES384       SignatureAlgorithm = "ES384" // ECDSA using P-384 and SHA-384
-	ES512       SignatureAlgorithm = "ES512" // ECDSA using P-521 and SHA-512
-	HS256       SignatureAlgorithm = "HS256" // HMAC using SHA-256
-	HS384       SignatureAlgorithm = "HS384" // HMAC using SHA-384
-	HS512       SignatureAlgorithm = "HS512" // HMAC using SHA-512
+	ES256       SignatureAlgorithm = "ES256"  // ECDSA using P-256 and SHA-256
+	ES256K      SignatureAlgorithm = "ES256K" // ECDSA using secp256k1 and SHA-256
+	ES384       SignatureAlgorithm = "ES384"  // ECDSA using P-384 and SHA-384
+	ES512       SignatureAlgorithm = "ES512"  // ECDSA using P-521 and SHA-512
+	EdDSA       SignatureAlgorithm = "EdDSA"  // EdDSA signature algorithms
+	HS256       SignatureAlgorithm = "HS256"  // HMAC using SHA-256
+	HS384       SignatureAlgorithm = "HS384"  // HMAC using SHA-384
+	HS512       SignatureAlgorithm = "HS512"  // HMAC using SHA-512
 	NoSignature SignatureAlgorithm = "none"
 	PS256       SignatureAlgorithm = "PS256" // RSASSA-PSS using SHA256 and MGF1-SHA256
 	PS384       SignatureAlgorithm = "PS384" // RSASSA-PSS using SHA384 and MGF1-SHA384
@@ -47,9 +49,9 @@ func (v *SignatureAlgorithm) Accept(value interface{}) error {
 		tmp = SignatureAlgorithm(s)
 	}
 	switch tmp {
-	case ES256, ES384, ES512, HS256, HS384, HS512, NoSignature, PS256, PS384, PS512, RS256, RS384, RS512:
+	case ES256, ES256K, ES384, ES512, EdDSA, HS256, HS384, HS512, NoSignature, PS256, PS384, PS512, RS256, RS384, RS512:
 	default:
-		return errors.Errorf(`invalid jwa.SignatureAlgorithm value`)
+		return errors.Errorf(`invalid jwa.SignatureAlgorithm value: %q`, tmp)
 	}
 
 	*v = tmp
@@ -60,3 +62,28 @@ func (v *SignatureAlgorithm) Accept(value interface{}) error {
 func (v SignatureAlgorithm) String() string {
 	return string(v)
 }
+
+// SignatureAlgorithms returns all of the supported values for SignatureAlgorithm.
+// A new slice is returned on every call, so it may be freely modified
+//
+// Note that the list includes NoSignature ("none"), which should be
+// excluded when building the list of algorithms accepted for verification
+func SignatureAlgorithms() []SignatureAlgorithm {
+	return []SignatureAlgorithm{
+		ES256,
+		ES256K,
+		ES384,
+		ES512,
+		EdDSA,
+		HS256,
+		HS384,
+		HS512,
+		NoSignature,
+		PS256,
+		PS384,
+		PS512,
+		RS256,
+		RS384,
+		RS512,
+	}
+}
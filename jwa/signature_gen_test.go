@@ -46,6 +46,42 @@ func TestSignatureAlgorithm(t *testing.T) {
 			return
 		}
 	})
+	t.Run(`accept jwa constant ES256K`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.ES256K), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ES256K, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string ES256K`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept("ES256K"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ES256K, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for ES256K`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "ES256K"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.ES256K, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for ES256K`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "ES256K", jwa.ES256K.String(), `stringified value matches`) {
+			return
+		}
+	})
 	t.Run(`accept jwa constant ES384`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.SignatureAlgorithm
@@ -118,6 +154,42 @@ func TestSignatureAlgorithm(t *testing.T) {
 			return
 		}
 	})
+	t.Run(`accept jwa constant EdDSA`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(jwa.EdDSA), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.EdDSA, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept the string EdDSA`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept("EdDSA"), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.EdDSA, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`accept fmt.Stringer for EdDSA`, func(t *testing.T) {
+		t.Parallel()
+		var dst jwa.SignatureAlgorithm
+		if !assert.NoError(t, dst.Accept(stringer{src: "EdDSA"}), `accept is successful`) {
+			return
+		}
+		if !assert.Equal(t, jwa.EdDSA, dst, `accepted value should be equal to constant`) {
+			return
+		}
+	})
+	t.Run(`stringification for EdDSA`, func(t *testing.T) {
+		t.Parallel()
+		if !assert.Equal(t, "EdDSA", jwa.EdDSA.String(), `stringified value matches`) {
+			return
+		}
+	})
 	t.Run(`accept jwa constant HS256`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.SignatureAlgorithm
@@ -478,6 +550,29 @@ func TestSignatureAlgorithm(t *testing.T) {
 			return
 		}
 	})
+	t.Run(`list all supported values`, func(t *testing.T) {
+		t.Parallel()
+		expected := []jwa.SignatureAlgorithm{
+			jwa.ES256,
+			jwa.ES256K,
+			jwa.ES384,
+			jwa.ES512,
+			jwa.EdDSA,
+			jwa.HS256,
+			jwa.HS384,
+			jwa.HS512,
+			jwa.NoSignature,
+			jwa.PS256,
+			jwa.PS384,
+			jwa.PS512,
+			jwa.RS256,
+			jwa.RS384,
+			jwa.RS512,
+		}
+		if !assert.Equal(t, expected, jwa.SignatureAlgorithms(), `list of values should match`) {
+			return
+		}
+	})
 	t.Run(`bail out on random integer value`, func(t *testing.T) {
 		t.Parallel()
 		var dst jwa.SignatureAlgorithm
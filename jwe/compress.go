@@ -3,19 +3,47 @@ package jwe
 import (
 	"bytes"
 	"compress/flate"
+	"io"
 	"io/ioutil"
 
 	"github.com/lestrrat-go/jwx/jwa"
 	"github.com/pkg/errors"
 )
 
-func uncompress(plaintext []byte) ([]byte, error) {
-	return ioutil.ReadAll(flate.NewReader(bytes.NewReader(plaintext)))
+// defaultMaxDecompressedSize is the maximum size of the decompressed
+// payload that is allowed by default, unless overridden by the
+// `jwe.WithMaxDecompressedSize` option
+const defaultMaxDecompressedSize = 10 * 1024 * 1024
+
+// uncompress inflates the payload. In order to protect against
+// decompression bombs, an error is returned if the uncompressed
+// payload is larger than maxSize bytes. A maxSize <= 0 disables
+// the check
+func uncompress(plaintext []byte, maxSize int64) ([]byte, error) {
+	var r io.Reader = flate.NewReader(bytes.NewReader(plaintext))
+	if maxSize <= 0 {
+		return ioutil.ReadAll(r)
+	}
+
+	// read one extra byte so we can tell if the payload was too large
+	buf, err := ioutil.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(buf)) > maxSize {
+		return nil, errors.Errorf(`uncompressed payload exceeds maximum allowed size (%d bytes)`, maxSize)
+	}
+	return buf, nil
 }
 
 func compress(plaintext []byte, alg jwa.CompressionAlgorithm) ([]byte, error) {
-	if alg == jwa.NoCompress {
+	switch alg {
+	case jwa.NoCompress:
 		return plaintext, nil
+	case jwa.Deflate:
+	default:
+		return nil, errors.Errorf(`unsupported compression algorithm (%s)`, alg)
 	}
 
 	var output bytes.Buffer
@@ -2,14 +2,23 @@ package jwe
 
 import (
 	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rsa"
+	"io"
+	"math"
 
+	"github.com/lestrrat-go/jwx/internal/base64"
 	"github.com/lestrrat-go/jwx/jwa"
 	"github.com/lestrrat-go/jwx/jwe/internal/keyenc"
 	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/x25519"
 	"github.com/pkg/errors"
 )
 
+// defaultMaxPBES2Count is the maximum PBES2 iteration count accepted
+// by Decrypt, unless changed using the WithMaxPBES2Count option
+const defaultMaxPBES2Count = keyenc.PBES2DefaultMaxCount
+
 func buildRSA15Decrypter(alg jwa.KeyEncryptionAlgorithm, _ Headers, key interface{}, keysize int) (keyenc.Decrypter, error) {
 	var privkey *rsa.PrivateKey
 	switch v := key.(type) {
@@ -46,6 +55,94 @@ func buildKeywrapDecrypter(alg jwa.KeyEncryptionAlgorithm, _ Headers, key interf
 	return keyenc.NewAESCGM(alg, sharedkey)
 }
 
+func buildDirectDecrypter(alg jwa.KeyEncryptionAlgorithm, _ Headers, key interface{}, _ int) (keyenc.Decrypter, error) {
+	sharedkey, ok := key.([]byte)
+	if !ok {
+		return nil, errors.Errorf("[]byte is required as the key to build %s key decrypter", alg)
+	}
+	return keyenc.DirectDecrypt{Key: sharedkey}, nil
+}
+
+func buildAESGCMKWDecrypter(alg jwa.KeyEncryptionAlgorithm, h Headers, key interface{}, _ int) (keyenc.Decrypter, error) {
+	sharedkey, ok := key.([]byte)
+	if !ok {
+		return nil, errors.Errorf("[]byte is required as the key to build %s key decrypter", alg)
+	}
+
+	iv, err := getBase64Header(h, "iv")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get 'iv' field to build %s key decrypter", alg)
+	}
+
+	tag, err := getBase64Header(h, "tag")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get 'tag' field to build %s key decrypter", alg)
+	}
+
+	return keyenc.NewAESGCMKWDecrypt(alg, sharedkey, iv, tag)
+}
+
+func buildPBES2Decrypter(alg jwa.KeyEncryptionAlgorithm, h Headers, key interface{}, maxCount int) (keyenc.Decrypter, error) {
+	password, ok := key.([]byte)
+	if !ok {
+		return nil, errors.Errorf("[]byte is required as the key to build %s key decrypter", alg)
+	}
+
+	salt, err := getBase64Header(h, "p2s")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get 'p2s' field to build %s key decrypter", alg)
+	}
+
+	count, err := getIntHeader(h, "p2c")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get 'p2c' field to build %s key decrypter", alg)
+	}
+
+	return keyenc.NewPBES2Decrypt(alg, password, salt, count, maxCount)
+}
+
+// getIntHeader fetches a non-standard header parameter whose value
+// is a (positive) integer
+func getIntHeader(h Headers, name string) (int, error) {
+	v, ok := h.Get(name)
+	if !ok {
+		return 0, errors.Errorf("'%s' field is missing", name)
+	}
+
+	switch v := v.(type) {
+	case int:
+		return v, nil
+	case float64:
+		// JSON numbers are decoded as float64
+		if v != math.Trunc(v) || v < 0 || v > math.MaxInt32 {
+			return 0, errors.Errorf("'%s' field must be a positive integer (got %v)", name, v)
+		}
+		return int(v), nil
+	default:
+		return 0, errors.Errorf("'%s' field must be a number (got %T)", name, v)
+	}
+}
+
+// getBase64Header fetches a non-standard header parameter whose value
+// is a base64url encoded string, and returns the decoded byte sequence
+func getBase64Header(h Headers, name string) ([]byte, error) {
+	v, ok := h.Get(name)
+	if !ok {
+		return nil, errors.Errorf("'%s' field is missing", name)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return nil, errors.Errorf("'%s' field must be a string (got %T)", name, v)
+	}
+
+	decoded, err := base64.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode '%s' field", name)
+	}
+	return decoded, nil
+}
+
 func buildECDHESDecrypter(alg jwa.KeyEncryptionAlgorithm, h Headers, key interface{}) (keyenc.Decrypter, error) {
 	epkif, ok := h.Get(EphemeralPublicKeyKey)
 	if !ok {
@@ -55,19 +152,30 @@ func buildECDHESDecrypter(alg jwa.KeyEncryptionAlgorithm, h Headers, key interfa
 		return nil, errors.Errorf("'epk' header is required as the key to build %s key decrypter", alg)
 	}
 
-	epk, ok := epkif.(jwk.ECDSAPublicKey)
-	if !ok {
+	var pubkey interface{}
+	switch epk := epkif.(type) {
+	case jwk.ECDSAPublicKey, jwk.OKPPublicKey:
+		// jwk.Key.Raw rejects points that are not on the curve, so
+		// report its errors as invalid keys
+		if err := epk.(jwk.Key).Raw(&pubkey); err != nil {
+			return nil, errors.Wrapf(ErrInvalidECDHKey, "failed to get public key: %s", err)
+		}
+	default:
 		return nil, errors.Errorf("'epk' header is required as the key to build %s key decrypter", alg)
 	}
 
-	var pubkey interface{}
-	if err := epk.Raw(&pubkey); err != nil {
-		return nil, errors.Wrap(err, "failed to get public key")
+	var privkey interface{}
+	switch v := key.(type) {
+	case ecdsa.PrivateKey:
+		privkey = &v
+	case *ecdsa.PrivateKey, x25519.PrivateKey:
+		privkey = v
+	default:
+		return nil, errors.Errorf("*ecdsa.PrivateKey or x25519.PrivateKey is required as the key to build %s key decrypter", alg)
 	}
 
-	privkey, ok := key.(*ecdsa.PrivateKey)
-	if !ok {
-		return nil, errors.Errorf("*ecdsa.PrivateKey is required as the key to build %s key decrypter", alg)
+	if err := validateEphemeralKey(pubkey, privkey); err != nil {
+		return nil, errors.Wrapf(err, "invalid 'epk' header to build %s key decrypter", alg)
 	}
 	var apuData, apvData []byte
 	apu := h.AgreementPartyUInfo()
@@ -77,13 +185,13 @@ func buildECDHESDecrypter(alg jwa.KeyEncryptionAlgorithm, h Headers, key interfa
 
 	apv := h.AgreementPartyVInfo()
 	if apv.Len() > 0 {
-		apuData = apu.Bytes()
+		apvData = apv.Bytes()
 	}
 
-	return keyenc.NewECDHESDecrypt(alg, h.ContentEncryption(), pubkey.(*ecdsa.PublicKey), apuData, apvData, privkey), nil
+	return keyenc.NewECDHESDecrypt(alg, h.ContentEncryption(), pubkey, apuData, apvData, privkey), nil
 }
 
-func buildECMRDecrypter(alg jwa.KeyEncryptionAlgorithm, h Headers, key interface{}) (keyenc.Decrypter, error) {
+func buildECMRDecrypter(alg jwa.KeyEncryptionAlgorithm, h Headers, key interface{}, rand io.Reader) (keyenc.Decrypter, error) {
 	epkif, ok := h.Get(EphemeralPublicKeyKey)
 	if !ok {
 		return nil, errors.New("failed to get 'epk' field")
@@ -114,28 +222,72 @@ func buildECMRDecrypter(alg jwa.KeyEncryptionAlgorithm, h Headers, key interface
 
 	apv := h.AgreementPartyVInfo()
 	if apv.Len() > 0 {
-		apuData = apu.Bytes()
+		apvData = apv.Bytes()
 	}
 
-	return keyenc.NewECMRDecrypt(alg, h.ContentEncryption(), pubkey.(*ecdsa.PublicKey), apuData, apvData, exchFn), nil
+	return keyenc.NewECMRDecrypt(alg, h.ContentEncryption(), pubkey.(*ecdsa.PublicKey), apuData, apvData, exchFn, rand), nil
+}
+
+// validateEphemeralKey makes sure that the ephemeral public key is on the
+// same curve as the private key, so that the shared secret is not computed
+// using a point chosen by an attacker (invalid curve attack)
+func validateEphemeralKey(pubkey, privkey interface{}) error {
+	switch privkey := privkey.(type) {
+	case *ecdsa.PrivateKey:
+		pubkey, ok := pubkey.(*ecdsa.PublicKey)
+		if !ok || !sameECCurve(pubkey.Curve, privkey.Curve) {
+			return ErrEphemeralKeyCurveMismatch
+		}
+		if !privkey.Curve.IsOnCurve(pubkey.X, pubkey.Y) {
+			return errors.Wrap(ErrInvalidECDHKey, `'epk' header contains a point that is not on the curve`)
+		}
+	case x25519.PrivateKey:
+		if _, ok := pubkey.(x25519.PublicKey); !ok {
+			return ErrEphemeralKeyCurveMismatch
+		}
+	}
+	return nil
+}
+
+func sameECCurve(a, b elliptic.Curve) bool {
+	if a == b {
+		return true
+	}
+	pa, pb := a.Params(), b.Params()
+	return pa.Name == pb.Name && pa.P.Cmp(pb.P) == 0
 }
 
 // buildKeyDecrypter creates a new KeyDecrypter instance from the given
 // parameters. It is used by the Message.Decrypt method to create
 // key decrypter(s) from the given message. `keysize` is only used by
 // some decrypters. Pass the value from ContentCipher.KeySize().
-func buildKeyDecrypter(alg jwa.KeyEncryptionAlgorithm, h Headers, key interface{}, keysize int) (keyenc.Decrypter, error) {
+// `maxPBES2Count` is the maximum "p2c" value accepted for PBES2.
+// `rand` is the source of randomness for ECMR, which may be nil.
+func buildKeyDecrypter(alg jwa.KeyEncryptionAlgorithm, h Headers, key interface{}, keysize, maxPBES2Count int, rand io.Reader) (keyenc.Decrypter, error) {
+	if ext, ok := key.(KeyDecrypter); ok {
+		if err := checkExternalKeyAlgorithm(alg, ext.Algorithm()); err != nil {
+			return nil, errors.Wrap(err, `invalid key decrypter`)
+		}
+		return ext, nil
+	}
+
 	switch alg {
+	case jwa.DIRECT:
+		return buildDirectDecrypter(alg, h, key, keysize)
 	case jwa.RSA1_5:
 		return buildRSA15Decrypter(alg, h, key, keysize)
-	case jwa.RSA_OAEP, jwa.RSA_OAEP_256:
+	case jwa.RSA_OAEP, jwa.RSA_OAEP_256, jwa.RSA_OAEP_384, jwa.RSA_OAEP_512:
 		return buildRSAOAEPDecrypter(alg, h, key, keysize)
 	case jwa.A128KW, jwa.A192KW, jwa.A256KW:
 		return buildKeywrapDecrypter(alg, h, key, keysize)
+	case jwa.A128GCMKW, jwa.A192GCMKW, jwa.A256GCMKW:
+		return buildAESGCMKWDecrypter(alg, h, key, keysize)
+	case jwa.PBES2_HS256_A128KW, jwa.PBES2_HS384_A192KW, jwa.PBES2_HS512_A256KW:
+		return buildPBES2Decrypter(alg, h, key, maxPBES2Count)
 	case jwa.ECDH_ES, jwa.ECDH_ES_A128KW, jwa.ECDH_ES_A192KW, jwa.ECDH_ES_A256KW:
 		return buildECDHESDecrypter(alg, h, key)
 	case jwa.ECMR:
-		return buildECMRDecrypter(alg, h, key)
+		return buildECMRDecrypter(alg, h, key, rand)
 	}
 
 	return nil, errors.Errorf(`unsupported algorithm for key decryption (%s)`, alg)
@@ -4,8 +4,9 @@ import (
 	"context"
 	"sync"
 
-	"github.com/lestrrat-go/jwx/buffer"
+	"github.com/lestrrat-go/jwx/internal/base64"
 	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwe/internal/keygen"
 	"github.com/lestrrat-go/pdebug"
 	"github.com/pkg/errors"
 )
@@ -25,9 +26,64 @@ func releaseEncryptCtx(ctx *encryptCtx) {
 	ctx.generator = nil
 	ctx.keyEncrypters = nil
 	ctx.compress = jwa.NoCompress
+	ctx.aad = nil
+	ctx.protected = nil
 	encryptCtxPool.Put(ctx)
 }
 
+// externalKeyEncrypter adapts a user supplied KeyEncrypter to the
+// interface used by the built-in key encrypters
+type externalKeyEncrypter struct {
+	KeyEncrypter
+}
+
+func (e externalKeyEncrypter) Encrypt(cek []byte) (keygen.ByteSource, error) {
+	enckey, err := e.KeyEncrypter.Encrypt(cek)
+	if err != nil {
+		return nil, err
+	}
+	return keygen.ByteKey(enckey), nil
+}
+
+// checkExternalKeyAlgorithm checks that a user supplied KeyEncrypter
+// or KeyDecrypter can be used for the given algorithm
+func checkExternalKeyAlgorithm(alg, provided jwa.KeyEncryptionAlgorithm) error {
+	if alg != provided {
+		return errors.Errorf(`algorithm mismatch (expected %s, got %s)`, alg, provided)
+	}
+	switch alg {
+	case jwa.RSA1_5, jwa.RSA_OAEP, jwa.RSA_OAEP_256, jwa.RSA_OAEP_384, jwa.RSA_OAEP_512, jwa.A128KW, jwa.A192KW, jwa.A256KW:
+		return nil
+	default:
+		return errors.Errorf(`%s cannot be used with an external key encrypter or decrypter`, alg)
+	}
+}
+
+// excludeProtectedHeaders returns a copy of the recipient headers without
+// the parameters that are already in the shared protected header, as
+// the two must be disjoint (https://tools.ietf.org/html/rfc7516#section-7.2.1)
+func excludeProtectedHeaders(ctx context.Context, protected, h Headers) (Headers, error) {
+	dst := NewHeaders()
+	for iter := h.Iterate(ctx); iter.Next(ctx); {
+		pair := iter.Pair()
+		key := pair.Key.(string)
+		if existing, ok := protected.Get(key); ok {
+			same, err := sameHeaderValue(existing, pair.Value)
+			if err != nil {
+				return nil, errors.Wrapf(err, `failed to compare values of header %q`, key)
+			}
+			if !same {
+				return nil, errors.Errorf(`header %q has conflicting values`, key)
+			}
+			continue
+		}
+		if err := dst.Set(key, pair.Value); err != nil {
+			return nil, errors.Wrapf(err, `failed to set header %q`, key)
+		}
+	}
+	return dst, nil
+}
+
 // Encrypt takes the plaintext and encrypts into a JWE message.
 func (e encryptCtx) Encrypt(plaintext []byte) (*Message, error) {
 	bk, err := e.generator.Generate()
@@ -43,7 +99,10 @@ func (e encryptCtx) Encrypt(plaintext []byte) (*Message, error) {
 		pdebug.Printf("Encrypt: generated cek len = %d", len(cek))
 	}
 
-	protected := NewHeaders()
+	protected, err := mergeHeaders(context.TODO(), nil, e.protected)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to copy protected headers`)
+	}
 	if err := protected.Set(ContentEncryptionKey, e.contentEncrypter.Algorithm()); err != nil {
 		return nil, errors.Wrap(err, `failed to set "enc" in protected header`)
 	}
@@ -91,13 +150,29 @@ func (e encryptCtx) Encrypt(plaintext []byte) (*Message, error) {
 	}
 
 	// If there's only one recipient, you want to include that in the
-	// protected header
+	// protected header. The header parameters must not appear in both
+	// the protected and the per-recipient header
+	// (https://tools.ietf.org/html/rfc7516#section-7.2.1), so the
+	// recipient header is left empty
 	if len(recipients) == 1 {
 		h, err := mergeHeaders(context.TODO(), protected, recipients[0].Headers())
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to merge protected headers")
 		}
 		protected = h
+		if err := recipients[0].SetHeaders(NewHeaders()); err != nil {
+			return nil, errors.Wrap(err, "failed to reset recipient headers")
+		}
+	} else {
+		for i, r := range recipients {
+			h, err := excludeProtectedHeaders(context.TODO(), protected, r.Headers())
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid headers for recipient #%d", i+1)
+			}
+			if err := r.SetHeaders(h); err != nil {
+				return nil, errors.Wrap(err, "failed to set recipient headers")
+			}
+		}
 	}
 
 	aad, err := protected.Encode()
@@ -105,6 +180,13 @@ func (e encryptCtx) Encrypt(plaintext []byte) (*Message, error) {
 		return nil, errors.Wrap(err, "failed to base64 encode protected headers")
 	}
 
+	// If the application supplied additional authenticated data, the
+	// AEAD additional data is BASE64URL(protected) || '.' || BASE64URL(aad)
+	// (https://tools.ietf.org/html/rfc7516#section-5.1)
+	if len(e.aad) > 0 {
+		aad = append(append(aad, '.'), base64.EncodeToString(e.aad)...)
+	}
+
 	plaintext, err = compress(plaintext, compression)
 	if err != nil {
 		return nil, errors.Wrap(err, `failed to compress payload before encryption`)
@@ -127,14 +209,14 @@ func (e encryptCtx) Encrypt(plaintext []byte) (*Message, error) {
 		pdebug.Printf("Encrypt.Encrypt: tag        = %x", tag)
 	}
 
+	// The "aad" member of the message is reserved for additional
+	// authenticated data supplied by the application, so the encoded
+	// protected header must not be stored there
 	msg := NewMessage()
-
-	decodedAad, err := buffer.FromBase64(aad)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to decode base64")
-	}
-	if err := msg.Set(AuthenticatedDataKey, decodedAad.Bytes()); err != nil {
-		return nil, errors.Wrapf(err, `failed to set %s`, AuthenticatedDataKey)
+	if len(e.aad) > 0 {
+		if err := msg.Set(AuthenticatedDataKey, e.aad); err != nil {
+			return nil, errors.Wrapf(err, `failed to set %s`, AuthenticatedDataKey)
+		}
 	}
 	if err := msg.Set(CipherTextKey, ciphertext); err != nil {
 		return nil, errors.Wrapf(err, `failed to set %s`, CipherTextKey)
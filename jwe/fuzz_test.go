@@ -0,0 +1,97 @@
+// +build go1.18
+
+package jwe_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"math/big"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwe"
+)
+
+type fuzzRecipient struct {
+	alg    jwa.KeyEncryptionAlgorithm
+	encKey interface{}
+	decKey interface{}
+}
+
+// fuzzRecipients returns a fixed set of keys, so that the messages in
+// the seed corpus can be decrypted by every fuzzing worker. PBES2 is
+// not included, as the iteration count is taken from the input
+func fuzzRecipients() []fuzzRecipient {
+	eckey := &ecdsa.PrivateKey{D: big.NewInt(0x6a77780a)}
+	eckey.Curve = elliptic.P256()
+	eckey.X, eckey.Y = eckey.Curve.ScalarBaseMult(eckey.D.Bytes())
+
+	symmetric := []byte("0123456789abcdef")
+	return []fuzzRecipient{
+		{alg: jwa.RSA1_5, encKey: &rsaPrivKey.PublicKey, decKey: &rsaPrivKey},
+		{alg: jwa.RSA_OAEP, encKey: &rsaPrivKey.PublicKey, decKey: &rsaPrivKey},
+		{alg: jwa.A128KW, encKey: symmetric, decKey: symmetric},
+		{alg: jwa.A128GCMKW, encKey: symmetric, decKey: symmetric},
+		{alg: jwa.ECDH_ES_A128KW, encKey: &eckey.PublicKey, decKey: eckey},
+	}
+}
+
+// FuzzDecrypt feeds arbitrary input to jwe.Decrypt. Any input may be
+// rejected, but none of them may cause a panic
+func FuzzDecrypt(f *testing.F) {
+	recipients := fuzzRecipients()
+	for _, r := range recipients {
+		for _, contentalg := range []jwa.ContentEncryptionAlgorithm{jwa.A128GCM, jwa.A128CBC_HS256} {
+			encrypted, err := jwe.Encrypt([]byte(examplePayload), r.alg, r.encKey, contentalg, jwa.NoCompress)
+			if err != nil {
+				f.Fatalf("failed to encrypt seed (%s, %s): %s", r.alg, contentalg, err)
+			}
+			f.Add(encrypted)
+
+			msg, err := jwe.Parse(encrypted)
+			if err != nil {
+				f.Fatalf("failed to parse seed (%s, %s): %s", r.alg, contentalg, err)
+			}
+			serialized, err := jwe.JSON(msg)
+			if err != nil {
+				f.Fatalf("failed to serialize seed (%s, %s): %s", r.alg, contentalg, err)
+			}
+			f.Add(serialized)
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, r := range recipients {
+			_, _ = jwe.Decrypt(data, r.alg, r.decKey)
+		}
+	})
+}
+
+// FuzzEncryptDecrypt checks that arbitrary payloads survive a round
+// trip through jwe.Encrypt and jwe.Decrypt
+func FuzzEncryptDecrypt(f *testing.F) {
+	f.Add([]byte(examplePayload))
+	f.Add([]byte{})
+
+	recipients := fuzzRecipients()
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		for _, r := range recipients {
+			for _, compress := range []jwa.CompressionAlgorithm{jwa.NoCompress, jwa.Deflate} {
+				encrypted, err := jwe.Encrypt(payload, r.alg, r.encKey, jwa.A128GCM, compress)
+				if err != nil {
+					t.Fatalf("failed to encrypt (%s): %s", r.alg, err)
+				}
+
+				decrypted, err := jwe.Decrypt(encrypted, r.alg, r.decKey)
+				if err != nil {
+					t.Fatalf("failed to decrypt (%s): %s", r.alg, err)
+				}
+
+				if !bytes.Equal(payload, decrypted) {
+					t.Fatalf("payload mismatch (%s): expected %x, got %x", r.alg, payload, decrypted)
+				}
+			}
+		}
+	})
+}
@@ -43,7 +43,7 @@ type Headers interface {
 	ContentEncryption() jwa.ContentEncryptionAlgorithm
 	ContentType() string
 	Critical() []string
-	EphemeralPublicKey() jwk.ECDSAPublicKey
+	EphemeralPublicKey() jwk.Key
 	JWK() jwk.Key
 	JWKSetURL() string
 	KeyID() string
@@ -57,6 +57,7 @@ type Headers interface {
 	AsMap(ctx context.Context) (map[string]interface{}, error)
 	Get(string) (interface{}, bool)
 	Set(string, interface{}) error
+	SetX509CertChain([]string) error
 	Remove(string) error
 	Encode() ([]byte, error)
 	Decode([]byte) error
@@ -71,7 +72,7 @@ type stdHeaders struct {
 	contentEncryption      *jwa.ContentEncryptionAlgorithm `json:"enc,omitempty"`      //
 	contentType            *string                         `json:"cty,omitempty"`      //
 	critical               []string                        `json:"crit,omitempty"`     //
-	ephemeralPublicKey     jwk.ECDSAPublicKey              `json:"epk,omitempty"`      //
+	ephemeralPublicKey     jwk.Key                         `json:"epk,omitempty"`      //
 	jwk                    jwk.Key                         `json:"jwk,omitempty"`      //
 	jwkSetURL              *string                         `json:"jku,omitempty"`      //
 	keyID                  *string                         `json:"kid,omitempty"`      //
@@ -154,7 +155,7 @@ func (h *stdHeaders) Critical() []string {
 	return h.critical
 }
 
-func (h *stdHeaders) EphemeralPublicKey() jwk.ECDSAPublicKey {
+func (h *stdHeaders) EphemeralPublicKey() jwk.Key {
 	return h.ephemeralPublicKey
 }
 
@@ -363,6 +364,12 @@ func (h *stdHeaders) Get(name string) (interface{}, bool) {
 	}
 }
 
+// SetX509CertChain sets the "x5c" header. It is equivalent to
+// calling Set(X509CertChainKey, chain)
+func (h *stdHeaders) SetX509CertChain(chain []string) error {
+	return h.Set(X509CertChainKey, chain)
+}
+
 func (h *stdHeaders) Set(name string, value interface{}) error {
 	switch name {
 	case AgreementPartyUInfoKey:
@@ -413,7 +420,7 @@ func (h *stdHeaders) Set(name string, value interface{}) error {
 		}
 		return errors.Errorf(`invalid value for %s key: %T`, CriticalKey, value)
 	case EphemeralPublicKeyKey:
-		if v, ok := value.(jwk.ECDSAPublicKey); ok {
+		if v, ok := value.(jwk.Key); ok {
 			h.ephemeralPublicKey = v
 			return nil
 		}
@@ -536,9 +543,10 @@ func (h *stdHeaders) UnmarshalJSON(buf []byte) error {
 		if err != nil {
 			return errors.Wrap(err, `failed to parse epk field`)
 		}
-		if ecEpk, ok := epk.(jwk.ECDSAPublicKey); ok {
-			h.ephemeralPublicKey = ecEpk
-		} else {
+		switch epk.(type) {
+		case jwk.ECDSAPublicKey, jwk.OKPPublicKey:
+			h.ephemeralPublicKey = epk
+		default:
 			return errors.Errorf(`invalid type for epk field %T`, epk)
 		}
 	}
@@ -78,6 +78,17 @@ func TestHeaders(t *testing.T) {
 			}
 		}
 	})
+	t.Run("SetX509CertChain", func(t *testing.T) {
+		chain := []string{"cert1", "cert2"}
+		h := jwe.NewHeaders()
+		if !assert.NoError(t, h.SetX509CertChain(chain), `SetX509CertChain should succeed`) {
+			return
+		}
+		if !assert.Equal(t, chain, h.X509CertChain(), `X509CertChain should match`) {
+			return
+		}
+	})
+
 	t.Run("PrivateParams", func(t *testing.T) {
 		h := base
 		pp := h.PrivateParams()
@@ -11,7 +11,19 @@ import (
 )
 
 const (
-	optkeyPrettyJSONFormat = "optkeyPrettyJSONFormat"
+	optkeyPrettyJSONFormat     = "optkeyPrettyJSONFormat"
+	optkeyPBES2Count           = "optkeyPBES2Count"
+	optkeyCompress             = "optkeyCompress"
+	optkeyMaxDecompressedSize  = "optkeyMaxDecompressedSize"
+	optkeyRecipient            = "optkeyRecipient"
+	optkeyAAD                  = "optkeyAAD"
+	optkeyRandomSource         = "optkeyRandomSource"
+	optkeyKeySet               = "optkeyKeySet"
+	optkeyProtectedHeaders     = "optkeyProtectedHeaders"
+	optkeyMessage              = "optkeyMessage"
+	optkeyContentEncryptionKey = "optkeyContentEncryptionKey"
+	optkeyRequireKeyID         = "optkeyRequireKeyID"
+	optkeyMaxPBES2Count        = "optkeyMaxPBES2Count"
 )
 
 // Recipient holds the encrypted key and hints to decrypt the key
@@ -38,6 +50,39 @@ type Message struct {
 	unprotectedHeaders   Headers
 }
 
+// KeyEncrypter encrypts content encryption keys using a key that it
+// holds itself, such as a key stored in a HSM or a KMS. Pass it as the
+// key to `jwe.Encrypt` (or `jwe.WithRecipient`) to use it.
+//
+// Only the algorithms that produce nothing but the encrypted key may be
+// used with a KeyEncrypter: RSA1_5, RSA-OAEP (all variants) and
+// A128KW/A192KW/A256KW
+type KeyEncrypter interface {
+	// Algorithm returns the key encryption algorithm. It must be the
+	// same as the algorithm passed to `jwe.Encrypt`
+	Algorithm() jwa.KeyEncryptionAlgorithm
+
+	// KeyID returns the value of the "kid" header for the recipient.
+	// It may be empty
+	KeyID() string
+
+	// Encrypt encrypts the content encryption key
+	Encrypt(cek []byte) ([]byte, error)
+}
+
+// KeyDecrypter is the counterpart of KeyEncrypter: it decrypts content
+// encryption keys using a key that it holds itself. Pass it as the key
+// to `jwe.Decrypt` or `(*jwe.Message).Decrypt` to use it. The same
+// algorithms as KeyEncrypter are supported
+type KeyDecrypter interface {
+	// Algorithm returns the key encryption algorithm. It must be the
+	// same as the algorithm passed to `jwe.Decrypt`
+	Algorithm() jwa.KeyEncryptionAlgorithm
+
+	// Decrypt decrypts the encrypted content encryption key
+	Decrypt(enckey []byte) ([]byte, error)
+}
+
 // contentEncrypter encrypts the content using the content using the
 // encrypted key
 type contentEncrypter interface {
@@ -50,6 +95,8 @@ type encryptCtx struct {
 	generator        keygen.Generator
 	keyEncrypters    []keyenc.Encrypter
 	compress         jwa.CompressionAlgorithm
+	aad              []byte
+	protected        Headers
 }
 
 // populater is an interface for things that may modify the
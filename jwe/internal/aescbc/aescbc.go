@@ -5,7 +5,6 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"crypto/sha512"
-	"crypto/subtle"
 	"encoding/binary"
 	"fmt"
 	"hash"
@@ -19,6 +18,11 @@ const (
 	NonceSize = 16
 )
 
+// errOpen is returned by Open when the ciphertext could not be
+// authenticated or decrypted. The same error is returned in both cases,
+// so that the cause of the failure is not revealed to the caller
+var errOpen = errors.New("invalid ciphertext (failed to authenticate)")
+
 type Hmac struct {
 	blockCipher  cipher.Block
 	hash         func() hash.Hash
@@ -81,6 +85,10 @@ func (c Hmac) Overhead() int {
 	return c.blockCipher.BlockSize() + c.tagsize
 }
 
+// ComputeAuthTag computes the authentication tag over the additional
+// authenticated data, the nonce, the ciphertext and the length of the
+// additional authenticated data in bits, as described in
+// https://tools.ietf.org/html/rfc7518#section-5.2.2.1
 func (c Hmac) ComputeAuthTag(aad, nonce, ciphertext []byte) ([]byte, error) {
 	if pdebug.Enabled {
 		pdebug.Printf("ComputeAuthTag: aad        = %x (%d)\n", aad, len(aad))
@@ -160,7 +168,13 @@ func (c Hmac) Open(dst, nonce, ciphertext, data []byte) ([]byte, error) {
 		defer g.End()
 	}
 
-	if len(ciphertext) < c.keysize {
+	if len(nonce) != NonceSize {
+		return nil, errors.Errorf("invalid nonce size (%d)", len(nonce))
+	}
+
+	// The ciphertext must contain at least one block of (padded)
+	// content, followed by the authentication tag
+	if len(ciphertext) < c.blockCipher.BlockSize()+c.tagsize {
 		return nil, errors.New("invalid ciphertext (too short)")
 	}
 
@@ -180,21 +194,29 @@ func (c Hmac) Open(dst, nonce, ciphertext, data []byte) ([]byte, error) {
 		return nil, errors.Wrap(err, `failed to compute auth tag`)
 	}
 
-	if subtle.ConstantTimeCompare(expectedTag, tag) != 1 {
+	// The tag must be verified, in constant time, before the ciphertext
+	// is decrypted (https://tools.ietf.org/html/rfc7518#section-5.2.2.2)
+	if !hmac.Equal(expectedTag, tag) {
 		if pdebug.Enabled {
 			pdebug.Printf("provided tag = %x\n", tag)
 			pdebug.Printf("expected tag = %x\n", expectedTag)
 		}
-		return nil, errors.New("invalid ciphertext (tag mismatch)")
+		return nil, errOpen
 	}
 
 	cbc := cipher.NewCBCDecrypter(c.blockCipher, nonce)
 	buf := make([]byte, tagOffset)
 	cbc.CryptBlocks(buf, ciphertext)
 
+	// The padding is only checked after the tag has been verified. Even
+	// so, an invalid padding produces the same error as an invalid tag,
+	// so that the two cannot be told apart
 	plaintext, err := padbuf.PadBuffer(buf).Unpad(c.blockCipher.BlockSize())
 	if err != nil {
-		return nil, errors.Wrap(err, `failed to generate plaintext from decrypted blocks`)
+		if pdebug.Enabled {
+			pdebug.Printf("failed to unpad decrypted blocks: %s", err)
+		}
+		return nil, errOpen
 	}
 	ret := ensureSize(dst, len(plaintext))
 	out := ret[len(dst):]
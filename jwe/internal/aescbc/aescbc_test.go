@@ -2,6 +2,8 @@ package aescbc
 
 import (
 	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -61,3 +63,112 @@ func TestVectorsAESCBC128(t *testing.T) {
 		return
 	}
 }
+
+func TestVectorsAESCBCHMAC(t *testing.T) {
+	// Source: https://tools.ietf.org/html/rfc7518#appendix-B.2 and
+	// https://tools.ietf.org/html/rfc7518#appendix-B.3
+	plaintext := []byte("A cipher system must not be required to be secret, and it must be able to fall into the hands of the enemy without inconvenience")
+	aad := []byte("The second principle of Auguste Kerckhoffs")
+	nonce, _ := hex.DecodeString("1af38c2dc2b96ffdd86694092341bc04")
+
+	testcases := []struct {
+		Name       string
+		KeySize    int
+		Ciphertext string
+		Tag        string
+	}{
+		{
+			Name:       "AES_192_CBC_HMAC_SHA_384",
+			KeySize:    48,
+			Ciphertext: "ea65da6b59e61edb419be62d19712ae5d303eeb50052d0dfd6697f77224c8edb000d279bdc14c1072654bd30944230c657bed4ca0c9f4a8466f22b226d1746214bf8cfc2400add9f5126e479663fc90b3bed787a2f0ffcbf3904be2a641d5c2105bfe591bae23b1d7449e532eef60a9ac8bb6c6b01d35d49787bcd57ef484927f280adc91ac0c4e79c7b11efc60054e3",
+			Tag:        "8490ac0e58949bfe51875d733f93ac2075168039ccc733d7",
+		},
+		{
+			Name:       "AES_256_CBC_HMAC_SHA_512",
+			KeySize:    64,
+			Ciphertext: "4affaaadb78c31c5da4b1b590d10ffbd3dd8d5d302423526912da037ecbcc7bd822c301dd67c373bccb584ad3e9279c2e6d12a1374b77f077553df829410446b36ebd97066296ae6427ea75c2e0846a11a09ccf5370dc80bfecbad28c73f09b3a3b75e662a2594410ae496b2e2e6609e31e6e02cc837f053d21f37ff4f51950bbe2638d09dd7a4930930806d0703b1f6",
+			Tag:        "4dd3b4c088a7f45c216839645b2012bf2e6269a8c56a816dbc1b267761955bc5",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			key := make([]byte, tc.KeySize)
+			for i := range key {
+				key[i] = byte(i)
+			}
+			ciphertext, _ := hex.DecodeString(tc.Ciphertext)
+			tag, _ := hex.DecodeString(tc.Tag)
+
+			enc, err := New(key, aes.NewCipher)
+			if !assert.NoError(t, err, "aescbc.New") {
+				return
+			}
+
+			out := enc.Seal(nil, nonce, plaintext, aad)
+			if !assert.Equal(t, ciphertext, out[:len(out)-len(tag)], "Ciphertext should match") {
+				return
+			}
+			if !assert.Equal(t, tag, out[len(out)-len(tag):], "Auth tag should match") {
+				return
+			}
+
+			decrypted, err := enc.Open(nil, nonce, out, aad)
+			if !assert.NoError(t, err, "Open should succeed") {
+				return
+			}
+			if !assert.Equal(t, plaintext, decrypted, "Open should get us original text") {
+				return
+			}
+
+			// Any modification to the tag must be detected
+			out[len(out)-1] ^= 0x01
+			if _, err := enc.Open(nil, nonce, out, aad); !assert.Error(t, err, "Open should fail") {
+				return
+			}
+			if _, err := enc.Open(nil, nonce[:8], out, aad); !assert.Error(t, err, "Open should fail with a short nonce") {
+				return
+			}
+			if _, err := enc.Open(nil, nonce, out[len(out)-len(tag):], aad); !assert.Error(t, err, "Open should fail with a short ciphertext") {
+				return
+			}
+		})
+	}
+}
+
+func TestOpen_InvalidPadding(t *testing.T) {
+	key := make([]byte, 32)
+	nonce := make([]byte, NonceSize)
+	aad := []byte("aad")
+
+	enc, err := New(key, aes.NewCipher)
+	if !assert.NoError(t, err, "aescbc.New") {
+		return
+	}
+
+	// Encrypt a block that does not end with a valid padding, and
+	// authenticate it with a valid tag
+	ciphertext := make([]byte, aes.BlockSize)
+	cbc := cipher.NewCBCEncrypter(enc.blockCipher, nonce)
+	cbc.CryptBlocks(ciphertext, ciphertext)
+	tag, err := enc.ComputeAuthTag(aad, nonce, ciphertext)
+	if !assert.NoError(t, err, "ComputeAuthTag should succeed") {
+		return
+	}
+
+	_, paddingErr := enc.Open(nil, nonce, append(ciphertext, tag...), aad)
+	if !assert.Error(t, paddingErr, "Open should fail with an invalid padding") {
+		return
+	}
+
+	tag[0] ^= 0x01
+	_, tagErr := enc.Open(nil, nonce, append(ciphertext, tag...), aad)
+	if !assert.Error(t, tagErr, "Open should fail with an invalid tag") {
+		return
+	}
+
+	if !assert.Equal(t, tagErr, paddingErr, "errors should be indistinguishable") {
+		return
+	}
+}
@@ -0,0 +1,110 @@
+package chacha20poly1305
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// The constant words "expand 32-byte k"
+const (
+	j0 uint32 = 0x61707865
+	j1 uint32 = 0x3320646e
+	j2 uint32 = 0x79622d32
+	j3 uint32 = 0x6b206574
+)
+
+func quarterRound(a, b, c, d uint32) (uint32, uint32, uint32, uint32) {
+	a += b
+	d ^= a
+	d = bits.RotateLeft32(d, 16)
+	c += d
+	b ^= c
+	b = bits.RotateLeft32(b, 12)
+	a += b
+	d ^= a
+	d = bits.RotateLeft32(d, 8)
+	c += d
+	b ^= c
+	b = bits.RotateLeft32(b, 7)
+	return a, b, c, d
+}
+
+// rounds applies the 20 ChaCha rounds (10 double rounds) to the state
+func rounds(x *[16]uint32) {
+	for i := 0; i < 10; i++ {
+		// column rounds
+		x[0], x[4], x[8], x[12] = quarterRound(x[0], x[4], x[8], x[12])
+		x[1], x[5], x[9], x[13] = quarterRound(x[1], x[5], x[9], x[13])
+		x[2], x[6], x[10], x[14] = quarterRound(x[2], x[6], x[10], x[14])
+		x[3], x[7], x[11], x[15] = quarterRound(x[3], x[7], x[11], x[15])
+
+		// diagonal rounds
+		x[0], x[5], x[10], x[15] = quarterRound(x[0], x[5], x[10], x[15])
+		x[1], x[6], x[11], x[12] = quarterRound(x[1], x[6], x[11], x[12])
+		x[2], x[7], x[8], x[13] = quarterRound(x[2], x[7], x[8], x[13])
+		x[3], x[4], x[9], x[14] = quarterRound(x[3], x[4], x[9], x[14])
+	}
+}
+
+func initialState(key *[KeySize]byte) [16]uint32 {
+	var s [16]uint32
+	s[0], s[1], s[2], s[3] = j0, j1, j2, j3
+	for i := 0; i < 8; i++ {
+		s[4+i] = binary.LittleEndian.Uint32(key[i*4:])
+	}
+	return s
+}
+
+// chacha20Block computes the ChaCha20 block function as described in
+// https://tools.ietf.org/html/rfc8439#section-2.3
+func chacha20Block(out *[64]byte, key *[KeySize]byte, nonce *[NonceSize]byte, counter uint32) {
+	s := initialState(key)
+	s[12] = counter
+	s[13] = binary.LittleEndian.Uint32(nonce[0:])
+	s[14] = binary.LittleEndian.Uint32(nonce[4:])
+	s[15] = binary.LittleEndian.Uint32(nonce[8:])
+
+	x := s
+	rounds(&x)
+	for i := range x {
+		binary.LittleEndian.PutUint32(out[i*4:], x[i]+s[i])
+	}
+}
+
+// xorKeyStream encrypts (or decrypts) src into dst using the ChaCha20
+// keystream, starting from the given block counter
+func xorKeyStream(dst, src []byte, key *[KeySize]byte, nonce *[NonceSize]byte, counter uint32) {
+	var block [64]byte
+	for len(src) > 0 {
+		chacha20Block(&block, key, nonce, counter)
+		counter++
+
+		n := len(src)
+		if n > len(block) {
+			n = len(block)
+		}
+		for i := 0; i < n; i++ {
+			dst[i] = src[i] ^ block[i]
+		}
+		dst = dst[n:]
+		src = src[n:]
+	}
+}
+
+// hchacha20 derives a subkey from the key and the first 16 bytes of an
+// extended nonce, as described in https://tools.ietf.org/html/draft-irtf-cfrg-xchacha-03#section-2.2
+func hchacha20(key *[KeySize]byte, nonce []byte) [KeySize]byte {
+	x := initialState(key)
+	x[12] = binary.LittleEndian.Uint32(nonce[0:])
+	x[13] = binary.LittleEndian.Uint32(nonce[4:])
+	x[14] = binary.LittleEndian.Uint32(nonce[8:])
+	x[15] = binary.LittleEndian.Uint32(nonce[12:])
+	rounds(&x)
+
+	var out [KeySize]byte
+	for i := 0; i < 4; i++ {
+		binary.LittleEndian.PutUint32(out[i*4:], x[i])
+		binary.LittleEndian.PutUint32(out[16+i*4:], x[12+i])
+	}
+	return out
+}
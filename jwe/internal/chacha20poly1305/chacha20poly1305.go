@@ -0,0 +1,151 @@
+// Package chacha20poly1305 implements the ChaCha20-Poly1305 AEAD as
+// described in https://tools.ietf.org/html/rfc8439, and its extended
+// nonce variant XChaCha20-Poly1305 as described in
+// https://tools.ietf.org/html/draft-irtf-cfrg-xchacha-03
+//
+// The API mirrors that of golang.org/x/crypto/chacha20poly1305
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// KeySize is the size of the key used by this AEAD, in bytes.
+	KeySize = 32
+	// NonceSize is the size of the nonce used with the standard variant of this AEAD, in bytes.
+	NonceSize = 12
+	// NonceSizeX is the size of the nonce used with the XChaCha20-Poly1305 variant of this AEAD, in bytes.
+	NonceSizeX = 24
+	// Overhead is the size of the Poly1305 authentication tag, in bytes.
+	Overhead = 16
+)
+
+type chacha20poly1305 struct {
+	key    [KeySize]byte
+	xnonce bool
+}
+
+// New returns a ChaCha20-Poly1305 AEAD that uses the given 256-bit key.
+func New(key []byte) (cipher.AEAD, error) {
+	return newAEAD(key, false)
+}
+
+// NewX returns a XChaCha20-Poly1305 AEAD that uses the given 256-bit key.
+func NewX(key []byte) (cipher.AEAD, error) {
+	return newAEAD(key, true)
+}
+
+func newAEAD(key []byte, xnonce bool) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, errors.Errorf(`chacha20poly1305: bad key length: %d`, len(key))
+	}
+	c := &chacha20poly1305{xnonce: xnonce}
+	copy(c.key[:], key)
+	return c, nil
+}
+
+func (c *chacha20poly1305) NonceSize() int {
+	if c.xnonce {
+		return NonceSizeX
+	}
+	return NonceSize
+}
+
+func (c *chacha20poly1305) Overhead() int {
+	return Overhead
+}
+
+// streamKey returns the key and the 96-bit nonce to be used with
+// the ChaCha20 stream cipher. For XChaCha20, these are derived using HChaCha20
+func (c *chacha20poly1305) streamKey(nonce []byte) ([KeySize]byte, [NonceSize]byte) {
+	var n [NonceSize]byte
+	if !c.xnonce {
+		copy(n[:], nonce)
+		return c.key, n
+	}
+
+	subkey := hchacha20(&c.key, nonce[:16])
+	copy(n[4:], nonce[16:])
+	return subkey, n
+}
+
+func (c *chacha20poly1305) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != c.NonceSize() {
+		panic("chacha20poly1305: bad nonce length passed to Seal")
+	}
+
+	key, n := c.streamKey(nonce)
+
+	ret, out := sliceForAppend(dst, len(plaintext)+Overhead)
+	ciphertext := out[:len(plaintext)]
+	xorKeyStream(ciphertext, plaintext, &key, &n, 1)
+
+	tag := computeTag(&key, &n, additionalData, ciphertext)
+	copy(out[len(plaintext):], tag[:])
+	return ret
+}
+
+func (c *chacha20poly1305) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != c.NonceSize() {
+		panic("chacha20poly1305: bad nonce length passed to Open")
+	}
+	if len(ciphertext) < Overhead {
+		return nil, errors.New(`chacha20poly1305: ciphertext too short`)
+	}
+
+	key, n := c.streamKey(nonce)
+
+	tag := ciphertext[len(ciphertext)-Overhead:]
+	ciphertext = ciphertext[:len(ciphertext)-Overhead]
+
+	expected := computeTag(&key, &n, additionalData, ciphertext)
+	if subtle.ConstantTimeCompare(expected[:], tag) != 1 {
+		return nil, errors.New(`chacha20poly1305: message authentication failed`)
+	}
+
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	xorKeyStream(out, ciphertext, &key, &n, 1)
+	return ret, nil
+}
+
+// computeTag computes the Poly1305 tag over the additional data and the
+// ciphertext, as described in https://tools.ietf.org/html/rfc8439#section-2.8
+func computeTag(key *[KeySize]byte, nonce *[NonceSize]byte, additionalData, ciphertext []byte) [Overhead]byte {
+	// The one-time Poly1305 key is the first 32 bytes of the
+	// keystream block with the counter set to 0
+	var block [64]byte
+	chacha20Block(&block, key, nonce, 0)
+
+	var mac poly1305
+	mac.init(block[:32])
+	mac.write(additionalData)
+	mac.pad()
+	mac.write(ciphertext)
+	mac.pad()
+
+	var lengths [16]byte
+	binary.LittleEndian.PutUint64(lengths[:8], uint64(len(additionalData)))
+	binary.LittleEndian.PutUint64(lengths[8:], uint64(len(ciphertext)))
+	mac.write(lengths[:])
+
+	return mac.sum()
+}
+
+// sliceForAppend takes a slice and a requested number of bytes. It returns a
+// slice with the contents of the given slice followed by that many bytes and a
+// second slice that aliases into it and contains only the extra bytes.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
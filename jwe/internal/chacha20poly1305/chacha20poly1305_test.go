@@ -0,0 +1,90 @@
+package chacha20poly1305_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/jwe/internal/chacha20poly1305"
+	"github.com/stretchr/testify/assert"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("failed to decode hex: %s", err)
+	}
+	return b
+}
+
+func TestChaCha20Poly1305(t *testing.T) {
+	const plaintext = "Ladies and Gentlemen of the class of '99: If I could offer you only one tip for the future, sunscreen would be it."
+	const key = `808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f`
+	const aad = `50515253c0c1c2c3c4c5c6c7`
+
+	testcases := []struct {
+		Name       string
+		X          bool
+		Nonce      string
+		Ciphertext string
+		Tag        string
+	}{
+		{
+			// https://tools.ietf.org/html/rfc8439#section-2.8.2
+			Name:       "ChaCha20-Poly1305",
+			Nonce:      `070000004041424344454647`,
+			Ciphertext: `d31a8d34648e60db7b86afbc53ef7ec2a4aded51296e08fea9e2b5a736ee62d63dbea45e8ca9671282fafb69da92728b1a71de0a9e060b2905d6a5b67ecd3b3692ddbd7f2d778b8c9803aee328091b58fab324e4fad675945585808b4831d7bc3ff4def08e4b7a9de576d26586cec64b6116`,
+			Tag:        `1ae10b594f09e26a7e902ecbd0600691`,
+		},
+		{
+			// https://tools.ietf.org/html/draft-irtf-cfrg-xchacha-03#appendix-A.3.1
+			Name:       "XChaCha20-Poly1305",
+			X:          true,
+			Nonce:      `404142434445464748494a4b4c4d4e4f5051525354555657`,
+			Ciphertext: `bd6d179d3e83d43b9576579493c0e939572a1700252bfaccbed2902c21396cbb731c7f1b0b4aa6440bf3a82f4eda7e39ae64c6708c54c216cb96b72e1213b4522f8c9ba40db5d945b11b69b982c1bb9e3f3fac2bc369488f76b2383565d3fff921f9664c97637da9768812f615c68b13b52e`,
+			Tag:        `c0875924c1c7987947deafd8780acf49`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			newAEAD := chacha20poly1305.New
+			if tc.X {
+				newAEAD = chacha20poly1305.NewX
+			}
+
+			aead, err := newAEAD(mustHex(t, key))
+			if !assert.NoError(t, err, `creating AEAD should succeed`) {
+				return
+			}
+
+			nonce := mustHex(t, tc.Nonce)
+			expected := append(mustHex(t, tc.Ciphertext), mustHex(t, tc.Tag)...)
+
+			sealed := aead.Seal(nil, nonce, []byte(plaintext), mustHex(t, aad))
+			if !assert.Equal(t, expected, sealed, `ciphertext and tag should match`) {
+				return
+			}
+
+			opened, err := aead.Open(nil, nonce, sealed, mustHex(t, aad))
+			if !assert.NoError(t, err, `aead.Open should succeed`) {
+				return
+			}
+			if !assert.Equal(t, plaintext, string(opened), `plaintext should match`) {
+				return
+			}
+
+			sealed[0] ^= 0x1
+			if _, err := aead.Open(nil, nonce, sealed, mustHex(t, aad)); !assert.Error(t, err, `aead.Open should fail for modified ciphertext`) {
+				return
+			}
+		})
+	}
+
+	t.Run("invalid key", func(t *testing.T) {
+		if _, err := chacha20poly1305.New(make([]byte, 16)); !assert.Error(t, err, `New should fail`) {
+			return
+		}
+	})
+}
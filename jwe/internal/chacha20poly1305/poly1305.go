@@ -0,0 +1,185 @@
+package chacha20poly1305
+
+import (
+	"encoding/binary"
+)
+
+// poly1305 computes the Poly1305 one-time authenticator as described
+// in https://tools.ietf.org/html/rfc8439#section-2.5, using 26-bit limbs
+// so that all arithmetic is carried out in constant time
+type poly1305 struct {
+	r      [5]uint32
+	s      [4]uint32
+	h      [5]uint32
+	buf    [16]byte
+	buflen int
+}
+
+const limbMask = 0x3ffffff
+
+func (p *poly1305) init(key []byte) {
+	// r is clamped as described in RFC8439 Section 2.5
+	p.r[0] = binary.LittleEndian.Uint32(key[0:]) & 0x3ffffff
+	p.r[1] = (binary.LittleEndian.Uint32(key[3:]) >> 2) & 0x3ffff03
+	p.r[2] = (binary.LittleEndian.Uint32(key[6:]) >> 4) & 0x3ffc0ff
+	p.r[3] = (binary.LittleEndian.Uint32(key[9:]) >> 6) & 0x3f03fff
+	p.r[4] = (binary.LittleEndian.Uint32(key[12:]) >> 8) & 0x00fffff
+
+	for i := 0; i < 4; i++ {
+		p.s[i] = binary.LittleEndian.Uint32(key[16+i*4:])
+	}
+}
+
+// block processes one 16 byte block. hibit is 1<<24 for full blocks,
+// and 0 for the final partial block, which is already padded with 0x01
+func (p *poly1305) block(m []byte, hibit uint32) {
+	r0, r1, r2, r3, r4 := uint64(p.r[0]), uint64(p.r[1]), uint64(p.r[2]), uint64(p.r[3]), uint64(p.r[4])
+	s1, s2, s3, s4 := r1*5, r2*5, r3*5, r4*5
+
+	h0 := uint64(p.h[0] + binary.LittleEndian.Uint32(m[0:])&limbMask)
+	h1 := uint64(p.h[1] + (binary.LittleEndian.Uint32(m[3:])>>2)&limbMask)
+	h2 := uint64(p.h[2] + (binary.LittleEndian.Uint32(m[6:])>>4)&limbMask)
+	h3 := uint64(p.h[3] + (binary.LittleEndian.Uint32(m[9:])>>6)&limbMask)
+	h4 := uint64(p.h[4] + (binary.LittleEndian.Uint32(m[12:])>>8 | hibit))
+
+	d0 := h0*r0 + h1*s4 + h2*s3 + h3*s2 + h4*s1
+	d1 := h0*r1 + h1*r0 + h2*s4 + h3*s3 + h4*s2
+	d2 := h0*r2 + h1*r1 + h2*r0 + h3*s4 + h4*s3
+	d3 := h0*r3 + h1*r2 + h2*r1 + h3*r0 + h4*s4
+	d4 := h0*r4 + h1*r3 + h2*r2 + h3*r1 + h4*r0
+
+	c := d0 >> 26
+	p.h[0] = uint32(d0) & limbMask
+	d1 += c
+	c = d1 >> 26
+	p.h[1] = uint32(d1) & limbMask
+	d2 += c
+	c = d2 >> 26
+	p.h[2] = uint32(d2) & limbMask
+	d3 += c
+	c = d3 >> 26
+	p.h[3] = uint32(d3) & limbMask
+	d4 += c
+	c = d4 >> 26
+	p.h[4] = uint32(d4) & limbMask
+	p.h[0] += uint32(c) * 5
+	p.h[1] += p.h[0] >> 26
+	p.h[0] &= limbMask
+}
+
+func (p *poly1305) write(m []byte) {
+	if p.buflen > 0 {
+		n := copy(p.buf[p.buflen:], m)
+		p.buflen += n
+		m = m[n:]
+		if p.buflen < len(p.buf) {
+			return
+		}
+		p.block(p.buf[:], 1<<24)
+		p.buflen = 0
+	}
+
+	for len(m) >= 16 {
+		p.block(m[:16], 1<<24)
+		m = m[16:]
+	}
+
+	if len(m) > 0 {
+		p.buflen = copy(p.buf[:], m)
+	}
+}
+
+// pad completes the current block with zeros, as required between
+// the fields of the AEAD construction
+func (p *poly1305) pad() {
+	if p.buflen == 0 {
+		return
+	}
+	for i := p.buflen; i < len(p.buf); i++ {
+		p.buf[i] = 0
+	}
+	p.block(p.buf[:], 1<<24)
+	p.buflen = 0
+}
+
+func (p *poly1305) sum() [16]byte {
+	if p.buflen > 0 {
+		p.buf[p.buflen] = 1
+		for i := p.buflen + 1; i < len(p.buf); i++ {
+			p.buf[i] = 0
+		}
+		p.block(p.buf[:], 0)
+		p.buflen = 0
+	}
+
+	h0, h1, h2, h3, h4 := p.h[0], p.h[1], p.h[2], p.h[3], p.h[4]
+
+	// fully carry h
+	c := h1 >> 26
+	h1 &= limbMask
+	h2 += c
+	c = h2 >> 26
+	h2 &= limbMask
+	h3 += c
+	c = h3 >> 26
+	h3 &= limbMask
+	h4 += c
+	c = h4 >> 26
+	h4 &= limbMask
+	h0 += c * 5
+	c = h0 >> 26
+	h0 &= limbMask
+	h1 += c
+
+	// compute g = h + -p = h - (2^130 - 5)
+	g0 := h0 + 5
+	c = g0 >> 26
+	g0 &= limbMask
+	g1 := h1 + c
+	c = g1 >> 26
+	g1 &= limbMask
+	g2 := h2 + c
+	c = g2 >> 26
+	g2 &= limbMask
+	g3 := h3 + c
+	c = g3 >> 26
+	g3 &= limbMask
+	g4 := h4 + c - (1 << 26)
+
+	// select h if h < p, or g if h >= p
+	mask := (g4 >> 31) - 1
+	g0 &= mask
+	g1 &= mask
+	g2 &= mask
+	g3 &= mask
+	g4 &= mask
+	mask = ^mask
+	h0 = (h0 & mask) | g0
+	h1 = (h1 & mask) | g1
+	h2 = (h2 & mask) | g2
+	h3 = (h3 & mask) | g3
+	h4 = (h4 & mask) | g4
+
+	// h = h % 2^128
+	h0 = h0 | (h1 << 26)
+	h1 = (h1 >> 6) | (h2 << 20)
+	h2 = (h2 >> 12) | (h3 << 14)
+	h3 = (h3 >> 18) | (h4 << 8)
+
+	// tag = (h + s) % 2^128
+	f := uint64(h0) + uint64(p.s[0])
+	h0 = uint32(f)
+	f = uint64(h1) + uint64(p.s[1]) + (f >> 32)
+	h1 = uint32(f)
+	f = uint64(h2) + uint64(p.s[2]) + (f >> 32)
+	h2 = uint32(f)
+	f = uint64(h3) + uint64(p.s[3]) + (f >> 32)
+	h3 = uint32(f)
+
+	var tag [16]byte
+	binary.LittleEndian.PutUint32(tag[0:], h0)
+	binary.LittleEndian.PutUint32(tag[4:], h1)
+	binary.LittleEndian.PutUint32(tag[8:], h2)
+	binary.LittleEndian.PutUint32(tag[12:], h3)
+	return tag
+}
@@ -7,13 +7,22 @@ import (
 
 	"github.com/lestrrat-go/jwx/jwa"
 	"github.com/lestrrat-go/jwx/jwe/internal/aescbc"
+	"github.com/lestrrat-go/jwx/jwe/internal/chacha20poly1305"
 	"github.com/lestrrat-go/jwx/jwe/internal/keygen"
 	"github.com/lestrrat-go/pdebug"
 	"github.com/pkg/errors"
 )
 
+// ErrAuthTagMismatch is returned when the decrypted content could not be
+// authenticated. This means that the ciphertext, the authentication tag,
+// or the additional authenticated data has been tampered with (or that the
+// wrong content encryption key was used)
+var ErrAuthTagMismatch = errors.New(`content decrypt: authentication tag mismatch`)
+
 var gcm = &gcmFetcher{}
 var cbc = &cbcFetcher{}
+var chacha = &chachaFetcher{}
+var xchacha = &chachaFetcher{xnonce: true}
 
 func GCMFetcher() Fetcher {
 	return gcm
@@ -54,16 +63,41 @@ func (f cbcFetcher) Fetch(key []byte) (cipher.AEAD, error) {
 	return aead, nil
 }
 
-func (c AesContentCipher) KeySize() int {
+func (f chachaFetcher) Fetch(key []byte) (cipher.AEAD, error) {
+	var aead cipher.AEAD
+	var err error
+	if f.xnonce {
+		aead, err = chacha20poly1305.NewX(key)
+	} else {
+		aead, err = chacha20poly1305.New(key)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "cipher: failed to create ChaCha20-Poly1305 cipher")
+	}
+	return aead, nil
+}
+
+func (c aeadContentCipher) KeySize() int {
 	return c.keysize
 }
 
-func (c AesContentCipher) TagSize() int {
+func (c aeadContentCipher) TagSize() int {
 	return c.tagsize
 }
 
+// New creates a content cipher for the given content encryption algorithm
+func New(alg jwa.ContentEncryptionAlgorithm) (ContentCipher, error) {
+	switch alg {
+	case jwa.C20P, jwa.XC20P:
+		return NewChaCha(alg)
+	default:
+		return NewAES(alg)
+	}
+}
+
 func NewAES(alg jwa.ContentEncryptionAlgorithm) (*AesContentCipher, error) {
 	var keysize int
+	var tagsize = TagSize
 	var fetcher Fetcher
 	switch alg {
 	case jwa.A128GCM:
@@ -75,27 +109,57 @@ func NewAES(alg jwa.ContentEncryptionAlgorithm) (*AesContentCipher, error) {
 	case jwa.A256GCM:
 		keysize = 32
 		fetcher = gcm
-	case jwa.A128CBC_HS256:
-		keysize = 16 * 2
-		fetcher = cbc
-	case jwa.A192CBC_HS384:
-		keysize = 24 * 2
-		fetcher = cbc
-	case jwa.A256CBC_HS512:
-		keysize = 32 * 2
+	case jwa.A128CBC_HS256, jwa.A192CBC_HS384, jwa.A256CBC_HS512:
+		// The key is split into a MAC key and an encryption key of the
+		// same size, and the authentication tag is the HMAC truncated
+		// to that size (https://tools.ietf.org/html/rfc7518#section-5.2)
+		switch alg {
+		case jwa.A128CBC_HS256:
+			tagsize = 16
+		case jwa.A192CBC_HS384:
+			tagsize = 24
+		case jwa.A256CBC_HS512:
+			tagsize = 32
+		}
+		keysize = tagsize * 2
 		fetcher = cbc
 	default:
 		return nil, errors.Errorf("failed to create AES content cipher: invalid algorithm (%s)", alg)
 	}
 
 	return &AesContentCipher{
-		keysize: keysize,
-		tagsize: TagSize,
-		fetch:   fetcher,
+		aeadContentCipher: aeadContentCipher{
+			keysize: keysize,
+			tagsize: tagsize,
+			fetch:   fetcher,
+		},
+	}, nil
+}
+
+// NewChaCha creates a content cipher for ChaCha20-Poly1305 (C20P) or
+// XChaCha20-Poly1305 (XC20P). Both use a 256 bit key, and a nonce
+// of 96 and 192 bits, respectively
+func NewChaCha(alg jwa.ContentEncryptionAlgorithm) (*ChaChaContentCipher, error) {
+	var fetcher Fetcher
+	switch alg {
+	case jwa.C20P:
+		fetcher = chacha
+	case jwa.XC20P:
+		fetcher = xchacha
+	default:
+		return nil, errors.Errorf("failed to create ChaCha20-Poly1305 content cipher: invalid algorithm (%s)", alg)
+	}
+
+	return &ChaChaContentCipher{
+		aeadContentCipher: aeadContentCipher{
+			keysize: chacha20poly1305.KeySize,
+			tagsize: chacha20poly1305.Overhead,
+			fetch:   fetcher,
+		},
 	}, nil
 }
 
-func (c AesContentCipher) Encrypt(cek, plaintext, aad []byte) (iv, ciphertext, tag []byte, err error) {
+func (c aeadContentCipher) Encrypt(cek, plaintext, aad []byte) (iv, ciphertext, tag []byte, err error) {
 	var aead cipher.AEAD
 	aead, err = c.fetch.Fetch(cek)
 	if err != nil {
@@ -122,7 +186,7 @@ func (c AesContentCipher) Encrypt(cek, plaintext, aad []byte) (iv, ciphertext, t
 
 	var bs keygen.ByteSource
 	if c.NonceGenerator == nil {
-		bs, err = keygen.NewRandom(aead.NonceSize()).Generate()
+		bs, err = keygen.NewRandomWithReader(aead.NonceSize(), c.Rand).Generate()
 	} else {
 		bs, err = c.NonceGenerator.Generate()
 	}
@@ -149,9 +213,9 @@ func (c AesContentCipher) Encrypt(cek, plaintext, aad []byte) (iv, ciphertext, t
 	return
 }
 
-func (c AesContentCipher) Decrypt(cek, iv, ciphertxt, tag, aad []byte) (plaintext []byte, err error) {
+func (c aeadContentCipher) Decrypt(cek, iv, ciphertxt, tag, aad []byte) (plaintext []byte, err error) {
 	if pdebug.Enabled {
-		g := pdebug.Marker("cipher.AesContentCipher.Decrypt")
+		g := pdebug.Marker("cipher.aeadContentCipher.Decrypt")
 		defer g.End()
 	}
 
@@ -163,6 +227,12 @@ func (c AesContentCipher) Decrypt(cek, iv, ciphertxt, tag, aad []byte) (plaintex
 		return nil, errors.Wrap(err, "failed to fetch AEAD data")
 	}
 
+	// The AEAD implementations panic when the nonce has the wrong size,
+	// so catch it here instead of relying on the recover() below
+	if len(iv) != aead.NonceSize() {
+		return nil, errors.Errorf("invalid iv size (expected %d, got %d)", aead.NonceSize(), len(iv))
+	}
+
 	// Open may panic (argh!), so protect ourselves from that
 	defer func() {
 		if e := recover(); e != nil {
@@ -184,9 +254,17 @@ func (c AesContentCipher) Decrypt(cek, iv, ciphertxt, tag, aad []byte) (plaintex
 	copy(combined[len(ciphertxt):], tag)
 
 	if pdebug.Enabled {
-		pdebug.Printf("AesContentCipher.decrypt: combined = %x (%d)", combined, len(combined))
+		pdebug.Printf("aeadContentCipher.decrypt: combined = %x (%d)", combined, len(combined))
 	}
 
-	plaintext, err = aead.Open(nil, iv, combined, aad)
-	return
+	// combined is not used after this point, so its storage is reused
+	// for the plaintext instead of allocating yet another buffer
+	plaintext, err = aead.Open(combined[:0], iv, combined, aad)
+	if err != nil {
+		if pdebug.Enabled {
+			pdebug.Printf("aeadContentCipher.decrypt: Open failed: %s", err)
+		}
+		return nil, errors.Wrap(ErrAuthTagMismatch, "failed to decrypt")
+	}
+	return plaintext, nil
 }
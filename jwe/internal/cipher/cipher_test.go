@@ -9,19 +9,74 @@ import (
 )
 
 func TestAES(t *testing.T) {
-	algs := []jwa.ContentEncryptionAlgorithm{
-		jwa.A128GCM,
-		jwa.A192GCM,
-		jwa.A256GCM,
-		jwa.A128CBC_HS256,
-		jwa.A192CBC_HS384,
-		jwa.A256CBC_HS512,
+	testcases := []struct {
+		Algorithm jwa.ContentEncryptionAlgorithm
+		KeySize   int
+		TagSize   int
+	}{
+		{Algorithm: jwa.A128GCM, KeySize: 16, TagSize: 16},
+		{Algorithm: jwa.A192GCM, KeySize: 24, TagSize: 16},
+		{Algorithm: jwa.A256GCM, KeySize: 32, TagSize: 16},
+		{Algorithm: jwa.A128CBC_HS256, KeySize: 32, TagSize: 16},
+		{Algorithm: jwa.A192CBC_HS384, KeySize: 48, TagSize: 24},
+		{Algorithm: jwa.A256CBC_HS512, KeySize: 64, TagSize: 32},
 	}
-	for _, alg := range algs {
-		c, err := cipher.NewAES(alg)
-		if !assert.NoError(t, err, "BuildCipher for %s succeeds", alg) {
+	for _, tc := range testcases {
+		c, err := cipher.NewAES(tc.Algorithm)
+		if !assert.NoError(t, err, "BuildCipher for %s succeeds", tc.Algorithm) {
 			return
 		}
-		t.Logf("keysize = %d", c.KeySize())
+		if !assert.Equal(t, tc.KeySize, c.KeySize(), `key size should match`) {
+			return
+		}
+
+		cek := make([]byte, c.KeySize())
+		_, _, tag, err := c.Encrypt(cek, []byte("Lorem ipsum"), []byte("aad"))
+		if !assert.NoError(t, err, `Encrypt should succeed`) {
+			return
+		}
+		if !assert.Len(t, tag, tc.TagSize, `tag size should match`) {
+			return
+		}
+	}
+}
+
+func TestChaCha(t *testing.T) {
+	testcases := []struct {
+		Algorithm jwa.ContentEncryptionAlgorithm
+		NonceSize int
+	}{
+		{Algorithm: jwa.C20P, NonceSize: 12},
+		{Algorithm: jwa.XC20P, NonceSize: 24},
+	}
+	for _, tc := range testcases {
+		c, err := cipher.NewChaCha(tc.Algorithm)
+		if !assert.NoError(t, err, "NewChaCha for %s succeeds", tc.Algorithm) {
+			return
+		}
+		if !assert.Equal(t, 32, c.KeySize(), `key size should be 32`) {
+			return
+		}
+
+		cek := make([]byte, c.KeySize())
+		iv, ciphertext, tag, err := c.Encrypt(cek, []byte("Lorem ipsum"), []byte("aad"))
+		if !assert.NoError(t, err, `Encrypt should succeed`) {
+			return
+		}
+		if !assert.Len(t, iv, tc.NonceSize, `nonce size should match`) {
+			return
+		}
+
+		plaintext, err := c.Decrypt(cek, iv, ciphertext, tag, []byte("aad"))
+		if !assert.NoError(t, err, `Decrypt should succeed`) {
+			return
+		}
+		if !assert.Equal(t, []byte("Lorem ipsum"), plaintext, `plaintext should match`) {
+			return
+		}
+	}
+
+	if _, err := cipher.NewChaCha(jwa.A128GCM); !assert.Error(t, err, `NewChaCha should fail for AES algorithms`) {
+		return
 	}
 }
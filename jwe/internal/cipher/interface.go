@@ -2,6 +2,7 @@ package cipher
 
 import (
 	"crypto/cipher"
+	"io"
 
 	"github.com/lestrrat-go/jwx/jwe/internal/keygen"
 )
@@ -24,11 +25,28 @@ type Fetcher interface {
 
 type gcmFetcher struct{}
 type cbcFetcher struct{}
+type chachaFetcher struct {
+	xnonce bool
+}
+
+// aeadContentCipher implements the parts that are common to all
+// content ciphers based on an AEAD
+type aeadContentCipher struct {
+	NonceGenerator keygen.Generator
+	// Rand is the source of randomness used to generate nonces when
+	// NonceGenerator is not specified. If nil, crypto/rand.Reader is used
+	Rand    io.Reader
+	fetch   Fetcher
+	keysize int
+	tagsize int
+}
 
 // AesContentCipher represents a cipher based on AES
 type AesContentCipher struct {
-	NonceGenerator keygen.Generator
-	fetch          Fetcher
-	keysize        int
-	tagsize        int
+	aeadContentCipher
+}
+
+// ChaChaContentCipher represents a cipher based on ChaCha20-Poly1305
+type ChaChaContentCipher struct {
+	aeadContentCipher
 }
@@ -72,7 +72,7 @@ func fieldStorageType(s string) string {
 }
 
 func fieldStorageTypeIsIndirect(s string) bool {
-	return !(s == "jwk.Key" || s == "jwk.ECDSAPublicKey" || strings.HasPrefix(s, `*`) || strings.HasPrefix(s, `[]`))
+	return !(s == "jwk.Key" || strings.HasPrefix(s, `*`) || strings.HasPrefix(s, `[]`))
 }
 
 func generateHeaders() error {
@@ -140,7 +140,7 @@ func generateHeaders() error {
 		{
 			name:   `ephemeralPublicKey`,
 			method: `EphemeralPublicKey`,
-			typ:    `jwk.ECDSAPublicKey`,
+			typ:    `jwk.Key`,
 			key:    `epk`,
 			//			comment: `https://tools.ietf.org/html/rfc7515#section-4.1.3`,
 			jsonTag: "`" + `json:"epk,omitempty"` + "`",
@@ -259,6 +259,7 @@ func generateHeaders() error {
 	// These are used to access a single element by key name
 	fmt.Fprintf(&buf, "\nGet(string) (interface{}, bool)")
 	fmt.Fprintf(&buf, "\nSet(string, interface{}) error")
+	fmt.Fprintf(&buf, "\nSetX509CertChain([]string) error")
 	fmt.Fprintf(&buf, "\nRemove(string) error")
 
 	// These are used to deal with encoded headers
@@ -362,6 +363,12 @@ func generateHeaders() error {
 	fmt.Fprintf(&buf, "\n}") // end switch name
 	fmt.Fprintf(&buf, "\n}") // func (h *stdHeaders) Get(name string) (interface{}, bool)
 
+	fmt.Fprintf(&buf, "\n\n// SetX509CertChain sets the \"x5c\" header. It is equivalent to")
+	fmt.Fprintf(&buf, "\n// calling Set(X509CertChainKey, chain)")
+	fmt.Fprintf(&buf, "\nfunc (h *stdHeaders) SetX509CertChain(chain []string) error {")
+	fmt.Fprintf(&buf, "\nreturn h.Set(X509CertChainKey, chain)")
+	fmt.Fprintf(&buf, "\n}")
+
 	fmt.Fprintf(&buf, "\n\nfunc (h *stdHeaders) Set(name string, value interface{}) error {")
 	fmt.Fprintf(&buf, "\nswitch name {")
 	for _, f := range fields {
@@ -435,9 +442,10 @@ func generateHeaders() error {
 	fmt.Fprintf(&buf, "\nif err != nil {")
 	fmt.Fprintf(&buf, "\nreturn errors.Wrap(err, `failed to parse epk field`)")
 	fmt.Fprintf(&buf, "\n}")
-	fmt.Fprintf(&buf, "\nif ecEpk, ok := epk.(jwk.ECDSAPublicKey); ok {")
-	fmt.Fprintf(&buf, "\nh.ephemeralPublicKey = ecEpk")
-	fmt.Fprintf(&buf, "\n} else {")
+	fmt.Fprintf(&buf, "\nswitch epk.(type) {")
+	fmt.Fprintf(&buf, "\ncase jwk.ECDSAPublicKey, jwk.OKPPublicKey:")
+	fmt.Fprintf(&buf, "\nh.ephemeralPublicKey = epk")
+	fmt.Fprintf(&buf, "\ndefault:")
 	fmt.Fprintf(&buf, "\nreturn errors.Errorf(`invalid type for epk field %%T`, epk)")
 	fmt.Fprintf(&buf, "\n}")
 	fmt.Fprintf(&buf, "\n}")
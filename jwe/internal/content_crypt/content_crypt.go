@@ -1,6 +1,8 @@
 package content_crypt
 
 import (
+	"io"
+
 	"github.com/lestrrat-go/jwx/jwa"
 	"github.com/lestrrat-go/jwx/jwe/internal/cipher"
 	"github.com/lestrrat-go/jwx/jwe/internal/keygen"
@@ -34,23 +36,32 @@ func (c Generic) Decrypt(cek, iv, ciphertext, tag, aad []byte) ([]byte, error) {
 	return c.cipher.Decrypt(cek, iv, ciphertext, tag, aad)
 }
 
-func NewAES(alg jwa.ContentEncryptionAlgorithm) (*Generic, error) {
+// New creates a content encrypter for the given content encryption algorithm.
+// The nonces are generated using rand, or crypto/rand.Reader if it is nil
+func New(alg jwa.ContentEncryptionAlgorithm, rand io.Reader) (*Generic, error) {
 	if pdebug.Enabled {
-		pdebug.Printf("AES Crypt: alg = %s", alg)
+		pdebug.Printf("Content Crypt: alg = %s", alg)
 	}
-	c, err := cipher.NewAES(alg)
+	c, err := cipher.New(alg)
 	if err != nil {
-		return nil, errors.Wrap(err, `aes crypt: failed to create content cipher`)
+		return nil, errors.Wrap(err, `content crypt: failed to create content cipher`)
+	}
+
+	switch c := c.(type) {
+	case *cipher.AesContentCipher:
+		c.Rand = rand
+	case *cipher.ChaChaContentCipher:
+		c.Rand = rand
 	}
 
 	if pdebug.Enabled {
-		pdebug.Printf("AES Crypt: cipher.keysize = %d", c.KeySize())
+		pdebug.Printf("Content Crypt: cipher.keysize = %d", c.KeySize())
 	}
 
 	return &Generic{
 		alg:     alg,
 		cipher:  c,
-		cekgen:  keygen.NewRandom(c.KeySize() * 2),
+		cekgen:  keygen.NewRandomWithReader(c.KeySize()*2, rand),
 		keysize: c.KeySize() * 2,
 		tagsize: 16,
 	}, nil
@@ -0,0 +1,127 @@
+// Package ecdhes implements the key agreement used by the ECDH-ES
+// family of key management algorithms. It is shared by the key
+// generators, which are used when encrypting, and the key decrypters.
+package ecdhes
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/binary"
+
+	"github.com/lestrrat-go/jwx/internal/concatkdf"
+	"github.com/lestrrat-go/jwx/internal/ecutil"
+	"github.com/lestrrat-go/jwx/internal/secp256k1"
+	"github.com/lestrrat-go/jwx/x25519"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidECDHKey is returned when the public key used in an ECDH
+// key agreement is not a valid point on the curve of the private key.
+// Performing the scalar multiplication with such a point would let an
+// attacker who chose it recover bits of the private key (invalid curve
+// attack), so the key is always checked before it is used.
+var ErrInvalidECDHKey = errors.New(`ecdh: invalid public key`)
+
+// SharedSecretFunc computes the shared secret Z that is fed to the
+// Concat KDF. The returned function is called to release Z once the
+// key has been derived
+type SharedSecretFunc func() ([]byte, func(), error)
+
+func releaseNothing() {}
+
+// Derive computes the shared secret Z from the given private and public
+// keys, and derives a key of the given size from it. The keys must be
+// either *ecdsa.PrivateKey/*ecdsa.PublicKey or x25519.PrivateKey/x25519.PublicKey
+func Derive(hash crypto.Hash, alg, apu, apv []byte, privkey, pubkey interface{}, keysize uint32) ([]byte, error) {
+	return DeriveFromSharedSecret(hash, alg, apu, apv, keysize, func() ([]byte, func(), error) {
+		return SharedSecret(privkey, pubkey)
+	})
+}
+
+// DeriveFromSharedSecret derives a key of the given size from the shared
+// secret computed by zfn, using the Concat KDF described in NIST
+// SP 800-56A and RFC 7518 Section 4.6.2. This is shared by all key
+// agreement algorithms, which only differ in how Z is computed
+func DeriveFromSharedSecret(hash crypto.Hash, alg, apu, apv []byte, keysize uint32, zfn SharedSecretFunc) ([]byte, error) {
+	if !hash.Available() {
+		return nil, errors.Errorf(`hash function %s is not available`, hash)
+	}
+
+	z, release, err := zfn()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// SuppPubInfo is the key size in bits
+	var pubinfo [4]byte
+	binary.BigEndian.PutUint32(pubinfo[:], keysize*8)
+
+	kdf := concatkdf.New(hash, alg, z, apu, apv, pubinfo[:], nil)
+	key := make([]byte, keysize)
+	if _, err := kdf.Read(key); err != nil {
+		return nil, errors.Wrap(err, "failed to read kdf")
+	}
+
+	return key, nil
+}
+
+// SharedSecret computes the shared secret Z for ECDH-ES. EC points are
+// encoded using the fixed size of the curve, as the recipient would
+// otherwise derive a different key when Z has leading zeros
+func SharedSecret(privkey, pubkey interface{}) ([]byte, func(), error) {
+	switch privkey := privkey.(type) {
+	case *ecdsa.PrivateKey:
+		pubkey, ok := pubkey.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, nil, errors.Errorf(`public key must be *ecdsa.PublicKey (got %T)`, pubkey)
+		}
+
+		if err := checkPublicKey(privkey.Curve, pubkey); err != nil {
+			return nil, nil, err
+		}
+
+		z, _ := privkey.PublicKey.Curve.ScalarMult(pubkey.X, pubkey.Y, privkey.D.Bytes())
+		zBytes := ecutil.AllocECPointBuffer(z, privkey.Curve)
+		return zBytes, func() { ecutil.ReleaseECPointBuffer(zBytes) }, nil
+	case x25519.PrivateKey:
+		pubkey, ok := pubkey.(x25519.PublicKey)
+		if !ok {
+			return nil, nil, errors.Errorf(`public key must be x25519.PublicKey (got %T)`, pubkey)
+		}
+
+		if len(pubkey) != x25519.PublicKeySize {
+			return nil, nil, errors.Errorf(`invalid X25519 public key length (%d)`, len(pubkey))
+		}
+
+		z, err := x25519.SharedSecret(privkey, pubkey)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, `failed to compute X25519 shared secret`)
+		}
+		return z, releaseNothing, nil
+	default:
+		return nil, nil, errors.Errorf(`unexpected private key type %T`, privkey)
+	}
+}
+
+// checkPublicKey makes sure that pubkey is a point on the given
+// curve. This must be done before the point is multiplied by the
+// private key, see ErrInvalidECDHKey
+func checkPublicKey(curve elliptic.Curve, pubkey *ecdsa.PublicKey) error {
+	if pubkey == nil || pubkey.Curve == nil || pubkey.X == nil || pubkey.Y == nil {
+		return errors.Wrap(ErrInvalidECDHKey, `public key is incomplete`)
+	}
+	if curve == secp256k1.Curve() {
+		// Scalar multiplication on secp256k1 is not constant time,
+		// so it must not be used with private keys
+		return errors.Errorf(`ECDH using curve %s is not supported`, secp256k1.Name)
+	}
+	if pubkey.Curve.Params().Name != curve.Params().Name {
+		return errors.Wrapf(ErrInvalidECDHKey, `public key is on curve %s, but private key is on curve %s`, pubkey.Curve.Params().Name, curve.Params().Name)
+	}
+	if !curve.IsOnCurve(pubkey.X, pubkey.Y) {
+		return errors.Wrapf(ErrInvalidECDHKey, `public key is not on the curve %s`, curve.Params().Name)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package keyenc
 import (
 	"crypto/ecdsa"
 	"crypto/rsa"
+	"io"
 
 	"github.com/lestrrat-go/jwx/jwa"
 	"github.com/lestrrat-go/jwx/jwe/internal/keygen"
@@ -32,6 +33,39 @@ type AESCGM struct {
 	keyID     string
 }
 
+// AESGCMKW encrypts content encryption keys using AES-GCM key wrap,
+// as described in https://tools.ietf.org/html/rfc7518#section-4.7.
+// It also decrypts encrypted keys, provided that it was created with
+// the IV and the authentication tag that was used during encryption
+type AESGCMKW struct {
+	alg       jwa.KeyEncryptionAlgorithm
+	sharedkey []byte
+	keyID     string
+	iv        []byte
+	tag       []byte
+	rand      io.Reader
+}
+
+// PBES2Encrypt encrypts content encryption keys using PBES2 key wrap,
+// as described in https://tools.ietf.org/html/rfc7518#section-4.8
+type PBES2Encrypt struct {
+	alg      jwa.KeyEncryptionAlgorithm
+	password []byte
+	count    int
+	keyID    string
+	rand     io.Reader
+}
+
+// PBES2Decrypt decrypts keys using PBES2 key wrap. It must be
+// created with the salt input and the iteration count that were
+// used during encryption
+type PBES2Decrypt struct {
+	alg      jwa.KeyEncryptionAlgorithm
+	password []byte
+	salt     []byte
+	count    int
+}
+
 // ECDHESEncrypt encrypts content encryption keys using ECDH-ES.
 type ECDHESEncrypt struct {
 	algorithm jwa.KeyEncryptionAlgorithm
@@ -45,8 +79,8 @@ type ECDHESDecrypt struct {
 	contentalg jwa.ContentEncryptionAlgorithm
 	apu        []byte
 	apv        []byte
-	privkey    *ecdsa.PrivateKey
-	pubkey     *ecdsa.PublicKey
+	privkey    interface{}
+	pubkey     interface{}
 }
 
 type ECMRExchangeFunc func(xfrKey *ecdsa.PublicKey) (respKey *ecdsa.PublicKey, srvKey *ecdsa.PublicKey, err error)
@@ -59,6 +93,7 @@ type ECMRDecrypt struct {
 	apv        []byte
 	pubkey     *ecdsa.PublicKey
 	exchFn     ECMRExchangeFunc
+	rand       io.Reader
 }
 
 // RSAOAEPEncrypt encrypts keys using RSA OAEP algorithm
@@ -66,6 +101,7 @@ type RSAOAEPEncrypt struct {
 	alg    jwa.KeyEncryptionAlgorithm
 	pubkey *rsa.PublicKey
 	keyID  string
+	rand   io.Reader
 }
 
 // RSAOAEPDecrypt decrypts keys using RSA OAEP algorithm
@@ -86,9 +122,18 @@ type RSAPKCSEncrypt struct {
 	alg    jwa.KeyEncryptionAlgorithm
 	pubkey *rsa.PublicKey
 	keyID  string
+	rand   io.Reader
+}
+
+// DirectEncrypt does no encryption. The shared key is used as the
+// content encryption key, and the encrypted key is empty
+type DirectEncrypt struct {
+	key   []byte
+	keyID string
 }
 
-// DirectDecrypt does no encryption (Note: Unimplemented)
+// DirectDecrypt does no decryption, and returns the shared key as
+// the content encryption key
 type DirectDecrypt struct {
 	Key []byte
 }
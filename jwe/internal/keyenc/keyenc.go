@@ -9,21 +9,36 @@ import (
 	"crypto/rsa"
 	"crypto/sha1"
 	"crypto/sha256"
+	"crypto/sha512"
 	"crypto/subtle"
 	"encoding/binary"
 	"fmt"
 	"hash"
+	"io"
 	"math/big"
 
-	"github.com/lestrrat-go/jwx/internal/concatkdf"
 	"github.com/lestrrat-go/jwx/internal/ecutil"
+	"github.com/lestrrat-go/jwx/internal/pbkdf2"
+	"github.com/lestrrat-go/jwx/internal/secp256k1"
 	"github.com/lestrrat-go/jwx/jwa"
 	contentcipher "github.com/lestrrat-go/jwx/jwe/internal/cipher"
+	"github.com/lestrrat-go/jwx/jwe/internal/ecdhes"
 	"github.com/lestrrat-go/jwx/jwe/internal/keygen"
+	"github.com/lestrrat-go/jwx/x25519"
 	"github.com/lestrrat-go/pdebug"
 	"github.com/pkg/errors"
 )
 
+// ErrUnwrapFailed is returned when the encrypted key could not be
+// decrypted using the given key. This usually means that the key was
+// not the one used to encrypt the content encryption key, and callers
+// that hold multiple keys may try the next one.
+var ErrUnwrapFailed = errors.New(`key unwrap: failed to unwrap key`)
+
+// ErrInvalidECDHKey is returned when the public key used in an ECDH
+// key agreement is not a valid point on the curve of the private key.
+var ErrInvalidECDHKey = ecdhes.ErrInvalidECDHKey
+
 // NewAESCGM creates a key-wrap encrypter using AES-CGM.
 // Although the name suggests otherwise, this does the decryption as well.
 func NewAESCGM(alg jwa.KeyEncryptionAlgorithm, sharedkey []byte) (*AESCGM, error) {
@@ -57,7 +72,7 @@ func (kw *AESCGM) Decrypt(enckey []byte) ([]byte, error) {
 	return cek, nil
 }
 
-// KeyEncrypt encrypts the given content encryption key
+// Encrypt encrypts the given content encryption key
 func (kw *AESCGM) Encrypt(cek []byte) (keygen.ByteSource, error) {
 	block, err := aes.NewCipher(kw.sharedkey)
 	if err != nil {
@@ -70,9 +85,310 @@ func (kw *AESCGM) Encrypt(cek []byte) (keygen.ByteSource, error) {
 	return keygen.ByteKey(encrypted), nil
 }
 
-// NewECDHESEncrypt creates a new key encrypter based on ECDH-ES
-func NewECDHESEncrypt(alg jwa.KeyEncryptionAlgorithm, key *ecdsa.PublicKey) (*ECDHESEncrypt, error) {
-	generator, err := keygen.NewEcdhes(alg, key)
+const (
+	aesgcmkwIVSize  = 12
+	aesgcmkwTagSize = 16
+)
+
+func aesgcmkwKeySize(alg jwa.KeyEncryptionAlgorithm) (int, error) {
+	switch alg {
+	case jwa.A128GCMKW:
+		return 16, nil
+	case jwa.A192GCMKW:
+		return 24, nil
+	case jwa.A256GCMKW:
+		return 32, nil
+	default:
+		return 0, errors.Errorf("invalid AES-GCM key wrap algorithm (%s)", alg)
+	}
+}
+
+// NewAESGCMKW creates a key encrypter using AES-GCM key wrap
+func NewAESGCMKW(alg jwa.KeyEncryptionAlgorithm, sharedkey []byte, rand io.Reader) (*AESGCMKW, error) {
+	keysize, err := aesgcmkwKeySize(alg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sharedkey) != keysize {
+		return nil, errors.Errorf("invalid key size for %s (expected %d, got %d)", alg, keysize, len(sharedkey))
+	}
+
+	return &AESGCMKW{
+		alg:       alg,
+		sharedkey: sharedkey,
+		rand:      rand,
+	}, nil
+}
+
+// NewAESGCMKWDecrypt creates a key decrypter using AES-GCM key wrap.
+// The iv and tag are the values of the "iv" and "tag" header
+// parameters associated with the encrypted key
+func NewAESGCMKWDecrypt(alg jwa.KeyEncryptionAlgorithm, sharedkey, iv, tag []byte) (*AESGCMKW, error) {
+	kw, err := NewAESGCMKW(alg, sharedkey, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(iv) != aesgcmkwIVSize {
+		return nil, errors.Errorf("invalid iv size for %s (expected %d, got %d)", alg, aesgcmkwIVSize, len(iv))
+	}
+
+	if len(tag) != aesgcmkwTagSize {
+		return nil, errors.Errorf("invalid tag size for %s (expected %d, got %d)", alg, aesgcmkwTagSize, len(tag))
+	}
+
+	kw.iv = iv
+	kw.tag = tag
+	return kw, nil
+}
+
+// Algorithm returns the key encryption algorithm being used
+func (kw *AESGCMKW) Algorithm() jwa.KeyEncryptionAlgorithm {
+	return kw.alg
+}
+
+// KeyID returns the key ID associated with this encrypter
+func (kw *AESGCMKW) KeyID() string {
+	return kw.keyID
+}
+
+// Decrypt decrypts the encrypted key using AES-GCM key wrap
+func (kw *AESGCMKW) Decrypt(enckey []byte) ([]byte, error) {
+	if kw.iv == nil || kw.tag == nil {
+		return nil, errors.New("iv and tag are required to decrypt keys using AES-GCM key wrap")
+	}
+
+	block, err := aes.NewCipher(kw.sharedkey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cipher from shared key")
+	}
+
+	aesgcm, err := cipher.NewGCMWithNonceSize(block, len(kw.iv))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create gcm from cipher")
+	}
+
+	tagged := make([]byte, 0, len(enckey)+len(kw.tag))
+	tagged = append(tagged, enckey...)
+	tagged = append(tagged, kw.tag...)
+	cek, err := aesgcm.Open(nil, kw.iv, tagged, nil)
+	if err != nil {
+		return nil, errors.Wrap(ErrUnwrapFailed, "failed to decrypt key")
+	}
+	return cek, nil
+}
+
+// Encrypt encrypts the given content encryption key using AES-GCM key wrap.
+// The returned value also carries the IV and the authentication tag, which
+// is set to the "iv" and "tag" header parameters
+func (kw *AESGCMKW) Encrypt(cek []byte) (keygen.ByteSource, error) {
+	block, err := aes.NewCipher(kw.sharedkey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cipher from shared key")
+	}
+
+	aesgcm, err := cipher.NewGCMWithNonceSize(block, aesgcmkwIVSize)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create gcm from cipher")
+	}
+
+	iv := make([]byte, aesgcmkwIVSize)
+	if _, err := io.ReadFull(keygen.RandReader(kw.rand), iv); err != nil {
+		return nil, errors.Wrap(err, "failed to get random iv")
+	}
+
+	encrypted := aesgcm.Seal(nil, iv, cek, nil)
+	tagOffset := len(encrypted) - aesgcm.Overhead()
+	return keygen.ByteWithIVAndTag{
+		ByteKey: keygen.ByteKey(encrypted[:tagOffset]),
+		IV:      iv,
+		Tag:     encrypted[tagOffset:],
+	}, nil
+}
+
+const (
+	// PBES2MinCount is the minimum PBES2 iteration count ("p2c") that
+	// is accepted. Smaller values make brute-force attacks against the
+	// password too cheap, so they are rejected both when encrypting
+	// and when decrypting
+	PBES2MinCount = 1000
+	// PBES2DefaultCount is the PBES2 iteration count used when
+	// none is specified
+	PBES2DefaultCount = 10000
+	// PBES2DefaultMaxCount is the default maximum PBES2 iteration
+	// count accepted when decrypting. The count is chosen by the
+	// sender, so without a limit anybody could make the recipient
+	// spend an arbitrary amount of CPU time deriving keys
+	PBES2DefaultMaxCount = 100000
+
+	pbes2SaltSize = 16
+)
+
+func pbes2Params(alg jwa.KeyEncryptionAlgorithm) (func() hash.Hash, int, error) {
+	switch alg {
+	case jwa.PBES2_HS256_A128KW:
+		return sha256.New, 16, nil
+	case jwa.PBES2_HS384_A192KW:
+		return sha512.New384, 24, nil
+	case jwa.PBES2_HS512_A256KW:
+		return sha512.New, 32, nil
+	default:
+		return nil, 0, errors.Errorf("invalid PBES2 key wrap algorithm (%s)", alg)
+	}
+}
+
+// pbes2DeriveKey derives the key encryption key from the password.
+// The salt used for PBKDF2 is (UTF8(alg) || 0x00 || p2s)
+func pbes2DeriveKey(alg jwa.KeyEncryptionAlgorithm, password, p2s []byte, count int) ([]byte, error) {
+	hashFn, keysize, err := pbes2Params(alg)
+	if err != nil {
+		return nil, err
+	}
+
+	algBytes := []byte(alg.String())
+	salt := make([]byte, 0, len(algBytes)+1+len(p2s))
+	salt = append(salt, algBytes...)
+	salt = append(salt, 0x0)
+	salt = append(salt, p2s...)
+
+	return pbkdf2.Key(password, salt, count, keysize, hashFn), nil
+}
+
+// NewPBES2Encrypt creates a key encrypter using PBES2. count is the
+// iteration count used to derive the key encryption key. If count
+// is 0, PBES2DefaultCount is used
+func NewPBES2Encrypt(alg jwa.KeyEncryptionAlgorithm, password []byte, count int, rand io.Reader) (*PBES2Encrypt, error) {
+	if _, _, err := pbes2Params(alg); err != nil {
+		return nil, err
+	}
+
+	if count == 0 {
+		count = PBES2DefaultCount
+	}
+
+	if count < PBES2MinCount {
+		return nil, errors.Errorf("iteration count for %s must be at least %d (got %d)", alg, PBES2MinCount, count)
+	}
+
+	return &PBES2Encrypt{
+		alg:      alg,
+		password: password,
+		count:    count,
+		rand:     rand,
+	}, nil
+}
+
+// Algorithm returns the key encryption algorithm being used
+func (kw *PBES2Encrypt) Algorithm() jwa.KeyEncryptionAlgorithm {
+	return kw.alg
+}
+
+// KeyID returns the key ID associated with this encrypter
+func (kw *PBES2Encrypt) KeyID() string {
+	return kw.keyID
+}
+
+// Encrypt encrypts the given content encryption key using PBES2.
+// The returned value also carries the randomly generated salt input
+// and the iteration count, which are set to the "p2s" and "p2c"
+// header parameters
+func (kw *PBES2Encrypt) Encrypt(cek []byte) (keygen.ByteSource, error) {
+	salt := make([]byte, pbes2SaltSize)
+	if _, err := io.ReadFull(keygen.RandReader(kw.rand), salt); err != nil {
+		return nil, errors.Wrap(err, "failed to get random salt")
+	}
+
+	kek, err := pbes2DeriveKey(kw.alg, kw.password, salt, kw.count)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to derive key encryption key")
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cipher from derived key")
+	}
+
+	encrypted, err := Wrap(block, cek)
+	if err != nil {
+		return nil, errors.Wrap(err, `keywrap: failed to wrap key`)
+	}
+
+	return keygen.ByteWithSaltAndCount{
+		ByteKey: keygen.ByteKey(encrypted),
+		Salt:    salt,
+		Count:   kw.count,
+	}, nil
+}
+
+// NewPBES2Decrypt creates a key decrypter using PBES2. The salt and
+// count are the values of the "p2s" and "p2c" header parameters
+// associated with the encrypted key. Iteration counts smaller than
+// PBES2MinCount, or larger than maxCount, are rejected. If maxCount
+// is 0 or less, the count is not limited
+func NewPBES2Decrypt(alg jwa.KeyEncryptionAlgorithm, password, salt []byte, count, maxCount int) (*PBES2Decrypt, error) {
+	if _, _, err := pbes2Params(alg); err != nil {
+		return nil, err
+	}
+
+	if count < PBES2MinCount {
+		return nil, errors.Errorf("iteration count for %s must be at least %d (got %d)", alg, PBES2MinCount, count)
+	}
+
+	if maxCount > 0 && count > maxCount {
+		return nil, errors.Errorf("iteration count for %s must be at most %d (got %d)", alg, maxCount, count)
+	}
+
+	// RFC7518 requires the salt input to be at least 8 octets
+	if len(salt) < 8 {
+		return nil, errors.Errorf("salt input for %s must be at least 8 bytes (got %d)", alg, len(salt))
+	}
+
+	return &PBES2Decrypt{
+		alg:      alg,
+		password: password,
+		salt:     salt,
+		count:    count,
+	}, nil
+}
+
+// Algorithm returns the key encryption algorithm being used
+func (kw *PBES2Decrypt) Algorithm() jwa.KeyEncryptionAlgorithm {
+	return kw.alg
+}
+
+// Decrypt decrypts the encrypted key using PBES2
+func (kw *PBES2Decrypt) Decrypt(enckey []byte) ([]byte, error) {
+	kek, err := pbes2DeriveKey(kw.alg, kw.password, kw.salt, kw.count)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to derive key encryption key")
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cipher from derived key")
+	}
+
+	cek, err := Unwrap(block, enckey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unwrap data")
+	}
+	return cek, nil
+}
+
+// NewECDHESEncrypt creates a new key encrypter based on ECDH-ES.
+// The key must be either *ecdsa.PublicKey or x25519.PublicKey
+func NewECDHESEncrypt(alg jwa.KeyEncryptionAlgorithm, key interface{}, rand io.Reader) (*ECDHESEncrypt, error) {
+	var generator keygen.Generator
+	var err error
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		generator, err = keygen.NewEcdhes(alg, key, rand)
+	case x25519.PublicKey:
+		generator, err = keygen.NewX25519(alg, key, rand)
+	default:
+		return nil, errors.Errorf("unexpected key type %T", key)
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create key generator")
 	}
@@ -92,19 +408,14 @@ func (kw ECDHESEncrypt) KeyID() string {
 	return kw.keyID
 }
 
-// KeyEncrypt encrypts the content encryption key using ECDH-ES
+// Encrypt encrypts the content encryption key using ECDH-ES
 func (kw ECDHESEncrypt) Encrypt(cek []byte) (keygen.ByteSource, error) {
 	kg, err := kw.generator.Generate()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create key generator")
 	}
 
-	bwpk, ok := kg.(keygen.ByteWithECPrivateKey)
-	if !ok {
-		return nil, errors.New("key generator generated invalid key (expected ByteWithECPrivateKey)")
-	}
-
-	block, err := aes.NewCipher(bwpk.Bytes())
+	block, err := aes.NewCipher(kg.Bytes())
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to generate cipher from generated key")
 	}
@@ -114,13 +425,22 @@ func (kw ECDHESEncrypt) Encrypt(cek []byte) (keygen.ByteSource, error) {
 		return nil, errors.Wrap(err, "failed to wrap data")
 	}
 
-	bwpk.ByteKey = keygen.ByteKey(jek)
-
-	return bwpk, nil
+	switch bwpk := kg.(type) {
+	case keygen.ByteWithECPrivateKey:
+		bwpk.ByteKey = keygen.ByteKey(jek)
+		return bwpk, nil
+	case keygen.ByteWithX25519PublicKey:
+		bwpk.ByteKey = keygen.ByteKey(jek)
+		return bwpk, nil
+	default:
+		return nil, errors.Errorf("key generator generated invalid key (expected ByteWithECPrivateKey or ByteWithX25519PublicKey, got %T)", kg)
+	}
 }
 
-// NewECDHESDecrypt creates a new key decrypter using ECDH-ES
-func NewECDHESDecrypt(keyalg jwa.KeyEncryptionAlgorithm, contentalg jwa.ContentEncryptionAlgorithm, pubkey *ecdsa.PublicKey, apu, apv []byte, privkey *ecdsa.PrivateKey) *ECDHESDecrypt {
+// NewECDHESDecrypt creates a new key decrypter using ECDH-ES.
+// The keys must be either *ecdsa.PublicKey/*ecdsa.PrivateKey or
+// x25519.PublicKey/x25519.PrivateKey
+func NewECDHESDecrypt(keyalg jwa.KeyEncryptionAlgorithm, contentalg jwa.ContentEncryptionAlgorithm, pubkey interface{}, apu, apv []byte, privkey interface{}) *ECDHESDecrypt {
 	return &ECDHESDecrypt{
 		keyalg:     keyalg,
 		contentalg: contentalg,
@@ -136,30 +456,24 @@ func (kw ECDHESDecrypt) Algorithm() jwa.KeyEncryptionAlgorithm {
 	return kw.keyalg
 }
 
-func DeriveECDHES(alg, apu, apv []byte, privkey *ecdsa.PrivateKey, pubkey *ecdsa.PublicKey, keysize uint32) ([]byte, error) {
+// DeriveECDHES derives the key encryption key from the given private
+// and public keys using ECDH-ES. The keys must be either
+// *ecdsa.PrivateKey/*ecdsa.PublicKey or x25519.PrivateKey/x25519.PublicKey
+//
+// The Concat KDF uses SHA-256, as mandated by RFC 7518
+func DeriveECDHES(alg, apu, apv []byte, privkey interface{}, pubkey interface{}, keysize uint32) ([]byte, error) {
+	return DeriveECDHESWithHash(crypto.SHA256, alg, apu, apv, privkey, pubkey, keysize)
+}
+
+// DeriveECDHESWithHash is the same as DeriveECDHES, but uses the
+// given hash function in the Concat KDF
+func DeriveECDHESWithHash(hash crypto.Hash, alg, apu, apv []byte, privkey interface{}, pubkey interface{}, keysize uint32) ([]byte, error) {
 	if pdebug.Enabled {
-		g := pdebug.Marker("DeriveECDHES (keysize = %d)", keysize)
+		g := pdebug.Marker("DeriveECDHES (hash = %s, keysize = %d)", hash, keysize)
 		defer g.End()
 	}
 
-	pubinfo := make([]byte, 4)
-	binary.BigEndian.PutUint32(pubinfo, keysize*8)
-
-	if !privkey.PublicKey.Curve.IsOnCurve(pubkey.X, pubkey.Y) {
-		return nil, errors.New(`public key must be on the same curve as private key`)
-	}
-
-	z, _ := privkey.PublicKey.Curve.ScalarMult(pubkey.X, pubkey.Y, privkey.D.Bytes())
-	zBytes := ecutil.AllocECPointBuffer(z, privkey.Curve)
-	defer ecutil.ReleaseECPointBuffer(zBytes)
-
-	kdf := concatkdf.New(crypto.SHA256, alg, zBytes, apu, apv, pubinfo, []byte{})
-	key := make([]byte, keysize)
-	if _, err := kdf.Read(key); err != nil {
-		return nil, errors.Wrap(err, "failed to read kdf")
-	}
-
-	return key, nil
+	return ecdhes.Derive(hash, alg, apu, apv, privkey, pubkey, keysize)
 }
 
 // Decrypt decrypts the encrypted key using ECDH-ES
@@ -178,7 +492,7 @@ func (kw ECDHESDecrypt) Decrypt(enckey []byte) ([]byte, error) {
 	switch kw.keyalg {
 	case jwa.ECDH_ES:
 		// Create a content cipher from the content encryption algorithm
-		c, err := contentcipher.NewAES(kw.contentalg)
+		c, err := contentcipher.New(kw.contentalg)
 		if err != nil {
 			return nil, errors.Wrapf(err, `failed to create content cipher for %s`, kw.contentalg)
 		}
@@ -216,8 +530,10 @@ func (kw ECDHESDecrypt) Decrypt(enckey []byte) ([]byte, error) {
 	return Unwrap(block, enckey)
 }
 
-// NewECMRDecrypt creates a new key decrypter using ECMR
-func NewECMRDecrypt(keyalg jwa.KeyEncryptionAlgorithm, contentalg jwa.ContentEncryptionAlgorithm, pubkey *ecdsa.PublicKey, apu, apv []byte, exchFn ECMRExchangeFunc) *ECMRDecrypt {
+// NewECMRDecrypt creates a new key decrypter using ECMR. `rand` is
+// used to generate the temporary key sent to the server, and may be
+// nil, in which case crypto/rand.Reader is used
+func NewECMRDecrypt(keyalg jwa.KeyEncryptionAlgorithm, contentalg jwa.ContentEncryptionAlgorithm, pubkey *ecdsa.PublicKey, apu, apv []byte, exchFn ECMRExchangeFunc, rand io.Reader) *ECMRDecrypt {
 	return &ECMRDecrypt{
 		keyalg:     keyalg,
 		contentalg: contentalg,
@@ -225,6 +541,7 @@ func NewECMRDecrypt(keyalg jwa.KeyEncryptionAlgorithm, contentalg jwa.ContentEnc
 		apv:        apv,
 		exchFn:     exchFn,
 		pubkey:     pubkey,
+		rand:       rand,
 	}
 }
 
@@ -233,53 +550,82 @@ func (kw ECMRDecrypt) Algorithm() jwa.KeyEncryptionAlgorithm {
 	return kw.keyalg
 }
 
-func DeriveECMR(alg, apu, apv []byte, exchFn ECMRExchangeFunc, pubkey *ecdsa.PublicKey, keysize uint32) ([]byte, error) {
+func DeriveECMR(alg, apu, apv []byte, exchFn ECMRExchangeFunc, pubkey *ecdsa.PublicKey, keysize uint32, rand io.Reader) ([]byte, error) {
 	if pdebug.Enabled {
 		g := pdebug.Marker("DeriveECMR (keysize = %d)", keysize)
 		defer g.End()
 	}
 
-	pubinfo := make([]byte, 4)
-	binary.BigEndian.PutUint32(pubinfo, keysize*8)
+	return ecdhes.DeriveFromSharedSecret(crypto.SHA256, alg, apu, apv, keysize, func() ([]byte, func(), error) {
+		return ecmrSharedSecret(exchFn, pubkey, rand)
+	})
+}
 
+// ecmrSharedSecret computes the shared secret Z for ECMR by performing
+// the key exchange with the server using exchFn
+func ecmrSharedSecret(exchFn ECMRExchangeFunc, pubkey *ecdsa.PublicKey, rand io.Reader) ([]byte, func(), error) {
 	ecCurve := pubkey.Curve // curve used for the key exchange
 
-	tempKey, err := ecdsa.GenerateKey(ecCurve, rand.Reader)
+	if ecCurve == secp256k1.Curve() {
+		// Scalar multiplication on secp256k1 is not constant time,
+		// so it must not be used with private keys
+		return nil, nil, errors.Errorf(`ECMR using curve %s is not supported`, secp256k1.Name)
+	}
+
+	if !ecCurve.IsOnCurve(pubkey.X, pubkey.Y) {
+		return nil, nil, errors.Errorf("public key is not on the curve %v", ecCurve.Params().Name)
+	}
+
+	tempKey, err := ecdsa.GenerateKey(ecCurve, keygen.RandReader(rand))
 	if err != nil {
-		return nil, err
+		return nil, nil, errors.Wrap(err, "failed to generate temporary key")
 	}
 
 	x, y := ecCurve.Add(tempKey.X, tempKey.Y, pubkey.X, pubkey.Y)
+	if isPointAtInfinity(x, y) {
+		return nil, nil, errors.New("exchange key is the point at infinity")
+	}
 
 	xfrKey := ecdsa.PublicKey{Curve: ecCurve, X: x, Y: y}
 
 	respKey, srvKey, err := exchFn(&xfrKey)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to exchange public key")
+		return nil, nil, errors.Wrap(err, "failed to exchange public key")
+	}
+
+	if respKey == nil || srvKey == nil {
+		return nil, nil, errors.New("exchange function must return both the response and the server keys")
 	}
 
 	if respKey.Curve != ecCurve {
-		return nil, errors.Errorf("expect EC curve type %v, got %v", ecCurve, respKey.Curve)
+		return nil, nil, errors.Errorf("expect EC curve type %v, got %v", ecCurve, respKey.Curve)
+	}
+
+	if !ecCurve.IsOnCurve(respKey.X, respKey.Y) {
+		return nil, nil, errors.Errorf("response key is not on the curve %v", ecCurve.Params().Name)
 	}
 
 	if !ecCurve.IsOnCurve(srvKey.X, srvKey.Y) {
-		return nil, errors.Errorf("server key is not on the curve %v", ecCurve)
+		return nil, nil, errors.Errorf("server key is not on the curve %v", ecCurve.Params().Name)
 	}
 
 	x, y = ecCurve.ScalarMult(srvKey.X, srvKey.Y, tempKey.D.Bytes())
 
-	// resp - tmp
-	z, _ := ecCurve.Add(respKey.X, respKey.Y, x, new(big.Int).Neg(y))
-	zBytes := ecutil.AllocECPointBuffer(z, ecCurve)
-	defer ecutil.ReleaseECPointBuffer(zBytes)
-
-	kdf := concatkdf.New(crypto.SHA256, alg, zBytes, apu, apv, pubinfo, []byte{})
-	key := make([]byte, keysize)
-	if _, err := kdf.Read(key); err != nil {
-		return nil, errors.Wrap(err, "failed to read kdf")
+	// resp - tmp. The negation of (x, y) is (x, p - y)
+	negY := new(big.Int).Sub(ecCurve.Params().P, y)
+	negY.Mod(negY, ecCurve.Params().P)
+	z, zy := ecCurve.Add(respKey.X, respKey.Y, x, negY)
+	if isPointAtInfinity(z, zy) {
+		return nil, nil, errors.New("shared secret is the point at infinity")
 	}
+	zBytes := ecutil.AllocECPointBuffer(z, ecCurve)
+	return zBytes, func() { ecutil.ReleaseECPointBuffer(zBytes) }, nil
+}
 
-	return key, nil
+// isPointAtInfinity reports whether the given coordinates represent the
+// identity element, which crypto/elliptic returns as (0, 0)
+func isPointAtInfinity(x, y *big.Int) bool {
+	return x.Sign() == 0 && y.Sign() == 0
 }
 
 // Decrypt decrypts the encrypted key using ECMR
@@ -298,7 +644,7 @@ func (kw ECMRDecrypt) Decrypt(enckey []byte) ([]byte, error) {
 	switch kw.keyalg {
 	case jwa.ECMR:
 		// Create a content cipher from the content encryption algorithm
-		c, err := contentcipher.NewAES(kw.contentalg)
+		c, err := contentcipher.New(kw.contentalg)
 		if err != nil {
 			return nil, errors.Wrapf(err, `failed to create content cipher for %s`, kw.contentalg)
 		}
@@ -312,7 +658,7 @@ func (kw ECMRDecrypt) Decrypt(enckey []byte) ([]byte, error) {
 		return nil, errors.Errorf("invalid ECMR key wrap algorithm (%s)", kw.keyalg)
 	}
 
-	key, err := DeriveECMR(algBytes, kw.apu, kw.apv, kw.exchFn, kw.pubkey, keysize)
+	key, err := DeriveECMR(algBytes, kw.apu, kw.apv, kw.exchFn, kw.pubkey, keysize, kw.rand)
 	if err != nil {
 		return nil, errors.Wrap(err, `failed to derive ECMR encryption key`)
 	}
@@ -330,21 +676,39 @@ func (kw ECMRDecrypt) Decrypt(enckey []byte) ([]byte, error) {
 	return Unwrap(block, enckey)
 }
 
+// rsaOAEPHash returns the hash function to be used for both OAEP and
+// MGF1 with the given algorithm
+func rsaOAEPHash(alg jwa.KeyEncryptionAlgorithm) (hash.Hash, error) {
+	switch alg {
+	case jwa.RSA_OAEP:
+		return sha1.New(), nil
+	case jwa.RSA_OAEP_256:
+		return sha256.New(), nil
+	case jwa.RSA_OAEP_384:
+		return sha512.New384(), nil
+	case jwa.RSA_OAEP_512:
+		return sha512.New(), nil
+	default:
+		return nil, errors.Errorf(`invalid RSA OAEP algorithm (%s)`, alg)
+	}
+}
+
 // NewRSAOAEPEncrypt creates a new key encrypter using RSA OAEP
-func NewRSAOAEPEncrypt(alg jwa.KeyEncryptionAlgorithm, pubkey *rsa.PublicKey) (*RSAOAEPEncrypt, error) {
+func NewRSAOAEPEncrypt(alg jwa.KeyEncryptionAlgorithm, pubkey *rsa.PublicKey, rand io.Reader) (*RSAOAEPEncrypt, error) {
 	switch alg {
-	case jwa.RSA_OAEP, jwa.RSA_OAEP_256:
+	case jwa.RSA_OAEP, jwa.RSA_OAEP_256, jwa.RSA_OAEP_384, jwa.RSA_OAEP_512:
 	default:
 		return nil, errors.Errorf("invalid RSA OAEP encrypt algorithm (%s)", alg)
 	}
 	return &RSAOAEPEncrypt{
 		alg:    alg,
 		pubkey: pubkey,
+		rand:   rand,
 	}, nil
 }
 
 // NewRSAPKCSEncrypt creates a new key encrypter using PKCS1v15
-func NewRSAPKCSEncrypt(alg jwa.KeyEncryptionAlgorithm, pubkey *rsa.PublicKey) (*RSAPKCSEncrypt, error) {
+func NewRSAPKCSEncrypt(alg jwa.KeyEncryptionAlgorithm, pubkey *rsa.PublicKey, rand io.Reader) (*RSAPKCSEncrypt, error) {
 	switch alg {
 	case jwa.RSA1_5:
 	default:
@@ -354,6 +718,7 @@ func NewRSAPKCSEncrypt(alg jwa.KeyEncryptionAlgorithm, pubkey *rsa.PublicKey) (*
 	return &RSAPKCSEncrypt{
 		alg:    alg,
 		pubkey: pubkey,
+		rand:   rand,
 	}, nil
 }
 
@@ -377,30 +742,25 @@ func (e RSAOAEPEncrypt) KeyID() string {
 	return e.keyID
 }
 
-// KeyEncrypt encrypts the content encryption key using RSA PKCS1v15
+// Encrypt encrypts the content encryption key using RSA PKCS1v15
 func (e RSAPKCSEncrypt) Encrypt(cek []byte) (keygen.ByteSource, error) {
 	if e.alg != jwa.RSA1_5 {
 		return nil, errors.Errorf("invalid RSA PKCS encrypt algorithm (%s)", e.alg)
 	}
-	encrypted, err := rsa.EncryptPKCS1v15(rand.Reader, e.pubkey, cek)
+	encrypted, err := rsa.EncryptPKCS1v15(keygen.RandReader(e.rand), e.pubkey, cek)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to encrypt using PKCS1v15")
 	}
 	return keygen.ByteKey(encrypted), nil
 }
 
-// KeyEncrypt encrypts the content encryption key using RSA OAEP
+// Encrypt encrypts the content encryption key using RSA OAEP
 func (e RSAOAEPEncrypt) Encrypt(cek []byte) (keygen.ByteSource, error) {
-	var hash hash.Hash
-	switch e.alg {
-	case jwa.RSA_OAEP:
-		hash = sha1.New()
-	case jwa.RSA_OAEP_256:
-		hash = sha256.New()
-	default:
-		return nil, errors.New("failed to generate key encrypter for RSA-OAEP: RSA_OAEP/RSA_OAEP_256 required")
+	hash, err := rsaOAEPHash(e.alg)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to generate key encrypter for RSA-OAEP`)
 	}
-	encrypted, err := rsa.EncryptOAEP(hash, rand.Reader, e.pubkey, cek, []byte{})
+	encrypted, err := rsa.EncryptOAEP(hash, keygen.RandReader(e.rand), e.pubkey, cek, []byte{})
 	if err != nil {
 		return nil, errors.Wrap(err, `failed to OAEP encrypt`)
 	}
@@ -474,7 +834,7 @@ func (d RSAPKCS15Decrypt) Decrypt(enckey []byte) ([]byte, error) {
 // NewRSAOAEPDecrypt creates a new key decrypter using RSA OAEP
 func NewRSAOAEPDecrypt(alg jwa.KeyEncryptionAlgorithm, privkey *rsa.PrivateKey) (*RSAOAEPDecrypt, error) {
 	switch alg {
-	case jwa.RSA_OAEP, jwa.RSA_OAEP_256:
+	case jwa.RSA_OAEP, jwa.RSA_OAEP_256, jwa.RSA_OAEP_384, jwa.RSA_OAEP_512:
 	default:
 		return nil, errors.Errorf("invalid RSA OAEP decrypt algorithm (%s)", alg)
 	}
@@ -495,24 +855,67 @@ func (d RSAOAEPDecrypt) Decrypt(enckey []byte) ([]byte, error) {
 	if pdebug.Enabled {
 		pdebug.Printf("START OAEP.Decrypt")
 	}
-	var hash hash.Hash
-	switch d.alg {
-	case jwa.RSA_OAEP:
-		hash = sha1.New()
-	case jwa.RSA_OAEP_256:
-		hash = sha256.New()
-	default:
-		return nil, errors.New("failed to generate key encrypter for RSA-OAEP: RSA_OAEP/RSA_OAEP_256 required")
+	hash, err := rsaOAEPHash(d.alg)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to generate key decrypter for RSA-OAEP`)
+	}
+	cek, err := rsa.DecryptOAEP(hash, rand.Reader, d.privkey, enckey, []byte{})
+	if err != nil {
+		return nil, errors.Wrap(ErrUnwrapFailed, "failed to decrypt via RSA-OAEP")
 	}
-	return rsa.DecryptOAEP(hash, rand.Reader, d.privkey, enckey, []byte{})
+	return cek, nil
+}
+
+// NewDirectEncrypt creates a key encrypter for direct encryption
+// (jwa.DIRECT) using the given shared key as the content encryption key
+func NewDirectEncrypt(sharedkey []byte) (*DirectEncrypt, error) {
+	if len(sharedkey) == 0 {
+		return nil, errors.New(`direct encryption requires a non-empty shared key`)
+	}
+	return &DirectEncrypt{
+		key: sharedkey,
+	}, nil
+}
+
+// Algorithm returns the key encryption algorithm being used
+func (d DirectEncrypt) Algorithm() jwa.KeyEncryptionAlgorithm {
+	return jwa.DIRECT
+}
+
+// KeyID returns the key ID associated with this encrypter
+func (d DirectEncrypt) KeyID() string {
+	return d.keyID
+}
+
+// ContentEncryptionKey returns the shared key, which must be used
+// as the content encryption key
+func (d DirectEncrypt) ContentEncryptionKey() []byte {
+	return d.key
+}
+
+// Encrypt for DirectEncrypt does not do anything other than
+// return an empty encrypted key, as the content encryption key
+// is the shared key itself and is never transmitted
+func (d DirectEncrypt) Encrypt(_ []byte) (keygen.ByteSource, error) {
+	return keygen.ByteKey(nil), nil
+}
+
+// Algorithm returns the key encryption algorithm being used
+func (d DirectDecrypt) Algorithm() jwa.KeyEncryptionAlgorithm {
+	return jwa.DIRECT
 }
 
 // Decrypt for DirectDecrypt does not do anything other than
-// return a copy of the embedded key
-func (d DirectDecrypt) Decrypt() ([]byte, error) {
-	cek := make([]byte, len(d.Key))
-	copy(cek, d.Key)
-	return cek, nil
+// return the embedded key. The encrypted key must be empty when
+// using direct encryption (https://tools.ietf.org/html/rfc7516#section-5.2)
+//
+// The returned key is not copied, as it is only ever read by
+// the content cipher
+func (d DirectDecrypt) Decrypt(enckey []byte) ([]byte, error) {
+	if len(enckey) > 0 {
+		return nil, errors.Errorf(`encrypted key must be empty when using %s`, jwa.DIRECT)
+	}
+	return d.Key, nil
 }
 
 var keywrapDefaultIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
@@ -568,6 +971,12 @@ func Unwrap(block cipher.Block, ciphertxt []byte) ([]byte, error) {
 		return nil, errors.Errorf(`keyunwrap input must be %d byte blocks`, keywrapChunkLen)
 	}
 
+	// The input consists of the integrity check block followed by at
+	// least one block of wrapped key, otherwise n would be zero below
+	if len(ciphertxt) < 2*keywrapChunkLen {
+		return nil, errors.Errorf(`keyunwrap input must be at least %d bytes (got %d)`, 2*keywrapChunkLen, len(ciphertxt))
+	}
+
 	n := (len(ciphertxt) / keywrapChunkLen) - 1
 	r := make([][]byte, n)
 
@@ -599,7 +1008,7 @@ func Unwrap(block cipher.Block, ciphertxt []byte) ([]byte, error) {
 			pdebug.Printf("prefix  = %x", buffer[:keywrapChunkLen])
 			pdebug.Printf("default = %x", keywrapDefaultIV)
 		}
-		return nil, errors.New("key unwrap: failed to unwrap key")
+		return nil, ErrUnwrapFailed
 	}
 
 	out := make([]byte, n*keywrapChunkLen)
@@ -2,11 +2,17 @@ package keyenc_test
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/aes"
 	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"encoding/hex"
+	"errors"
+	"math/big"
 	"testing"
 
+	"github.com/lestrrat-go/jwx/internal/secp256k1"
 	"github.com/lestrrat-go/jwx/jwe/internal/keyenc"
 	"github.com/lestrrat-go/jwx/jwk"
 	"github.com/stretchr/testify/assert"
@@ -78,12 +84,12 @@ func TestRFC3394_Wrap(t *testing.T) {
 	}
 }
 
-func TestDeriveECDHES(t *testing.T) {
-	// stolen from go-jose
-	// Example keys from JWA, Appendix C
-	var aliceKey ecdsa.PrivateKey
-	var bobKey ecdsa.PrivateKey
+// appendixCKeys returns the example keys of Alice and Bob from
+// RFC 7518, Appendix C
+func appendixCKeys(t *testing.T) (*ecdsa.PrivateKey, *ecdsa.PrivateKey) {
+	t.Helper()
 
+	// stolen from go-jose
 	const aliceKeySrc = `{"kty":"EC",
       "crv":"P-256",
       "x":"gI0GAILBdu7T53akrFmMyGcsF3n5dO7MmwNBHKW5SV0",
@@ -97,28 +103,28 @@ func TestDeriveECDHES(t *testing.T) {
       "d":"VEmDZpDXXK8p8N0Cndsxs924q6nS1RXFASRl6BfUqdw"
      }`
 
-	aliceWebKey, err := jwk.ParseKey([]byte(aliceKeySrc))
-	if !assert.NoError(t, err, `jwk.ParseKey should succeed`) {
-		return
-	}
-	if !assert.NoError(t, aliceWebKey.Raw(&aliceKey), `aliceWebKey.Raw should succeed`) {
-		return
+	var keys [2]ecdsa.PrivateKey
+	for i, src := range []string{aliceKeySrc, bobKeySrc} {
+		webKey, err := jwk.ParseKey([]byte(src))
+		if !assert.NoError(t, err, `jwk.ParseKey should succeed`) {
+			t.FailNow()
+		}
+		if !assert.NoError(t, webKey.Raw(&keys[i]), `webKey.Raw should succeed`) {
+			t.FailNow()
+		}
 	}
+	return &keys[0], &keys[1]
+}
 
-	bobWebKey, err := jwk.ParseKey([]byte(bobKeySrc))
-	if !assert.NoError(t, err, `jwk.ParseKey should succeed`) {
-		return
-	}
-	if !assert.NoError(t, bobWebKey.Raw(&bobKey), `bobWebKey.Raw should succeed`) {
-		return
-	}
+func TestDeriveECDHES(t *testing.T) {
+	aliceKey, bobKey := appendixCKeys(t)
 
 	apuData := []byte("Alice")
 	apvData := []byte("Bob")
 
 	expected := []byte{86, 170, 141, 234, 248, 35, 109, 32, 92, 34, 40, 205, 113, 167, 16, 26}
 
-	output, err := keyenc.DeriveECDHES([]byte("A128GCM"), apuData, apvData, &bobKey, &aliceKey.PublicKey, 16)
+	output, err := keyenc.DeriveECDHES([]byte("A128GCM"), apuData, apvData, bobKey, &aliceKey.PublicKey, 16)
 	if !assert.NoError(t, err, `keyenc.DeriveECDHES should succeed`) {
 		return
 	}
@@ -126,6 +132,216 @@ func TestDeriveECDHES(t *testing.T) {
 	if !assert.Equal(t, output, expected, `result should match`) {
 		return
 	}
+
+	t.Run("Unavailable hash", func(t *testing.T) {
+		_, err := keyenc.DeriveECDHESWithHash(crypto.Hash(0), []byte("A128GCM"), apuData, apvData, bobKey, &aliceKey.PublicKey, 16)
+		if !assert.Error(t, err, `keyenc.DeriveECDHESWithHash should fail for unavailable hash functions`) {
+			return
+		}
+	})
+	t.Run("Invalid public key", func(t *testing.T) {
+		otherKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		if !assert.NoError(t, err, `ecdsa.GenerateKey should succeed`) {
+			return
+		}
+
+		testcases := []struct {
+			name   string
+			pubkey *ecdsa.PublicKey
+		}{
+			{
+				name:   "Point not on curve",
+				pubkey: &ecdsa.PublicKey{Curve: elliptic.P256(), X: aliceKey.X, Y: new(big.Int).Add(aliceKey.Y, big.NewInt(1))},
+			},
+			{
+				// The coordinates are on P-384, but the key claims to
+				// be on the same curve as the private key
+				name:   "Point on another curve",
+				pubkey: &ecdsa.PublicKey{Curve: elliptic.P256(), X: otherKey.X, Y: otherKey.Y},
+			},
+			{
+				name:   "Curve mismatch",
+				pubkey: &otherKey.PublicKey,
+			},
+			{
+				name:   "Missing coordinates",
+				pubkey: &ecdsa.PublicKey{Curve: elliptic.P256()},
+			},
+		}
+		for _, tc := range testcases {
+			_, err := keyenc.DeriveECDHES([]byte("A128GCM"), apuData, apvData, bobKey, tc.pubkey, 16)
+			if !assert.True(t, errors.Is(err, keyenc.ErrInvalidECDHKey), `%s: error should be ErrInvalidECDHKey (got %v)`, tc.name, err) {
+				return
+			}
+		}
+	})
+	t.Run("secp256k1", func(t *testing.T) {
+		// The secp256k1 implementation is not constant time, so it
+		// must not be used with private keys
+		privkey, err := ecdsa.GenerateKey(secp256k1.Curve(), rand.Reader)
+		if !assert.NoError(t, err, `ecdsa.GenerateKey should succeed`) {
+			return
+		}
+		_, err = keyenc.DeriveECDHES([]byte("A128GCM"), apuData, apvData, privkey, &privkey.PublicKey, 16)
+		if !assert.Error(t, err, `keyenc.DeriveECDHES should fail`) {
+			return
+		}
+	})
+}
+
+func TestDeriveECMR(t *testing.T) {
+	crv := elliptic.P256()
+
+	// pubkey is the public key that is "advertised" by the server
+	// (P = p*G), and srvkey is the key that is returned along with
+	// the response (S = s*G)
+	pubkey, err := ecdsa.GenerateKey(crv, rand.Reader)
+	if !assert.NoError(t, err, `ecdsa.GenerateKey should succeed`) {
+		return
+	}
+	srvkey, err := ecdsa.GenerateKey(crv, rand.Reader)
+	if !assert.NoError(t, err, `ecdsa.GenerateKey should succeed`) {
+		return
+	}
+
+	alg := []byte("A128GCM")
+	t.Run("Valid exchange", func(t *testing.T) {
+		exchFn := func(xfr *ecdsa.PublicKey) (*ecdsa.PublicKey, *ecdsa.PublicKey, error) {
+			x, y := crv.ScalarMult(xfr.X, xfr.Y, srvkey.D.Bytes())
+			return &ecdsa.PublicKey{Curve: crv, X: x, Y: y}, &srvkey.PublicKey, nil
+		}
+		key, err := keyenc.DeriveECMR(alg, nil, nil, exchFn, &pubkey.PublicKey, 16, nil)
+		if !assert.NoError(t, err, `DeriveECMR should succeed`) {
+			return
+		}
+		if !assert.Len(t, key, 16, `key should be 16 bytes`) {
+			return
+		}
+	})
+	t.Run("Custom random source", func(t *testing.T) {
+		var called bool
+		exchFn := func(xfr *ecdsa.PublicKey) (*ecdsa.PublicKey, *ecdsa.PublicKey, error) {
+			called = true
+			return nil, nil, errors.New(`exchange should not be called`)
+		}
+		// An empty reader cannot provide the temporary key
+		_, err := keyenc.DeriveECMR(alg, nil, nil, exchFn, &pubkey.PublicKey, 16, bytes.NewReader(nil))
+		if !assert.Error(t, err, `DeriveECMR should fail`) {
+			return
+		}
+		if !assert.False(t, called, `exchange function should not be called`) {
+			return
+		}
+	})
+	t.Run("Response key not on curve", func(t *testing.T) {
+		exchFn := func(xfr *ecdsa.PublicKey) (*ecdsa.PublicKey, *ecdsa.PublicKey, error) {
+			return &ecdsa.PublicKey{Curve: crv, X: big.NewInt(1), Y: big.NewInt(1)}, &srvkey.PublicKey, nil
+		}
+		_, err := keyenc.DeriveECMR(alg, nil, nil, exchFn, &pubkey.PublicKey, 16, nil)
+		if !assert.Error(t, err, `DeriveECMR should fail`) {
+			return
+		}
+	})
+	t.Run("Shared secret is the point at infinity", func(t *testing.T) {
+		// The temporary key T can be recovered from the exchange key
+		// as T = xfr - P, which allows us to return s*T as the response,
+		// so that the computed point (s*T - s*T) is the identity element
+		exchFn := func(xfr *ecdsa.PublicKey) (*ecdsa.PublicKey, *ecdsa.PublicKey, error) {
+			p := crv.Params().P
+			negY := new(big.Int).Sub(p, pubkey.Y)
+			tx, ty := crv.Add(xfr.X, xfr.Y, pubkey.X, negY)
+			x, y := crv.ScalarMult(tx, ty, srvkey.D.Bytes())
+			return &ecdsa.PublicKey{Curve: crv, X: x, Y: y}, &srvkey.PublicKey, nil
+		}
+		_, err := keyenc.DeriveECMR(alg, nil, nil, exchFn, &pubkey.PublicKey, 16, nil)
+		if !assert.Error(t, err, `DeriveECMR should fail`) {
+			return
+		}
+		if !assert.Contains(t, err.Error(), `point at infinity`, `error should mention the point at infinity`) {
+			return
+		}
+	})
+}
+
+func TestDeriveKnownVectors(t *testing.T) {
+	aliceKey, bobKey := appendixCKeys(t)
+	crv := aliceKey.Curve
+	alg := []byte("A128GCM")
+	apu := []byte("Alice")
+	apv := []byte("Bob")
+
+	// With ECMR, the derived key is the same as the one derived using
+	// ECDH-ES between the advertised key (Alice's) and the server key
+	// (Bob's), so both paths are checked against RFC 7518, Appendix C
+	ecmrExchange := func(xfr *ecdsa.PublicKey) (*ecdsa.PublicKey, *ecdsa.PublicKey, error) {
+		x, y := crv.ScalarMult(xfr.X, xfr.Y, bobKey.D.Bytes())
+		return &ecdsa.PublicKey{Curve: crv, X: x, Y: y}, &bobKey.PublicKey, nil
+	}
+
+	testcases := []struct {
+		Name     string
+		Derive   func() ([]byte, error)
+		Expected []byte
+	}{
+		{
+			Name: "ECDH-ES",
+			Derive: func() ([]byte, error) {
+				return keyenc.DeriveECDHES(alg, apu, apv, bobKey, &aliceKey.PublicKey, 16)
+			},
+			Expected: []byte{86, 170, 141, 234, 248, 35, 109, 32, 92, 34, 40, 205, 113, 167, 16, 26},
+		},
+		{
+			Name: "ECDH-ES (SHA-384)",
+			Derive: func() ([]byte, error) {
+				return keyenc.DeriveECDHESWithHash(crypto.SHA384, alg, apu, apv, bobKey, &aliceKey.PublicKey, 16)
+			},
+			// computed independently as SHA-384(counter || Z || OtherInfo)
+			Expected: []byte{1, 4, 104, 168, 69, 192, 107, 141, 161, 242, 10, 186, 148, 82, 17, 160},
+		},
+		{
+			Name: "ECMR",
+			Derive: func() ([]byte, error) {
+				return keyenc.DeriveECMR(alg, apu, apv, ecmrExchange, &aliceKey.PublicKey, 16, nil)
+			},
+			Expected: []byte{86, 170, 141, 234, 248, 35, 109, 32, 92, 34, 40, 205, 113, 167, 16, 26},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			key, err := tc.Derive()
+			if !assert.NoError(t, err, `key derivation should succeed`) {
+				return
+			}
+			if !assert.Equal(t, tc.Expected, key, `derived key should match`) {
+				return
+			}
+		})
+	}
+}
+
+func BenchmarkDeriveECDHES(b *testing.B) {
+	privkey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	peerkey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	alg := []byte("ECDH-ES+A128KW")
+	apu := []byte("Alice")
+	apv := []byte("Bob")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := keyenc.DeriveECDHES(alg, apu, apv, privkey, &peerkey.PublicKey, 16); err != nil {
+			b.Fatal(err)
+		}
+	}
 }
 
 func TestKeyWrap(t *testing.T) {
@@ -182,3 +398,41 @@ func TestKeyWrap(t *testing.T) {
 		t.Error("key unwrap did not return original input, got", unwrap2, "wanted", cek2)
 	}
 }
+
+func TestDirectDecrypt(t *testing.T) {
+	key := []byte("0123456789abcdef")
+
+	var d keyenc.Decrypter = keyenc.DirectDecrypt{Key: key}
+	cek, err := d.Decrypt(nil)
+	if !assert.NoError(t, err, `Decrypt should succeed`) {
+		return
+	}
+	if !assert.Equal(t, key, cek, `cek should be the shared key`) {
+		return
+	}
+
+	if _, err := d.Decrypt([]byte("not empty")); !assert.Error(t, err, `Decrypt should fail with a non-empty encrypted key`) {
+		return
+	}
+}
+
+func TestUnwrap_InvalidLength(t *testing.T) {
+	block, err := aes.NewCipher(mustHexDecode("000102030405060708090A0B0C0D0E0F"))
+	if !assert.NoError(t, err, `aes.NewCipher should succeed`) {
+		return
+	}
+
+	for _, size := range []int{0, 4, 8, 12, 20} {
+		_, err := keyenc.Unwrap(block, make([]byte, size))
+		if !assert.Error(t, err, `Unwrap should fail for %d bytes of input`, size) {
+			return
+		}
+	}
+
+	// Two blocks is the shortest valid input. It does not unwrap to
+	// anything meaningful, but must be processed without panicking
+	_, err = keyenc.Unwrap(block, make([]byte, 16))
+	if !assert.Equal(t, keyenc.ErrUnwrapFailed, err, `Unwrap should fail the integrity check`) {
+		return
+	}
+}
@@ -2,8 +2,10 @@ package keygen
 
 import (
 	"crypto/ecdsa"
+	"io"
 
 	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/x25519"
 )
 
 type Generator interface {
@@ -11,19 +13,29 @@ type Generator interface {
 	Generate() (ByteSource, error)
 }
 
-// StaticKeyGenerate uses a static byte buffer to provide keys.
+// Static uses a static byte buffer to provide keys.
 type Static []byte
 
-// RandomKeyGenerate generates random keys
+// Random generates random keys
 type Random struct {
 	keysize int
+	rand    io.Reader
 }
 
-// EcdhesKeyGenerate generates keys using ECDH-ES algorithm
+// Ecdhes generates keys using ECDH-ES algorithm
 type Ecdhes struct {
 	algorithm jwa.KeyEncryptionAlgorithm
 	keysize   int
 	pubkey    *ecdsa.PublicKey
+	rand      io.Reader
+}
+
+// X25519 generates keys using ECDH-ES algorithm / X25519 curve
+type X25519 struct {
+	algorithm jwa.KeyEncryptionAlgorithm
+	keysize   int
+	pubkey    x25519.PublicKey
+	rand      io.Reader
 }
 
 // ByteKey is a generated key that only has the key's byte buffer
@@ -39,6 +51,33 @@ type ByteWithECPrivateKey struct {
 	PrivateKey *ecdsa.PrivateKey
 }
 
+// ByteWithX25519PublicKey holds the ephemeral X25519 public key that
+// was used to generate the key along with the key itself. This is
+// required to set the "epk" value in the JWE headers
+type ByteWithX25519PublicKey struct {
+	ByteKey
+	PublicKey x25519.PublicKey
+}
+
+// ByteWithIVAndTag holds the encrypted key along with the IV and
+// the authentication tag that were used to generate it. This is
+// required to set the "iv" and "tag" values in the JWE headers
+type ByteWithIVAndTag struct {
+	ByteKey
+	IV  []byte
+	Tag []byte
+}
+
+// ByteWithSaltAndCount holds the encrypted key along with the salt
+// input and the iteration count that were used to derive the key
+// encryption key. This is required to set the "p2s" and "p2c" values
+// in the JWE headers
+type ByteWithSaltAndCount struct {
+	ByteKey
+	Salt  []byte
+	Count int
+}
+
 // ByteSource is an interface for things that return a byte sequence.
 // This is used for KeyGenerator so that the result of computations can
 // carry more than just the generate byte sequence.
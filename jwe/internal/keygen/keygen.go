@@ -4,12 +4,14 @@ import (
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/rand"
-	"encoding/binary"
 	"io"
 
-	"github.com/lestrrat-go/jwx/internal/concatkdf"
+	"github.com/lestrrat-go/jwx/internal/base64"
+	"github.com/lestrrat-go/jwx/internal/secp256k1"
 	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwe/internal/ecdhes"
 	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/x25519"
 	"github.com/pkg/errors"
 )
 
@@ -30,12 +32,27 @@ func (g Static) Generate() (ByteSource, error) {
 	return ByteKey(buf), nil
 }
 
+// RandReader returns r, or crypto/rand.Reader if r is nil
+func RandReader(r io.Reader) io.Reader {
+	if r == nil {
+		return rand.Reader
+	}
+	return r
+}
+
 // NewRandom creates a new Generator that returns
 // random bytes
 func NewRandom(n int) Random {
 	return Random{keysize: n}
 }
 
+// NewRandomWithReader creates a new Generator that returns random
+// bytes read from the given source. If rand is nil, crypto/rand.Reader
+// is used
+func NewRandomWithReader(n int, rand io.Reader) Random {
+	return Random{keysize: n, rand: rand}
+}
+
 // Size returns the key size
 func (g Random) Size() int {
 	return g.keysize
@@ -44,14 +61,15 @@ func (g Random) Size() int {
 // Generate generates a random new key
 func (g Random) Generate() (ByteSource, error) {
 	buf := make([]byte, g.keysize)
-	if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+	if _, err := io.ReadFull(RandReader(g.rand), buf); err != nil {
 		return nil, errors.Wrap(err, "failed to read from rand.Reader")
 	}
 	return ByteKey(buf), nil
 }
 
-// NewEcdhes creates a new key generator using ECDH-ES
-func NewEcdhes(alg jwa.KeyEncryptionAlgorithm, pubkey *ecdsa.PublicKey) (*Ecdhes, error) {
+// NewEcdhes creates a new key generator using ECDH-ES. The ephemeral
+// keys are generated using rand, or crypto/rand.Reader if it is nil
+func NewEcdhes(alg jwa.KeyEncryptionAlgorithm, pubkey *ecdsa.PublicKey, rand io.Reader) (*Ecdhes, error) {
 	var keysize int
 	switch alg {
 	case jwa.ECDH_ES:
@@ -70,6 +88,7 @@ func NewEcdhes(alg jwa.KeyEncryptionAlgorithm, pubkey *ecdsa.PublicKey) (*Ecdhes
 		algorithm: alg,
 		keysize:   keysize,
 		pubkey:    pubkey,
+		rand:      rand,
 	}, nil
 }
 
@@ -80,19 +99,20 @@ func (g Ecdhes) Size() int {
 
 // Generate generates new keys using ECDH-ES
 func (g Ecdhes) Generate() (ByteSource, error) {
-	priv, err := ecdsa.GenerateKey(g.pubkey.Curve, rand.Reader)
+	if g.pubkey.Curve == secp256k1.Curve() {
+		// Key generation and scalar multiplication on secp256k1 are
+		// not constant time, so they must not be used with private keys
+		return nil, errors.Errorf(`ECDH-ES using curve %s is not supported`, secp256k1.Name)
+	}
+
+	priv, err := ecdsa.GenerateKey(g.pubkey.Curve, RandReader(g.rand))
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to generate key for ECDH-ES")
 	}
 
-	pubinfo := make([]byte, 4)
-	binary.BigEndian.PutUint32(pubinfo, uint32(g.keysize)*8)
-
-	z, _ := priv.PublicKey.Curve.ScalarMult(g.pubkey.X, g.pubkey.Y, priv.D.Bytes())
-	kdf := concatkdf.New(crypto.SHA256, []byte(g.algorithm.String()), z.Bytes(), []byte{}, []byte{}, pubinfo, []byte{})
-	kek := make([]byte, g.keysize)
-	if _, err := kdf.Read(kek); err != nil {
-		return nil, errors.Wrap(err, "failed to read kdf")
+	kek, err := ecdhes.Derive(crypto.SHA256, []byte(g.algorithm.String()), nil, nil, priv, g.pubkey, uint32(g.keysize))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to derive key for ECDH-ES")
 	}
 
 	return ByteWithECPrivateKey{
@@ -101,7 +121,7 @@ func (g Ecdhes) Generate() (ByteSource, error) {
 	}, nil
 }
 
-// HeaderPopulate populates the header with the required EC-DSA public key
+// Populate populates the header with the required EC-DSA public key
 // information ('epk' key)
 func (k ByteWithECPrivateKey) Populate(h Setter) error {
 	key, err := jwk.New(&k.PrivateKey.PublicKey)
@@ -114,3 +134,96 @@ func (k ByteWithECPrivateKey) Populate(h Setter) error {
 	}
 	return nil
 }
+
+// NewX25519 creates a new key generator using ECDH-ES with X25519 keys.
+// The ephemeral keys are generated using rand, or crypto/rand.Reader
+// if it is nil
+func NewX25519(alg jwa.KeyEncryptionAlgorithm, pubkey x25519.PublicKey, rand io.Reader) (*X25519, error) {
+	var keysize int
+	switch alg {
+	case jwa.ECDH_ES:
+		return nil, errors.New("unimplemented")
+	case jwa.ECDH_ES_A128KW:
+		keysize = 16
+	case jwa.ECDH_ES_A192KW:
+		keysize = 24
+	case jwa.ECDH_ES_A256KW:
+		keysize = 32
+	default:
+		return nil, errors.Errorf("invalid ECDH-ES key generation algorithm (%s)", alg)
+	}
+
+	if len(pubkey) != x25519.PublicKeySize {
+		return nil, errors.Errorf("invalid X25519 public key length (%d)", len(pubkey))
+	}
+
+	return &X25519{
+		algorithm: alg,
+		keysize:   keysize,
+		pubkey:    pubkey,
+		rand:      rand,
+	}, nil
+}
+
+// Size returns the key size associated with this generator
+func (g X25519) Size() int {
+	return g.keysize
+}
+
+// Generate generates new keys using ECDH-ES with X25519 keys
+func (g X25519) Generate() (ByteSource, error) {
+	pub, priv, err := x25519.GenerateKey(RandReader(g.rand))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate key for X25519")
+	}
+
+	kek, err := ecdhes.Derive(crypto.SHA256, []byte(g.algorithm.String()), nil, nil, priv, g.pubkey, uint32(g.keysize))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to derive key for X25519")
+	}
+
+	return ByteWithX25519PublicKey{
+		PublicKey: pub,
+		ByteKey:   ByteKey(kek),
+	}, nil
+}
+
+// Populate populates the header with the required X25519 public key
+// information ('epk' key)
+func (k ByteWithX25519PublicKey) Populate(h Setter) error {
+	key := jwk.NewOKPPublicKey()
+	if err := key.FromRaw(k.PublicKey); err != nil {
+		return errors.Wrap(err, "failed to create JWK")
+	}
+
+	if err := h.Set("epk", key); err != nil {
+		return errors.Wrap(err, "failed to write header")
+	}
+	return nil
+}
+
+// Populate populates the header with the required AES-GCM key
+// wrap parameters ('iv' and 'tag' keys)
+func (k ByteWithIVAndTag) Populate(h Setter) error {
+	if err := h.Set("iv", base64.EncodeToString(k.IV)); err != nil {
+		return errors.Wrap(err, "failed to write header")
+	}
+
+	if err := h.Set("tag", base64.EncodeToString(k.Tag)); err != nil {
+		return errors.Wrap(err, "failed to write header")
+	}
+	return nil
+}
+
+// Populate populates the header with the required PBES2
+// parameters ('p2s' and 'p2c' keys)
+func (k ByteWithSaltAndCount) Populate(h Setter) error {
+	if err := h.Set("p2s", base64.EncodeToString(k.Salt)); err != nil {
+		return errors.Wrap(err, "failed to write header")
+	}
+
+	if err := h.Set("p2c", k.Count); err != nil {
+		return errors.Wrap(err, "failed to write header")
+	}
+	return nil
+}
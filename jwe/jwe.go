@@ -9,25 +9,203 @@ import (
 	"crypto/ecdsa"
 	"crypto/rsa"
 	"encoding/json"
+	"io"
 
 	"github.com/lestrrat-go/jwx/buffer"
 	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwe/internal/cipher"
 	"github.com/lestrrat-go/jwx/jwe/internal/content_crypt"
 	"github.com/lestrrat-go/jwx/jwe/internal/keyenc"
 	"github.com/lestrrat-go/jwx/jwe/internal/keygen"
+	"github.com/lestrrat-go/jwx/x25519"
 	"github.com/lestrrat-go/pdebug"
 	"github.com/pkg/errors"
 )
 
+// These errors may be inspected using `errors.Is` on the error
+// returned from Decrypt and Message.Decrypt.
+var (
+	// ErrUnwrapFailed is returned when the content encryption key could
+	// not be decrypted using the given key. The message may still be
+	// decrypted using a different key.
+	ErrUnwrapFailed = keyenc.ErrUnwrapFailed
+
+	// ErrAuthTagMismatch is returned when the content could not be
+	// authenticated, which means that the message has been tampered with
+	ErrAuthTagMismatch = cipher.ErrAuthTagMismatch
+
+	// ErrEphemeralKeyCurveMismatch is returned when the ephemeral
+	// public key in the "epk" header of an ECDH-ES message is not on
+	// the same curve as the recipient's private key
+	ErrEphemeralKeyCurveMismatch = errors.New(`'epk' header is not on the same curve as the private key`)
+
+	// ErrInvalidECDHKey is returned when the ephemeral public key in
+	// the "epk" header of an ECDH-ES message is not a valid point on
+	// its curve. Such keys are rejected before they are used in any
+	// computation involving the private key, as they could otherwise
+	// be used to recover the private key (invalid curve attack)
+	ErrInvalidECDHKey = keyenc.ErrInvalidECDHKey
+
+	// ErrUnprotectedCompression is returned when the "zip" header
+	// appears in the shared unprotected header or in a per-recipient
+	// header. As compression applies to the plaintext shared by all
+	// recipients, it must be specified in the protected header
+	ErrUnprotectedCompression = errors.New(`"zip" header must be in the protected header`)
+)
+
 // Encrypt takes the plaintext payload and encrypts it in JWE compact format.
-func Encrypt(payload []byte, keyalg jwa.KeyEncryptionAlgorithm, key interface{}, contentalg jwa.ContentEncryptionAlgorithm, compressalg jwa.CompressionAlgorithm) ([]byte, error) {
-	contentcrypt, err := content_crypt.NewAES(contentalg)
+//
+// When using the PBES2 key encryption algorithms, the key must be the
+// password as a []byte. The iteration count may be specified by
+// passing the `jwe.WithPBES2Count` option.
+//
+// When using jwa.DIRECT, the key must be the shared content encryption
+// key as a []byte of the size required by the content encryption
+// algorithm. Direct encryption cannot be combined with other recipients.
+//
+// Additional recipients may be specified by passing one or more
+// `jwe.WithRecipient` options. All recipients share the same content
+// encryption key, which is encrypted separately for each of them. When
+// there is more than one recipient, the message is serialized in
+// the JSON serialization format. In this case `keyalg` may be left
+// empty to only use the recipients specified via options.
+//
+// Additional authenticated data may be specified by passing the
+// `jwe.WithAAD` option. As the compact serialization format cannot
+// carry this value, the message is then serialized in the JSON
+// serialization format as well.
+//
+// By default all random values (the content encryption key, nonces,
+// salts, ephemeral keys, etc) are read from crypto/rand.Reader.
+// A different source may be specified by passing the
+// `jwe.WithRandomSource` option.
+//
+// The content encryption key is randomly generated, unless it is given
+// using the `jwe.WithContentEncryptionKey` option.
+//
+// Additional protected header parameters such as "typ" and "cty" may be
+// specified by passing the `jwe.WithProtectedHeaders` option.
+//
+// If the content encryption key must be encrypted by a key that is
+// not available to this process, such as a key stored in a KMS, pass
+// a `jwe.KeyEncrypter` as the key.
+func Encrypt(payload []byte, keyalg jwa.KeyEncryptionAlgorithm, key interface{}, contentalg jwa.ContentEncryptionAlgorithm, compressalg jwa.CompressionAlgorithm, options ...Option) ([]byte, error) {
+	var pbes2Count int
+	var extraRecipients []recipientSpec
+	var aad []byte
+	var rnd io.Reader
+	var protected Headers
+	var cek []byte
+	for _, option := range options {
+		switch option.Name() {
+		case optkeyRecipient:
+			extraRecipients = append(extraRecipients, option.Value().(recipientSpec))
+		case optkeyPBES2Count:
+			pbes2Count = option.Value().(int)
+		case optkeyCompress:
+			compressalg = option.Value().(jwa.CompressionAlgorithm)
+		case optkeyAAD:
+			aad = option.Value().([]byte)
+		case optkeyRandomSource:
+			rnd = option.Value().(io.Reader)
+		case optkeyProtectedHeaders:
+			protected = option.Value().(Headers)
+		case optkeyContentEncryptionKey:
+			cek = option.Value().([]byte)
+		}
+	}
+
+	contentcrypt, err := content_crypt.New(contentalg, rnd)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to create content encrypter`)
+	}
+
+	var recipients []recipientSpec
+	if keyalg != "" {
+		recipients = append(recipients, recipientSpec{alg: keyalg, key: key})
+	}
+	recipients = append(recipients, extraRecipients...)
+	if len(recipients) == 0 {
+		return nil, errors.New(`no recipients specified`)
+	}
+
+	var keysize int
+	var generator keygen.Generator
+	encrypters := make([]keyenc.Encrypter, len(recipients))
+	for i, r := range recipients {
+		enc, size, err := buildKeyEncrypter(r.alg, r.key, contentcrypt, pbes2Count, rnd)
+		if err != nil {
+			return nil, errors.Wrapf(err, `failed to create key encrypter for recipient #%d`, i+1)
+		}
+		// With direct encryption the shared key is the CEK, so it
+		// cannot be shared with any other recipient
+		if direct, ok := enc.(*keyenc.DirectEncrypt); ok {
+			if len(recipients) > 1 {
+				return nil, errors.Errorf(`recipient #%d: %s cannot be used with multiple recipients`, i+1, jwa.DIRECT)
+			}
+			generator = keygen.Static(direct.ContentEncryptionKey())
+		}
+		if i > 0 && size != keysize {
+			return nil, errors.Errorf(`recipient #%d requires a different content encryption key size (%d != %d)`, i+1, size, keysize)
+		}
+		keysize = size
+		encrypters[i] = enc
+	}
+
+	if pdebug.Enabled {
+		pdebug.Printf("Encrypt: keysize = %d", keysize)
+	}
+	encctx := getEncryptCtx()
+	defer releaseEncryptCtx(encctx)
+
+	encctx.contentEncrypter = contentcrypt
+	if cek != nil {
+		if generator != nil {
+			return nil, errors.Errorf(`a content encryption key cannot be specified when using %s`, jwa.DIRECT)
+		}
+		if len(cek) != keysize {
+			return nil, errors.Errorf(`invalid content encryption key size %d (content encryption algorithm %s requires %d bytes)`, len(cek), contentalg, keysize)
+		}
+		generator = keygen.Static(cek)
+	}
+	if generator == nil {
+		generator = keygen.NewRandomWithReader(keysize, rnd)
+	}
+	encctx.generator = generator
+	encctx.keyEncrypters = encrypters
+	encctx.compress = compressalg
+	encctx.aad = aad
+	encctx.protected = protected
+	msg, err := encctx.Encrypt(payload)
 	if err != nil {
-		return nil, errors.Wrap(err, `failed to create AES encrypter`)
+		if pdebug.Enabled {
+			pdebug.Printf("Encrypt: failed to encrypt: %s", err)
+		}
+		return nil, errors.Wrap(err, "failed to encrypt payload")
+	}
+
+	// The compact serialization can neither represent multiple
+	// recipients nor additional authenticated data
+	if len(encrypters) > 1 || len(aad) > 0 {
+		return JSON(msg)
+	}
+	return Compact(msg)
+}
+
+// buildKeyEncrypter creates the key encrypter for the given key encryption
+// algorithm and key, and returns it along with the size of the content
+// encryption key that should be generated
+func buildKeyEncrypter(keyalg jwa.KeyEncryptionAlgorithm, key interface{}, contentcrypt *content_crypt.Generic, pbes2Count int, rnd io.Reader) (keyenc.Encrypter, int, error) {
+	if ext, ok := key.(KeyEncrypter); ok {
+		if err := checkExternalKeyAlgorithm(keyalg, ext.Algorithm()); err != nil {
+			return nil, 0, errors.Wrap(err, `invalid key encrypter`)
+		}
+		return externalKeyEncrypter{ext}, contentcrypt.KeySize() / 2, nil
 	}
 
 	var enc keyenc.Encrypter
 	var keysize int
+	var err error
 	switch keyalg {
 	case jwa.RSA1_5:
 		var pubkey *rsa.PublicKey
@@ -37,15 +215,15 @@ func Encrypt(payload []byte, keyalg jwa.KeyEncryptionAlgorithm, key interface{},
 		case *rsa.PublicKey:
 			pubkey = v
 		default:
-			return nil, errors.Errorf("*rsa.PublicKey is required as the key to build %s key encrypter", keyalg)
+			return nil, 0, errors.Errorf("*rsa.PublicKey is required as the key to build %s key encrypter", keyalg)
 		}
 
-		enc, err = keyenc.NewRSAPKCSEncrypt(keyalg, pubkey)
+		enc, err = keyenc.NewRSAPKCSEncrypt(keyalg, pubkey, rnd)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to create RSA PKCS encrypter")
+			return nil, 0, errors.Wrap(err, "failed to create RSA PKCS encrypter")
 		}
 		keysize = contentcrypt.KeySize() / 2
-	case jwa.RSA_OAEP, jwa.RSA_OAEP_256:
+	case jwa.RSA_OAEP, jwa.RSA_OAEP_256, jwa.RSA_OAEP_384, jwa.RSA_OAEP_512:
 		var pubkey *rsa.PublicKey
 		switch v := key.(type) {
 		case rsa.PublicKey:
@@ -53,83 +231,121 @@ func Encrypt(payload []byte, keyalg jwa.KeyEncryptionAlgorithm, key interface{},
 		case *rsa.PublicKey:
 			pubkey = v
 		default:
-			return nil, errors.Errorf("*rsa.PublicKey is required as the key to build %s key encrypter", keyalg)
+			return nil, 0, errors.Errorf("*rsa.PublicKey is required as the key to build %s key encrypter", keyalg)
 		}
 
-		enc, err = keyenc.NewRSAOAEPEncrypt(keyalg, pubkey)
+		enc, err = keyenc.NewRSAOAEPEncrypt(keyalg, pubkey, rnd)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to create RSA OAEP encrypter")
+			return nil, 0, errors.Wrap(err, "failed to create RSA OAEP encrypter")
 		}
 		keysize = contentcrypt.KeySize() / 2
 	case jwa.A128KW, jwa.A192KW, jwa.A256KW:
 		sharedkey, ok := key.([]byte)
 		if !ok {
-			return nil, errors.New("invalid key: []byte required")
+			return nil, 0, errors.New("invalid key: []byte required")
 		}
 		enc, err = keyenc.NewAESCGM(keyalg, sharedkey)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to create key wrap encrypter")
+			return nil, 0, errors.Wrap(err, "failed to create key wrap encrypter")
 		}
-		keysize = contentcrypt.KeySize()
-		switch aesKeySize := keysize / 2; aesKeySize {
-		case 16, 24, 32:
+		keysize = contentcrypt.KeySize() / 2
+	case jwa.ECDH_ES_A128KW, jwa.ECDH_ES_A192KW, jwa.ECDH_ES_A256KW:
+		var pubkey interface{}
+		switch v := key.(type) {
+		case ecdsa.PublicKey:
+			pubkey = &v
+		case *ecdsa.PublicKey, x25519.PublicKey:
+			pubkey = v
 		default:
-			return nil, errors.Errorf("unsupported keysize %d (from content encryption algorithm %s). consider using content encryption that uses 32, 48, or 64 byte keys", keysize, contentalg)
+			return nil, 0, errors.New("invalid key: *ecdsa.PublicKey or x25519.PublicKey required")
 		}
-	case jwa.ECDH_ES_A128KW, jwa.ECDH_ES_A192KW, jwa.ECDH_ES_A256KW:
-		pubkey, ok := key.(*ecdsa.PublicKey)
+		enc, err = keyenc.NewECDHESEncrypt(keyalg, pubkey, rnd)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "failed to create ECDHS key wrap encrypter")
+		}
+		keysize = contentcrypt.KeySize() / 2
+	case jwa.DIRECT:
+		sharedkey, ok := key.([]byte)
 		if !ok {
-			return nil, errors.New("invalid key: *ecdsa.PublicKey required")
+			return nil, 0, errors.New("invalid key: []byte required")
+		}
+		keysize = contentcrypt.KeySize() / 2
+		if len(sharedkey) != keysize {
+			return nil, 0, errors.Errorf("invalid key size %d for direct encryption (content encryption algorithm %s requires %d bytes)", len(sharedkey), contentcrypt.Algorithm(), keysize)
 		}
-		enc, err = keyenc.NewECDHESEncrypt(keyalg, pubkey)
+		enc, err = keyenc.NewDirectEncrypt(sharedkey)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to create ECDHS key wrap encrypter")
+			return nil, 0, errors.Wrap(err, "failed to create direct encrypter")
 		}
-		keysize = contentcrypt.KeySize() / 2
-	case jwa.ECDH_ES:
-		fallthrough
 	case jwa.A128GCMKW, jwa.A192GCMKW, jwa.A256GCMKW:
-		fallthrough
+		sharedkey, ok := key.([]byte)
+		if !ok {
+			return nil, 0, errors.New("invalid key: []byte required")
+		}
+		enc, err = keyenc.NewAESGCMKW(keyalg, sharedkey, rnd)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "failed to create AES-GCM key wrap encrypter")
+		}
+		keysize = contentcrypt.KeySize() / 2
 	case jwa.PBES2_HS256_A128KW, jwa.PBES2_HS384_A192KW, jwa.PBES2_HS512_A256KW:
+		password, ok := key.([]byte)
+		if !ok {
+			return nil, 0, errors.New("invalid key: []byte required")
+		}
+		enc, err = keyenc.NewPBES2Encrypt(keyalg, password, pbes2Count, rnd)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "failed to create PBES2 key wrap encrypter")
+		}
+		keysize = contentcrypt.KeySize() / 2
+	case jwa.ECDH_ES:
 		fallthrough
 	default:
 		if pdebug.Enabled {
 			pdebug.Printf("Encrypt: unknown key encryption algorithm: %s", keyalg)
 		}
-		return nil, errors.Errorf(`invalid key encryption algorithm (%s)`, keyalg)
-	}
-
-	if pdebug.Enabled {
-		pdebug.Printf("Encrypt: keysize = %d", keysize)
+		return nil, 0, errors.Errorf(`invalid key encryption algorithm (%s)`, keyalg)
 	}
-	encctx := getEncryptCtx()
-	defer releaseEncryptCtx(encctx)
 
-	encctx.contentEncrypter = contentcrypt
-	encctx.generator = keygen.NewRandom(keysize)
-	encctx.keyEncrypters = []keyenc.Encrypter{enc}
-	encctx.compress = compressalg
-	msg, err := encctx.Encrypt(payload)
-	if err != nil {
-		if pdebug.Enabled {
-			pdebug.Printf("Encrypt: failed to encrypt: %s", err)
-		}
-		return nil, errors.Wrap(err, "failed to encrypt payload")
-	}
-
-	return Compact(msg)
+	return enc, keysize, nil
 }
 
 // Decrypt takes the key encryption algorithm and the corresponding
 // key to decrypt the JWE message, and returns the decrypted payload.
 // The JWE message can be either compact or full JSON format.
-func Decrypt(buf []byte, alg jwa.KeyEncryptionAlgorithm, key interface{}) ([]byte, error) {
+//
+// If the message was compressed, the payload is transparently
+// decompressed. The maximum size of the decompressed payload can
+// be specified using the `jwe.WithMaxDecompressedSize` option.
+//
+// If the `jwe.WithKeySet` option is given, the key is looked up in the
+// key set using the "alg" and "kid" headers of the message, and `key`
+// is ignored.
+//
+// If the `jwe.WithMessage` option is given, the parsed message is
+// stored in the given Message, so that headers such as "cty" can be
+// inspected by the caller.
+//
+// If the content encryption key must be decrypted by a key that is
+// not available to this process, pass a `jwe.KeyDecrypter` as the key.
+//
+// The entire message is held in memory, and there is no streaming
+// variant of Decrypt: the authentication tag covers the whole
+// ciphertext, and no part of the plaintext may be released before
+// the tag has been verified.
+func Decrypt(buf []byte, alg jwa.KeyEncryptionAlgorithm, key interface{}, options ...Option) ([]byte, error) {
 	msg, err := Parse(buf)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to parse buffer for Decrypt")
 	}
 
-	return msg.Decrypt(alg, key)
+	for _, option := range options {
+		switch option.Name() {
+		case optkeyMessage:
+			*(option.Value().(*Message)) = *msg
+		}
+	}
+
+	return msg.Decrypt(alg, key, options...)
 }
 
 // Parse parses the JWE message into a Message object. The JWE message
@@ -222,6 +438,11 @@ func parseCompact(buf []byte) (*Message, error) {
 	if err := hdrs.Remove(ContentEncryptionKey); err != nil {
 		return nil, errors.Wrapf(err, "failed to remove %#v from public header", ContentEncryptionKey)
 	}
+	// "zip" applies to the shared plaintext, and is only allowed in
+	// the protected header
+	if err := hdrs.Remove(CompressionKey); err != nil {
+		return nil, errors.Wrapf(err, "failed to remove %#v from public header", CompressionKey)
+	}
 	if err := m.Set(RecipientsKey, []Recipient{
 		&stdRecipient{
 			headers:      hdrs,
@@ -1,19 +1,26 @@
 package jwe_test
 
 import (
+	"bytes"
 	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha1"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"math/big"
 	"strings"
 	"testing"
 
 	"github.com/lestrrat-go/jwx/jwa"
 	"github.com/lestrrat-go/jwx/jwe"
 	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/x25519"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -302,71 +309,1432 @@ func TestRoundtrip_RSAES_OAEP_AES_GCM(t *testing.T) {
 	}
 }
 
+func TestRoundtrip_RSA_OAEP_Hashes(t *testing.T) {
+	plaintext := []byte("Lorem ipsum")
+	for _, alg := range []jwa.KeyEncryptionAlgorithm{jwa.RSA_OAEP, jwa.RSA_OAEP_256, jwa.RSA_OAEP_384, jwa.RSA_OAEP_512} {
+		alg := alg
+		t.Run(alg.String(), func(t *testing.T) {
+			encrypted, err := jwe.Encrypt(plaintext, alg, &rsaPrivKey.PublicKey, jwa.A256GCM, jwa.NoCompress)
+			if !assert.NoError(t, err, "Encrypt should succeed") {
+				return
+			}
+
+			decrypted, err := jwe.Decrypt(encrypted, alg, rsaPrivKey)
+			if !assert.NoError(t, err, "Decrypt should succeed") {
+				return
+			}
+
+			if !assert.Equal(t, plaintext, decrypted, "Decrypted content should match") {
+				return
+			}
+		})
+	}
+}
+
 func TestRoundtrip_RSA1_5_A128CBC_HS256(t *testing.T) {
 	var plaintext = []byte{
 		76, 105, 118, 101, 32, 108, 111, 110, 103, 32, 97, 110, 100, 32,
 		112, 114, 111, 115, 112, 101, 114, 46,
 	}
 
-	max := 100
-	if testing.Short() {
-		max = 1
+	max := 100
+	if testing.Short() {
+		max = 1
+	}
+
+	for i := 0; i < max; i++ {
+		encrypted, err := jwe.Encrypt(plaintext, jwa.RSA1_5, &rsaPrivKey.PublicKey, jwa.A128CBC_HS256, jwa.NoCompress)
+		if !assert.NoError(t, err, "Encrypt is successful") {
+			return
+		}
+
+		decrypted, err := jwe.Decrypt(encrypted, jwa.RSA1_5, rsaPrivKey)
+		if !assert.NoError(t, err, "Decrypt successful") {
+			return
+		}
+
+		if !assert.Equal(t, plaintext, decrypted, "Decrypted correct plaintext") {
+			return
+		}
+	}
+}
+
+// https://tools.ietf.org/html/rfc7516#appendix-A.3. Note that cek is dynamically
+// generated, so the encrypted values will NOT match that of the RFC.
+func TestEncode_A128KW_A128CBC_HS256(t *testing.T) {
+	var plaintext = []byte{
+		76, 105, 118, 101, 32, 108, 111, 110, 103, 32, 97, 110, 100, 32,
+		112, 114, 111, 115, 112, 101, 114, 46,
+	}
+	var sharedkey = []byte{
+		25, 172, 32, 130, 225, 114, 26, 181, 138, 106, 254, 192, 95, 133, 74, 82,
+	}
+
+	max := 100
+	if testing.Short() {
+		max = 1
+	}
+
+	for i := 0; i < max; i++ {
+		encrypted, err := jwe.Encrypt(plaintext, jwa.A128KW, sharedkey, jwa.A128CBC_HS256, jwa.NoCompress)
+		if !assert.NoError(t, err, "Encrypt is successful") {
+			return
+		}
+
+		decrypted, err := jwe.Decrypt(encrypted, jwa.A128KW, sharedkey)
+		if !assert.NoError(t, err, "Decrypt successful") {
+			return
+		}
+
+		if !assert.Equal(t, plaintext, decrypted, "Decrypted correct plaintext") {
+			return
+		}
+	}
+}
+
+func TestEncode_AESKW(t *testing.T) {
+	plaintext := []byte("Lorem ipsum")
+
+	// The content encryption key must be exactly as long as the
+	// content encryption algorithm requires
+	cekSizes := map[jwa.ContentEncryptionAlgorithm]int{
+		jwa.A128CBC_HS256: 32,
+		jwa.A192CBC_HS384: 48,
+		jwa.A256CBC_HS512: 64,
+		jwa.A128GCM:       16,
+		jwa.A192GCM:       24,
+		jwa.A256GCM:       32,
+		jwa.C20P:          32,
+		jwa.XC20P:         32,
+	}
+
+	keyalgs := map[jwa.KeyEncryptionAlgorithm]int{
+		jwa.A128KW: 16,
+		jwa.A192KW: 24,
+		jwa.A256KW: 32,
+	}
+
+	for keyalg, size := range keyalgs {
+		sharedkey := make([]byte, size)
+		if _, err := rand.Read(sharedkey); !assert.NoError(t, err, `rand.Read should succeed`) {
+			return
+		}
+
+		for _, contentalg := range jwa.ContentEncryptionAlgorithms() {
+			keyalg := keyalg
+			contentalg := contentalg
+			t.Run(keyalg.String()+"/"+contentalg.String(), func(t *testing.T) {
+				encrypted, err := jwe.Encrypt(plaintext, keyalg, sharedkey, contentalg, jwa.NoCompress)
+				if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+					return
+				}
+
+				msg, err := jwe.Parse(encrypted)
+				if !assert.NoError(t, err, `jwe.Parse should succeed`) {
+					return
+				}
+
+				// AES key wrap adds an 8 byte integrity check value
+				if !assert.Len(t, msg.Recipients()[0].EncryptedKey().Bytes(), cekSizes[contentalg]+8, `wrapped key should have the expected length`) {
+					return
+				}
+
+				decrypted, err := jwe.Decrypt(encrypted, keyalg, sharedkey)
+				if !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
+					return
+				}
+				if !assert.Equal(t, plaintext, decrypted, `decrypted payload should match`) {
+					return
+				}
+			})
+		}
+	}
+}
+
+func TestEncode_AESGCMKW(t *testing.T) {
+	plaintext := []byte("Lorem ipsum")
+
+	testcases := []struct {
+		Algorithm jwa.KeyEncryptionAlgorithm
+		KeySize   int
+	}{
+		{Algorithm: jwa.A128GCMKW, KeySize: 16},
+		{Algorithm: jwa.A192GCMKW, KeySize: 24},
+		{Algorithm: jwa.A256GCMKW, KeySize: 32},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Algorithm.String(), func(t *testing.T) {
+			sharedkey := make([]byte, tc.KeySize)
+			if _, err := rand.Read(sharedkey); !assert.NoError(t, err, `rand.Read should succeed`) {
+				return
+			}
+
+			for _, contentalg := range []jwa.ContentEncryptionAlgorithm{jwa.A128GCM, jwa.A256GCM, jwa.A128CBC_HS256, jwa.A256CBC_HS512} {
+				encrypted, err := jwe.Encrypt(plaintext, tc.Algorithm, sharedkey, contentalg, jwa.NoCompress)
+				if !assert.NoError(t, err, "Encrypt succeeds") {
+					return
+				}
+
+				msg, err := jwe.Parse(encrypted)
+				if !assert.NoError(t, err, `jwe.Parse should succeed`) {
+					return
+				}
+
+				for _, name := range []string{"iv", "tag"} {
+					if _, ok := msg.ProtectedHeaders().Get(name); !assert.True(t, ok, `%s header should be present`, name) {
+						return
+					}
+				}
+
+				decrypted, err := jwe.Decrypt(encrypted, tc.Algorithm, sharedkey)
+				if !assert.NoError(t, err, "Decrypt succeeds") {
+					return
+				}
+
+				if !assert.Equal(t, plaintext, decrypted, "Decrypted correct plaintext") {
+					return
+				}
+			}
+		})
+	}
+	t.Run("Missing iv/tag", func(t *testing.T) {
+		sharedkey := make([]byte, 16)
+		encrypted, err := jwe.Encrypt(plaintext, jwa.A128GCMKW, sharedkey, jwa.A128GCM, jwa.NoCompress)
+		if !assert.NoError(t, err, "Encrypt succeeds") {
+			return
+		}
+
+		for _, name := range []string{"iv", "tag"} {
+			msg, err := jwe.Parse(encrypted)
+			if !assert.NoError(t, err, `jwe.Parse should succeed`) {
+				return
+			}
+
+			if !assert.NoError(t, msg.ProtectedHeaders().Remove(name), `Remove should succeed`) {
+				return
+			}
+
+			_, err = msg.Decrypt(jwa.A128GCMKW, sharedkey)
+			if !assert.Error(t, err, `Decrypt should fail without %s`, name) {
+				return
+			}
+		}
+	})
+}
+
+func TestEncode_PBES2(t *testing.T) {
+	plaintext := []byte("Lorem ipsum")
+	password := []byte("Thus from my lips, by yours, my sin is purged.")
+
+	algorithms := []jwa.KeyEncryptionAlgorithm{
+		jwa.PBES2_HS256_A128KW,
+		jwa.PBES2_HS384_A192KW,
+		jwa.PBES2_HS512_A256KW,
+	}
+
+	for _, alg := range algorithms {
+		alg := alg
+		t.Run(alg.String(), func(t *testing.T) {
+			for _, contentalg := range []jwa.ContentEncryptionAlgorithm{jwa.A128GCM, jwa.A256GCM, jwa.A128CBC_HS256, jwa.A256CBC_HS512} {
+				encrypted, err := jwe.Encrypt(plaintext, alg, password, contentalg, jwa.NoCompress)
+				if !assert.NoError(t, err, "Encrypt succeeds") {
+					return
+				}
+
+				msg, err := jwe.Parse(encrypted)
+				if !assert.NoError(t, err, `jwe.Parse should succeed`) {
+					return
+				}
+
+				for _, name := range []string{"p2s", "p2c"} {
+					if _, ok := msg.ProtectedHeaders().Get(name); !assert.True(t, ok, `%s header should be present`, name) {
+						return
+					}
+				}
+
+				decrypted, err := jwe.Decrypt(encrypted, alg, password)
+				if !assert.NoError(t, err, "Decrypt succeeds") {
+					return
+				}
+
+				if !assert.Equal(t, plaintext, decrypted, "Decrypted correct plaintext") {
+					return
+				}
+
+				_, err = jwe.Decrypt(encrypted, alg, []byte("wrong password"))
+				if !assert.Error(t, err, "Decrypt with the wrong password should fail") {
+					return
+				}
+			}
+		})
+	}
+	t.Run("Iteration count", func(t *testing.T) {
+		encrypted, err := jwe.Encrypt(plaintext, jwa.PBES2_HS256_A128KW, password, jwa.A128GCM, jwa.NoCompress, jwe.WithPBES2Count(2000))
+		if !assert.NoError(t, err, "Encrypt succeeds") {
+			return
+		}
+
+		msg, err := jwe.Parse(encrypted)
+		if !assert.NoError(t, err, `jwe.Parse should succeed`) {
+			return
+		}
+
+		p2c, ok := msg.ProtectedHeaders().Get("p2c")
+		if !assert.True(t, ok, `p2c header should be present`) {
+			return
+		}
+		if !assert.Equal(t, float64(2000), p2c, `p2c should match`) {
+			return
+		}
+
+		_, err = jwe.Encrypt(plaintext, jwa.PBES2_HS256_A128KW, password, jwa.A128GCM, jwa.NoCompress, jwe.WithPBES2Count(999))
+		if !assert.Error(t, err, "Encrypt with a small iteration count should fail") {
+			return
+		}
+
+		if !assert.NoError(t, msg.ProtectedHeaders().Set("p2c", 999), `Set should succeed`) {
+			return
+		}
+		_, err = msg.Decrypt(jwa.PBES2_HS256_A128KW, password)
+		if !assert.Error(t, err, "Decrypt with a small iteration count should fail") {
+			return
+		}
+	})
+	t.Run("Maximum iteration count", func(t *testing.T) {
+		encrypted, err := jwe.Encrypt(plaintext, jwa.PBES2_HS256_A128KW, password, jwa.A128GCM, jwa.NoCompress, jwe.WithPBES2Count(200000))
+		if !assert.NoError(t, err, "Encrypt succeeds") {
+			return
+		}
+
+		_, err = jwe.Decrypt(encrypted, jwa.PBES2_HS256_A128KW, password)
+		if !assert.Error(t, err, "Decrypt with an iteration count above the default maximum should fail") {
+			return
+		}
+
+		decrypted, err := jwe.Decrypt(encrypted, jwa.PBES2_HS256_A128KW, password, jwe.WithMaxPBES2Count(200000))
+		if !assert.NoError(t, err, "Decrypt with a raised maximum should succeed") {
+			return
+		}
+		if !assert.Equal(t, plaintext, decrypted, "payload should match") {
+			return
+		}
+
+		// A huge count must be rejected before any key is derived,
+		// otherwise this would take minutes
+		parts := bytes.Split(encrypted, []byte{'.'})
+		hdrbuf, err := base64.RawURLEncoding.DecodeString(string(parts[0]))
+		if !assert.NoError(t, err, `base64.DecodeString should succeed`) {
+			return
+		}
+		hdrbuf = bytes.Replace(hdrbuf, []byte(`"p2c":200000`), []byte(`"p2c":1073741824`), 1)
+		parts[0] = []byte(base64.RawURLEncoding.EncodeToString(hdrbuf))
+		_, err = jwe.Decrypt(bytes.Join(parts, []byte{'.'}), jwa.PBES2_HS256_A128KW, password)
+		if !assert.Error(t, err, "Decrypt with a huge iteration count should fail") {
+			return
+		}
+		if !assert.Contains(t, err.Error(), "must be at most", `error should mention the maximum`) {
+			return
+		}
+	})
+}
+
+func TestEncode_Compress(t *testing.T) {
+	plaintext := bytes.Repeat([]byte("Lorem ipsum"), 1024)
+	sharedkey := make([]byte, 16)
+	if _, err := rand.Read(sharedkey); !assert.NoError(t, err, `rand.Read should succeed`) {
+		return
+	}
+
+	encrypted, err := jwe.Encrypt(plaintext, jwa.A128KW, sharedkey, jwa.A128CBC_HS256, jwa.NoCompress, jwe.WithCompress(jwa.Deflate))
+	if !assert.NoError(t, err, "Encrypt succeeds") {
+		return
+	}
+
+	msg, err := jwe.Parse(encrypted)
+	if !assert.NoError(t, err, `jwe.Parse should succeed`) {
+		return
+	}
+
+	if !assert.Equal(t, jwa.Deflate, msg.ProtectedHeaders().Compression(), `zip header should be DEF`) {
+		return
+	}
+
+	if !assert.True(t, len(msg.CipherText()) < len(plaintext), `ciphertext should be smaller than the plaintext`) {
+		return
+	}
+
+	t.Run("Default maximum size", func(t *testing.T) {
+		decrypted, err := jwe.Decrypt(encrypted, jwa.A128KW, sharedkey)
+		if !assert.NoError(t, err, "Decrypt succeeds") {
+			return
+		}
+
+		if !assert.Equal(t, plaintext, decrypted, "Decrypted correct plaintext") {
+			return
+		}
+	})
+	t.Run("Exact maximum size", func(t *testing.T) {
+		decrypted, err := jwe.Decrypt(encrypted, jwa.A128KW, sharedkey, jwe.WithMaxDecompressedSize(int64(len(plaintext))))
+		if !assert.NoError(t, err, "Decrypt succeeds") {
+			return
+		}
+
+		if !assert.Equal(t, plaintext, decrypted, "Decrypted correct plaintext") {
+			return
+		}
+	})
+	t.Run("Exceeds maximum size", func(t *testing.T) {
+		_, err := jwe.Decrypt(encrypted, jwa.A128KW, sharedkey, jwe.WithMaxDecompressedSize(int64(len(plaintext)-1)))
+		if !assert.Error(t, err, "Decrypt should fail") {
+			return
+		}
+	})
+}
+
+func TestEncode_ECDH(t *testing.T) {
+	plaintext := []byte("Lorem ipsum")
+	privkey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if !assert.NoError(t, err, "ecdsa key generated") {
+		return
+	}
+
+	algorithms := []jwa.KeyEncryptionAlgorithm{
+		jwa.ECDH_ES_A256KW,
+		jwa.ECDH_ES_A192KW,
+		jwa.ECDH_ES_A128KW,
+	}
+
+	for _, alg := range algorithms {
+		alg := alg
+		t.Run(alg.String(), func(t *testing.T) {
+			encrypted, err := jwe.Encrypt(plaintext, alg, &privkey.PublicKey, jwa.A256GCM, jwa.NoCompress)
+			if !assert.NoError(t, err, "Encrypt succeeds") {
+				return
+			}
+
+			t.Logf("encrypted = %s", encrypted)
+
+			msg, err := jwe.Parse(encrypted)
+			if !assert.NoError(t, err, `jwe.Parse should succeed`) {
+				return
+			}
+
+			t.Logf("%#v", msg)
+
+			{
+				buf, _ := json.MarshalIndent(msg, "", "  ")
+				t.Logf("%s", buf)
+			}
+			{
+				buf, _ := json.MarshalIndent(msg.ProtectedHeaders(), "", "  ")
+				t.Logf("%s", buf)
+			}
+
+			decrypted, err := jwe.Decrypt(encrypted, alg, privkey)
+			if !assert.NoError(t, err, "Decrypt succeeds") {
+				return
+			}
+			t.Logf("%s", decrypted)
+		})
+	}
+}
+
+func TestEncode_ChaCha20Poly1305(t *testing.T) {
+	plaintext := []byte("Lorem ipsum")
+
+	rsakey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if !assert.NoError(t, err, `rsa.GenerateKey should succeed`) {
+		return
+	}
+	eckey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if !assert.NoError(t, err, `ecdsa.GenerateKey should succeed`) {
+		return
+	}
+	sharedkey := make([]byte, 32)
+	if _, err := rand.Read(sharedkey); !assert.NoError(t, err, `rand.Read should succeed`) {
+		return
+	}
+
+	testcases := []struct {
+		Algorithm  jwa.KeyEncryptionAlgorithm
+		PublicKey  interface{}
+		PrivateKey interface{}
+	}{
+		{Algorithm: jwa.RSA_OAEP, PublicKey: &rsakey.PublicKey, PrivateKey: rsakey},
+		{Algorithm: jwa.A256KW, PublicKey: sharedkey, PrivateKey: sharedkey},
+		{Algorithm: jwa.A256GCMKW, PublicKey: sharedkey, PrivateKey: sharedkey},
+		{Algorithm: jwa.ECDH_ES_A256KW, PublicKey: &eckey.PublicKey, PrivateKey: eckey},
+	}
+
+	for _, contentalg := range []jwa.ContentEncryptionAlgorithm{jwa.C20P, jwa.XC20P} {
+		for _, tc := range testcases {
+			contentalg := contentalg
+			tc := tc
+			t.Run(contentalg.String()+"/"+tc.Algorithm.String(), func(t *testing.T) {
+				encrypted, err := jwe.Encrypt(plaintext, tc.Algorithm, tc.PublicKey, contentalg, jwa.NoCompress)
+				if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+					return
+				}
+
+				msg, err := jwe.Parse(encrypted)
+				if !assert.NoError(t, err, `jwe.Parse should succeed`) {
+					return
+				}
+
+				if !assert.Equal(t, contentalg, msg.ProtectedHeaders().ContentEncryption(), `enc should match`) {
+					return
+				}
+
+				decrypted, err := jwe.Decrypt(encrypted, tc.Algorithm, tc.PrivateKey)
+				if !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
+					return
+				}
+
+				if !assert.Equal(t, plaintext, decrypted, `decrypted payload should match`) {
+					return
+				}
+			})
+		}
+	}
+}
+
+func TestEncode_MultipleRecipients(t *testing.T) {
+	plaintext := []byte("Lorem ipsum")
+
+	rsakey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if !assert.NoError(t, err, `rsa.GenerateKey should succeed`) {
+		return
+	}
+	eckey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if !assert.NoError(t, err, `ecdsa.GenerateKey should succeed`) {
+		return
+	}
+	sharedkey1 := make([]byte, 16)
+	sharedkey2 := make([]byte, 16)
+	for _, k := range [][]byte{sharedkey1, sharedkey2} {
+		if _, err := rand.Read(k); !assert.NoError(t, err, `rand.Read should succeed`) {
+			return
+		}
+	}
+
+	encrypted, err := jwe.Encrypt(plaintext, jwa.RSA_OAEP, &rsakey.PublicKey, jwa.A128GCM, jwa.NoCompress,
+		jwe.WithRecipient(jwa.ECDH_ES_A128KW, &eckey.PublicKey),
+		jwe.WithRecipient(jwa.A128KW, sharedkey1),
+		jwe.WithRecipient(jwa.A128KW, sharedkey2),
+	)
+	if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+		return
+	}
+
+	var m map[string]interface{}
+	if !assert.NoError(t, json.Unmarshal(encrypted, &m), `message should be in JSON serialization format`) {
+		return
+	}
+	if !assert.Len(t, m["recipients"], 4, `there should be 4 recipients`) {
+		return
+	}
+
+	testcases := []struct {
+		Name      string
+		Algorithm jwa.KeyEncryptionAlgorithm
+		Key       interface{}
+	}{
+		{Name: "RSA-OAEP", Algorithm: jwa.RSA_OAEP, Key: rsakey},
+		{Name: "ECDH-ES+A128KW", Algorithm: jwa.ECDH_ES_A128KW, Key: eckey},
+		{Name: "A128KW (first key)", Algorithm: jwa.A128KW, Key: sharedkey1},
+		{Name: "A128KW (second key)", Algorithm: jwa.A128KW, Key: sharedkey2},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			decrypted, err := jwe.Decrypt(encrypted, tc.Algorithm, tc.Key)
+			if !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
+				return
+			}
+			if !assert.Equal(t, plaintext, decrypted, `decrypted payload should match`) {
+				return
+			}
+		})
+	}
+
+	t.Run("wrong key", func(t *testing.T) {
+		_, err := jwe.Decrypt(encrypted, jwa.A128KW, make([]byte, 16))
+		if !assert.Error(t, err, `jwe.Decrypt should fail`) {
+			return
+		}
+	})
+	// checkDisjoint parses the JSON serialization of the message, and
+	// checks that no header parameter in the protected header is
+	// repeated in the header of any of the recipients
+	checkDisjoint := func(t *testing.T, encrypted []byte) bool {
+		t.Helper()
+		var m struct {
+			Protected  string `json:"protected"`
+			Recipients []struct {
+				Header map[string]interface{} `json:"header"`
+			} `json:"recipients"`
+		}
+		if !assert.NoError(t, json.Unmarshal(encrypted, &m), `json.Unmarshal should succeed`) {
+			return false
+		}
+		decoded, err := base64.RawURLEncoding.DecodeString(m.Protected)
+		if !assert.NoError(t, err, `base64 decode should succeed`) {
+			return false
+		}
+		var protected map[string]interface{}
+		if !assert.NoError(t, json.Unmarshal(decoded, &protected), `json.Unmarshal should succeed`) {
+			return false
+		}
+		for i, r := range m.Recipients {
+			for name := range r.Header {
+				if !assert.NotContains(t, protected, name, `%q should not be in both the protected header and the header of recipient #%d`, name, i+1) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	t.Run("disjoint headers", func(t *testing.T) {
+		if !checkDisjoint(t, encrypted) {
+			return
+		}
+	})
+	t.Run("shared protected header", func(t *testing.T) {
+		hdrs := jwe.NewHeaders()
+		if !assert.NoError(t, hdrs.Set(jwe.AlgorithmKey, jwa.A128KW), `hdrs.Set should succeed`) {
+			return
+		}
+		encrypted, err := jwe.Encrypt(plaintext, jwa.A128KW, sharedkey1, jwa.A128GCM, jwa.NoCompress,
+			jwe.WithRecipient(jwa.A128KW, sharedkey2),
+			jwe.WithProtectedHeaders(hdrs),
+		)
+		if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+			return
+		}
+		if !checkDisjoint(t, encrypted) {
+			return
+		}
+
+		// "alg" is shared by all recipients, so there is nothing
+		// left to put in the recipient headers
+		var m struct {
+			Recipients []map[string]interface{} `json:"recipients"`
+		}
+		if !assert.NoError(t, json.Unmarshal(encrypted, &m), `json.Unmarshal should succeed`) {
+			return
+		}
+		if !assert.Len(t, m.Recipients, 2, `there should be 2 recipients`) {
+			return
+		}
+		for _, r := range m.Recipients {
+			if !assert.NotContains(t, r, "header", `"header" should be omitted`) {
+				return
+			}
+		}
+
+		for _, key := range [][]byte{sharedkey1, sharedkey2} {
+			decrypted, err := jwe.Decrypt(encrypted, jwa.A128KW, key)
+			if !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
+				return
+			}
+			if !assert.Equal(t, plaintext, decrypted, `decrypted payload should match`) {
+				return
+			}
+		}
+
+		_, err = jwe.Encrypt(plaintext, jwa.A128KW, sharedkey1, jwa.A128GCM, jwa.NoCompress,
+			jwe.WithRecipient(jwa.ECDH_ES_A128KW, &eckey.PublicKey),
+			jwe.WithProtectedHeaders(hdrs),
+		)
+		if !assert.Error(t, err, `jwe.Encrypt should fail when a recipient header conflicts with the protected header`) {
+			return
+		}
+	})
+	t.Run("recipients only", func(t *testing.T) {
+		encrypted, err := jwe.Encrypt(plaintext, "", nil, jwa.A128GCM, jwa.NoCompress, jwe.WithRecipient(jwa.A128KW, sharedkey1))
+		if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+			return
+		}
+		decrypted, err := jwe.Decrypt(encrypted, jwa.A128KW, sharedkey1)
+		if !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
+			return
+		}
+		if !assert.Equal(t, plaintext, decrypted, `decrypted payload should match`) {
+			return
+		}
+	})
+}
+
+func TestDecrypt_RecipientHeaders(t *testing.T) {
+	plaintext := []byte("Lorem ipsum")
+	sharedkey1 := []byte("0123456789abcdef")
+	sharedkey2 := []byte("0123456789abcdef0123456789abcdef")
+
+	encrypted, err := jwe.Encrypt(plaintext, jwa.A128KW, sharedkey1, jwa.A128GCM, jwa.NoCompress, jwe.WithRecipient(jwa.A256KW, sharedkey2))
+	if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+		return
+	}
+
+	msg, err := jwe.Parse(encrypted)
+	if !assert.NoError(t, err, `jwe.Parse should succeed`) {
+		return
+	}
+	// "alg" is specific to each recipient, and must not be part of
+	// the shared protected header
+	if !assert.Equal(t, jwa.KeyEncryptionAlgorithm(""), msg.ProtectedHeaders().Algorithm(), `protected header should not contain "alg"`) {
+		return
+	}
+	if !assert.Equal(t, jwa.A128KW, msg.Recipients()[0].Headers().Algorithm(), `"alg" of the first recipient should match`) {
+		return
+	}
+	if !assert.Equal(t, jwa.A256KW, msg.Recipients()[1].Headers().Algorithm(), `"alg" of the second recipient should match`) {
+		return
+	}
+
+	// The unprotected headers are not integrity protected, so they can
+	// be added after encryption
+	unprotected := jwe.NewHeaders()
+	if !assert.NoError(t, unprotected.Set(jwe.JWKSetURLKey, "https://example.com/jwks.json"), `unprotected.Set should succeed`) {
+		return
+	}
+	if !assert.NoError(t, msg.Set(jwe.UnprotectedHeadersKey, unprotected), `msg.Set should succeed`) {
+		return
+	}
+	if !assert.NoError(t, msg.Recipients()[1].Headers().Set(jwe.KeyIDKey, "key2"), `Headers().Set should succeed`) {
+		return
+	}
+
+	serialized, err := json.Marshal(msg)
+	if !assert.NoError(t, err, `json.Marshal should succeed`) {
+		return
+	}
+
+	t.Run("Roundtrip", func(t *testing.T) {
+		parsed, err := jwe.Parse(serialized)
+		if !assert.NoError(t, err, `jwe.Parse should succeed`) {
+			return
+		}
+		if !assert.Equal(t, "https://example.com/jwks.json", parsed.UnprotectedHeaders().JWKSetURL(), `shared unprotected header should match`) {
+			return
+		}
+		if !assert.Equal(t, "", parsed.Recipients()[0].Headers().KeyID(), `"kid" of the first recipient should be empty`) {
+			return
+		}
+		if !assert.Equal(t, "key2", parsed.Recipients()[1].Headers().KeyID(), `"kid" of the second recipient should match`) {
+			return
+		}
+
+		for alg, key := range map[jwa.KeyEncryptionAlgorithm][]byte{jwa.A128KW: sharedkey1, jwa.A256KW: sharedkey2} {
+			decrypted, err := parsed.Decrypt(alg, key)
+			if !assert.NoError(t, err, `parsed.Decrypt should succeed`) {
+				return
+			}
+			if !assert.Equal(t, plaintext, decrypted, `decrypted payload should match`) {
+				return
+			}
+		}
+	})
+	t.Run("Key set", func(t *testing.T) {
+		key, err := jwk.New(sharedkey2)
+		if !assert.NoError(t, err, `jwk.New should succeed`) {
+			return
+		}
+		if !assert.NoError(t, key.Set(jwk.KeyIDKey, "key2"), `key.Set should succeed`) {
+			return
+		}
+		decrypted, err := jwe.Decrypt(serialized, "", nil, jwe.WithKeySet(&jwk.Set{Keys: []jwk.Key{key}}))
+		if !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
+			return
+		}
+		if !assert.Equal(t, plaintext, decrypted, `decrypted payload should match`) {
+			return
+		}
+	})
+	t.Run("Conflicting headers", func(t *testing.T) {
+		if !assert.NoError(t, unprotected.Set(jwe.KeyIDKey, "shared"), `unprotected.Set should succeed`) {
+			return
+		}
+		defer unprotected.Remove(jwe.KeyIDKey)
+
+		_, err := msg.Decrypt(jwa.A256KW, sharedkey2)
+		if !assert.Error(t, err, `msg.Decrypt should fail`) {
+			return
+		}
+	})
+	t.Run("Flattened", func(t *testing.T) {
+		// all header parameters are in the protected header, and the
+		// flattened message has no "header" field
+		compact, err := jwe.Encrypt(plaintext, jwa.A128KW, sharedkey1, jwa.A128GCM, jwa.NoCompress)
+		if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+			return
+		}
+		parts := strings.Split(string(compact), ".")
+		flattened, err := json.Marshal(map[string]string{
+			"protected":     parts[0],
+			"encrypted_key": parts[1],
+			"iv":            parts[2],
+			"ciphertext":    parts[3],
+			"tag":           parts[4],
+		})
+		if !assert.NoError(t, err, `json.Marshal should succeed`) {
+			return
+		}
+		decrypted, err := jwe.Decrypt(flattened, jwa.A128KW, sharedkey1)
+		if !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
+			return
+		}
+		if !assert.Equal(t, plaintext, decrypted, `decrypted payload should match`) {
+			return
+		}
+	})
+	t.Run("Unprotected zip", func(t *testing.T) {
+		var proxy map[string]interface{}
+		if !assert.NoError(t, json.Unmarshal(serialized, &proxy), `json.Unmarshal should succeed`) {
+			return
+		}
+		recipients := proxy["recipients"].([]interface{})
+
+		zip := map[string]interface{}{"zip": "DEF"}
+		testcases := map[string]func(map[string]interface{}){
+			"shared unprotected header": func(m map[string]interface{}) {
+				m["unprotected"] = zip
+			},
+			"per-recipient header": func(m map[string]interface{}) {
+				m["recipients"] = []interface{}{
+					map[string]interface{}{
+						"header":        zip,
+						"encrypted_key": recipients[0].(map[string]interface{})["encrypted_key"],
+					},
+				}
+			},
+			"flattened header": func(m map[string]interface{}) {
+				delete(m, "recipients")
+				m["header"] = zip
+				m["encrypted_key"] = recipients[0].(map[string]interface{})["encrypted_key"]
+			},
+		}
+		for name, mutate := range testcases {
+			mutate := mutate
+			t.Run(name, func(t *testing.T) {
+				m := make(map[string]interface{})
+				for k, v := range proxy {
+					m[k] = v
+				}
+				mutate(m)
+				buf, err := json.Marshal(m)
+				if !assert.NoError(t, err, `json.Marshal should succeed`) {
+					return
+				}
+				_, err = jwe.Parse(buf)
+				if !assert.True(t, errors.Is(err, jwe.ErrUnprotectedCompression), `jwe.Parse should fail with ErrUnprotectedCompression`) {
+					return
+				}
+			})
+		}
+
+		parsed, err := jwe.Parse(serialized)
+		if !assert.NoError(t, err, `jwe.Parse should succeed`) {
+			return
+		}
+		if !assert.NoError(t, parsed.Recipients()[0].Headers().Set(jwe.CompressionKey, jwa.Deflate), `Headers().Set should succeed`) {
+			return
+		}
+		_, err = parsed.Decrypt(jwa.A128KW, sharedkey1)
+		if !assert.True(t, errors.Is(err, jwe.ErrUnprotectedCompression), `parsed.Decrypt should fail with ErrUnprotectedCompression`) {
+			return
+		}
+	})
+}
+
+func TestEncode_AAD(t *testing.T) {
+	plaintext := []byte("Lorem ipsum")
+	aad := []byte("additional authenticated data")
+	sharedkey := make([]byte, 16)
+	if _, err := rand.Read(sharedkey); !assert.NoError(t, err, `rand.Read should succeed`) {
+		return
+	}
+
+	encrypted, err := jwe.Encrypt(plaintext, jwa.A128KW, sharedkey, jwa.A128GCM, jwa.NoCompress, jwe.WithAAD(aad))
+	if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+		return
+	}
+
+	msg, err := jwe.Parse(encrypted)
+	if !assert.NoError(t, err, `jwe.Parse should succeed`) {
+		return
+	}
+	if !assert.Equal(t, aad, msg.AuthenticatedData(), `aad should match`) {
+		return
+	}
+
+	decrypted, err := jwe.Decrypt(encrypted, jwa.A128KW, sharedkey)
+	if !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
+		return
+	}
+	if !assert.Equal(t, plaintext, decrypted, `decrypted payload should match`) {
+		return
+	}
+
+	t.Run("disjoint headers", func(t *testing.T) {
+		var m map[string]interface{}
+		if !assert.NoError(t, json.Unmarshal(encrypted, &m), `json.Unmarshal should succeed`) {
+			return
+		}
+		encoded, ok := m["protected"].(string)
+		if !assert.True(t, ok, `"protected" should be a string`) {
+			return
+		}
+		decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+		if !assert.NoError(t, err, `base64 decode should succeed`) {
+			return
+		}
+		var protected map[string]interface{}
+		if !assert.NoError(t, json.Unmarshal(decoded, &protected), `json.Unmarshal should succeed`) {
+			return
+		}
+		for _, name := range []string{"alg", "enc"} {
+			if !assert.Contains(t, protected, name, `protected header should contain %q`, name) {
+				return
+			}
+		}
+
+		// With a single recipient, all header parameters are in the
+		// protected header, so "header" is omitted altogether
+		header, ok := m["header"].(map[string]interface{})
+		if ok {
+			for name := range header {
+				if !assert.NotContains(t, protected, name, `%q should not be in both the protected and the recipient header`, name) {
+					return
+				}
+			}
+		}
+		if !assert.NotContains(t, m, "header", `"header" should be omitted`) {
+			return
+		}
+	})
+	t.Run("modified aad", func(t *testing.T) {
+		var m map[string]interface{}
+		if !assert.NoError(t, json.Unmarshal(encrypted, &m), `json.Unmarshal should succeed`) {
+			return
+		}
+		m["aad"] = base64.RawURLEncoding.EncodeToString([]byte("modified data"))
+		modified, err := json.Marshal(m)
+		if !assert.NoError(t, err, `json.Marshal should succeed`) {
+			return
+		}
+		if _, err := jwe.Decrypt(modified, jwa.A128KW, sharedkey); !assert.Error(t, err, `jwe.Decrypt should fail`) {
+			return
+		}
+	})
+}
+
+func TestDecrypt_Errors(t *testing.T) {
+	plaintext := []byte("Lorem ipsum")
+	sharedkey := make([]byte, 16)
+	if _, err := rand.Read(sharedkey); !assert.NoError(t, err, `rand.Read should succeed`) {
+		return
+	}
+
+	encrypted, err := jwe.Encrypt(plaintext, jwa.A128KW, sharedkey, jwa.A128GCM, jwa.NoCompress)
+	if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+		return
+	}
+
+	t.Run("wrong key", func(t *testing.T) {
+		wrongkey := make([]byte, 16)
+		if _, err := rand.Read(wrongkey); !assert.NoError(t, err, `rand.Read should succeed`) {
+			return
+		}
+		_, err := jwe.Decrypt(encrypted, jwa.A128KW, wrongkey)
+		if !assert.True(t, errors.Is(err, jwe.ErrUnwrapFailed), `error should be ErrUnwrapFailed (%s)`, err) {
+			return
+		}
+		if !assert.False(t, errors.Is(err, jwe.ErrAuthTagMismatch), `error should not be ErrAuthTagMismatch`) {
+			return
+		}
+	})
+	t.Run("modified ciphertext", func(t *testing.T) {
+		parts := strings.Split(string(encrypted), ".")
+		ciphertext, err := base64.RawURLEncoding.DecodeString(parts[3])
+		if !assert.NoError(t, err, `base64 decode should succeed`) {
+			return
+		}
+		ciphertext[0] ^= 0x1
+		parts[3] = base64.RawURLEncoding.EncodeToString(ciphertext)
+
+		_, err = jwe.Decrypt([]byte(strings.Join(parts, ".")), jwa.A128KW, sharedkey)
+		if !assert.True(t, errors.Is(err, jwe.ErrAuthTagMismatch), `error should be ErrAuthTagMismatch (%s)`, err) {
+			return
+		}
+		if !assert.False(t, errors.Is(err, jwe.ErrUnwrapFailed), `error should not be ErrUnwrapFailed`) {
+			return
+		}
+	})
+}
+
+func TestDecrypt_KeySet(t *testing.T) {
+	plaintext := []byte("Lorem ipsum")
+
+	rsakey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if !assert.NoError(t, err, `rsa.GenerateKey should succeed`) {
+		return
+	}
+	p256key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if !assert.NoError(t, err, `ecdsa.GenerateKey should succeed`) {
+		return
+	}
+	p384key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if !assert.NoError(t, err, `ecdsa.GenerateKey should succeed`) {
+		return
+	}
+
+	newKey := func(t *testing.T, raw interface{}, kid string) jwk.Key {
+		t.Helper()
+		key, err := jwk.New(raw)
+		if !assert.NoError(t, err, `jwk.New should succeed`) {
+			return nil
+		}
+		if kid != "" {
+			if !assert.NoError(t, key.Set(jwk.KeyIDKey, kid), `key.Set should succeed`) {
+				return nil
+			}
+		}
+		return key
+	}
+
+	t.Run("Matching kid", func(t *testing.T) {
+		encrypted, err := jwe.Encrypt(plaintext, jwa.RSA_OAEP, &rsakey.PublicKey, jwa.A128GCM, jwa.NoCompress, jwe.WithRecipient(jwa.ECDH_ES_A128KW, &p256key.PublicKey))
+		if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+			return
+		}
+
+		// Tag each recipient with the ID of its key
+		msg, err := jwe.Parse(encrypted)
+		if !assert.NoError(t, err, `jwe.Parse should succeed`) {
+			return
+		}
+		for i, kid := range []string{"rsa", "ec"} {
+			if !assert.NoError(t, msg.Recipients()[i].Headers().Set(jwe.KeyIDKey, kid), `Set should succeed`) {
+				return
+			}
+		}
+		encrypted, err = jwe.JSON(msg)
+		if !assert.NoError(t, err, `jwe.JSON should succeed`) {
+			return
+		}
+
+		for _, kid := range []string{"rsa", "ec"} {
+			var set jwk.Set
+			set.Keys = append(set.Keys, newKey(t, p384key, kid))
+			if kid == "rsa" {
+				set.Keys = append(set.Keys, newKey(t, rsakey, kid))
+			} else {
+				set.Keys = append(set.Keys, newKey(t, p256key, kid))
+			}
+
+			decrypted, err := jwe.Decrypt(encrypted, "", nil, jwe.WithKeySet(&set))
+			if !assert.NoError(t, err, `jwe.Decrypt should succeed (kid = %s)`, kid) {
+				return
+			}
+			if !assert.Equal(t, plaintext, decrypted, `payload should match`) {
+				return
+			}
+		}
+
+		set := jwk.Set{Keys: []jwk.Key{newKey(t, p256key, "unknown")}}
+		_, err = jwe.Decrypt(encrypted, "", nil, jwe.WithKeySet(&set))
+		if !assert.Error(t, err, `jwe.Decrypt should fail`) {
+			return
+		}
+		if !assert.Contains(t, err.Error(), "no key in the key set", `error should mention the key set`) {
+			return
+		}
+	})
+	t.Run("No kid", func(t *testing.T) {
+		encrypted, err := jwe.Encrypt(plaintext, jwa.ECDH_ES_A128KW, &p256key.PublicKey, jwa.A128GCM, jwa.NoCompress)
+		if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+			return
+		}
+
+		// The P-384 key must be skipped, because its curve does
+		// not match that of the ephemeral public key
+		set := jwk.Set{Keys: []jwk.Key{newKey(t, p384key, ""), newKey(t, rsakey, ""), newKey(t, p256key, "")}}
+		decrypted, err := jwe.Decrypt(encrypted, "", nil, jwe.WithKeySet(&set))
+		if !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
+			return
+		}
+		if !assert.Equal(t, plaintext, decrypted, `payload should match`) {
+			return
+		}
+
+		set = jwk.Set{Keys: []jwk.Key{newKey(t, p384key, "")}}
+		_, err = jwe.Decrypt(encrypted, "", nil, jwe.WithKeySet(&set))
+		if !assert.Error(t, err, `jwe.Decrypt should fail`) {
+			return
+		}
+		if !assert.Contains(t, err.Error(), "no key in the key set", `error should mention the key set`) {
+			return
+		}
+
+		if _, err := jwe.Decrypt(encrypted, jwa.RSA_OAEP, nil, jwe.WithKeySet(&set)); !assert.Error(t, err, `jwe.Decrypt should fail when the algorithm does not match`) {
+			return
+		}
+	})
+}
+
+func TestEncode_ECDH_LeadingZero(t *testing.T) {
+	plaintext := []byte("Lorem ipsum")
+	privkey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if !assert.NoError(t, err, `ecdsa.GenerateKey should succeed`) {
+		return
+	}
+
+	// About 1 in 256 ephemeral keys produce a shared secret whose
+	// first byte is zero. Keep encrypting until we hit one, and make
+	// sure that the recipient derives the same key from it
+	for i := 0; i < 8192; i++ {
+		encrypted, err := jwe.Encrypt(plaintext, jwa.ECDH_ES_A128KW, &privkey.PublicKey, jwa.A128GCM, jwa.NoCompress)
+		if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+			return
+		}
+
+		msg, err := jwe.Parse(encrypted)
+		if !assert.NoError(t, err, `jwe.Parse should succeed`) {
+			return
+		}
+
+		var epk ecdsa.PublicKey
+		if !assert.NoError(t, msg.ProtectedHeaders().EphemeralPublicKey().Raw(&epk), `epk.Raw should succeed`) {
+			return
+		}
+
+		z, _ := elliptic.P256().ScalarMult(epk.X, epk.Y, privkey.D.Bytes())
+		if z.BitLen() > 248 {
+			continue
+		}
+
+		decrypted, err := jwe.Decrypt(encrypted, jwa.ECDH_ES_A128KW, privkey)
+		if !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
+			return
+		}
+		assert.Equal(t, plaintext, decrypted, `decrypted payload should match`)
+		return
+	}
+	t.Fatal(`failed to generate a shared secret with a leading zero byte`)
+}
+
+func TestDecrypt_AgreementPartyInfo(t *testing.T) {
+	// Keys, headers and the derived key are taken from RFC 7518,
+	// Appendix C. "apu" and "apv" differ, so the key can only be
+	// derived if both of them are passed to the KDF
+	const bobKeySrc = `{"kty":"EC",
+      "crv":"P-256",
+      "x":"weNJy2HscCSM6AEDTDg04biOvhFhyyWvOHQfeF_PxMQ",
+      "y":"e8lnCO-AlStT-NJVX-crhB7QRYhiix03illJOVAOyck",
+      "d":"VEmDZpDXXK8p8N0Cndsxs924q6nS1RXFASRl6BfUqdw"
+     }`
+	const protected = `{"alg":"ECDH-ES","enc":"A128GCM","apu":"QWxpY2U","apv":"Qm9i","epk":{"kty":"EC","crv":"P-256","x":"gI0GAILBdu7T53akrFmMyGcsF3n5dO7MmwNBHKW5SV0","y":"SLW_xSffzlPWrHEVI30DHM_4egVwt3NQqeUD7nMFpps"}}`
+	cek := []byte{86, 170, 141, 234, 248, 35, 109, 32, 92, 34, 40, 205, 113, 167, 16, 26}
+	plaintext := []byte("Lorem ipsum")
+
+	webKey, err := jwk.ParseKey([]byte(bobKeySrc))
+	if !assert.NoError(t, err, `jwk.ParseKey should succeed`) {
+		return
+	}
+	var bobKey ecdsa.PrivateKey
+	if !assert.NoError(t, webKey.Raw(&bobKey), `webKey.Raw should succeed`) {
+		return
+	}
+
+	block, err := aes.NewCipher(cek)
+	if !assert.NoError(t, err, `aes.NewCipher should succeed`) {
+		return
+	}
+	aead, err := cipher.NewGCM(block)
+	if !assert.NoError(t, err, `cipher.NewGCM should succeed`) {
+		return
+	}
+	iv := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(iv); !assert.NoError(t, err, `rand.Read should succeed`) {
+		return
+	}
+
+	// The authenticated data is computed from the encoded headers
+	h := jwe.NewHeaders()
+	if !assert.NoError(t, json.Unmarshal([]byte(protected), h), `json.Unmarshal should succeed`) {
+		return
+	}
+	hdr, err := h.Encode()
+	if !assert.NoError(t, err, `h.Encode should succeed`) {
+		return
+	}
+
+	sealed := aead.Seal(nil, iv, plaintext, hdr)
+	tagOffset := len(sealed) - aead.Overhead()
+	encrypted := strings.Join([]string{
+		string(hdr),
+		"",
+		base64.RawURLEncoding.EncodeToString(iv),
+		base64.RawURLEncoding.EncodeToString(sealed[:tagOffset]),
+		base64.RawURLEncoding.EncodeToString(sealed[tagOffset:]),
+	}, ".")
+
+	decrypted, err := jwe.Decrypt([]byte(encrypted), jwa.ECDH_ES, &bobKey)
+	if !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
+		return
+	}
+	if !assert.Equal(t, plaintext, decrypted, `decrypted payload should match`) {
+		return
+	}
+}
+
+func TestDecrypt_EphemeralKey(t *testing.T) {
+	plaintext := []byte("Lorem ipsum")
+	privkey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if !assert.NoError(t, err, `ecdsa.GenerateKey should succeed`) {
+		return
+	}
+
+	encrypted, err := jwe.Encrypt(plaintext, jwa.ECDH_ES_A128KW, &privkey.PublicKey, jwa.A128GCM, jwa.NoCompress)
+	if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+		return
+	}
+
+	// replaceEPK returns a copy of the message whose "epk" header
+	// is replaced with the given key
+	replaceEPK := func(t *testing.T, epk map[string]interface{}) []byte {
+		t.Helper()
+		parts := bytes.Split(encrypted, []byte{'.'})
+		hdrbuf, err := base64.RawURLEncoding.DecodeString(string(parts[0]))
+		if !assert.NoError(t, err, `base64 decode should succeed`) {
+			return nil
+		}
+		var hdrs map[string]interface{}
+		if !assert.NoError(t, json.Unmarshal(hdrbuf, &hdrs), `json.Unmarshal should succeed`) {
+			return nil
+		}
+		hdrs["epk"] = epk
+		hdrbuf, err = json.Marshal(hdrs)
+		if !assert.NoError(t, err, `json.Marshal should succeed`) {
+			return nil
+		}
+		parts[0] = []byte(base64.RawURLEncoding.EncodeToString(hdrbuf))
+		return bytes.Join(parts, []byte{'.'})
+	}
+
+	t.Run("Curve mismatch", func(t *testing.T) {
+		otherkey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		if !assert.NoError(t, err, `ecdsa.GenerateKey should succeed`) {
+			return
+		}
+		epk := map[string]interface{}{
+			"kty": "EC",
+			"crv": "P-384",
+			"x":   base64.RawURLEncoding.EncodeToString(otherkey.X.Bytes()),
+			"y":   base64.RawURLEncoding.EncodeToString(otherkey.Y.Bytes()),
+		}
+		_, err = jwe.Decrypt(replaceEPK(t, epk), jwa.ECDH_ES_A128KW, privkey)
+		if !assert.True(t, errors.Is(err, jwe.ErrEphemeralKeyCurveMismatch), `error should be ErrEphemeralKeyCurveMismatch (got %v)`, err) {
+			return
+		}
+	})
+	t.Run("Point not on curve", func(t *testing.T) {
+		epk := map[string]interface{}{
+			"kty": "EC",
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(privkey.X.Bytes()),
+			"y":   base64.RawURLEncoding.EncodeToString(new(big.Int).Add(privkey.Y, big.NewInt(1)).Bytes()),
+		}
+		_, err := jwe.Decrypt(replaceEPK(t, epk), jwa.ECDH_ES_A128KW, privkey)
+		if !assert.True(t, errors.Is(err, jwe.ErrInvalidECDHKey), `error should be ErrInvalidECDHKey (got %v)`, err) {
+			return
+		}
+		if !assert.Contains(t, err.Error(), "not on the curve", `error should mention the curve`) {
+			return
+		}
+	})
+	t.Run("Key type mismatch", func(t *testing.T) {
+		_, x25519key, err := x25519.GenerateKey(rand.Reader)
+		if !assert.NoError(t, err, `x25519.GenerateKey should succeed`) {
+			return
+		}
+		_, err = jwe.Decrypt(encrypted, jwa.ECDH_ES_A128KW, x25519key)
+		if !assert.True(t, errors.Is(err, jwe.ErrEphemeralKeyCurveMismatch), `error should be ErrEphemeralKeyCurveMismatch (got %v)`, err) {
+			return
+		}
+	})
+}
+
+func TestDecrypt_RequireKeyID(t *testing.T) {
+	key := []byte("0123456789abcdef")
+
+	hdrs := jwe.NewHeaders()
+	if !assert.NoError(t, hdrs.Set(jwe.KeyIDKey, "my-key"), `hdrs.Set should succeed`) {
+		return
+	}
+	withKeyID, err := jwe.Encrypt([]byte(examplePayload), jwa.A128KW, key, jwa.A128GCM, jwa.NoCompress, jwe.WithProtectedHeaders(hdrs))
+	if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+		return
+	}
+	withoutKeyID, err := jwe.Encrypt([]byte(examplePayload), jwa.A128KW, key, jwa.A128GCM, jwa.NoCompress)
+	if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+		return
+	}
+
+	decrypted, err := jwe.Decrypt(withKeyID, jwa.A128KW, key, jwe.WithRequireKeyID(true))
+	if !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
+		return
+	}
+	if !assert.Equal(t, examplePayload, string(decrypted), `payload should match`) {
+		return
+	}
+	if _, err := jwe.Decrypt(withoutKeyID, jwa.A128KW, key, jwe.WithRequireKeyID(true)); !assert.Error(t, err, `jwe.Decrypt should fail`) {
+		return
+	}
+	if _, err := jwe.Decrypt(withoutKeyID, jwa.A128KW, key); !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
+		return
+	}
+}
+
+func TestEncode_RandomSource(t *testing.T) {
+	plaintext := []byte("Lorem ipsum")
+	sharedkey := []byte("0123456789abcdef")
+	source := bytes.Repeat([]byte{0xde, 0xad, 0xbe, 0xef}, 256)
+
+	for _, alg := range []jwa.KeyEncryptionAlgorithm{jwa.A128KW, jwa.A128GCMKW, jwa.PBES2_HS256_A128KW} {
+		alg := alg
+		t.Run(alg.String(), func(t *testing.T) {
+			first, err := jwe.Encrypt(plaintext, alg, sharedkey, jwa.A128CBC_HS256, jwa.NoCompress, jwe.WithRandomSource(bytes.NewReader(source)))
+			if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+				return
+			}
+			second, err := jwe.Encrypt(plaintext, alg, sharedkey, jwa.A128CBC_HS256, jwa.NoCompress, jwe.WithRandomSource(bytes.NewReader(source)))
+			if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+				return
+			}
+			if !assert.Equal(t, first, second, `messages encrypted using the same random source should match`) {
+				return
+			}
+
+			decrypted, err := jwe.Decrypt(first, alg, sharedkey)
+			if !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
+				return
+			}
+			if !assert.Equal(t, plaintext, decrypted, `decrypted payload should match`) {
+				return
+			}
+		})
 	}
 
-	for i := 0; i < max; i++ {
-		encrypted, err := jwe.Encrypt(plaintext, jwa.RSA1_5, &rsaPrivKey.PublicKey, jwa.A128CBC_HS256, jwa.NoCompress)
-		if !assert.NoError(t, err, "Encrypt is successful") {
+	t.Run("exhausted source", func(t *testing.T) {
+		_, err := jwe.Encrypt(plaintext, jwa.A128KW, sharedkey, jwa.A128GCM, jwa.NoCompress, jwe.WithRandomSource(bytes.NewReader(nil)))
+		if !assert.Error(t, err, `jwe.Encrypt should fail`) {
 			return
 		}
+	})
+}
 
-		decrypted, err := jwe.Decrypt(encrypted, jwa.RSA1_5, rsaPrivKey)
-		if !assert.NoError(t, err, "Decrypt successful") {
-			return
-		}
+func TestEncode_ProtectedHeaders(t *testing.T) {
+	plaintext := []byte("Lorem ipsum")
+	sharedkey := []byte("0123456789abcdef")
 
-		if !assert.Equal(t, plaintext, decrypted, "Decrypted correct plaintext") {
-			return
-		}
+	hdrs := jwe.NewHeaders()
+	if !assert.NoError(t, hdrs.Set(jwe.TypeKey, "JWT"), `hdrs.Set should succeed`) {
+		return
+	}
+	if !assert.NoError(t, hdrs.Set(jwe.ContentTypeKey, "JWT"), `hdrs.Set should succeed`) {
+		return
+	}
+	// computed by jwe.Encrypt, and should be overwritten
+	if !assert.NoError(t, hdrs.Set(jwe.ContentEncryptionKey, jwa.A256GCM), `hdrs.Set should succeed`) {
+		return
 	}
-}
 
-// https://tools.ietf.org/html/rfc7516#appendix-A.3. Note that cek is dynamically
-// generated, so the encrypted values will NOT match that of the RFC.
-func TestEncode_A128KW_A128CBC_HS256(t *testing.T) {
-	var plaintext = []byte{
-		76, 105, 118, 101, 32, 108, 111, 110, 103, 32, 97, 110, 100, 32,
-		112, 114, 111, 115, 112, 101, 114, 46,
+	encrypted, err := jwe.Encrypt(plaintext, jwa.A128KW, sharedkey, jwa.A128GCM, jwa.NoCompress, jwe.WithProtectedHeaders(hdrs))
+	if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+		return
 	}
-	var sharedkey = []byte{
-		25, 172, 32, 130, 225, 114, 26, 181, 138, 106, 254, 192, 95, 133, 74, 82,
+
+	msg, err := jwe.Parse(encrypted)
+	if !assert.NoError(t, err, `jwe.Parse should succeed`) {
+		return
+	}
+	h := msg.ProtectedHeaders()
+	if !assert.Equal(t, "JWT", h.Type(), `"typ" should match`) {
+		return
+	}
+	if !assert.Equal(t, "JWT", h.ContentType(), `"cty" should match`) {
+		return
+	}
+	if !assert.Equal(t, jwa.A128GCM, h.ContentEncryption(), `"enc" should match`) {
+		return
+	}
+	if !assert.Equal(t, jwa.A128KW, h.Algorithm(), `"alg" should match`) {
+		return
 	}
 
-	max := 100
-	if testing.Short() {
-		max = 1
+	decrypted, err := jwe.Decrypt(encrypted, jwa.A128KW, sharedkey)
+	if !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
+		return
+	}
+	if !assert.Equal(t, plaintext, decrypted, `decrypted payload should match`) {
+		return
 	}
 
-	for i := 0; i < max; i++ {
-		encrypted, err := jwe.Encrypt(plaintext, jwa.A128KW, sharedkey, jwa.A128CBC_HS256, jwa.NoCompress)
-		if !assert.NoError(t, err, "Encrypt is successful") {
-			return
-		}
+	// the headers passed by the caller are not modified
+	if !assert.Equal(t, jwa.A256GCM, hdrs.ContentEncryption(), `"enc" in the original headers should not change`) {
+		return
+	}
+}
 
-		decrypted, err := jwe.Decrypt(encrypted, jwa.A128KW, sharedkey)
-		if !assert.NoError(t, err, "Decrypt successful") {
-			return
-		}
+func TestDecrypt_WithMessage(t *testing.T) {
+	plaintext := []byte("Lorem ipsum")
+	sharedkey := []byte("0123456789abcdef")
 
-		if !assert.Equal(t, plaintext, decrypted, "Decrypted correct plaintext") {
-			return
-		}
+	hdrs := jwe.NewHeaders()
+	if !assert.NoError(t, hdrs.Set(jwe.ContentTypeKey, "jwt"), `hdrs.Set should succeed`) {
+		return
+	}
+
+	nested, err := jwe.Encrypt(plaintext, jwa.A128KW, sharedkey, jwa.A128GCM, jwa.NoCompress, jwe.WithProtectedHeaders(hdrs))
+	if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+		return
+	}
+	plain, err := jwe.Encrypt(plaintext, jwa.A128KW, sharedkey, jwa.A128GCM, jwa.NoCompress)
+	if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+		return
+	}
+
+	var msg jwe.Message
+	decrypted, err := jwe.Decrypt(nested, jwa.A128KW, sharedkey, jwe.WithMessage(&msg))
+	if !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
+		return
+	}
+	if !assert.Equal(t, plaintext, decrypted, `decrypted payload should match`) {
+		return
+	}
+	if !assert.Equal(t, "jwt", msg.ProtectedHeaders().ContentType(), `"cty" should be available`) {
+		return
+	}
+	if !assert.True(t, msg.IsNestedJWT(), `message should contain a nested JWT`) {
+		return
+	}
+
+	if _, err := jwe.Decrypt(plain, jwa.A128KW, sharedkey, jwe.WithMessage(&msg)); !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
+		return
+	}
+	if !assert.False(t, msg.IsNestedJWT(), `message should not contain a nested JWT`) {
+		return
 	}
 }
 
-func TestEncode_ECDH(t *testing.T) {
+func TestEncode_X25519(t *testing.T) {
 	plaintext := []byte("Lorem ipsum")
-	privkey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-	if !assert.NoError(t, err, "ecdsa key generated") {
+	pubkey, privkey, err := x25519.GenerateKey(rand.Reader)
+	if !assert.NoError(t, err, "x25519 key generated") {
 		return
 	}
 
@@ -379,34 +1747,33 @@ func TestEncode_ECDH(t *testing.T) {
 	for _, alg := range algorithms {
 		alg := alg
 		t.Run(alg.String(), func(t *testing.T) {
-			encrypted, err := jwe.Encrypt(plaintext, alg, &privkey.PublicKey, jwa.A256GCM, jwa.NoCompress)
+			encrypted, err := jwe.Encrypt(plaintext, alg, pubkey, jwa.A256GCM, jwa.NoCompress)
 			if !assert.NoError(t, err, "Encrypt succeeds") {
 				return
 			}
 
-			t.Logf("encrypted = %s", encrypted)
-
 			msg, err := jwe.Parse(encrypted)
 			if !assert.NoError(t, err, `jwe.Parse should succeed`) {
 				return
 			}
 
-			t.Logf("%#v", msg)
-
-			{
-				buf, _ := json.MarshalIndent(msg, "", "  ")
-				t.Logf("%s", buf)
+			epk, ok := msg.ProtectedHeaders().EphemeralPublicKey().(jwk.OKPPublicKey)
+			if !assert.True(t, ok, `epk should be jwk.OKPPublicKey`) {
+				return
 			}
-			{
-				buf, _ := json.MarshalIndent(msg.ProtectedHeaders(), "", "  ")
-				t.Logf("%s", buf)
+
+			if !assert.Equal(t, jwa.X25519, epk.Crv(), `epk crv should be X25519`) {
+				return
 			}
 
 			decrypted, err := jwe.Decrypt(encrypted, alg, privkey)
 			if !assert.NoError(t, err, "Decrypt succeeds") {
 				return
 			}
-			t.Logf("%s", decrypted)
+
+			if !assert.Equal(t, plaintext, decrypted, "Decrypted correct plaintext") {
+				return
+			}
 		})
 	}
 }
@@ -417,8 +1784,16 @@ func Test_A256KW_A256CBC_HS512(t *testing.T) {
 	for i := 0; i < keysize; i++ {
 		key[i] = byte(i)
 	}
-	_, err := jwe.Encrypt([]byte(examplePayload), jwa.A256KW, key, jwa.A256CBC_HS512, jwa.NoCompress)
-	if !assert.Error(t, err, "should fail to encrypt payload") {
+	encrypted, err := jwe.Encrypt([]byte(examplePayload), jwa.A256KW, key, jwa.A256CBC_HS512, jwa.NoCompress)
+	if !assert.NoError(t, err, "should encrypt payload") {
+		return
+	}
+
+	decrypted, err := jwe.Decrypt(encrypted, jwa.A256KW, key)
+	if !assert.NoError(t, err, "should decrypt payload") {
+		return
+	}
+	if !assert.Equal(t, []byte(examplePayload), decrypted, "decrypted payload should match") {
 		return
 	}
 }
@@ -490,45 +1865,123 @@ func Test_GHIssue207(t *testing.T) {
 	}
 }
 
+// RFC 7516, Appendix A.3: reproducible when the CEK and the IV are fixed
+func TestEncode_ContentEncryptionKey(t *testing.T) {
+	plaintext := []byte("Live long and prosper.")
+	cek := []byte{4, 211, 31, 197, 84, 157, 252, 254, 11, 100, 157, 250, 63, 170, 106, 206, 107, 124, 212, 45, 111, 107, 9, 219, 200, 177, 0, 240, 143, 156, 44, 207}
+	iv := []byte{3, 22, 60, 12, 43, 67, 104, 105, 108, 108, 105, 99, 111, 116, 104, 101}
+	sharedkey, err := base64.RawURLEncoding.DecodeString("GawgguFyGrWKav7AX4VKUg")
+	if !assert.NoError(t, err, `base64.DecodeString should succeed`) {
+		return
+	}
+	const expected = `eyJhbGciOiJBMTI4S1ciLCJlbmMiOiJBMTI4Q0JDLUhTMjU2In0.6KB707dM9YTIgHtLvtgWQ8mKwboJW3of9locizkDTHzBC2IlrT1oOQ.AxY8DCtDaGlsbGljb3RoZQ.KDlTtXchhZTGufMYmOYGS4HffxPSUrfmqCHXaI9wOGY.U0m_YmjN04DJvceFICbCVQ`
+
+	encrypted, err := jwe.Encrypt(plaintext, jwa.A128KW, sharedkey, jwa.A128CBC_HS256, jwa.NoCompress, jwe.WithContentEncryptionKey(cek), jwe.WithRandomSource(bytes.NewReader(iv)))
+	if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+		return
+	}
+	if !assert.Equal(t, expected, string(encrypted), `encrypted message should match RFC 7516 A.3`) {
+		return
+	}
+
+	t.Run("Invalid key size", func(t *testing.T) {
+		_, err := jwe.Encrypt(plaintext, jwa.A128KW, sharedkey, jwa.A256GCM, jwa.NoCompress, jwe.WithContentEncryptionKey(cek[:16]))
+		if !assert.Error(t, err, `jwe.Encrypt should fail`) {
+			return
+		}
+	})
+	t.Run("Direct encryption", func(t *testing.T) {
+		_, err := jwe.Encrypt(plaintext, jwa.DIRECT, cek, jwa.A128CBC_HS256, jwa.NoCompress, jwe.WithContentEncryptionKey(cek))
+		if !assert.Error(t, err, `jwe.Encrypt should fail`) {
+			return
+		}
+	})
+}
+
 // tests direct key encryption by encrypting-decrypting a plaintext
 func TestEncode_Direct(t *testing.T) {
-	// TODO: uncomment once jwe.Encrypt() supports jwa.DIRECT
-	/*	var testcases = []struct {
-			Algorithm jwa.ContentEncryptionAlgorithm
-			KeySize   int // in bytes
-		}{
-			{jwa.A128CBC_HS256, 16},
-			{jwa.A128GCM, 16},
-			{jwa.A192CBC_HS384, 24},
-			{jwa.A192GCM, 24},
-			{jwa.A256CBC_HS512, 32},
-			{jwa.A256GCM, 32},
-		}
-		plaintext := []byte("Lorem ipsum")
+	var testcases = []struct {
+		Algorithm jwa.ContentEncryptionAlgorithm
+		KeySize   int // in bytes
+	}{
+		{jwa.A128CBC_HS256, 32},
+		{jwa.A128GCM, 16},
+		{jwa.A192CBC_HS384, 48},
+		{jwa.A192GCM, 24},
+		{jwa.A256CBC_HS512, 64},
+		{jwa.A256GCM, 32},
+	}
+	plaintext := []byte("Lorem ipsum")
 
-		for _, tc := range testcases {
-			tc := tc
-			t.Run(tc.Algorithm.String(), func(t *testing.T) {
-				key := make([]byte, tc.KeySize)
-				_, err := rand.Read(key)
-				if !assert.NoError(t, err, "Key generation succeeds") {
-					return
-				}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Algorithm.String(), func(t *testing.T) {
+			key := make([]byte, tc.KeySize)
+			_, err := rand.Read(key)
+			if !assert.NoError(t, err, "Key generation succeeds") {
+				return
+			}
 
-				encrypted, err := jwe.Encrypt(plaintext, jwa.DIRECT, key, tc.Algorithm, jwa.NoCompress)
-				if !assert.NoError(t, err, "Encrypt succeeds") {
-					return
-				}
+			encrypted, err := jwe.Encrypt(plaintext, jwa.DIRECT, key, tc.Algorithm, jwa.NoCompress)
+			if !assert.NoError(t, err, "Encrypt succeeds") {
+				return
+			}
 
-				decrypted, err := jwe.Decrypt(encrypted, jwa.DIRECT, key)
-				if !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
-					return
-				}
+			msg, err := jwe.Parse(encrypted)
+			if !assert.NoError(t, err, `jwe.Parse should succeed`) {
+				return
+			}
+			if !assert.Len(t, msg.Recipients(), 1, `there should be exactly one recipient`) {
+				return
+			}
+			if !assert.Empty(t, msg.Recipients()[0].EncryptedKey().Bytes(), `encrypted key should be empty`) {
+				return
+			}
 
-				assert.Equal(t, plaintext, decrypted, `jwe.Decrypt should match input plaintext`)
-			})
+			decrypted, err := jwe.Decrypt(encrypted, jwa.DIRECT, key)
+			if !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
+				return
+			}
+
+			assert.Equal(t, plaintext, decrypted, `jwe.Decrypt should match input plaintext`)
+		})
+	}
+	t.Run("Invalid key size", func(t *testing.T) {
+		_, err := jwe.Encrypt(plaintext, jwa.DIRECT, make([]byte, 16), jwa.A256GCM, jwa.NoCompress)
+		if !assert.Error(t, err, `jwe.Encrypt should fail`) {
+			return
+		}
+	})
+	t.Run("Multiple recipients", func(t *testing.T) {
+		_, err := jwe.Encrypt(plaintext, jwa.DIRECT, make([]byte, 16), jwa.A128GCM, jwa.NoCompress, jwe.WithRecipient(jwa.A128KW, make([]byte, 16)))
+		if !assert.Error(t, err, `jwe.Encrypt should fail`) {
+			return
+		}
+	})
+	t.Run("Decrypt errors", func(t *testing.T) {
+		key := make([]byte, 16)
+		encrypted, err := jwe.Encrypt(plaintext, jwa.DIRECT, key, jwa.A128GCM, jwa.NoCompress)
+		if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+			return
+		}
+
+		if _, err := jwe.Decrypt(encrypted, jwa.DIRECT, "not a []byte"); !assert.Error(t, err, `jwe.Decrypt should fail with an invalid key type`) {
+			return
+		}
+
+		parts := strings.Split(string(encrypted), ".")
+		if !assert.Len(t, parts, 5, `message should be in compact serialization format`) {
+			return
+		}
+		parts[1] = base64.RawURLEncoding.EncodeToString([]byte("not empty"))
+		_, err = jwe.Decrypt([]byte(strings.Join(parts, ".")), jwa.DIRECT, key)
+		if !assert.Error(t, err, `jwe.Decrypt should fail with a non-empty encrypted key`) {
+			return
+		}
+		if !assert.Contains(t, err.Error(), `encrypted key must be empty`, `error should mention the encrypted key`) {
+			return
 		}
-	*/
+	})
 }
 
 // Decrypts messages generated by `jose` tool. It helps check compatibility with other jwx implementations.
@@ -698,3 +2151,123 @@ func TestGoJoseCompatibility(t *testing.T) {
 }
 
 */
+
+// kmsKey mimics a key stored in a KMS: the private key never leaves it,
+// and only the results of the operations are returned
+type kmsKey struct {
+	alg       jwa.KeyEncryptionAlgorithm
+	key       *rsa.PrivateKey
+	encrypted int
+	decrypted int
+}
+
+func (k *kmsKey) Algorithm() jwa.KeyEncryptionAlgorithm {
+	return k.alg
+}
+
+func (k *kmsKey) KeyID() string {
+	return "kms-key"
+}
+
+func (k *kmsKey) Encrypt(cek []byte) ([]byte, error) {
+	k.encrypted++
+	return rsa.EncryptOAEP(sha1.New(), rand.Reader, &k.key.PublicKey, cek, []byte{})
+}
+
+func (k *kmsKey) Decrypt(enckey []byte) ([]byte, error) {
+	k.decrypted++
+	return rsa.DecryptOAEP(sha1.New(), rand.Reader, k.key, enckey, []byte{})
+}
+
+func TestEncode_ExternalKey(t *testing.T) {
+	kms := &kmsKey{alg: jwa.RSA_OAEP, key: &rsaPrivKey}
+
+	t.Run("Roundtrip", func(t *testing.T) {
+		encrypted, err := jwe.Encrypt([]byte(examplePayload), jwa.RSA_OAEP, kms, jwa.A256GCM, jwa.NoCompress)
+		if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+			return
+		}
+		if !assert.Equal(t, 1, kms.encrypted, `KeyEncrypter should be used`) {
+			return
+		}
+
+		msg, err := jwe.Parse(encrypted)
+		if !assert.NoError(t, err, `jwe.Parse should succeed`) {
+			return
+		}
+		if !assert.Equal(t, "kms-key", msg.ProtectedHeaders().KeyID(), `"kid" should be set`) {
+			return
+		}
+
+		decrypted, err := jwe.Decrypt(encrypted, jwa.RSA_OAEP, kms)
+		if !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
+			return
+		}
+		if !assert.Equal(t, 1, kms.decrypted, `KeyDecrypter should be used`) {
+			return
+		}
+		if !assert.Equal(t, examplePayload, string(decrypted), `payload should match`) {
+			return
+		}
+
+		// messages encrypted by the external key can be decrypted by
+		// the private key, and vice versa
+		decrypted, err = jwe.Decrypt(encrypted, jwa.RSA_OAEP, &rsaPrivKey)
+		if !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
+			return
+		}
+		if !assert.Equal(t, examplePayload, string(decrypted), `payload should match`) {
+			return
+		}
+
+		encrypted, err = jwe.Encrypt([]byte(examplePayload), jwa.RSA_OAEP, &rsaPrivKey.PublicKey, jwa.A128CBC_HS256, jwa.NoCompress)
+		if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+			return
+		}
+		decrypted, err = jwe.Decrypt(encrypted, jwa.RSA_OAEP, kms)
+		if !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
+			return
+		}
+		if !assert.Equal(t, examplePayload, string(decrypted), `payload should match`) {
+			return
+		}
+	})
+	t.Run("Algorithm mismatch", func(t *testing.T) {
+		_, err := jwe.Encrypt([]byte(examplePayload), jwa.RSA_OAEP_256, kms, jwa.A256GCM, jwa.NoCompress)
+		if !assert.Error(t, err, `jwe.Encrypt should fail`) {
+			return
+		}
+
+		encrypted, err := jwe.Encrypt([]byte(examplePayload), jwa.RSA_OAEP_256, &rsaPrivKey.PublicKey, jwa.A256GCM, jwa.NoCompress)
+		if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+			return
+		}
+		_, err = jwe.Decrypt(encrypted, jwa.RSA_OAEP_256, kms)
+		if !assert.Error(t, err, `jwe.Decrypt should fail`) {
+			return
+		}
+	})
+	t.Run("Key wrap", func(t *testing.T) {
+		// The external key encrypter receives the content encryption
+		// key as is, regardless of its length
+		kw := &kmsKey{alg: jwa.A256KW, key: &rsaPrivKey}
+		encrypted, err := jwe.Encrypt([]byte(examplePayload), jwa.A256KW, kw, jwa.A256CBC_HS512, jwa.NoCompress)
+		if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+			return
+		}
+		decrypted, err := jwe.Decrypt(encrypted, jwa.A256KW, kw)
+		if !assert.NoError(t, err, `jwe.Decrypt should succeed`) {
+			return
+		}
+		if !assert.Equal(t, examplePayload, string(decrypted), `payload should match`) {
+			return
+		}
+	})
+	t.Run("Unsupported algorithm", func(t *testing.T) {
+		pbes2 := &kmsKey{alg: jwa.PBES2_HS256_A128KW, key: &rsaPrivKey}
+		_, err := jwe.Encrypt([]byte(examplePayload), jwa.PBES2_HS256_A128KW, pbes2, jwa.A256GCM, jwa.NoCompress)
+		if !assert.Error(t, err, `jwe.Encrypt should fail`) {
+			return
+		}
+	})
+}
@@ -5,11 +5,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"strings"
 
 	"github.com/lestrrat-go/jwx/buffer"
 	"github.com/lestrrat-go/jwx/internal/base64"
 	"github.com/lestrrat-go/jwx/jwa"
 	"github.com/lestrrat-go/jwx/jwe/internal/cipher"
+	"github.com/lestrrat-go/jwx/jwk"
 	"github.com/lestrrat-go/pdebug"
 	"github.com/pkg/errors"
 )
@@ -39,7 +42,7 @@ func (r *stdRecipient) EncryptedKey() buffer.Buffer {
 }
 
 type recipientMarshalProxy struct {
-	Headers      Headers       `json:"header"`
+	Headers      Headers       `json:"header,omitempty"`
 	EncryptedKey buffer.Buffer `json:"encrypted_key"`
 }
 
@@ -57,7 +60,11 @@ func (r *stdRecipient) UnmarshalJSON(buf []byte) error {
 
 func (r *stdRecipient) MarshalJSON() ([]byte, error) {
 	var proxy recipientMarshalProxy
-	proxy.Headers = r.headers
+	// "header" is omitted when all header parameters are in the
+	// protected or the shared unprotected header
+	if r.headers != nil && !r.headers.(isZeroer).isZero() {
+		proxy.Headers = r.headers
+	}
 	proxy.EncryptedKey = r.encryptedKey
 
 	return json.Marshal(proxy)
@@ -87,11 +94,73 @@ func mergeHeaders(ctx context.Context, h1, h2 Headers) (Headers, error) {
 	return h3, nil
 }
 
+// recipientHeaders computes the JOSE header of a recipient, which is the
+// union of the protected header, the shared unprotected header and the
+// per-recipient unprotected header. As described in
+// https://tools.ietf.org/html/rfc7516#section-7.2.1, these must be
+// disjoint: a header parameter that appears in more than one of them
+// is only accepted if the values are identical
+func recipientHeaders(ctx context.Context, protected, unprotected, recipient Headers) (Headers, error) {
+	h := NewHeaders()
+	for i, src := range []Headers{protected, unprotected, recipient} {
+		if src == nil {
+			continue
+		}
+		if i > 0 {
+			if err := checkUnprotectedHeaders(src); err != nil {
+				return nil, err
+			}
+		}
+		for iter := src.Iterate(ctx); iter.Next(ctx); {
+			pair := iter.Pair()
+			key := pair.Key.(string)
+			if existing, ok := h.Get(key); ok {
+				same, err := sameHeaderValue(existing, pair.Value)
+				if err != nil {
+					return nil, errors.Wrapf(err, `failed to compare values of header %q`, key)
+				}
+				if !same {
+					return nil, errors.Errorf(`header %q has conflicting values`, key)
+				}
+				continue
+			}
+			if err := h.Set(key, pair.Value); err != nil {
+				return nil, errors.Wrapf(err, `failed to set header %q`, key)
+			}
+		}
+	}
+	return h, nil
+}
+
+// checkUnprotectedHeaders checks that the given headers, which are not
+// integrity protected, do not contain parameters that must be in the
+// protected header
+func checkUnprotectedHeaders(h Headers) error {
+	if _, ok := h.Get(CompressionKey); ok {
+		return ErrUnprotectedCompression
+	}
+	return nil
+}
+
+func sameHeaderValue(a, b interface{}) (bool, error) {
+	abuf, err := json.Marshal(a)
+	if err != nil {
+		return false, err
+	}
+	bbuf, err := json.Marshal(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(abuf, bbuf), nil
+}
+
 // NewMessage creates a new message
 func NewMessage() *Message {
 	return &Message{}
 }
 
+// AuthenticatedData returns the decoded value of the "aad" member
+// of the message, if any
 func (m *Message) AuthenticatedData() []byte {
 	if m.authenticatedData == nil {
 		return nil
@@ -99,6 +168,7 @@ func (m *Message) AuthenticatedData() []byte {
 	return m.authenticatedData.Bytes()
 }
 
+// CipherText returns the decoded ciphertext of the message
 func (m *Message) CipherText() []byte {
 	if m.cipherText == nil {
 		return nil
@@ -106,6 +176,8 @@ func (m *Message) CipherText() []byte {
 	return m.cipherText.Bytes()
 }
 
+// InitializationVector returns the decoded initialization vector
+// of the message
 func (m *Message) InitializationVector() []byte {
 	if m.initializationVector == nil {
 		return nil
@@ -113,6 +185,7 @@ func (m *Message) InitializationVector() []byte {
 	return m.initializationVector.Bytes()
 }
 
+// Tag returns the decoded authentication tag of the message
 func (m *Message) Tag() []byte {
 	if m.tag == nil {
 		return nil
@@ -120,14 +193,33 @@ func (m *Message) Tag() []byte {
 	return m.tag.Bytes()
 }
 
+// ProtectedHeaders returns the protected headers of the message. These
+// headers may be inspected before decrypting the message, for example
+// to look up the key to use by its "kid"
 func (m *Message) ProtectedHeaders() Headers {
 	return m.protectedHeaders
 }
 
+// IsNestedJWT returns true if the "cty" protected header of the
+// message is `JWT` (compared case-insensitively), meaning that the
+// payload is itself a JWT, either signed or encrypted, as described
+// in RFC 7519 Section 5.2
+func (m *Message) IsNestedJWT() bool {
+	if m.protectedHeaders == nil {
+		return false
+	}
+	return strings.EqualFold(m.protectedHeaders.ContentType(), `JWT`)
+}
+
+// Recipients returns the recipients of the message. The headers of
+// each recipient contain the per-recipient parameters, such as the
+// "alg" and "kid" used to encrypt the content encryption key
 func (m *Message) Recipients() []Recipient {
 	return m.recipients
 }
 
+// UnprotectedHeaders returns the shared unprotected headers of
+// the message. Messages in compact serialization format have none
 func (m *Message) UnprotectedHeaders() Headers {
 	return m.unprotectedHeaders
 }
@@ -265,21 +357,34 @@ func (m *Message) MarshalJSON() ([]byte, error) {
 	}
 
 	if recipients := m.Recipients(); len(recipients) > 0 {
-		if wrote {
-			fmt.Fprintf(&buf, `,`)
-		}
 		if len(recipients) == 1 { // Use flattened format
-			fmt.Fprintf(&buf, `%#v:`, HeadersKey)
-			if err := enc.Encode(recipients[0].Headers()); err != nil {
-				return nil, errors.Wrapf(err, `failed to encode %s field`, HeadersKey)
+			// Both "header" and "encrypted_key" are optional, e.g. when
+			// all header parameters are in the protected header
+			if h := recipients[0].Headers(); h != nil && !h.(isZeroer).isZero() {
+				if wrote {
+					fmt.Fprintf(&buf, `,`)
+				}
+				wrote = true
+				fmt.Fprintf(&buf, `%#v:`, HeadersKey)
+				if err := enc.Encode(h); err != nil {
+					return nil, errors.Wrapf(err, `failed to encode %s field`, HeadersKey)
+				}
 			}
 			if ek := recipients[0].EncryptedKey(); ek.Len() > 0 {
-				fmt.Fprintf(&buf, `,%#v:`, EncryptedKeyKey)
+				if wrote {
+					fmt.Fprintf(&buf, `,`)
+				}
+				wrote = true
+				fmt.Fprintf(&buf, `%#v:`, EncryptedKeyKey)
 				if err := enc.Encode(ek); err != nil {
 					return nil, errors.Wrapf(err, `failed to encode %s field`, EncryptedKeyKey)
 				}
 			}
 		} else {
+			if wrote {
+				fmt.Fprintf(&buf, `,`)
+			}
+			wrote = true
 			fmt.Fprintf(&buf, `%#v:`, RecipientsKey)
 			if err := enc.Encode(recipients); err != nil {
 				return nil, errors.Wrapf(err, `failed to encode %s field`, RecipientsKey)
@@ -304,7 +409,7 @@ func (m *Message) MarshalJSON() ([]byte, error) {
 		}
 
 		if len(unprotected) > 2 {
-			fmt.Fprintf(&buf, `,%#v:%#v`, UnprotectedHeadersKey, string(unprotected))
+			fmt.Fprintf(&buf, `,%#v:%s`, UnprotectedHeadersKey, unprotected)
 		}
 	}
 	fmt.Fprintf(&buf, `}`)
@@ -330,13 +435,20 @@ func (m *Message) UnmarshalJSON(buf []byte) error {
 		return errors.Wrap(err, `failed to decode protected headers`)
 	}
 
-	// if this were a flattened message, we would see a "header" and "ciphertext"
-	// field. TODO: do both of these conditions need to meet, or just one?
-	if proxy.Headers != nil || len(proxy.EncryptedKey) > 0 {
+	// If this is a flattened message, there is no "recipients" field,
+	// and the "header" and "encrypted_key" fields of the single recipient
+	// are stored in the message itself. Both are optional, e.g. when
+	// all header parameters are in the protected header and "dir" is used
+	if len(proxy.Recipients) == 0 {
 		recipient := NewRecipient()
 		hdrs := NewHeaders()
-		if err := json.Unmarshal(proxy.Headers, hdrs); err != nil {
-			return errors.Wrap(err, `failed to decode headers field`)
+		if proxy.Headers != nil {
+			if err := json.Unmarshal(proxy.Headers, hdrs); err != nil {
+				return errors.Wrap(err, `failed to decode headers field`)
+			}
+		}
+		if err := checkUnprotectedHeaders(hdrs); err != nil {
+			return errors.Wrap(err, `invalid headers field`)
 		}
 
 		if err := recipient.SetHeaders(hdrs); err != nil {
@@ -354,6 +466,9 @@ func (m *Message) UnmarshalJSON(buf []byte) error {
 			if err := json.Unmarshal(recipientbuf, recipient); err != nil {
 				return errors.Wrapf(err, `failed to decode recipient at index %d`, i)
 			}
+			if err := checkUnprotectedHeaders(recipient.Headers()); err != nil {
+				return errors.Wrapf(err, `invalid headers for recipient at index %d`, i)
+			}
 
 			m.recipients = append(m.recipients, recipient)
 		}
@@ -364,6 +479,9 @@ func (m *Message) UnmarshalJSON(buf []byte) error {
 	m.initializationVector = proxy.InitializationVector
 	m.protectedHeaders = h
 	m.tag = proxy.Tag
+	if err := checkUnprotectedHeaders(proxy.UnprotectedHeaders); err != nil {
+		return errors.Wrap(err, `invalid unprotected headers`)
+	}
 	if !proxy.UnprotectedHeaders.(isZeroer).isZero() {
 		m.unprotectedHeaders = proxy.UnprotectedHeaders
 	}
@@ -371,25 +489,44 @@ func (m *Message) UnmarshalJSON(buf []byte) error {
 	return nil
 }
 
-// Decrypt decrypts the message using the specified algorithm and key
-func (m *Message) Decrypt(alg jwa.KeyEncryptionAlgorithm, key interface{}) ([]byte, error) {
+// Decrypt decrypts the message using the specified algorithm and key.
+// Only the recipients whose "alg" header matches the algorithm are
+// considered. When the `jwe.WithKeySet` option is given, `alg` may be
+// empty, in which case every recipient is considered, and the keys in
+// the set are looked up using the "alg" and "kid" headers of each
+// recipient. `jwe.Decrypt` is equivalent to calling `jwe.Parse`
+// followed by this method, so use this method when the headers of the
+// message must be inspected before deciding how to decrypt it
+func (m *Message) Decrypt(alg jwa.KeyEncryptionAlgorithm, key interface{}, options ...Option) ([]byte, error) {
 	var err error
 
-	if pdebug.Enabled {
-		g := pdebug.Marker("message.Decrypt (alg = %s)", alg)
-		defer g.End()
+	var maxDecompressedSize int64 = defaultMaxDecompressedSize
+	var maxPBES2Count = defaultMaxPBES2Count
+	var keyset *jwk.Set
+	var requireKeyID bool
+	var rand io.Reader
+	for _, option := range options {
+		switch option.Name() {
+		case optkeyMaxDecompressedSize:
+			maxDecompressedSize = option.Value().(int64)
+		case optkeyKeySet:
+			keyset = option.Value().(*jwk.Set)
+		case optkeyRequireKeyID:
+			requireKeyID = option.Value().(bool)
+		case optkeyMaxPBES2Count:
+			maxPBES2Count = option.Value().(int)
+		case optkeyRandomSource:
+			rand = option.Value().(io.Reader)
+		}
 	}
 
-	h, err := mergeHeaders(context.TODO(), nil, m.protectedHeaders)
-	if err != nil {
-		return nil, errors.Wrap(err, `failed to copy protected headers`)
+	if requireKeyID && (m.protectedHeaders == nil || m.protectedHeaders.KeyID() == "") {
+		return nil, errors.New(`required "kid" header is missing from the protected header`)
 	}
-	h, err = mergeHeaders(context.TODO(), h, m.unprotectedHeaders)
-	if err != nil {
-		if pdebug.Enabled {
-			pdebug.Printf("failed to merge unprotected header")
-		}
-		return nil, errors.Wrap(err, "failed to merge headers for message decryption")
+
+	if pdebug.Enabled {
+		g := pdebug.Marker("message.Decrypt (alg = %s)", alg)
+		defer g.End()
 	}
 
 	enc := m.protectedHeaders.ContentEncryption()
@@ -410,104 +547,77 @@ func (m *Message) Decrypt(alg jwa.KeyEncryptionAlgorithm, key interface{}) ([]by
 		computedAad = append(append(computedAad, '.'), aad...)
 	}
 
-	ciphertext := m.cipherText.Bytes()
-	iv := m.initializationVector.Bytes()
-	tag := m.tag.Bytes()
+	// The fields may be missing if the message was parsed from JSON,
+	// so use the accessors that handle unset fields
+	ciphertext := m.CipherText()
+	iv := m.InitializationVector()
+	tag := m.Tag()
 
 	cipher, err := buildContentCipher(enc)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unsupported content cipher algorithm '%s'", enc)
 	}
-	keysize := cipher.KeySize()
 
 	var plaintext []byte
 	var lastError error
+	var matched bool
 	for _, recipient := range m.recipients {
 		// strategy: try each recipient. If we fail in one of the steps,
 		// keep looping because there might be another key with the same algo
 
-		if pdebug.Enabled {
-			pdebug.Printf("Attempting to check if we can decode for recipient (alg = %s)", recipient.Headers().Algorithm())
-		}
-
-		if recipient.Headers().Algorithm() != alg {
-			// algorithms don't match
-			continue
-		}
-
-		h2, err := mergeHeaders(context.TODO(), nil, h)
+		// The "alg" and "kid" headers may be in the protected header,
+		// the shared unprotected header, or the per-recipient header
+		h2, err := recipientHeaders(context.TODO(), m.protectedHeaders, m.unprotectedHeaders, recipient.Headers())
 		if err != nil {
-			lastError = errors.Wrap(err, `failed to copy headers (1)`)
+			lastError = errors.Wrap(err, `failed to compute recipient headers`)
 			if pdebug.Enabled {
 				pdebug.Printf(`%s`, lastError)
 			}
 			continue
 		}
 
-		h2, err = mergeHeaders(context.TODO(), h2, recipient.Headers())
-		if err != nil {
-			lastError = errors.Wrap(err, `failed to copy headers (2)`)
-			if pdebug.Enabled {
-				pdebug.Printf(`%s`, lastError)
-			}
-			continue
+		if pdebug.Enabled {
+			pdebug.Printf("Attempting to check if we can decode for recipient (alg = %s)", h2.Algorithm())
 		}
 
-		var cek []byte
-		if h2.Algorithm() == jwa.DIRECT {
-			var ok bool
-			cek, ok = key.([]byte)
-			if !ok {
-				return nil, errors.Errorf("[]byte is required as the key to build %s key decrypter", alg)
-			}
-		} else {
-			k, err := buildKeyDecrypter(h2.Algorithm(), h2, key, keysize)
-			if err != nil {
-				lastError = errors.Wrap(err, `failed to build key decrypter`)
-				if pdebug.Enabled {
-					pdebug.Printf(`%s`, lastError)
-				}
-				continue
-			}
-
-			cek, err = k.Decrypt(recipient.EncryptedKey().Bytes())
-			if err != nil {
-				lastError = errors.Wrap(err, `failed to decrypt key`)
-				if pdebug.Enabled {
-					pdebug.Printf(`%s`, lastError)
-				}
-				return nil, lastError
-				//			continue
-			}
+		if (keyset == nil || alg != "") && h2.Algorithm() != alg {
+			// algorithms don't match
+			continue
 		}
 
-		plaintext, err = cipher.Decrypt(cek, iv, ciphertext, tag, computedAad)
-		if err != nil {
-			lastError = errors.Wrap(err, `failed to decrypt payload`)
-			if pdebug.Enabled {
-				pdebug.Printf(`%s`, lastError)
+		keys := []interface{}{key}
+		if keyset != nil {
+			keys = lookupDecryptionKeys(keyset, h2)
+			if len(keys) > 0 {
+				matched = true
 			}
-			continue
 		}
 
-		if h2.Compression() == jwa.Deflate {
-			buf, err := uncompress(plaintext)
+		for _, rawkey := range keys {
+			decrypted, err := decryptRecipient(recipient, h2, rawkey, cipher, iv, ciphertext, tag, computedAad, maxDecompressedSize, maxPBES2Count, rand)
 			if err != nil {
-				lastError = errors.Wrap(err, `failed to uncompress payload`)
+				lastError = err
 				if pdebug.Enabled {
 					pdebug.Printf(`%s`, lastError)
 				}
+				// the key may be intended for another recipient
 				continue
 			}
-			plaintext = buf
+			plaintext = decrypted
+			break
 		}
 
-		break
+		if plaintext != nil {
+			break
+		}
 	}
 
 	if plaintext == nil {
+		if keyset != nil && !matched {
+			return nil, errors.New(`no key in the key set matches the "alg" and "kid" headers of any recipient`)
+		}
 		if lastError != nil {
-			return nil, errors.Errorf(`failed to find matching recipient to decrypt key (last error = %s)`, lastError)
+			return nil, errors.Wrap(lastError, `failed to find matching recipient to decrypt key`)
 		}
 		return nil, errors.New("failed to find matching recipient to decrypt key")
 	}
@@ -515,10 +625,99 @@ func (m *Message) Decrypt(alg jwa.KeyEncryptionAlgorithm, key interface{}) ([]by
 	return plaintext, nil
 }
 
+// decryptRecipient decrypts the content encryption key of the recipient
+// using the given key, and uses it to decrypt the payload
+func decryptRecipient(recipient Recipient, h Headers, key interface{}, cipher cipher.ContentCipher, iv, ciphertext, tag, aad []byte, maxDecompressedSize int64, maxPBES2Count int, rand io.Reader) ([]byte, error) {
+	k, err := buildKeyDecrypter(h.Algorithm(), h, key, cipher.KeySize(), maxPBES2Count, rand)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to build key decrypter`)
+	}
+
+	cek, err := k.Decrypt(recipient.EncryptedKey().Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to decrypt key`)
+	}
+
+	decrypted, err := cipher.Decrypt(cek, iv, ciphertext, tag, aad)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to decrypt payload`)
+	}
+
+	if h.Compression() == jwa.Deflate {
+		buf, err := uncompress(decrypted, maxDecompressedSize)
+		if err != nil {
+			return nil, errors.Wrap(err, `failed to uncompress payload`)
+		}
+		decrypted = buf
+	}
+	return decrypted, nil
+}
+
+// lookupDecryptionKeys returns the raw keys in the key set that may be
+// used to decrypt the content encryption key of a recipient with the
+// given headers. If the headers specify a "kid", only the keys with the
+// same key ID are considered. Keys that specify an "alg" different from
+// the recipient's, keys intended for signatures, and for ECDH-ES, keys
+// whose curve differs from that of the ephemeral public key are skipped
+func lookupDecryptionKeys(keyset *jwk.Set, h Headers) []interface{} {
+	alg := h.Algorithm()
+	if alg == "" {
+		return nil
+	}
+
+	candidates := keyset.Keys
+	if kid := h.KeyID(); kid != "" {
+		candidates = keyset.LookupKeyID(kid)
+	}
+
+	var keys []interface{}
+	for _, key := range candidates {
+		if key.KeyUsage() == string(jwk.ForSignature) {
+			continue
+		}
+		if v := key.Algorithm(); v != "" && v != alg.String() {
+			continue
+		}
+		switch alg {
+		case jwa.ECDH_ES, jwa.ECDH_ES_A128KW, jwa.ECDH_ES_A192KW, jwa.ECDH_ES_A256KW:
+			if !sameCurve(key, h.EphemeralPublicKey()) {
+				continue
+			}
+		}
+
+		var rawkey interface{}
+		if err := key.Raw(&rawkey); err != nil {
+			continue
+		}
+		keys = append(keys, rawkey)
+	}
+	return keys
+}
+
+type curveKey interface {
+	Crv() jwa.EllipticCurveAlgorithm
+}
+
+// sameCurve returns true if both keys are elliptic curve keys
+// on the same curve
+func sameCurve(key, epk jwk.Key) bool {
+	k1, ok := key.(curveKey)
+	if !ok {
+		return false
+	}
+	k2, ok := epk.(curveKey)
+	if !ok {
+		return false
+	}
+	return k1.Crv() == k2.Crv()
+}
+
 func buildContentCipher(alg jwa.ContentEncryptionAlgorithm) (cipher.ContentCipher, error) {
 	switch alg {
 	case jwa.A128GCM, jwa.A192GCM, jwa.A256GCM, jwa.A128CBC_HS256, jwa.A192CBC_HS384, jwa.A256CBC_HS512:
 		return cipher.NewAES(alg)
+	case jwa.C20P, jwa.XC20P:
+		return cipher.NewChaCha(alg)
 	}
 
 	return nil, errors.Errorf(`invalid content cipher algorith (%s)`, alg)
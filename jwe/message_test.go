@@ -1,9 +1,12 @@
 package jwe_test
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
 	"encoding/json"
 	"testing"
 
+	"github.com/lestrrat-go/jwx/jwa"
 	"github.com/lestrrat-go/jwx/jwe"
 	"github.com/stretchr/testify/assert"
 )
@@ -27,3 +30,61 @@ func TestRecipient(t *testing.T) {
 		}
 	})
 }
+
+func TestMessage_Accessors(t *testing.T) {
+	plaintext := []byte("Lorem ipsum")
+	rsakey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if !assert.NoError(t, err, `rsa.GenerateKey should succeed`) {
+		return
+	}
+	sharedkey := make([]byte, 16)
+	if _, err := rand.Read(sharedkey); !assert.NoError(t, err, `rand.Read should succeed`) {
+		return
+	}
+
+	encrypted, err := jwe.Encrypt(plaintext, jwa.RSA_OAEP, &rsakey.PublicKey, jwa.A128GCM, jwa.NoCompress, jwe.WithRecipient(jwa.A128KW, sharedkey))
+	if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+		return
+	}
+
+	msg, err := jwe.Parse(encrypted)
+	if !assert.NoError(t, err, `jwe.Parse should succeed`) {
+		return
+	}
+
+	if !assert.Equal(t, jwa.A128GCM, msg.ProtectedHeaders().ContentEncryption(), `"enc" should be available before decryption`) {
+		return
+	}
+	if !assert.Len(t, msg.Recipients(), 2, `message should have two recipients`) {
+		return
+	}
+
+	algs := []jwa.KeyEncryptionAlgorithm{jwa.RSA_OAEP, jwa.A128KW}
+	keys := []interface{}{rsakey, sharedkey}
+	for i, recipient := range msg.Recipients() {
+		if !assert.Equal(t, algs[i], recipient.Headers().Algorithm(), `"alg" of recipient #%d should match`, i+1) {
+			return
+		}
+		if !assert.NotEmpty(t, recipient.EncryptedKey().Bytes(), `encrypted key of recipient #%d should be available`, i+1) {
+			return
+		}
+
+		decrypted, err := msg.Decrypt(recipient.Headers().Algorithm(), keys[i])
+		if !assert.NoError(t, err, `msg.Decrypt should succeed for recipient #%d`, i+1) {
+			return
+		}
+		if !assert.Equal(t, plaintext, decrypted, `payload should match`) {
+			return
+		}
+	}
+
+	if !assert.NotEmpty(t, msg.CipherText(), `ciphertext should be available`) {
+		return
+	}
+	if !assert.NotEmpty(t, msg.InitializationVector(), `iv should be available`) {
+		return
+	}
+	if !assert.NotEmpty(t, msg.Tag(), `tag should be available`) {
+		return
+	}
+}
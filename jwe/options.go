@@ -1,9 +1,137 @@
 package jwe
 
-import "github.com/lestrrat-go/jwx/internal/option"
+import (
+	"io"
+
+	"github.com/lestrrat-go/jwx/internal/option"
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
+)
 
 // WithPrettyJSONFormat specifies if the `jwe.JSON` serialization tool
 // should generate pretty-formatted output
 func WithPrettyJSONFormat(b bool) Option {
 	return option.New(optkeyPrettyJSONFormat, b)
 }
+
+// WithPBES2Count specifies the iteration count ("p2c") used to derive
+// the key encryption key when encrypting using one of the PBES2 key
+// encryption algorithms. Values smaller than 1000 are rejected
+func WithPBES2Count(n int) Option {
+	return option.New(optkeyPBES2Count, n)
+}
+
+// WithMaxPBES2Count specifies the maximum iteration count ("p2c") that
+// `jwe.Decrypt` accepts for the PBES2 key encryption algorithms. The
+// count is chosen by whoever created the message, so messages with
+// larger counts are rejected before any key is derived, which prevents
+// them from consuming an arbitrary amount of CPU time. The default is
+// 100000. Specifying a value <= 0 disables the check
+func WithMaxPBES2Count(n int) Option {
+	return option.New(optkeyMaxPBES2Count, n)
+}
+
+// WithCompress specifies the compression algorithm to apply to the
+// plaintext before encryption, and sets the "zip" protected header.
+// If given, it takes precedence over the compression algorithm
+// passed as the parameter to `jwe.Encrypt`
+func WithCompress(alg jwa.CompressionAlgorithm) Option {
+	return option.New(optkeyCompress, alg)
+}
+
+// WithMaxDecompressedSize specifies the maximum size in bytes of
+// the payload after it has been decompressed by `jwe.Decrypt`.
+// Messages whose payload would exceed this size are rejected,
+// which protects against decompression bombs. The default is 10MB.
+// Specifying a value <= 0 disables the check
+func WithMaxDecompressedSize(n int64) Option {
+	return option.New(optkeyMaxDecompressedSize, n)
+}
+
+type recipientSpec struct {
+	alg jwa.KeyEncryptionAlgorithm
+	key interface{}
+}
+
+// WithRecipient specifies an additional recipient for `jwe.Encrypt`,
+// identified by the key encryption algorithm and the key used to
+// encrypt the content encryption key for that recipient. This option
+// may be specified multiple times
+func WithRecipient(alg jwa.KeyEncryptionAlgorithm, key interface{}) Option {
+	return option.New(optkeyRecipient, recipientSpec{alg: alg, key: key})
+}
+
+// WithAAD specifies additional authenticated data for `jwe.Encrypt`.
+// The value is integrity protected, but not encrypted, and is included
+// in the "aad" member of the resulting message. Because the compact
+// serialization format has no place for this value, specifying this
+// option forces the message to be serialized in the JSON serialization
+// format
+func WithAAD(aad []byte) Option {
+	return option.New(optkeyAAD, aad)
+}
+
+// WithRandomSource specifies the source of randomness used by
+// `jwe.Encrypt` to generate the content encryption key, nonces,
+// salts and ephemeral keys. It is also accepted by `jwe.Decrypt`,
+// which uses it to generate the temporary key for ECMR. If not
+// specified, crypto/rand.Reader is used. This is mostly useful for
+// reproducible tests, or to use a hardware random number generator
+func WithRandomSource(r io.Reader) Option {
+	return option.New(optkeyRandomSource, r)
+}
+
+// WithKeySet specifies a JWK key set that `jwe.Decrypt` uses to look
+// up the key to decrypt the content encryption key, instead of the key
+// passed as its argument (which should be nil). For each recipient, the
+// keys whose "kid" matches the "kid" header are tried in order; if the
+// recipient does not specify a "kid", all keys in the set are tried.
+// Keys that specify an "alg" different from the recipient's are skipped,
+// and for ECDH-ES, so are keys on a curve different from that of the
+// "epk" header.
+//
+// The algorithm passed to `jwe.Decrypt` may be left empty, in which
+// case the algorithm declared in the headers of each recipient is used
+func WithKeySet(set *jwk.Set) Option {
+	return option.New(optkeyKeySet, set)
+}
+
+// WithContentEncryptionKey specifies the content encryption key to be
+// used by `jwe.Encrypt`, instead of generating a random one. The length
+// of the key must match the key size required by the content encryption
+// algorithm. This is useful to reproduce test vectors, or when the
+// content encryption key is managed externally. It may not be combined
+// with jwa.DIRECT, where the shared key is the content encryption key.
+//
+// Never use the same content encryption key for more than one message
+func WithContentEncryptionKey(cek []byte) Option {
+	return option.New(optkeyContentEncryptionKey, cek)
+}
+
+// WithMessage specifies a Message that `jwe.Decrypt` fills with the
+// parsed JWE message, so that its headers can be inspected along with
+// the decrypted payload. For example, `(*jwe.Message).IsNestedJWT`
+// tells if the payload is itself a JWT. The message is filled in
+// even if the decryption fails, as long as the input could be parsed
+func WithMessage(m *Message) Option {
+	return option.New(optkeyMessage, m)
+}
+
+// WithProtectedHeaders specifies additional header parameters to be
+// included in the protected header of the message created by
+// `jwe.Encrypt`, such as "typ" and "cty". For example, "cty" should
+// be set to "JWT" when the payload is itself a JWT (nested JWT).
+// The "alg", "enc" and "zip" parameters are always computed by
+// `jwe.Encrypt`, and override the values given here
+func WithProtectedHeaders(h Headers) Option {
+	return option.New(optkeyProtectedHeaders, h)
+}
+
+// WithRequireKeyID specifies that `jwe.Decrypt` must reject messages
+// whose protected header does not contain a "kid" header parameter,
+// before attempting to decrypt them. A "kid" in the unprotected or
+// per-recipient headers does not satisfy this requirement. By default
+// the "kid" header parameter is optional
+func WithRequireKeyID(b bool) Option {
+	return option.New(optkeyRequireKeyID, b)
+}
@@ -0,0 +1,240 @@
+package jwk
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultMinRefreshInterval = 15 * time.Minute
+	defaultMaxRefreshInterval = 24 * time.Hour
+
+	// minRefreshIntervalFloor is the lower bound of the minimum refresh
+	// interval, so that a misconfiguration cannot make AutoRefresh
+	// hammer the server
+	minRefreshIntervalFloor = time.Second
+)
+
+// AutoRefresh is a container for JWKS that are periodically refreshed
+// in the background. The interval between refreshes is determined by
+// the "max-age" directive in the "Cache-Control" header sent by the
+// server, bounded by the values specified with
+// `jwk.WithMinRefreshInterval` and `jwk.WithMaxRefreshInterval`.
+//
+// If a refresh fails, the previously fetched JWKS is kept, so that
+// users can continue to use it during transient outages. The error
+// is reported to the function specified by `jwk.WithRefreshErrorHandler`,
+// and the refresh is retried with an exponential backoff, starting
+// from the minimum refresh interval up to the maximum refresh interval.
+//
+// AutoRefresh is safe for concurrent use
+type AutoRefresh struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	errSink   func(string, error)
+	mu        sync.RWMutex
+	resources map[string]*autoRefreshResource
+}
+
+type autoRefreshResource struct {
+	cache       *Cache
+	minInterval time.Duration
+	maxInterval time.Duration
+	cancel      context.CancelFunc
+}
+
+// NewAutoRefresh creates a new AutoRefresh. The background refreshes
+// stop when ctx is canceled, or when Close is called
+func NewAutoRefresh(ctx context.Context, options ...Option) *AutoRefresh {
+	var errSink func(string, error)
+	for _, option := range options {
+		switch option.Name() {
+		case optkeyRefreshErrorHandler:
+			errSink = option.Value().(func(string, error))
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	return &AutoRefresh{
+		ctx:       ctx,
+		cancel:    cancel,
+		errSink:   errSink,
+		resources: make(map[string]*autoRefreshResource),
+	}
+}
+
+// Configure registers the JWKS located at url, and starts refreshing
+// it in the background. If url has already been configured, its
+// settings are replaced.
+//
+// The `jwk.WithHTTPClient`, `jwk.WithHTTPMaxBodySize`,
+// `jwk.WithMinRefreshInterval` and `jwk.WithMaxRefreshInterval`
+// options may be specified
+func (af *AutoRefresh) Configure(url string, options ...Option) {
+	httpcl := http.DefaultClient
+	var maxBodySize int64 = defaultHTTPMaxBodySize
+	minInterval := defaultMinRefreshInterval
+	maxInterval := defaultMaxRefreshInterval
+	for _, option := range options {
+		switch option.Name() {
+		case optkeyHTTPClient:
+			httpcl = option.Value().(*http.Client)
+		case optkeyHTTPMaxBodySize:
+			maxBodySize = option.Value().(int64)
+		case optkeyMinRefreshInterval:
+			minInterval = option.Value().(time.Duration)
+		case optkeyMaxRefreshInterval:
+			maxInterval = option.Value().(time.Duration)
+		}
+	}
+
+	if minInterval < minRefreshIntervalFloor {
+		minInterval = minRefreshIntervalFloor
+	}
+	if maxInterval < minInterval {
+		maxInterval = minInterval
+	}
+
+	ctx, cancel := context.WithCancel(af.ctx)
+	r := &autoRefreshResource{
+		cache:       NewCache(url, WithHTTPClient(httpcl), WithHTTPMaxBodySize(maxBodySize)),
+		minInterval: minInterval,
+		maxInterval: maxInterval,
+		cancel:      cancel,
+	}
+
+	af.mu.Lock()
+	if old, ok := af.resources[url]; ok {
+		old.cancel()
+	}
+	af.resources[url] = r
+	af.mu.Unlock()
+
+	go af.refreshLoop(ctx, r)
+}
+
+// Fetch returns the JWKS for url that is currently held by AutoRefresh.
+// If the JWKS has not been fetched yet, it is fetched synchronously.
+// url must have been registered using Configure
+func (af *AutoRefresh) Fetch(ctx context.Context, url string) (*Set, error) {
+	af.mu.RLock()
+	r, ok := af.resources[url]
+	af.mu.RUnlock()
+
+	if !ok {
+		return nil, errors.Errorf(`url %s has not been configured`, url)
+	}
+
+	if set := r.cache.cached(); set != nil {
+		return set, nil
+	}
+
+	set, err := r.cache.Refresh(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, `failed to fetch %s`, url)
+	}
+	return set, nil
+}
+
+// Refresh forces a refresh of the JWKS for url, outside of the
+// regular schedule. url must have been registered using Configure
+func (af *AutoRefresh) Refresh(ctx context.Context, url string) (*Set, error) {
+	af.mu.RLock()
+	r, ok := af.resources[url]
+	af.mu.RUnlock()
+
+	if !ok {
+		return nil, errors.Errorf(`url %s has not been configured`, url)
+	}
+
+	set, err := r.cache.Refresh(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, `failed to refresh %s`, url)
+	}
+	return set, nil
+}
+
+// Remove stops refreshing the JWKS for url in the background, and
+// discards the JWKS held by AutoRefresh. url must have been registered
+// using Configure
+func (af *AutoRefresh) Remove(url string) error {
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	r, ok := af.resources[url]
+	if !ok {
+		return errors.Errorf(`url %s has not been configured`, url)
+	}
+	r.cancel()
+	delete(af.resources, url)
+	return nil
+}
+
+// Close stops all background refreshes, and removes all urls
+// registered using Configure
+func (af *AutoRefresh) Close() {
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	af.cancel()
+	for url, r := range af.resources {
+		r.cancel()
+		delete(af.resources, url)
+	}
+}
+
+func (af *AutoRefresh) refreshLoop(ctx context.Context, r *autoRefreshResource) {
+	var failures int
+	for {
+		interval := r.minInterval
+		_, maxAge, ok, err := r.cache.refresh(ctx)
+		if err != nil {
+			// the context being canceled is not an error worth reporting
+			if ctx.Err() != nil {
+				return
+			}
+			if af.errSink != nil {
+				af.errSink(r.cache.URL(), err)
+			}
+			failures++
+			interval = backoffInterval(r.minInterval, r.maxInterval, failures)
+		} else {
+			failures = 0
+			if ok {
+				interval = maxAge
+				if interval < r.minInterval {
+					interval = r.minInterval
+				}
+				if interval > r.maxInterval {
+					interval = r.maxInterval
+				}
+			}
+		}
+
+		t := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// backoffInterval returns the interval to wait before retrying after
+// the given number of consecutive failures. The interval is doubled on
+// each failure, starting from min, and never exceeds max
+func backoffInterval(min, max time.Duration, failures int) time.Duration {
+	interval := min
+	for i := 1; i < failures && interval < max; i++ {
+		interval *= 2
+	}
+	if interval > max {
+		interval = max
+	}
+	return interval
+}
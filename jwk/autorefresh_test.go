@@ -0,0 +1,176 @@
+package jwk_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoRefresh(t *testing.T) {
+	t.Parallel()
+
+	key, err := generateSymmetricKey()
+	if !assert.NoError(t, err, `jwk generation should be successful`) {
+		return
+	}
+
+	buf, err := json.Marshal(key)
+	if !assert.NoError(t, err, `json.Marshal should succeed`) {
+		return
+	}
+
+	var requests int32
+	var failing int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=0")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(buf)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error, 16)
+	af := jwk.NewAutoRefresh(ctx, jwk.WithRefreshErrorHandler(func(_ string, err error) {
+		select {
+		case errCh <- err:
+		default:
+		}
+	}))
+
+	if _, err := af.Fetch(ctx, srv.URL); !assert.Error(t, err, `Fetch should fail for unconfigured urls`) {
+		return
+	}
+
+	// The minimum refresh interval is rounded up to one second, so
+	// that AutoRefresh does not spin
+	af.Configure(srv.URL, jwk.WithHTTPClient(srv.Client()), jwk.WithMinRefreshInterval(0))
+
+	set, err := af.Fetch(ctx, srv.URL)
+	if !assert.NoError(t, err, `Fetch should succeed`) {
+		return
+	}
+	if !assert.Len(t, set.Keys, 1, `set should contain one key`) {
+		return
+	}
+
+	time.Sleep(2500 * time.Millisecond)
+	if !assert.True(t, atomic.LoadInt32(&requests) > 2, `JWKS should be refreshed periodically`) {
+		return
+	}
+	if !assert.True(t, atomic.LoadInt32(&requests) < 10, `JWKS should not be refreshed more often than the minimum interval`) {
+		return
+	}
+
+	atomic.StoreInt32(&failing, 1)
+	select {
+	case err := <-errCh:
+		if !assert.Error(t, err, `error handler should receive an error`) {
+			return
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf(`error handler should have been called`)
+		return
+	}
+
+	// Subsequent retries are done after 1s, 2s, 4s... so at most two
+	// more requests should be made in the next 3.5 seconds
+	failed := atomic.LoadInt32(&requests)
+	time.Sleep(3500 * time.Millisecond)
+	if !assert.True(t, atomic.LoadInt32(&requests)-failed <= 2, `failed refreshes should be retried with an exponential backoff`) {
+		return
+	}
+
+	set, err = af.Fetch(ctx, srv.URL)
+	if !assert.NoError(t, err, `Fetch should succeed while refreshes fail`) {
+		return
+	}
+	if !assert.Len(t, set.Keys, 1, `previously fetched set should be kept`) {
+		return
+	}
+}
+
+func TestAutoRefresh_Remove(t *testing.T) {
+	t.Parallel()
+
+	key, err := generateSymmetricKey()
+	if !assert.NoError(t, err, `jwk generation should be successful`) {
+		return
+	}
+
+	buf, err := json.Marshal(key)
+	if !assert.NoError(t, err, `json.Marshal should succeed`) {
+		return
+	}
+
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Cache-Control", "max-age=0")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(buf)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// checkStopped checks that no more requests are made once the
+	// background refresh has been stopped
+	checkStopped := func(t *testing.T) bool {
+		t.Helper()
+		time.Sleep(100 * time.Millisecond)
+		count := atomic.LoadInt32(&requests)
+		time.Sleep(1500 * time.Millisecond)
+		return assert.Equal(t, count, atomic.LoadInt32(&requests), `JWKS should not be refreshed anymore`)
+	}
+
+	t.Run("Remove", func(t *testing.T) {
+		af := jwk.NewAutoRefresh(ctx)
+		af.Configure(srv.URL, jwk.WithHTTPClient(srv.Client()), jwk.WithMinRefreshInterval(time.Second))
+		if _, err := af.Fetch(ctx, srv.URL); !assert.NoError(t, err, `Fetch should succeed`) {
+			return
+		}
+
+		if !assert.NoError(t, af.Remove(srv.URL), `Remove should succeed`) {
+			return
+		}
+		if !assert.Error(t, af.Remove(srv.URL), `Remove should fail for unconfigured urls`) {
+			return
+		}
+		if _, err := af.Fetch(ctx, srv.URL); !assert.Error(t, err, `Fetch should fail after Remove`) {
+			return
+		}
+		if !checkStopped(t) {
+			return
+		}
+	})
+	t.Run("Close", func(t *testing.T) {
+		af := jwk.NewAutoRefresh(ctx)
+		af.Configure(srv.URL, jwk.WithHTTPClient(srv.Client()), jwk.WithMinRefreshInterval(time.Second))
+		if _, err := af.Fetch(ctx, srv.URL); !assert.NoError(t, err, `Fetch should succeed`) {
+			return
+		}
+
+		af.Close()
+		if _, err := af.Fetch(ctx, srv.URL); !assert.Error(t, err, `Fetch should fail after Close`) {
+			return
+		}
+		if !checkStopped(t) {
+			return
+		}
+	})
+}
@@ -0,0 +1,229 @@
+package jwk
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Cache fetches a remote JWKS and keeps a copy of it, so that it
+// need not be fetched and parsed every time it is required.
+//
+// The cached copy is considered fresh for the duration specified by
+// the "max-age" directive of the "Cache-Control" response header.
+// Once it is stale, the next call to Fetch revalidates it using the
+// "ETag" response header (via "If-None-Match"), in which case the
+// server may respond with "304 Not Modified" and the cached copy
+// is reused without being parsed again.
+//
+// Cache is safe for concurrent use. Concurrent refreshes are coalesced
+// into a single request, and the lock protecting the cached copy is not
+// held while the request is in progress
+type Cache struct {
+	mu          sync.RWMutex
+	url         string
+	httpcl      *http.Client
+	maxBodySize int64
+	set         *Set
+	etag        string
+	expires     time.Time
+	inflight    *cacheFetch
+}
+
+// cacheFetch holds the result of a request that is in progress, so
+// that it can be shared by all goroutines that need a fresh JWKS
+type cacheFetch struct {
+	done   chan struct{}
+	set    *Set
+	maxAge time.Duration
+	ok     bool
+	err    error
+}
+
+// NewCache creates a new Cache for the JWKS located at url. The
+// `jwk.WithHTTPClient` option may be used to specify the *http.Client
+// that is used to fetch the JWKS (e.g. to set proxies and timeouts),
+// and the `jwk.WithHTTPMaxBodySize` option may be used to limit the
+// size of the response
+func NewCache(url string, options ...Option) *Cache {
+	httpcl := http.DefaultClient
+	var maxBodySize int64 = defaultHTTPMaxBodySize
+	for _, option := range options {
+		switch option.Name() {
+		case optkeyHTTPClient:
+			httpcl = option.Value().(*http.Client)
+		case optkeyHTTPMaxBodySize:
+			maxBodySize = option.Value().(int64)
+		}
+	}
+
+	return &Cache{
+		url:         url,
+		httpcl:      httpcl,
+		maxBodySize: maxBodySize,
+	}
+}
+
+// URL returns the URL of the JWKS that is being cached
+func (c *Cache) URL() string {
+	return c.url
+}
+
+// Fetch returns the cached JWKS if it is still fresh. Otherwise
+// the JWKS is refreshed by calling Refresh
+func (c *Cache) Fetch(ctx context.Context) (*Set, error) {
+	c.mu.RLock()
+	set := c.set
+	fresh := set != nil && time.Now().Before(c.expires)
+	c.mu.RUnlock()
+
+	if fresh {
+		return set, nil
+	}
+	set, _, _, err := c.fetch(ctx, true)
+	return set, err
+}
+
+// Refresh unconditionally fetches the JWKS from the remote server,
+// sending the "If-None-Match" header if an "ETag" is known.
+// If the request fails, the previously cached JWKS (if any) is kept
+// intact, and an error is returned
+func (c *Cache) Refresh(ctx context.Context) (*Set, error) {
+	set, _, _, err := c.refresh(ctx)
+	return set, err
+}
+
+// refresh does the work for Refresh. It additionally returns the
+// value of "max-age" reported by the server, if any
+func (c *Cache) refresh(ctx context.Context) (*Set, time.Duration, bool, error) {
+	return c.fetch(ctx, false)
+}
+
+// fetch fetches the JWKS from the remote server. If a request is
+// already in progress, its result is used instead of sending another
+// one. If onlyIfStale is true and the cached JWKS has been refreshed
+// by another goroutine in the meantime, it is returned as is
+func (c *Cache) fetch(ctx context.Context, onlyIfStale bool) (*Set, time.Duration, bool, error) {
+	c.mu.Lock()
+	if onlyIfStale && c.set != nil && time.Now().Before(c.expires) {
+		set := c.set
+		c.mu.Unlock()
+		return set, 0, false, nil
+	}
+
+	if call := c.inflight; call != nil {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.set, call.maxAge, call.ok, call.err
+		case <-ctx.Done():
+			return nil, 0, false, errors.Wrap(ctx.Err(), "failed to fetch remote JWK")
+		}
+	}
+
+	call := &cacheFetch{done: make(chan struct{})}
+	c.inflight = call
+	var etag string
+	if c.set != nil {
+		etag = c.etag
+	}
+	c.mu.Unlock()
+
+	// The request is sent without holding the lock, so that readers
+	// can keep using the cached copy in the meantime
+	set, newEtag, maxAge, ok, err := c.get(ctx, etag)
+
+	c.mu.Lock()
+	if err == nil {
+		switch {
+		case set != nil:
+			c.set = set
+			c.etag = newEtag
+		case c.set == nil:
+			err = errors.New("failed to fetch remote JWK (received 304 without a cached copy)")
+		}
+	}
+	if err == nil {
+		c.expires = time.Now().Add(maxAge)
+		call.set, call.maxAge, call.ok = c.set, maxAge, ok
+	}
+	call.err = err
+	c.inflight = nil
+	c.mu.Unlock()
+	close(call.done)
+
+	return call.set, call.maxAge, call.ok, call.err
+}
+
+// get sends the request for the JWKS, using etag for the "If-None-Match"
+// header if it is not empty. It returns the parsed JWKS and the "ETag"
+// of the response, or a nil JWKS if the server responded with
+// "304 Not Modified"
+func (c *Cache) get(ctx context.Context, etag string) (*Set, string, time.Duration, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, "", 0, false, errors.Wrap(err, "failed to new request to remote JWK")
+	}
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	res, err := c.httpcl.Do(req)
+	if err != nil {
+		return nil, "", 0, false, wrapFetchError(ctx, err, c.url, "failed to fetch remote JWK")
+	}
+	defer res.Body.Close()
+
+	var set *Set
+	switch res.StatusCode {
+	case http.StatusNotModified:
+	case http.StatusOK:
+		set, err = parseHTTPBody(res.Body, c.maxBodySize)
+		if err != nil {
+			return nil, "", 0, false, wrapFetchError(ctx, err, c.url, "failed to parse remote JWK")
+		}
+	default:
+		return nil, "", 0, false, errors.Errorf("failed to fetch remote JWK (status = %d)", res.StatusCode)
+	}
+
+	maxAge, ok := parseMaxAge(res.Header)
+	return set, res.Header.Get("ETag"), maxAge, ok, nil
+}
+
+// cached returns the currently cached JWKS regardless of its
+// freshness. It returns nil if the JWKS has never been fetched
+func (c *Cache) cached() *Set {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.set
+}
+
+// parseMaxAge returns the duration specified by the "max-age"
+// directive of the "Cache-Control" header. The second return value
+// is false if the directive is missing, or if the response must not
+// be cached
+func parseMaxAge(h http.Header) (time.Duration, bool) {
+	var maxAge time.Duration
+	var found bool
+	for _, directive := range strings.Split(h.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-cache" || directive == "no-store":
+			return 0, false
+		case strings.HasPrefix(directive, "max-age="):
+			v, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(directive, "max-age="), `"`), 10, 64)
+			if err != nil || v < 0 {
+				continue
+			}
+			maxAge = time.Duration(v) * time.Second
+			found = true
+		}
+	}
+	return maxAge, found
+}
@@ -0,0 +1,229 @@
+package jwk_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	key, err := generateSymmetricKey()
+	if !assert.NoError(t, err, `jwk generation should be successful`) {
+		return
+	}
+
+	buf, err := json.Marshal(key)
+	if !assert.NoError(t, err, `json.Marshal should succeed`) {
+		return
+	}
+
+	const etag = `"v1"`
+	var requests, revalidations int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", r.URL.Query().Get("cache-control"))
+		if r.Header.Get("If-None-Match") == etag {
+			atomic.AddInt32(&revalidations, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(buf)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.Run("max-age", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		atomic.StoreInt32(&revalidations, 0)
+
+		c := jwk.NewCache(srv.URL+"?cache-control=max-age%3D3600", jwk.WithHTTPClient(srv.Client()))
+		set1, err := c.Fetch(ctx)
+		if !assert.NoError(t, err, `Fetch should succeed`) {
+			return
+		}
+
+		if !assert.Len(t, set1.Keys, 1, `set should contain one key`) {
+			return
+		}
+
+		set2, err := c.Fetch(ctx)
+		if !assert.NoError(t, err, `Fetch should succeed`) {
+			return
+		}
+
+		if !assert.True(t, set1 == set2, `cached set should be returned`) {
+			return
+		}
+
+		if !assert.Equal(t, int32(1), atomic.LoadInt32(&requests), `only one request should be made`) {
+			return
+		}
+
+		set3, err := c.Refresh(ctx)
+		if !assert.NoError(t, err, `Refresh should succeed`) {
+			return
+		}
+
+		if !assert.True(t, set1 == set3, `cached set should be returned after 304`) {
+			return
+		}
+
+		if !assert.Equal(t, int32(1), atomic.LoadInt32(&revalidations), `Refresh should revalidate using ETag`) {
+			return
+		}
+	})
+	t.Run("no-cache", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		atomic.StoreInt32(&revalidations, 0)
+
+		c := jwk.NewCache(srv.URL+"?cache-control=no-cache", jwk.WithHTTPClient(srv.Client()))
+		for i := 0; i < 3; i++ {
+			if _, err := c.Fetch(ctx); !assert.NoError(t, err, `Fetch should succeed`) {
+				return
+			}
+		}
+
+		if !assert.Equal(t, int32(3), atomic.LoadInt32(&requests), `every Fetch should make a request`) {
+			return
+		}
+
+		if !assert.Equal(t, int32(2), atomic.LoadInt32(&revalidations), `subsequent requests should be revalidations`) {
+			return
+		}
+	})
+	t.Run("failed refresh", func(t *testing.T) {
+		c := jwk.NewCache(srv.URL+"/?cache-control=max-age%3D3600", jwk.WithHTTPClient(srv.Client()))
+		set, err := c.Fetch(ctx)
+		if !assert.NoError(t, err, `Fetch should succeed`) {
+			return
+		}
+
+		srv.Close()
+
+		_, err = c.Refresh(ctx)
+		if !assert.Error(t, err, `Refresh should fail`) {
+			return
+		}
+
+		got, err := c.Fetch(ctx)
+		if !assert.NoError(t, err, `Fetch should succeed`) {
+			return
+		}
+
+		if !assert.True(t, set == got, `previously cached set should be kept`) {
+			return
+		}
+	})
+}
+
+func TestCache_Concurrent(t *testing.T) {
+	key, err := generateSymmetricKey()
+	if !assert.NoError(t, err, `jwk generation should be successful`) {
+		return
+	}
+
+	buf, err := json.Marshal(key)
+	if !assert.NoError(t, err, `json.Marshal should succeed`) {
+		return
+	}
+
+	// Requests are blocked until release is closed, and received is
+	// notified when a request arrives
+	var requests int32
+	received := make(chan struct{}, 16)
+	var release chan struct{}
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		mu.Lock()
+		ch := release
+		mu.Unlock()
+		received <- struct{}{}
+		<-ch
+		w.Header().Set("Cache-Control", "max-age=3600")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(buf)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := jwk.NewCache(srv.URL, jwk.WithHTTPClient(srv.Client()))
+
+	t.Run("coalesced requests", func(t *testing.T) {
+		mu.Lock()
+		release = make(chan struct{})
+		mu.Unlock()
+
+		const count = 10
+		var wg sync.WaitGroup
+		sets := make([]*jwk.Set, count)
+		errs := make([]error, count)
+		for i := 0; i < count; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				sets[i], errs[i] = c.Fetch(ctx)
+			}(i)
+		}
+
+		<-received
+		// give the other goroutines time to join the request in progress
+		time.Sleep(100 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		for i := 0; i < count; i++ {
+			if !assert.NoError(t, errs[i], `Fetch should succeed`) {
+				return
+			}
+			if !assert.True(t, sets[0] == sets[i], `all goroutines should receive the same set`) {
+				return
+			}
+		}
+		if !assert.Equal(t, int32(1), atomic.LoadInt32(&requests), `only one request should be made`) {
+			return
+		}
+	})
+	t.Run("readers are not blocked", func(t *testing.T) {
+		mu.Lock()
+		release = make(chan struct{})
+		mu.Unlock()
+		defer close(release)
+
+		cached, err := c.Fetch(ctx)
+		if !assert.NoError(t, err, `Fetch should succeed`) {
+			return
+		}
+
+		go func() { _, _ = c.Refresh(ctx) }()
+		<-received
+
+		done := make(chan *jwk.Set, 1)
+		go func() {
+			set, _ := c.Fetch(ctx)
+			done <- set
+		}()
+		select {
+		case set := <-done:
+			if !assert.True(t, cached == set, `cached set should be returned while refreshing`) {
+				return
+			}
+		case <-time.After(time.Second):
+			t.Errorf(`Fetch should not wait for the refresh in progress`)
+		}
+	})
+}
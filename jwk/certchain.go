@@ -1,6 +1,12 @@
 package jwk
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
 
@@ -36,10 +42,29 @@ func (c CertificateChain) Get() []*x509.Certificate {
 	return c.certs
 }
 
+// Accept populates the CertificateChain from the given value. The value
+// may be a base64 encoded DER certificate (or a list of them, as found in
+// the "x5c" header), or *x509.Certificate (or a list of them)
 func (c *CertificateChain) Accept(v interface{}) error {
 	var list []string
 
 	switch x := v.(type) {
+	case CertificateChain:
+		*c = x
+		return nil
+	case *x509.Certificate:
+		*c = CertificateChain{certs: []*x509.Certificate{x}}
+		return nil
+	case []*x509.Certificate:
+		for i, cert := range x {
+			if cert == nil {
+				return errors.Errorf(`invalid nil certificate at element %d`, i)
+			}
+		}
+		certs := make([]*x509.Certificate, len(x))
+		copy(certs, x)
+		*c = CertificateChain{certs: certs}
+		return nil
 	case string:
 		list = []string{x}
 	case []interface{}:
@@ -61,11 +86,11 @@ func (c *CertificateChain) Accept(v interface{}) error {
 	for i, e := range list {
 		buf, err := base64.DecodeString(e)
 		if err != nil {
-			return errors.Wrap(err, `failed to base64 decode list element`)
+			return errors.Wrapf(err, `failed to base64 decode list element %d`, i)
 		}
 		cert, err := x509.ParseCertificate(buf)
 		if err != nil {
-			return errors.Wrap(err, `failed to parse certificate`)
+			return errors.Wrapf(err, `failed to parse certificate at element %d`, i)
 		}
 		certs[i] = cert
 	}
@@ -75,3 +100,69 @@ func (c *CertificateChain) Accept(v interface{}) error {
 	}
 	return nil
 }
+
+// FromCertificate creates a new public jwk.Key from the public key
+// contained in the certificate, and populates the "x5c", "x5t" and
+// "x5t#S256" fields using the certificate. RSA, ECDSA and Ed25519
+// public keys are supported.
+//
+// This is equivalent to calling `jwk.New` with the certificate
+func FromCertificate(cert *x509.Certificate) (Key, error) {
+	if cert == nil {
+		return nil, errors.New(`invalid nil certificate`)
+	}
+	return newFromCertificateChain(cert, []*x509.Certificate{cert})
+}
+
+// FromTLSCertificate creates a new public jwk.Key from the leaf
+// certificate of the tls.Certificate, as described in `jwk.FromCertificate`.
+// Unlike `jwk.FromCertificate`, the "x5c" field contains the whole
+// certificate chain. The private key of the tls.Certificate is not used
+func FromTLSCertificate(cert tls.Certificate) (Key, error) {
+	if len(cert.Certificate) == 0 {
+		return nil, errors.New(`tls.Certificate does not contain any certificates`)
+	}
+
+	chain := make([]*x509.Certificate, len(cert.Certificate))
+	for i, der := range cert.Certificate {
+		if i == 0 && cert.Leaf != nil {
+			chain[i] = cert.Leaf
+			continue
+		}
+		c, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, errors.Wrapf(err, `failed to parse certificate at element %d`, i)
+		}
+		chain[i] = c
+	}
+	return newFromCertificateChain(chain[0], chain)
+}
+
+func newFromCertificateChain(cert *x509.Certificate, chain []*x509.Certificate) (Key, error) {
+	switch cert.PublicKey.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+	default:
+		return nil, errors.Errorf(`unsupported public key type %T in certificate (algorithm %s)`, cert.PublicKey, cert.PublicKeyAlgorithm)
+	}
+
+	key, err := New(cert.PublicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to create jwk.Key from certificate public key`)
+	}
+
+	if err := key.SetX509CertChain(chain); err != nil {
+		return nil, errors.Wrapf(err, `failed to set %s`, X509CertChainKey)
+	}
+
+	s1 := sha1.Sum(cert.Raw)
+	if err := key.Set(X509CertThumbprintKey, base64.EncodeToString(s1[:])); err != nil {
+		return nil, errors.Wrapf(err, `failed to set %s`, X509CertThumbprintKey)
+	}
+
+	s256 := sha256.Sum256(cert.Raw)
+	if err := key.Set(X509CertThumbprintS256Key, base64.EncodeToString(s256[:])); err != nil {
+		return nil, errors.Wrapf(err, `failed to set %s`, X509CertThumbprintS256Key)
+	}
+
+	return key, nil
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/lestrrat-go/jwx/internal/base64"
 	"github.com/lestrrat-go/jwx/internal/ecutil"
+	"github.com/lestrrat-go/jwx/internal/secp256k1"
 	"github.com/lestrrat-go/jwx/jwa"
 	"github.com/pkg/errors"
 )
@@ -34,8 +35,6 @@ func newECDSAPrivateKey() *ecdsaPrivateKey {
 }
 
 func (k *ecdsaPublicKey) FromRaw(rawKey *ecdsa.PublicKey) error {
-	k.x = rawKey.X.Bytes()
-	k.y = rawKey.Y.Bytes()
 	switch rawKey.Curve {
 	case elliptic.P256():
 		if err := k.Set(ECDSACrvKey, jwa.P256); err != nil {
@@ -49,16 +48,29 @@ func (k *ecdsaPublicKey) FromRaw(rawKey *ecdsa.PublicKey) error {
 		if err := k.Set(ECDSACrvKey, jwa.P521); err != nil {
 			return errors.Wrap(err, `failed to set header`)
 		}
+	case secp256k1.Curve():
+		if err := k.Set(ECDSACrvKey, jwa.Secp256k1); err != nil {
+			return errors.Wrap(err, `failed to set header`)
+		}
 	default:
 		return errors.Errorf(`invalid elliptic curve %s`, rawKey.Curve)
 	}
 
+	x, err := EncodeCoordinate(rawKey.X, rawKey.Curve)
+	if err != nil {
+		return errors.Wrap(err, `invalid x coordinate`)
+	}
+	y, err := EncodeCoordinate(rawKey.Y, rawKey.Curve)
+	if err != nil {
+		return errors.Wrap(err, `invalid y coordinate`)
+	}
+	k.x = x
+	k.y = y
+
 	return nil
 }
 
 func (k *ecdsaPrivateKey) FromRaw(rawKey *ecdsa.PrivateKey) error {
-	k.x = rawKey.X.Bytes()
-	k.y = rawKey.Y.Bytes()
 	switch rawKey.Curve {
 	case elliptic.P256():
 		if err := k.Set(ECDSACrvKey, jwa.P256); err != nil {
@@ -72,15 +84,54 @@ func (k *ecdsaPrivateKey) FromRaw(rawKey *ecdsa.PrivateKey) error {
 		if err := k.Set(ECDSACrvKey, jwa.P521); err != nil {
 			return errors.Wrap(err, "failed to write header")
 		}
+	case secp256k1.Curve():
+		if err := k.Set(ECDSACrvKey, jwa.Secp256k1); err != nil {
+			return errors.Wrap(err, "failed to write header")
+		}
 	default:
 		return errors.Errorf(`invalid elliptic curve %s`, rawKey.Curve)
 	}
 
-	k.d = rawKey.D.Bytes()
+	x, err := EncodeCoordinate(rawKey.X, rawKey.Curve)
+	if err != nil {
+		return errors.Wrap(err, `invalid x coordinate`)
+	}
+	y, err := EncodeCoordinate(rawKey.Y, rawKey.Curve)
+	if err != nil {
+		return errors.Wrap(err, `invalid y coordinate`)
+	}
+	d, err := EncodeCoordinate(rawKey.D, rawKey.Curve)
+	if err != nil {
+		return errors.Wrap(err, `invalid private key`)
+	}
+	k.x = x
+	k.y = y
+	k.d = d
 
 	return nil
 }
 
+// CurveSize returns the size in bytes of the coordinates (and private
+// keys) of the given curve, which is the length that the "x", "y", and
+// "d" parameters must have in JWKs (https://tools.ietf.org/html/rfc7518#section-6.2.1.2)
+func CurveSize(crv elliptic.Curve) int {
+	return ecutil.CurveSize(crv)
+}
+
+// EncodeCoordinate returns the fixed-width, big-endian representation
+// of the coordinate v for the given curve, as required for the "x", "y",
+// and "d" parameters of JWKs. The value is left padded with zeros to
+// CurveSize(crv) bytes.
+//
+// An error is returned if v is nil or negative, or if it does not fit
+// in CurveSize(crv) bytes
+func EncodeCoordinate(v *big.Int, crv elliptic.Curve) ([]byte, error) {
+	if v == nil || v.Sign() < 0 || (v.BitLen()+7)/8 > CurveSize(crv) {
+		return nil, errors.Errorf(`invalid value for curve %s`, crv.Params().Name)
+	}
+	return ecutil.EncodeCoordinate(v, crv), nil
+}
+
 func buildECDSAPublicKey(alg jwa.EllipticCurveAlgorithm, xbuf, ybuf []byte) (*ecdsa.PublicKey, error) {
 	var curve elliptic.Curve
 	switch alg {
@@ -90,6 +141,8 @@ func buildECDSAPublicKey(alg jwa.EllipticCurveAlgorithm, xbuf, ybuf []byte) (*ec
 		curve = elliptic.P384()
 	case jwa.P521:
 		curve = elliptic.P521()
+	case jwa.Secp256k1:
+		curve = secp256k1.Curve()
 	default:
 		return nil, errors.Errorf(`invalid curve algorithm %s`, alg)
 	}
@@ -98,6 +151,12 @@ func buildECDSAPublicKey(alg jwa.EllipticCurveAlgorithm, xbuf, ybuf []byte) (*ec
 	x.SetBytes(xbuf)
 	y.SetBytes(ybuf)
 
+	// Reject points that are not on the declared curve, so that they
+	// cannot be used in invalid curve attacks against ECDH-ES
+	if !curve.IsOnCurve(&x, &y) {
+		return nil, errors.Errorf(`point (x, y) is not on the curve %s`, alg)
+	}
+
 	return &ecdsa.PublicKey{Curve: curve, X: &x, Y: &y}, nil
 }
 
@@ -136,6 +195,9 @@ func (k *ecdsaPrivateKey) PublicKey() (ECDSAPublicKey, error) {
 	if err := newKey.FromRaw(&privk.PublicKey); err != nil {
 		return nil, errors.Wrap(err, `failed to initialize ECDSAPublicKey`)
 	}
+	if err := copyPublicKeyFields(newKey, k); err != nil {
+		return nil, errors.Wrap(err, `failed to copy fields to ECDSAPublicKey`)
+	}
 	return newKey, nil
 }
 
@@ -164,9 +226,11 @@ func (k ecdsaPublicKey) Thumbprint(hash crypto.Hash) ([]byte, error) {
 	defer ecutil.ReleaseECPointBuffer(xbuf)
 	defer ecutil.ReleaseECPointBuffer(ybuf)
 
+	// Use the "crv" value rather than the name of the curve, as the
+	// thumbprint must contain the exact value registered for JWKs
 	return ecdsaThumbprint(
 		hash,
-		key.Curve.Params().Name,
+		k.Crv().String(),
 		base64.EncodeToString(xbuf),
 		base64.EncodeToString(ybuf),
 	), nil
@@ -185,9 +249,11 @@ func (k ecdsaPrivateKey) Thumbprint(hash crypto.Hash) ([]byte, error) {
 	defer ecutil.ReleaseECPointBuffer(xbuf)
 	defer ecutil.ReleaseECPointBuffer(ybuf)
 
+	// Use the "crv" value rather than the name of the curve, as the
+	// thumbprint must contain the exact value registered for JWKs
 	return ecdsaThumbprint(
 		hash,
-		key.Curve.Params().Name,
+		k.Crv().String(),
 		base64.EncodeToString(xbuf),
 		base64.EncodeToString(ybuf),
 	), nil
@@ -55,17 +55,17 @@ type ecdsaPrivateKey struct {
 type ecdsaPrivateKeyMarshalProxy struct {
 	XkeyType                jwa.KeyType                 `json:"kty"`
 	Xalgorithm              *string                     `json:"alg,omitempty"`
+	XkeyUsage               *string                     `json:"use,omitempty"`
+	XkeyID                  *string                     `json:"kid,omitempty"`
 	Xcrv                    *jwa.EllipticCurveAlgorithm `json:"crv,omitempty"`
 	Xd                      *string                     `json:"d,omitempty"`
-	XkeyID                  *string                     `json:"kid,omitempty"`
-	XkeyUsage               *string                     `json:"use,omitempty"`
-	Xkeyops                 *KeyOperationList           `json:"key_ops,omitempty"`
 	Xx                      *string                     `json:"x,omitempty"`
+	Xy                      *string                     `json:"y,omitempty"`
+	Xkeyops                 *KeyOperationList           `json:"key_ops,omitempty"`
 	Xx509CertChain          *CertificateChain           `json:"x5c,omitempty"`
 	Xx509CertThumbprint     *string                     `json:"x5t,omitempty"`
 	Xx509CertThumbprintS256 *string                     `json:"x5t#S256,omitempty"`
 	Xx509URL                *string                     `json:"x5u,omitempty"`
-	Xy                      *string                     `json:"y,omitempty"`
 }
 
 func (h ecdsaPrivateKey) KeyType() jwa.KeyType {
@@ -282,6 +282,18 @@ func (h *ecdsaPrivateKey) Set(name string, value interface{}) error {
 		switch v := value.(type) {
 		case string:
 			h.algorithm = &v
+		case jwa.SignatureAlgorithm:
+			if !isSignatureAlgorithm(h.KeyType(), v.String()) {
+				return errors.Errorf(`algorithm %q cannot be used with key type %q`, v, h.KeyType())
+			}
+			tmp := v.String()
+			h.algorithm = &tmp
+		case jwa.KeyEncryptionAlgorithm:
+			if !isKeyEncryptionAlgorithm(h.KeyType(), v.String()) {
+				return errors.Errorf(`algorithm %q cannot be used with key type %q`, v, h.KeyType())
+			}
+			tmp := v.String()
+			h.algorithm = &tmp
 		case fmt.Stringer:
 			tmp := v.String()
 			h.algorithm = &tmp
@@ -291,6 +303,9 @@ func (h *ecdsaPrivateKey) Set(name string, value interface{}) error {
 		return nil
 	case ECDSACrvKey:
 		if v, ok := value.(jwa.EllipticCurveAlgorithm); ok {
+			if !isValidCurve(h.KeyType(), v) {
+				return errors.Errorf(`curve %q cannot be used with key type %q`, v, h.KeyType())
+			}
 			h.crv = &v
 			return nil
 		}
@@ -376,6 +391,9 @@ func (h *ecdsaPrivateKey) UnmarshalJSON(buf []byte) error {
 	}
 	h.algorithm = proxy.Xalgorithm
 	h.crv = proxy.Xcrv
+	if h.crv != nil && !isValidCurve(jwa.EC, *(h.crv)) {
+		return errors.Errorf(`curve %q cannot be used with key type %q`, *(h.crv), jwa.EC)
+	}
 	if proxy.Xd == nil {
 		return errors.New(`required field d is missing`)
 	}
@@ -499,6 +517,87 @@ func (h *ecdsaPrivateKey) AsMap(ctx context.Context) (map[string]interface{}, er
 	return iter.AsMap(ctx, h)
 }
 
+func (h *ecdsaPrivateKey) ValidateX509() error {
+	return validateX509(h)
+}
+
+func (h *ecdsaPrivateKey) SetX509CertChain(chain []*x509.Certificate) error {
+	return h.Set(X509CertChainKey, chain)
+}
+
+func (h *ecdsaPrivateKey) CanPerform(op KeyOperation) bool {
+	return h.keyops.canPerform(op)
+}
+
+func (h *ecdsaPrivateKey) SignatureAlgorithm() jwa.SignatureAlgorithm {
+	return signatureAlgorithmOf(h)
+}
+
+func (h *ecdsaPrivateKey) KeyEncryptionAlgorithm() jwa.KeyEncryptionAlgorithm {
+	return keyEncryptionAlgorithmOf(h)
+}
+
+func (h *ecdsaPrivateKey) Clone() (Key, error) {
+	dst := &ecdsaPrivateKey{}
+	if h.algorithm != nil {
+		v := *h.algorithm
+		dst.algorithm = &v
+	}
+	if h.crv != nil {
+		v := *h.crv
+		dst.crv = &v
+	}
+	if h.d != nil {
+		dst.d = make([]byte, len(h.d))
+		copy(dst.d, h.d)
+	}
+	if h.keyID != nil {
+		v := *h.keyID
+		dst.keyID = &v
+	}
+	if h.keyUsage != nil {
+		v := *h.keyUsage
+		dst.keyUsage = &v
+	}
+	if h.keyops != nil {
+		v := make(KeyOperationList, len(*h.keyops))
+		copy(v, *h.keyops)
+		dst.keyops = &v
+	}
+	if h.x != nil {
+		dst.x = make([]byte, len(h.x))
+		copy(dst.x, h.x)
+	}
+	if h.x509CertChain != nil {
+		v := CertificateChain{certs: make([]*x509.Certificate, len(h.x509CertChain.certs))}
+		copy(v.certs, h.x509CertChain.certs)
+		dst.x509CertChain = &v
+	}
+	if h.x509CertThumbprint != nil {
+		v := *h.x509CertThumbprint
+		dst.x509CertThumbprint = &v
+	}
+	if h.x509CertThumbprintS256 != nil {
+		v := *h.x509CertThumbprintS256
+		dst.x509CertThumbprintS256 = &v
+	}
+	if h.x509URL != nil {
+		v := *h.x509URL
+		dst.x509URL = &v
+	}
+	if h.y != nil {
+		dst.y = make([]byte, len(h.y))
+		copy(dst.y, h.y)
+	}
+	if h.privateParams != nil {
+		dst.privateParams = make(map[string]interface{}, len(h.privateParams))
+		for k, v := range h.privateParams {
+			dst.privateParams[k] = cloneParam(v)
+		}
+	}
+	return dst, nil
+}
+
 type ECDSAPublicKey interface {
 	Key
 	FromRaw(*ecdsa.PublicKey) error
@@ -525,16 +624,16 @@ type ecdsaPublicKey struct {
 type ecdsaPublicKeyMarshalProxy struct {
 	XkeyType                jwa.KeyType                 `json:"kty"`
 	Xalgorithm              *string                     `json:"alg,omitempty"`
-	Xcrv                    *jwa.EllipticCurveAlgorithm `json:"crv,omitempty"`
-	XkeyID                  *string                     `json:"kid,omitempty"`
 	XkeyUsage               *string                     `json:"use,omitempty"`
-	Xkeyops                 *KeyOperationList           `json:"key_ops,omitempty"`
+	XkeyID                  *string                     `json:"kid,omitempty"`
+	Xcrv                    *jwa.EllipticCurveAlgorithm `json:"crv,omitempty"`
 	Xx                      *string                     `json:"x,omitempty"`
+	Xy                      *string                     `json:"y,omitempty"`
+	Xkeyops                 *KeyOperationList           `json:"key_ops,omitempty"`
 	Xx509CertChain          *CertificateChain           `json:"x5c,omitempty"`
 	Xx509CertThumbprint     *string                     `json:"x5t,omitempty"`
 	Xx509CertThumbprintS256 *string                     `json:"x5t#S256,omitempty"`
 	Xx509URL                *string                     `json:"x5u,omitempty"`
-	Xy                      *string                     `json:"y,omitempty"`
 }
 
 func (h ecdsaPublicKey) KeyType() jwa.KeyType {
@@ -739,6 +838,18 @@ func (h *ecdsaPublicKey) Set(name string, value interface{}) error {
 		switch v := value.(type) {
 		case string:
 			h.algorithm = &v
+		case jwa.SignatureAlgorithm:
+			if !isSignatureAlgorithm(h.KeyType(), v.String()) {
+				return errors.Errorf(`algorithm %q cannot be used with key type %q`, v, h.KeyType())
+			}
+			tmp := v.String()
+			h.algorithm = &tmp
+		case jwa.KeyEncryptionAlgorithm:
+			if !isKeyEncryptionAlgorithm(h.KeyType(), v.String()) {
+				return errors.Errorf(`algorithm %q cannot be used with key type %q`, v, h.KeyType())
+			}
+			tmp := v.String()
+			h.algorithm = &tmp
 		case fmt.Stringer:
 			tmp := v.String()
 			h.algorithm = &tmp
@@ -748,6 +859,9 @@ func (h *ecdsaPublicKey) Set(name string, value interface{}) error {
 		return nil
 	case ECDSACrvKey:
 		if v, ok := value.(jwa.EllipticCurveAlgorithm); ok {
+			if !isValidCurve(h.KeyType(), v) {
+				return errors.Errorf(`curve %q cannot be used with key type %q`, v, h.KeyType())
+			}
 			h.crv = &v
 			return nil
 		}
@@ -827,6 +941,9 @@ func (h *ecdsaPublicKey) UnmarshalJSON(buf []byte) error {
 	}
 	h.algorithm = proxy.Xalgorithm
 	h.crv = proxy.Xcrv
+	if h.crv != nil && !isValidCurve(jwa.EC, *(h.crv)) {
+		return errors.Errorf(`curve %q cannot be used with key type %q`, *(h.crv), jwa.EC)
+	}
 	h.keyID = proxy.XkeyID
 	h.keyUsage = proxy.XkeyUsage
 	h.keyops = proxy.Xkeyops
@@ -934,3 +1051,80 @@ func (h *ecdsaPublicKey) Walk(ctx context.Context, visitor HeaderVisitor) error
 func (h *ecdsaPublicKey) AsMap(ctx context.Context) (map[string]interface{}, error) {
 	return iter.AsMap(ctx, h)
 }
+
+func (h *ecdsaPublicKey) ValidateX509() error {
+	return validateX509(h)
+}
+
+func (h *ecdsaPublicKey) SetX509CertChain(chain []*x509.Certificate) error {
+	return h.Set(X509CertChainKey, chain)
+}
+
+func (h *ecdsaPublicKey) CanPerform(op KeyOperation) bool {
+	return h.keyops.canPerform(op)
+}
+
+func (h *ecdsaPublicKey) SignatureAlgorithm() jwa.SignatureAlgorithm {
+	return signatureAlgorithmOf(h)
+}
+
+func (h *ecdsaPublicKey) KeyEncryptionAlgorithm() jwa.KeyEncryptionAlgorithm {
+	return keyEncryptionAlgorithmOf(h)
+}
+
+func (h *ecdsaPublicKey) Clone() (Key, error) {
+	dst := &ecdsaPublicKey{}
+	if h.algorithm != nil {
+		v := *h.algorithm
+		dst.algorithm = &v
+	}
+	if h.crv != nil {
+		v := *h.crv
+		dst.crv = &v
+	}
+	if h.keyID != nil {
+		v := *h.keyID
+		dst.keyID = &v
+	}
+	if h.keyUsage != nil {
+		v := *h.keyUsage
+		dst.keyUsage = &v
+	}
+	if h.keyops != nil {
+		v := make(KeyOperationList, len(*h.keyops))
+		copy(v, *h.keyops)
+		dst.keyops = &v
+	}
+	if h.x != nil {
+		dst.x = make([]byte, len(h.x))
+		copy(dst.x, h.x)
+	}
+	if h.x509CertChain != nil {
+		v := CertificateChain{certs: make([]*x509.Certificate, len(h.x509CertChain.certs))}
+		copy(v.certs, h.x509CertChain.certs)
+		dst.x509CertChain = &v
+	}
+	if h.x509CertThumbprint != nil {
+		v := *h.x509CertThumbprint
+		dst.x509CertThumbprint = &v
+	}
+	if h.x509CertThumbprintS256 != nil {
+		v := *h.x509CertThumbprintS256
+		dst.x509CertThumbprintS256 = &v
+	}
+	if h.x509URL != nil {
+		v := *h.x509URL
+		dst.x509URL = &v
+	}
+	if h.y != nil {
+		dst.y = make([]byte, len(h.y))
+		copy(dst.y, h.y)
+	}
+	if h.privateParams != nil {
+		dst.privateParams = make(map[string]interface{}, len(h.privateParams))
+		for k, v := range h.privateParams {
+			dst.privateParams[k] = cloneParam(v)
+		}
+	}
+	return dst, nil
+}
@@ -5,9 +5,12 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
+	"math/big"
 	"testing"
 
+	"github.com/lestrrat-go/jwx/internal/secp256k1"
 	"github.com/lestrrat-go/jwx/jwa"
 	"github.com/lestrrat-go/jwx/jwk"
 	"github.com/stretchr/testify/assert"
@@ -237,7 +240,7 @@ func TestECDSA(t *testing.T) {
 				 }
        ]
   }`
-		expectedPrivKey := `{"kty":"EC","crv":"P-256","d":"870MB6gfuTJ4HtUnUvYMyJpr5eUZNP4Bk43bVdj3eAE","key_ops":["verify"],"x":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4","y":"4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM"}`
+		expectedPrivKey := `{"kty":"EC","crv":"P-256","d":"870MB6gfuTJ4HtUnUvYMyJpr5eUZNP4Bk43bVdj3eAE","x":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4","y":"4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM","key_ops":["verify"]}`
 
 		set, err := jwk.ParseString(s)
 		if err != nil {
@@ -269,7 +272,7 @@ func TestECDSA(t *testing.T) {
 		}
 	})
 	t.Run("Curve types", func(t *testing.T) {
-		crvs := []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()}
+		crvs := []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521(), secp256k1.Curve()}
 
 		for _, crv := range crvs {
 			crv := crv
@@ -301,7 +304,184 @@ func TestECDSA(t *testing.T) {
 				if !assert.Equal(t, privtp, pubtp, `Thumbprints should match`) {
 					return
 				}
+
+				var raw ecdsa.PrivateKey
+				if !assert.NoError(t, privkey.Raw(&raw), `privkey.Raw should succeed`) {
+					return
+				}
+				if !assert.Equal(t, crv, raw.Curve, `curves should match`) {
+					return
+				}
+				if !assert.True(t, key.Equal(&raw), `raw keys should match`) {
+					return
+				}
 			})
 		}
 	})
+	t.Run("Fixed size coordinates", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+		if !assert.NoError(t, err, `ecdsa.GenerateKey should succeed`) {
+			return
+		}
+		// Values with leading zero bytes must be padded to the size of the curve
+		key.X = big.NewInt(1)
+		key.Y = big.NewInt(2)
+		key.D = big.NewInt(3)
+
+		privkey := jwk.NewECDSAPrivateKey()
+		if !assert.NoError(t, privkey.FromRaw(key), `privkey.FromRaw should succeed`) {
+			return
+		}
+		pubkey := jwk.NewECDSAPublicKey()
+		if !assert.NoError(t, pubkey.FromRaw(&key.PublicKey), `pubkey.FromRaw should succeed`) {
+			return
+		}
+
+		for _, buf := range [][]byte{privkey.X(), privkey.Y(), privkey.D(), pubkey.X(), pubkey.Y()} {
+			if !assert.Len(t, buf, 66, `parameters should be padded to the size of the curve`) {
+				return
+			}
+		}
+		if !assert.Equal(t, byte(3), privkey.D()[65], `value should be preserved`) {
+			return
+		}
+
+		key.X = new(big.Int).Lsh(big.NewInt(1), 528)
+		if !assert.Error(t, pubkey.FromRaw(&key.PublicKey), `pubkey.FromRaw should fail for values larger than the curve`) {
+			return
+		}
+	})
+	t.Run("secp256k1", func(t *testing.T) {
+		// The public key corresponding to the private key d = 2
+		const src = `{"kty":"EC","crv":"secp256k1","x":"xgR_lEHtfW0wRUBulcB82Fx3jkuM7zynq6wJuVxwnuU","y":"GuFo_qY9wzmjxYQZRmzq7vf2MmUyZtDhI2QxqVDP5So"}`
+		key, err := jwk.ParseKey([]byte(src))
+		if !assert.NoError(t, err, `jwk.ParseKey should succeed`) {
+			return
+		}
+
+		ecdsakey, ok := key.(jwk.ECDSAPublicKey)
+		if !assert.True(t, ok, `key should be a jwk.ECDSAPublicKey`) {
+			return
+		}
+		if !assert.Equal(t, jwa.Secp256k1, ecdsakey.Crv(), `crv should be secp256k1`) {
+			return
+		}
+
+		var raw ecdsa.PublicKey
+		if !assert.NoError(t, key.Raw(&raw), `key.Raw should succeed`) {
+			return
+		}
+		if !assert.Equal(t, secp256k1.Curve(), raw.Curve, `curve should be secp256k1`) {
+			return
+		}
+		if !assert.True(t, raw.Curve.IsOnCurve(raw.X, raw.Y), `point should be on the curve`) {
+			return
+		}
+	})
+	t.Run("Unknown curve", func(t *testing.T) {
+		for _, src := range []string{
+			`{"kty":"EC","crv":"P-257","x":"xgR_lEHtfW0wRUBulcB82Fx3jkuM7zynq6wJuVxwnuU","y":"GuFo_qY9wzmjxYQZRmzq7vf2MmUyZtDhI2QxqVDP5So"}`,
+			`{"kty":"EC","crv":"P-257","x":"xgR_lEHtfW0wRUBulcB82Fx3jkuM7zynq6wJuVxwnuU","y":"GuFo_qY9wzmjxYQZRmzq7vf2MmUyZtDhI2QxqVDP5So","d":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAI"}`,
+		} {
+			_, err := jwk.ParseKey([]byte(src))
+			if !assert.Error(t, err, `jwk.ParseKey should fail`) {
+				return
+			}
+			if !assert.Contains(t, err.Error(), `"P-257"`, `error should contain the invalid curve`) {
+				return
+			}
+		}
+	})
+}
+
+func TestEncodeCoordinate(t *testing.T) {
+	testcases := []struct {
+		crv  elliptic.Curve
+		size int
+	}{
+		{crv: elliptic.P256(), size: 32},
+		{crv: elliptic.P384(), size: 48},
+		{crv: elliptic.P521(), size: 66},
+		{crv: secp256k1.Curve(), size: 32},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.crv.Params().Name, func(t *testing.T) {
+			if !assert.Equal(t, tc.size, jwk.CurveSize(tc.crv), `jwk.CurveSize should match`) {
+				return
+			}
+
+			buf, err := jwk.EncodeCoordinate(big.NewInt(0x0102), tc.crv)
+			if !assert.NoError(t, err, `jwk.EncodeCoordinate should succeed`) {
+				return
+			}
+			if !assert.Len(t, buf, tc.size, `encoded coordinate should be left padded`) {
+				return
+			}
+			if !assert.Equal(t, []byte{0x01, 0x02}, buf[tc.size-2:], `encoded coordinate should end with the value`) {
+				return
+			}
+			if !assert.Equal(t, make([]byte, tc.size-2), buf[:tc.size-2], `encoded coordinate should be padded with zeros`) {
+				return
+			}
+
+			oversized := new(big.Int).Lsh(big.NewInt(1), uint(tc.size*8))
+			for _, v := range []*big.Int{nil, big.NewInt(-1), oversized} {
+				if _, err := jwk.EncodeCoordinate(v, tc.crv); !assert.Error(t, err, `jwk.EncodeCoordinate should fail for %v`, v) {
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestECDSA_PointOnCurve(t *testing.T) {
+	raw, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if !assert.NoError(t, err, `ecdsa.GenerateKey should succeed`) {
+		return
+	}
+
+	encode := func(v *big.Int, size int) string {
+		buf := make([]byte, size)
+		b := v.Bytes()
+		copy(buf[size-len(b):], b)
+		return base64.RawURLEncoding.EncodeToString(buf)
+	}
+
+	t.Run("Variably padded coordinates", func(t *testing.T) {
+		for _, size := range []int{len(raw.X.Bytes()), 32, 40} {
+			src := `{"kty":"EC","crv":"P-256","x":"` + encode(raw.X, size) + `","y":"` + encode(raw.Y, size) + `"}`
+			key, err := jwk.ParseKey([]byte(src))
+			if !assert.NoError(t, err, `jwk.ParseKey should succeed`) {
+				return
+			}
+			var pubkey ecdsa.PublicKey
+			if !assert.NoError(t, key.Raw(&pubkey), `key.Raw should succeed`) {
+				return
+			}
+			if !assert.Equal(t, 0, raw.X.Cmp(pubkey.X), `x should match`) {
+				return
+			}
+			if !assert.Equal(t, 0, raw.Y.Cmp(pubkey.Y), `y should match`) {
+				return
+			}
+		}
+	})
+	t.Run("Point not on curve", func(t *testing.T) {
+		y := new(big.Int).Add(raw.Y, big.NewInt(1))
+		src := `{"kty":"EC","crv":"P-256","x":"` + encode(raw.X, 32) + `","y":"` + encode(y, 32) + `"}`
+		key, err := jwk.ParseKey([]byte(src))
+		if !assert.NoError(t, err, `jwk.ParseKey should succeed`) {
+			return
+		}
+		var pubkey ecdsa.PublicKey
+		err = key.Raw(&pubkey)
+		if !assert.Error(t, err, `key.Raw should fail`) {
+			return
+		}
+		if !assert.Contains(t, err.Error(), "not on the curve", `error should mention the curve`) {
+			return
+		}
+	})
 }
@@ -0,0 +1,127 @@
+package jwk_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetch_Context(t *testing.T) {
+	unblock := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-unblock:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(unblock)
+
+	fetchers := map[string]func(context.Context) error{
+		"FetchHTTPWithContext": func(ctx context.Context) error {
+			_, err := jwk.FetchHTTPWithContext(ctx, srv.URL, jwk.WithHTTPClient(srv.Client()))
+			return err
+		},
+		"FetchWithContext": func(ctx context.Context) error {
+			_, err := jwk.FetchWithContext(ctx, srv.URL, jwk.WithHTTPClient(srv.Client()))
+			return err
+		},
+		"Cache.Fetch": func(ctx context.Context) error {
+			_, err := jwk.NewCache(srv.URL, jwk.WithHTTPClient(srv.Client())).Fetch(ctx)
+			return err
+		},
+	}
+
+	for name, fetch := range fetchers {
+		fetch := fetch
+		t.Run(name, func(t *testing.T) {
+			t.Run("Deadline", func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+				defer cancel()
+
+				start := time.Now()
+				err := fetch(ctx)
+				if !assert.True(t, errors.Is(err, context.DeadlineExceeded), `error should be context.DeadlineExceeded (got %v)`, err) {
+					return
+				}
+				if !assert.Contains(t, err.Error(), srv.URL, `error should contain the url`) {
+					return
+				}
+				if !assert.True(t, time.Since(start) < 5*time.Second, `fetch should be aborted promptly`) {
+					return
+				}
+			})
+			t.Run("Cancel", func(t *testing.T) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(100*time.Millisecond, cancel)
+
+				err := fetch(ctx)
+				if !assert.True(t, errors.Is(err, context.Canceled), `error should be context.Canceled (got %v)`, err) {
+					return
+				}
+			})
+		})
+	}
+}
+
+func TestFetch_MaxBodySize(t *testing.T) {
+	key, err := generateSymmetricKey()
+	if !assert.NoError(t, err, `jwk generation should be successful`) {
+		return
+	}
+	buf, err := json.Marshal(key)
+	if !assert.NoError(t, err, `json.Marshal should succeed`) {
+		return
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		if r.URL.Query().Get("huge") != "" {
+			// 11MB of whitespace, followed by the key
+			_, _ = w.Write(bytes.Repeat([]byte{' '}, 11*1024*1024))
+		}
+		_, _ = w.Write(buf)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	fetchers := map[string]func(string, ...jwk.Option) error{
+		"FetchHTTPWithContext": func(u string, options ...jwk.Option) error {
+			_, err := jwk.FetchHTTPWithContext(ctx, u, append(options, jwk.WithHTTPClient(srv.Client()))...)
+			return err
+		},
+		"Cache.Fetch": func(u string, options ...jwk.Option) error {
+			_, err := jwk.NewCache(u, append(options, jwk.WithHTTPClient(srv.Client()))...).Fetch(ctx)
+			return err
+		},
+	}
+
+	for name, fetch := range fetchers {
+		fetch := fetch
+		t.Run(name, func(t *testing.T) {
+			if !assert.NoError(t, fetch(srv.URL), `fetch should succeed`) {
+				return
+			}
+			if !assert.Error(t, fetch(srv.URL+"?huge=1"), `fetch should fail with the default limit`) {
+				return
+			}
+			if !assert.Error(t, fetch(srv.URL, jwk.WithHTTPMaxBodySize(int64(len(buf)-1))), `fetch should fail when the body exceeds the limit`) {
+				return
+			}
+			if !assert.NoError(t, fetch(srv.URL, jwk.WithHTTPMaxBodySize(int64(len(buf)))), `fetch should succeed when the body is exactly the limit`) {
+				return
+			}
+			if !assert.NoError(t, fetch(srv.URL+"?huge=1", jwk.WithHTTPMaxBodySize(0)), `fetch should succeed when the limit is disabled`) {
+				return
+			}
+		})
+	}
+}
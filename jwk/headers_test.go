@@ -1,6 +1,9 @@
 package jwk_test
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
 	"fmt"
 	"testing"
 
@@ -106,7 +109,7 @@ func TestHeader(t *testing.T) {
 		if !assert.NoError(t, err, `jwk.New should succeed`) {
 			return
 		}
-		for _, value := range []interface{}{jwa.RS256, jwa.RSA1_5} {
+		for _, value := range []interface{}{jwa.HS256, jwa.A128KW} {
 			if !assert.NoError(t, h.Set(jwk.AlgorithmKey, value), "Set for alg should succeed") {
 				return
 			}
@@ -120,5 +123,157 @@ func TestHeader(t *testing.T) {
 				return
 			}
 		}
+
+		for _, value := range []interface{}{jwa.RS256, jwa.RSA1_5} {
+			if !assert.Error(t, h.Set(jwk.AlgorithmKey, value), "Set for alg should fail for incompatible algorithms") {
+				return
+			}
+		}
+	})
+	t.Run("Typed algorithm", func(t *testing.T) {
+		h, err := jwk.New([]byte("dummy"))
+		if !assert.NoError(t, err, `jwk.New should succeed`) {
+			return
+		}
+		if !assert.Empty(t, h.SignatureAlgorithm(), `SignatureAlgorithm should be empty`) {
+			return
+		}
+		if !assert.Empty(t, h.KeyEncryptionAlgorithm(), `KeyEncryptionAlgorithm should be empty`) {
+			return
+		}
+
+		if !assert.NoError(t, h.Set(jwk.AlgorithmKey, jwa.HS256), `Set for alg should succeed`) {
+			return
+		}
+		if !assert.Equal(t, jwa.HS256, h.SignatureAlgorithm(), `SignatureAlgorithm should match`) {
+			return
+		}
+		if !assert.Empty(t, h.KeyEncryptionAlgorithm(), `KeyEncryptionAlgorithm should be empty`) {
+			return
+		}
+
+		if !assert.NoError(t, h.Set(jwk.AlgorithmKey, jwa.A128KW), `Set for alg should succeed`) {
+			return
+		}
+		if !assert.Empty(t, h.SignatureAlgorithm(), `SignatureAlgorithm should be empty`) {
+			return
+		}
+		if !assert.Equal(t, jwa.A128KW, h.KeyEncryptionAlgorithm(), `KeyEncryptionAlgorithm should match`) {
+			return
+		}
+
+		if !assert.NoError(t, h.Set(jwk.KeyUsageKey, string(jwk.ForSignature)), `Set for use should succeed`) {
+			return
+		}
+		if !assert.Empty(t, h.KeyEncryptionAlgorithm(), `KeyEncryptionAlgorithm should be empty for "use":"sig"`) {
+			return
+		}
+	})
+	t.Run("Curve", func(t *testing.T) {
+		testcases := []struct {
+			name    string
+			key     jwk.Key
+			valid   []jwa.EllipticCurveAlgorithm
+			invalid []jwa.EllipticCurveAlgorithm
+		}{
+			{
+				name:    "ECDSA public key",
+				key:     jwk.NewECDSAPublicKey(),
+				valid:   []jwa.EllipticCurveAlgorithm{jwa.P256, jwa.P384, jwa.P521, jwa.Secp256k1},
+				invalid: []jwa.EllipticCurveAlgorithm{jwa.Ed25519, jwa.X25519, jwa.InvalidEllipticCurve},
+			},
+			{
+				name:    "ECDSA private key",
+				key:     jwk.NewECDSAPrivateKey(),
+				valid:   []jwa.EllipticCurveAlgorithm{jwa.P256},
+				invalid: []jwa.EllipticCurveAlgorithm{jwa.X448},
+			},
+			{
+				name:    "OKP public key",
+				key:     jwk.NewOKPPublicKey(),
+				valid:   []jwa.EllipticCurveAlgorithm{jwa.Ed25519, jwa.Ed448, jwa.X25519, jwa.X448},
+				invalid: []jwa.EllipticCurveAlgorithm{jwa.P256, jwa.Secp256k1},
+			},
+			{
+				name:    "OKP private key",
+				key:     jwk.NewOKPPrivateKey(),
+				valid:   []jwa.EllipticCurveAlgorithm{jwa.X25519},
+				invalid: []jwa.EllipticCurveAlgorithm{jwa.P521},
+			},
+			{
+				name:    "RSA public key",
+				key:     jwk.NewRSAPublicKey(),
+				invalid: []jwa.EllipticCurveAlgorithm{jwa.P256, jwa.Ed25519},
+			},
+			{
+				name:    "Symmetric key",
+				key:     jwk.NewSymmetricKey(),
+				invalid: []jwa.EllipticCurveAlgorithm{jwa.P256},
+			},
+		}
+		for _, tc := range testcases {
+			for _, crv := range tc.valid {
+				if !assert.NoError(t, tc.key.Set("crv", crv), `%s: Set for crv %s should succeed`, tc.name, crv) {
+					return
+				}
+			}
+			for _, crv := range tc.invalid {
+				if !assert.Error(t, tc.key.Set("crv", crv), `%s: Set for crv %s should fail`, tc.name, crv) {
+					return
+				}
+			}
+			if len(tc.valid) == 0 {
+				if _, ok := tc.key.Get("crv"); !assert.False(t, ok, `%s: crv should not be set`, tc.name) {
+					return
+				}
+			}
+		}
+	})
+	t.Run("Parse curve", func(t *testing.T) {
+		generateOKPPrivateKey := func() (jwk.Key, error) {
+			_, raw, err := ed25519.GenerateKey(rand.Reader)
+			if err != nil {
+				return nil, err
+			}
+			return jwk.New(raw)
+		}
+		testcases := []struct {
+			name     string
+			generate func() (jwk.Key, error)
+			crv      interface{}
+		}{
+			{name: "ECDSA public key", generate: generateECDSAPublicKey, crv: jwa.X25519},
+			{name: "ECDSA private key", generate: generateECDSAPrivateKey, crv: jwa.Ed25519},
+			{name: "OKP private key", generate: generateOKPPrivateKey, crv: jwa.P256},
+			{name: "RSA public key", generate: generateRSAPublicKey, crv: jwa.P256},
+			{name: "RSA private key", generate: generateRSAPrivateKey, crv: jwa.P256},
+			{name: "Symmetric key", generate: generateSymmetricKey, crv: jwa.P256},
+		}
+		for _, tc := range testcases {
+			key, err := tc.generate()
+			if !assert.NoError(t, err, `%s: key generation should succeed`, tc.name) {
+				return
+			}
+			buf, err := json.Marshal(key)
+			if !assert.NoError(t, err, `%s: json.Marshal should succeed`, tc.name) {
+				return
+			}
+			if _, err := jwk.ParseKey(buf); !assert.NoError(t, err, `%s: jwk.ParseKey should succeed`, tc.name) {
+				return
+			}
+
+			var m map[string]interface{}
+			if !assert.NoError(t, json.Unmarshal(buf, &m), `%s: json.Unmarshal should succeed`, tc.name) {
+				return
+			}
+			m["crv"] = tc.crv
+			buf, err = json.Marshal(m)
+			if !assert.NoError(t, err, `%s: json.Marshal should succeed`, tc.name) {
+				return
+			}
+			if _, err := jwk.ParseKey(buf); !assert.Error(t, err, `%s: jwk.ParseKey should fail for crv %s`, tc.name, tc.crv) {
+				return
+			}
+		}
 	})
 }
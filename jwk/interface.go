@@ -42,6 +42,10 @@ const (
 // JWK sets as opposed to single JWKs
 type Set struct {
 	Keys []Key `json:"keys"`
+
+	// privateParams holds the non-standard top-level members of the
+	// JWK Set, so that they are preserved when the set is re-encoded
+	privateParams map[string]interface{}
 }
 
 type HeaderVisitor = iter.MapVisitor
@@ -72,6 +72,40 @@ type Key interface {
 	// PrivateParams returns the non-standard elements in the source structure
 	PrivateParams() map[string]interface{}
 
+	// ValidateX509 verifies that the leaf certificate in the "x5c" field,
+	// if any, corresponds to the key. The "x5t" and "x5t#S256" fields,
+	// if present, must match the thumbprints of the leaf certificate, and
+	// the public key of the leaf certificate must be the same as the
+	// (public half of the) key itself
+	ValidateX509() error
+
+	// SetX509CertChain sets the "x5c" field to the given certificate
+	// chain, with the leaf certificate first. It is equivalent to calling
+	// Set(X509CertChainKey, chain)
+	SetX509CertChain([]*x509.Certificate) error
+
+	// Clone creates a new instance of the same type, and copies all
+	// of the fields of the source. The copy does not share any of its
+	// storage with the source, so either may be modified freely
+	Clone() (Key, error)
+
+	// CanPerform reports whether the key may be used for the given
+	// operation, according to its "key_ops" field. Keys without
+	// a "key_ops" field may be used for any operation
+	CanPerform(KeyOperation) bool
+
+	// SignatureAlgorithm returns the value of the "alg" field as a
+	// signature algorithm. An empty value is returned when the field is
+	// not set, when it is not a signature algorithm that can be used
+	// with the key type, or when the "use" field is "enc"
+	SignatureAlgorithm() jwa.SignatureAlgorithm
+
+	// KeyEncryptionAlgorithm returns the value of the "alg" field as a
+	// key encryption algorithm. An empty value is returned when the field
+	// is not set, when it is not a key encryption algorithm that can be
+	// used with the key type, or when the "use" field is "sig"
+	KeyEncryptionAlgorithm() jwa.KeyEncryptionAlgorithm
+
 	KeyType() jwa.KeyType
 	KeyUsage() string
 	KeyOps() KeyOperationList
@@ -1,7 +1,8 @@
 package main
 
 // This program generates all of the possible key types that we use
-// RSA public/private keys, ECDSA private/public keys, and symmetric keys
+// RSA public/private keys, ECDSA private/public keys, OKP private/public keys,
+// and symmetric keys
 //
 // Each share the same standard header section, but have their own
 // header fields
@@ -103,6 +104,42 @@ func fieldStorageTypeIsIndirect(s string) bool {
 	return s == "KeyOperationList" || !(strings.HasPrefix(s, `*`) || strings.HasPrefix(s, `[]`) || strings.HasSuffix(s, `List`))
 }
 
+// canonicalRanks lists the standard headers that are marshaled
+// right after "kty", in this order
+var canonicalRanks = map[string]int{
+	`alg`: 1,
+	`use`: 2,
+	`kid`: 3,
+}
+
+// canonicalOrder returns the fields in the order in which they
+// should be marshaled: "alg", "use", and "kid" first, followed by
+// the fields specific to the key type, followed by the rest of
+// the standard headers. "kty" is always marshaled first, and the
+// private parameters are always marshaled last.
+func canonicalOrder(fields []headerField) []headerField {
+	rank := func(f headerField) int {
+		if r, ok := canonicalRanks[f.key]; ok {
+			return r
+		}
+		if !f.isStd {
+			return 4
+		}
+		return 5
+	}
+
+	sorted := make([]headerField, len(fields))
+	copy(sorted, fields)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ri, rj := rank(sorted[i]), rank(sorted[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return sorted[i].key < sorted[j].key
+	})
+	return sorted
+}
+
 var standardHeaders []headerField
 
 func init() {
@@ -192,6 +229,16 @@ type headerType struct {
 	ifName     string
 }
 
+// hasCurve returns true if the key has a "crv" field
+func (ht headerType) hasCurve() bool {
+	for _, f := range ht.allHeaders {
+		if f.name == `crv` {
+			return true
+		}
+	}
+	return false
+}
+
 var keyTypes = []keyType{
 	{
 		filename: `rsa_gen.go`,
@@ -342,6 +389,58 @@ var keyTypes = []keyType{
 			},
 		},
 	},
+	{
+		filename: `okp_gen.go`,
+		prefix:   `OKP`,
+		keyType:  `jwa.OKP`,
+		headerTypes: []headerType{
+			{
+				name:       `PublicKey`,
+				rawKeyType: `interface{}`,
+				headers: []headerField{
+					{
+						name:   `x`,
+						method: `X`,
+						typ:    `[]byte`,
+						key:    `x`,
+					},
+					{
+						name:   `crv`,
+						method: `Crv`,
+						typ:    `jwa.EllipticCurveAlgorithm`,
+						key:    `crv`,
+					},
+				},
+			},
+			{
+				name:       `PrivateKey`,
+				rawKeyType: `interface{}`,
+				ifMethods: []string{
+					`PublicKey() (OKPPublicKey, error)`,
+				},
+				headers: []headerField{
+					{
+						name:   `x`,
+						method: `X`,
+						typ:    `[]byte`,
+						key:    `x`,
+					},
+					{
+						name:   `d`,
+						method: `D`,
+						typ:    `[]byte`,
+						key:    `d`,
+					},
+					{
+						name:   `crv`,
+						method: `Crv`,
+						typ:    `jwa.EllipticCurveAlgorithm`,
+						key:    `crv`,
+					},
+				},
+			},
+		},
+	},
 	{
 		filename: `symmetric_gen.go`,
 		prefix:   `Symmetric`,
@@ -352,6 +451,9 @@ var keyTypes = []keyType{
 				structName: `symmetricKey`,
 				ifName:     `SymmetricKey`,
 				rawKeyType: `[]byte`,
+				ifMethods: []string{
+					`PublicKey() (Key, error)`,
+				},
 				headers: []headerField{
 					{
 						name:   `octets`,
@@ -428,6 +530,37 @@ func generateGenericHeaders() error {
 	fmt.Fprintf(&buf, "\nAsMap(context.Context) (map[string]interface{}, error)")
 	fmt.Fprintf(&buf, "\n\n// PrivateParams returns the non-standard elements in the source structure")
 	fmt.Fprintf(&buf, "\nPrivateParams() map[string]interface{}")
+	fmt.Fprintf(&buf, "\n\n// ValidateX509 verifies that the leaf certificate in the \"x5c\" field,")
+	fmt.Fprintf(&buf, "\n// if any, corresponds to the key. The \"x5t\" and \"x5t#S256\" fields,")
+	fmt.Fprintf(&buf, "\n// if present, must match the thumbprints of the leaf certificate, and")
+	fmt.Fprintf(&buf, "\n// the public key of the leaf certificate must be the same as the")
+	fmt.Fprintf(&buf, "\n// (public half of the) key itself")
+	fmt.Fprintf(&buf, "\nValidateX509() error")
+	fmt.Fprintf(&buf, "\n\n// SetX509CertChain sets the \"x5c\" field to the given certificate")
+	fmt.Fprintf(&buf, "\n// chain, with the leaf certificate first. It is equivalent to calling")
+	fmt.Fprintf(&buf, "\n// Set(X509CertChainKey, chain)")
+	fmt.Fprintf(&buf, "\nSetX509CertChain([]*x509.Certificate) error")
+
+	fmt.Fprintf(&buf, "\n\n// Clone creates a new instance of the same type, and copies all")
+	fmt.Fprintf(&buf, "\n// of the fields of the source. The copy does not share any of its")
+	fmt.Fprintf(&buf, "\n// storage with the source, so either may be modified freely")
+	fmt.Fprintf(&buf, "\nClone() (Key, error)")
+
+	fmt.Fprintf(&buf, "\n\n// CanPerform reports whether the key may be used for the given")
+	fmt.Fprintf(&buf, "\n// operation, according to its \"key_ops\" field. Keys without")
+	fmt.Fprintf(&buf, "\n// a \"key_ops\" field may be used for any operation")
+	fmt.Fprintf(&buf, "\nCanPerform(KeyOperation) bool")
+
+	fmt.Fprintf(&buf, "\n\n// SignatureAlgorithm returns the value of the \"alg\" field as a")
+	fmt.Fprintf(&buf, "\n// signature algorithm. An empty value is returned when the field is")
+	fmt.Fprintf(&buf, "\n// not set, when it is not a signature algorithm that can be used")
+	fmt.Fprintf(&buf, "\n// with the key type, or when the \"use\" field is \"enc\"")
+	fmt.Fprintf(&buf, "\nSignatureAlgorithm() jwa.SignatureAlgorithm")
+	fmt.Fprintf(&buf, "\n\n// KeyEncryptionAlgorithm returns the value of the \"alg\" field as a")
+	fmt.Fprintf(&buf, "\n// key encryption algorithm. An empty value is returned when the field")
+	fmt.Fprintf(&buf, "\n// is not set, when it is not a key encryption algorithm that can be")
+	fmt.Fprintf(&buf, "\n// used with the key type, or when the \"use\" field is \"sig\"")
+	fmt.Fprintf(&buf, "\nKeyEncryptionAlgorithm() jwa.KeyEncryptionAlgorithm")
 	fmt.Fprintf(&buf, "\n\nKeyType() jwa.KeyType")
 	for _, f := range standardHeaders {
 		fmt.Fprintf(&buf, "\n%s() ", f.method)
@@ -540,10 +673,11 @@ func generateHeader(kt keyType) error {
 		fmt.Fprintf(&buf, "\nprivateParams map[string]interface{}")
 		fmt.Fprintf(&buf, "\n}")
 
-		// Proxy is used when unmarshaling headers
+		// Proxy is used when unmarshaling headers. The order of its fields
+		// determines the order in which the fields are marshaled
 		fmt.Fprintf(&buf, "\n\ntype %s%sMarshalProxy struct {", strings.ToLower(kt.prefix), ht.name)
 		fmt.Fprintf(&buf, "\nXkeyType jwa.KeyType `json:\"kty\"`")
-		for _, f := range ht.allHeaders {
+		for _, f := range canonicalOrder(ht.allHeaders) {
 			switch f.typ {
 			case byteSliceType:
 				// XXX encoding/json uses base64.StdEncoding, which require padding
@@ -670,6 +804,18 @@ func generateHeader(kt keyType) error {
 				fmt.Fprintf(&buf, "\nswitch v := value.(type) {")
 				fmt.Fprintf(&buf, "\ncase string:")
 				fmt.Fprintf(&buf, "\nh.algorithm = &v")
+				fmt.Fprintf(&buf, "\ncase jwa.SignatureAlgorithm:")
+				fmt.Fprintf(&buf, "\nif !isSignatureAlgorithm(h.KeyType(), v.String()) {")
+				fmt.Fprintf(&buf, "\nreturn errors.Errorf(`algorithm %%q cannot be used with key type %%q`, v, h.KeyType())")
+				fmt.Fprintf(&buf, "\n}")
+				fmt.Fprintf(&buf, "\ntmp := v.String()")
+				fmt.Fprintf(&buf, "\nh.algorithm = &tmp")
+				fmt.Fprintf(&buf, "\ncase jwa.KeyEncryptionAlgorithm:")
+				fmt.Fprintf(&buf, "\nif !isKeyEncryptionAlgorithm(h.KeyType(), v.String()) {")
+				fmt.Fprintf(&buf, "\nreturn errors.Errorf(`algorithm %%q cannot be used with key type %%q`, v, h.KeyType())")
+				fmt.Fprintf(&buf, "\n}")
+				fmt.Fprintf(&buf, "\ntmp := v.String()")
+				fmt.Fprintf(&buf, "\nh.algorithm = &tmp")
 				fmt.Fprintf(&buf, "\ncase fmt.Stringer:")
 				fmt.Fprintf(&buf, "\ntmp := v.String()")
 				fmt.Fprintf(&buf, "\nh.algorithm = &tmp")
@@ -677,6 +823,15 @@ func generateHeader(kt keyType) error {
 				fmt.Fprintf(&buf, "\nreturn errors.Errorf(`invalid type for %%s key: %%T`, %s, value)", keyName)
 				fmt.Fprintf(&buf, "\n}")
 				fmt.Fprintf(&buf, "\nreturn nil")
+			} else if f.name == `crv` {
+				fmt.Fprintf(&buf, "\nif v, ok := value.(%s); ok {", f.typ)
+				fmt.Fprintf(&buf, "\nif !isValidCurve(h.KeyType(), v) {")
+				fmt.Fprintf(&buf, "\nreturn errors.Errorf(`curve %%q cannot be used with key type %%q`, v, h.KeyType())")
+				fmt.Fprintf(&buf, "\n}")
+				fmt.Fprintf(&buf, "\nh.%s = &v", f.name)
+				fmt.Fprintf(&buf, "\nreturn nil")
+				fmt.Fprintf(&buf, "\n}") // end if v, ok := value.(%s)
+				fmt.Fprintf(&buf, "\nreturn errors.Errorf(`invalid value for %%s key: %%T`, %s, value)", keyName)
 			} else if f.hasAccept {
 				fmt.Fprintf(&buf, "\nvar acceptor %s", f.typ)
 				fmt.Fprintf(&buf, "\nif err := acceptor.Accept(value); err != nil {")
@@ -700,6 +855,11 @@ func generateHeader(kt keyType) error {
 				fmt.Fprintf(&buf, "\nreturn errors.Errorf(`invalid value for %%s key: %%T`, %s, value)", keyName)
 			}
 		}
+		if !ht.hasCurve() {
+			// Only EC and OKP keys have a curve
+			fmt.Fprintf(&buf, "\ncase \"crv\":")
+			fmt.Fprintf(&buf, "\nreturn errors.Errorf(`\"crv\" cannot be used with key type %%q`, h.KeyType())")
+		}
 		fmt.Fprintf(&buf, "\ndefault:")
 		fmt.Fprintf(&buf, "\nif h.privateParams == nil {")
 		fmt.Fprintf(&buf, "\nh.privateParams = map[string]interface{}{}")
@@ -741,6 +901,11 @@ func generateHeader(kt keyType) error {
 				fmt.Fprintf(&buf, "\n}")
 			default:
 				fmt.Fprintf(&buf, "\nh.%[1]s = proxy.X%[1]s", f.name)
+				if f.name == `crv` {
+					fmt.Fprintf(&buf, "\nif h.%[1]s != nil && !isValidCurve(%[2]s, *(h.%[1]s)) {", f.name, kt.keyType)
+					fmt.Fprintf(&buf, "\nreturn errors.Errorf(`curve %%q cannot be used with key type %%q`, *(h.%s), %s)", f.name, kt.keyType)
+					fmt.Fprintf(&buf, "\n}")
+				}
 			}
 		}
 
@@ -761,6 +926,12 @@ func generateHeader(kt keyType) error {
 			}
 			fmt.Fprintf(&buf, "\ndelete(m, %s)", keyName)
 		}
+		if !ht.hasCurve() {
+			// Only EC and OKP keys have a curve
+			fmt.Fprintf(&buf, "\nif _, ok := m[\"crv\"]; ok {")
+			fmt.Fprintf(&buf, "\nreturn errors.Errorf(`\"crv\" cannot be used with key type %%q`, %s)", kt.keyType)
+			fmt.Fprintf(&buf, "\n}")
+		}
 
 		fmt.Fprintf(&buf, "\nh.privateParams = m")
 		fmt.Fprintf(&buf, "\nreturn nil")
@@ -830,6 +1001,57 @@ func generateHeader(kt keyType) error {
 		fmt.Fprintf(&buf, "\n\nfunc (h *%s) AsMap(ctx context.Context) (map[string]interface{}, error) {", structName)
 		fmt.Fprintf(&buf, "\nreturn iter.AsMap(ctx, h)")
 		fmt.Fprintf(&buf, "\n}")
+
+		fmt.Fprintf(&buf, "\n\nfunc (h *%s) ValidateX509() error {", structName)
+		fmt.Fprintf(&buf, "\nreturn validateX509(h)")
+		fmt.Fprintf(&buf, "\n}")
+
+		fmt.Fprintf(&buf, "\n\nfunc (h *%s) SetX509CertChain(chain []*x509.Certificate) error {", structName)
+		fmt.Fprintf(&buf, "\nreturn h.Set(X509CertChainKey, chain)")
+		fmt.Fprintf(&buf, "\n}")
+
+		fmt.Fprintf(&buf, "\n\nfunc (h *%s) CanPerform(op KeyOperation) bool {", structName)
+		fmt.Fprintf(&buf, "\nreturn h.keyops.canPerform(op)")
+		fmt.Fprintf(&buf, "\n}")
+
+		fmt.Fprintf(&buf, "\n\nfunc (h *%s) SignatureAlgorithm() jwa.SignatureAlgorithm {", structName)
+		fmt.Fprintf(&buf, "\nreturn signatureAlgorithmOf(h)")
+		fmt.Fprintf(&buf, "\n}")
+
+		fmt.Fprintf(&buf, "\n\nfunc (h *%s) KeyEncryptionAlgorithm() jwa.KeyEncryptionAlgorithm {", structName)
+		fmt.Fprintf(&buf, "\nreturn keyEncryptionAlgorithmOf(h)")
+		fmt.Fprintf(&buf, "\n}")
+
+		fmt.Fprintf(&buf, "\n\nfunc (h *%s) Clone() (Key, error) {", structName)
+		fmt.Fprintf(&buf, "\ndst := &%s{}", structName)
+		for _, f := range ht.allHeaders {
+			fmt.Fprintf(&buf, "\nif h.%s != nil {", f.name)
+			switch f.typ {
+			case byteSliceType:
+				fmt.Fprintf(&buf, "\ndst.%s = make([]byte, len(h.%s))", f.name, f.name)
+				fmt.Fprintf(&buf, "\ncopy(dst.%s, h.%s)", f.name, f.name)
+			case "KeyOperationList":
+				fmt.Fprintf(&buf, "\nv := make(KeyOperationList, len(*h.%s))", f.name)
+				fmt.Fprintf(&buf, "\ncopy(v, *h.%s)", f.name)
+				fmt.Fprintf(&buf, "\ndst.%s = &v", f.name)
+			case "CertificateChain":
+				fmt.Fprintf(&buf, "\nv := CertificateChain{certs: make([]*x509.Certificate, len(h.%s.certs))}", f.name)
+				fmt.Fprintf(&buf, "\ncopy(v.certs, h.%s.certs)", f.name)
+				fmt.Fprintf(&buf, "\ndst.%s = &v", f.name)
+			default:
+				fmt.Fprintf(&buf, "\nv := *h.%s", f.name)
+				fmt.Fprintf(&buf, "\ndst.%s = &v", f.name)
+			}
+			fmt.Fprintf(&buf, "\n}")
+		}
+		fmt.Fprintf(&buf, "\nif h.privateParams != nil {")
+		fmt.Fprintf(&buf, "\ndst.privateParams = make(map[string]interface{}, len(h.privateParams))")
+		fmt.Fprintf(&buf, "\nfor k, v := range h.privateParams {")
+		fmt.Fprintf(&buf, "\ndst.privateParams[k] = cloneParam(v)")
+		fmt.Fprintf(&buf, "\n}")
+		fmt.Fprintf(&buf, "\n}")
+		fmt.Fprintf(&buf, "\nreturn dst, nil")
+		fmt.Fprintf(&buf, "\n}")
 	}
 
 	return codegen.WriteFormattedCodeToFile(kt.filename, &buf)
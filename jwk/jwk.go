@@ -8,30 +8,41 @@ import (
 	"context"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
+	"crypto/subtle"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/lestrrat-go/iter/arrayiter"
 	"github.com/lestrrat-go/jwx/internal/base64"
 	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/x25519"
 	"github.com/pkg/errors"
 )
 
-// New creates a jwk.Key from the given key (RSA/ECDSA/symmetric keys).
+// New creates a jwk.Key from the given key (RSA/ECDSA/OKP/symmetric keys).
 //
 // The constructor auto-detects the type of key to be instantiated
 // based on the input type:
 //
 // * "crypto/rsa".PrivateKey and "crypto/rsa".PublicKey creates an RSA based key
 // * "crypto/ecdsa".PrivateKey and "crypto/ecdsa".PublicKey creates an EC based key
+// * "crypto/ed25519".PrivateKey and "crypto/ed25519".PublicKey creates an OKP based key
+// * "github.com/lestrrat-go/jwx/x25519".PrivateKey and "github.com/lestrrat-go/jwx/x25519".PublicKey creates an OKP based key
 // * []byte creates a symmetric key
+// * "crypto/x509".Certificate creates a public key from the certificate's
+//   public key, with the "x5c", "x5t" and "x5t#S256" fields populated
 func New(key interface{}) (Key, error) {
 	if key == nil {
 		return nil, errors.New(`jwk.New requires a non-nil key`)
@@ -47,6 +58,8 @@ func New(key interface{}) (Key, error) {
 		ptr = &v
 	case ecdsa.PublicKey:
 		ptr = &v
+	case x509.Certificate:
+		ptr = &v
 	default:
 		ptr = v
 	}
@@ -76,12 +89,26 @@ func New(key interface{}) (Key, error) {
 			return nil, errors.Wrapf(err, `failed to initialize %T from %T`, k, rawKey)
 		}
 		return k, nil
+	case ed25519.PrivateKey, x25519.PrivateKey:
+		k := NewOKPPrivateKey()
+		if err := k.FromRaw(rawKey); err != nil {
+			return nil, errors.Wrapf(err, `failed to initialize %T from %T`, k, rawKey)
+		}
+		return k, nil
+	case ed25519.PublicKey, x25519.PublicKey:
+		k := NewOKPPublicKey()
+		if err := k.FromRaw(rawKey); err != nil {
+			return nil, errors.Wrapf(err, `failed to initialize %T from %T`, k, rawKey)
+		}
+		return k, nil
 	case []byte:
 		k := NewSymmetricKey()
 		if err := k.FromRaw(rawKey); err != nil {
 			return nil, errors.Wrapf(err, `failed to initialize %T from %T`, k, rawKey)
 		}
 		return k, nil
+	case *x509.Certificate:
+		return FromCertificate(rawKey)
 	default:
 		return nil, errors.Errorf(`invalid key type '%T' for jwk.New`, key)
 	}
@@ -132,8 +159,199 @@ func PublicKeyOf(v interface{}) (interface{}, error) {
 	}
 }
 
-// Fetch fetches a JWK resource specified by a URL
+// PublicSetOf returns a new Set containing the public counterparts of
+// the keys in the given Set, in the same order. Private keys are
+// converted to public keys using their `PublicKey()` method, and
+// public keys are included as is. The top-level members of the Set
+// other than "keys" are carried over as well.
+//
+// Symmetric keys have no public counterpart, and are therefore dropped
+// from the result. If the `jwk.WithRejectSymmetricKeys(true)` option
+// is given, an error is returned instead.
+//
+// This is useful for publishing a JWK Set without leaking any of the
+// private key material
+func PublicSetOf(set *Set, options ...Option) (*Set, error) {
+	var reject bool
+	for _, option := range options {
+		switch option.Name() {
+		case optkeyRejectSymmetricKeys:
+			reject = option.Value().(bool)
+		}
+	}
+
+	result := &Set{
+		Keys: make([]Key, 0, len(set.Keys)),
+	}
+	if len(set.privateParams) > 0 {
+		result.privateParams = make(map[string]interface{}, len(set.privateParams))
+		for name, v := range set.privateParams {
+			result.privateParams[name] = v
+		}
+	}
+
+	for i, key := range set.Keys {
+		var pubkey Key
+		var err error
+		switch key := key.(type) {
+		case RSAPrivateKey:
+			pubkey, err = key.PublicKey()
+		case ECDSAPrivateKey:
+			pubkey, err = key.PublicKey()
+		case OKPPrivateKey:
+			pubkey, err = key.PublicKey()
+		case SymmetricKey:
+			if reject {
+				return nil, errors.Errorf(`key #%d is a symmetric key, which cannot be made public`, i+1)
+			}
+			continue
+		default:
+			pubkey = key
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, `failed to create public key from key #%d`, i+1)
+		}
+		result.Keys = append(result.Keys, pubkey)
+	}
+	return result, nil
+}
+
+// PublicKeyEqual returns true if the two keys represent the same
+// public key. The keys are materialized, and their public key
+// parameters are compared: the modulus and the exponent for RSA keys,
+// the curve and the coordinates for EC keys, and the raw bytes for OKP
+// keys. A private key is considered equal to its public counterpart.
+// Symmetric keys are compared by their octets.
+//
+// Metadata such as "kid", "alg" and "use" is not taken into account
+func PublicKeyEqual(a, b Key) (bool, error) {
+	var rawa, rawb interface{}
+	if err := a.Raw(&rawa); err != nil {
+		return false, errors.Wrap(err, `failed to materialize first key`)
+	}
+	if err := b.Raw(&rawb); err != nil {
+		return false, errors.Wrap(err, `failed to materialize second key`)
+	}
+
+	octetsa, ok := rawa.([]byte)
+	if ok {
+		octetsb, ok := rawb.([]byte)
+		if !ok {
+			return false, nil
+		}
+		return subtle.ConstantTimeCompare(octetsa, octetsb) == 1, nil
+	}
+	if _, ok := rawb.([]byte); ok {
+		return false, nil
+	}
+
+	puba, err := rawPublicKeyOf(rawa)
+	if err != nil {
+		return false, errors.Wrap(err, `failed to get public key of first key`)
+	}
+	pubb, err := rawPublicKeyOf(rawb)
+	if err != nil {
+		return false, errors.Wrap(err, `failed to get public key of second key`)
+	}
+	return rawPublicKeyEqual(puba, pubb), nil
+}
+
+// MergeSets returns a new Set containing the keys of all of the given
+// Sets, in order. Keys that represent the same public key as a key that
+// appears earlier, as determined by `jwk.PublicKeyEqual`, are dropped, so
+// the metadata (e.g. "kid") of the first occurrence is the one that is
+// kept. The top-level members of the Sets other than "keys" are merged
+// in the same way, with the first occurrence of each member winning.
+//
+// The keys themselves are not copied, so they are shared between the
+// result and the source Sets. nil Sets are ignored
+func MergeSets(sets ...*Set) (*Set, error) {
+	result := &Set{}
+	for i, set := range sets {
+		if set == nil {
+			continue
+		}
+
+	KEYS:
+		for j, key := range set.Keys {
+			for _, existing := range result.Keys {
+				equal, err := PublicKeyEqual(existing, key)
+				if err != nil {
+					return nil, errors.Wrapf(err, `failed to compare key #%d of set #%d`, j+1, i+1)
+				}
+				if equal {
+					continue KEYS
+				}
+			}
+			result.Keys = append(result.Keys, key)
+		}
+
+		for name, v := range set.privateParams {
+			if _, ok := result.privateParams[name]; ok {
+				continue
+			}
+			if result.privateParams == nil {
+				result.privateParams = make(map[string]interface{})
+			}
+			result.privateParams[name] = v
+		}
+	}
+	return result, nil
+}
+
+// copyPublicKeyFields copies the fields that describe the key, and
+// are therefore also relevant to its public half, from src to dst
+func copyPublicKeyFields(dst, src Key) error {
+	for _, name := range []string{KeyIDKey, AlgorithmKey, KeyUsageKey} {
+		v, ok := src.Get(name)
+		if !ok {
+			continue
+		}
+		if err := dst.Set(name, v); err != nil {
+			return errors.Wrapf(err, `failed to set %s`, name)
+		}
+	}
+	return nil
+}
+
+// cloneParam returns a deep copy of the value of a private parameter.
+// Maps and slices, such as the ones created when parsing JSON, are
+// copied recursively. Other values are copied as is
+func cloneParam(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		dst := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			dst[key] = cloneParam(value)
+		}
+		return dst
+	case []interface{}:
+		dst := make([]interface{}, len(v))
+		for i, value := range v {
+			dst[i] = cloneParam(value)
+		}
+		return dst
+	case []string:
+		dst := make([]string, len(v))
+		copy(dst, v)
+		return dst
+	case []byte:
+		dst := make([]byte, len(v))
+		copy(dst, v)
+		return dst
+	default:
+		return v
+	}
+}
+
+// Fetch wraps FetchWithContext using the background context.
 func Fetch(urlstring string, options ...Option) (*Set, error) {
+	return FetchWithContext(context.Background(), urlstring, options...)
+}
+
+// FetchWithContext fetches a JWK resource specified by a URL. For
+// remote resources, the request is aborted when the context is canceled
+func FetchWithContext(ctx context.Context, urlstring string, options ...Option) (*Set, error) {
 	u, err := url.Parse(urlstring)
 	if err != nil {
 		return nil, errors.Wrap(err, `failed to parse url`)
@@ -141,7 +359,7 @@ func Fetch(urlstring string, options ...Option) (*Set, error) {
 
 	switch u.Scheme {
 	case "http", "https":
-		return FetchHTTP(urlstring, options...)
+		return FetchHTTPWithContext(ctx, urlstring, options...)
 	case "file":
 		f, err := os.Open(u.Path)
 		if err != nil {
@@ -162,21 +380,24 @@ func FetchHTTP(jwkurl string, options ...Option) (*Set, error) {
 // FetchHTTPWithContext fetches the remote JWK and parses its contents
 func FetchHTTPWithContext(ctx context.Context, jwkurl string, options ...Option) (*Set, error) {
 	httpcl := http.DefaultClient
+	var maxBodySize int64 = defaultHTTPMaxBodySize
 	for _, option := range options {
 		switch option.Name() {
 		case optkeyHTTPClient:
 			httpcl = option.Value().(*http.Client)
+		case optkeyHTTPMaxBodySize:
+			maxBodySize = option.Value().(int64)
 		}
 	}
 
-	req, err := http.NewRequest(http.MethodGet, jwkurl, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwkurl, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to new request to remote JWK")
 	}
 
-	res, err := httpcl.Do(req.WithContext(ctx))
+	res, err := httpcl.Do(req)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to fetch remote JWK")
+		return nil, wrapFetchError(ctx, err, jwkurl, "failed to fetch remote JWK")
 	}
 	defer res.Body.Close()
 
@@ -184,10 +405,59 @@ func FetchHTTPWithContext(ctx context.Context, jwkurl string, options ...Option)
 		return nil, fmt.Errorf("failed to fetch remote JWK (status = %d)", res.StatusCode)
 	}
 
-	return Parse(res.Body)
+	set, err := parseHTTPBody(res.Body, maxBodySize)
+	if err != nil {
+		return nil, wrapFetchError(ctx, err, jwkurl, "failed to parse remote JWK")
+	}
+	return set, nil
 }
 
-func ParseKey(data []byte) (Key, error) {
+const defaultHTTPMaxBodySize = 10 * 1024 * 1024
+
+// parseHTTPBody parses the response body of a remote JWK, making
+// sure that it does not exceed maxBodySize bytes
+func parseHTTPBody(body io.Reader, maxBodySize int64) (*Set, error) {
+	if maxBodySize <= 0 {
+		return Parse(body)
+	}
+
+	buf, err := ioutil.ReadAll(io.LimitReader(body, maxBodySize+1))
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to read response body`)
+	}
+	if int64(len(buf)) > maxBodySize {
+		return nil, errors.Errorf(`response body exceeds maximum size of %d bytes`, maxBodySize)
+	}
+	return ParseBytes(buf)
+}
+
+// wrapFetchError wraps errors that occurred while fetching a remote
+// JWK. If the context was canceled or its deadline exceeded, ctx.Err()
+// is returned (wrapped with the url) instead of the error reported by
+// net/http, so that callers can check for it using errors.Is
+func wrapFetchError(ctx context.Context, err error, jwkurl, msg string) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return errors.Wrapf(ctxErr, `failed to fetch %s`, jwkurl)
+	}
+	return errors.Wrap(err, msg)
+}
+
+// ParseKey parses a single key from the JSON encoded data. If the
+// `jwk.WithPEM(true)` option is given, data is parsed as PEM instead,
+// and it is an error for it to contain more than one key. Use
+// `jwk.Parse` (and its variants) for PEM data containing multiple keys
+func ParseKey(data []byte, options ...Option) (Key, error) {
+	if isPEM(options) {
+		keys, err := parsePEM(data)
+		if err != nil {
+			return nil, errors.Wrap(err, `failed to parse PEM encoded key`)
+		}
+		if len(keys) != 1 {
+			return nil, errors.Errorf(`expected exactly one key in PEM encoded data (got %d)`, len(keys))
+		}
+		return keys[0], nil
+	}
+
 	var hint struct {
 		Kty string          `json:"kty"`
 		D   json.RawMessage `json:"d"`
@@ -211,6 +481,12 @@ func ParseKey(data []byte) (Key, error) {
 		} else {
 			key = newECDSAPublicKey()
 		}
+	case jwa.OKP:
+		if len(hint.D) > 0 {
+			key = newOKPPrivateKey()
+		} else {
+			key = newOKPPublicKey()
+		}
 	case jwa.OctetSeq:
 		key = newSymmetricKey()
 	default:
@@ -221,66 +497,205 @@ func ParseKey(data []byte) (Key, error) {
 		return nil, errors.Wrapf(err, `failed to unmarshal JSON into key (%T)`, key)
 	}
 
+	if isStrict(options) {
+		if err := validateStrict(key); err != nil {
+			return nil, errors.Wrap(err, `failed to validate key`)
+		}
+	}
+
+	if isValidate(options) {
+		if err := validateKeyParameters(key); err != nil {
+			return nil, errors.Wrap(err, `failed to validate key`)
+		}
+	}
+
 	return key, nil
 }
 
+// UnmarshalJSON parses either a JWK Set (a JSON object with a "keys"
+// array) or a single JWK (a JSON object with a "kty" field). In the
+// latter case, the Set ends up containing just the one key.
 func (s *Set) UnmarshalJSON(data []byte) error {
-	var proxy struct {
-		Keys []json.RawMessage `json:"keys"`
-	}
-
+	var proxy map[string]json.RawMessage
 	if err := json.Unmarshal(data, &proxy); err != nil {
-		return errors.Wrap(err, `failed to unmarshal into Key (proxy)`)
+		return errors.Wrap(err, `failed to unmarshal JWK or JWK Set: expected a JSON object`)
 	}
 
-	if len(proxy.Keys) == 0 {
+	rawkeys, ok := proxy["keys"]
+	if !ok {
+		if _, ok := proxy["kty"]; !ok {
+			return errors.New(`failed to unmarshal JWK or JWK Set: neither "keys" nor "kty" field found`)
+		}
+
 		k, err := ParseKey(data)
 		if err != nil {
 			return errors.Wrap(err, `failed to unmarshal key from JSON headers`)
 		}
 		s.Keys = append(s.Keys, k)
-	} else {
-		for i, buf := range proxy.Keys {
-			k, err := ParseKey([]byte(buf))
-			if err != nil {
-				return errors.Wrapf(err, `failed to unmarshal key #%d (total %d) from multi-key JWK set`, i+1, len(proxy.Keys))
-			}
-			s.Keys = append(s.Keys, k)
+		return nil
+	}
+
+	var keys []json.RawMessage
+	if err := json.Unmarshal(rawkeys, &keys); err != nil {
+		return errors.Wrap(err, `failed to unmarshal JWK Set: "keys" field must be an array of keys`)
+	}
+
+	for i, buf := range keys {
+		k, err := ParseKey([]byte(buf))
+		if err != nil {
+			return errors.Wrapf(err, `failed to unmarshal key #%d (total %d) from multi-key JWK set`, i+1, len(keys))
 		}
+		s.Keys = append(s.Keys, k)
+	}
+
+	for name, raw := range proxy {
+		if name == "keys" {
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return errors.Wrapf(err, `failed to unmarshal JWK Set member %q`, name)
+		}
+		if s.privateParams == nil {
+			s.privateParams = make(map[string]interface{})
+		}
+		s.privateParams[name] = v
 	}
 	return nil
 }
 
+// MarshalJSON encodes the Set as a JWK Set: a JSON object whose "keys"
+// field is an array of keys. This is true even when the Set contains
+// one key or none at all. Non-standard top-level members that the Set
+// was parsed with are emitted after the "keys" field.
+func (s Set) MarshalJSON() ([]byte, error) {
+	keys := s.Keys
+	if keys == nil {
+		keys = []Key{}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`{"keys":`)
+	enc := json.NewEncoder(&buf)
+	if err := enc.Encode(keys); err != nil {
+		return nil, errors.Wrap(err, `failed to encode keys`)
+	}
+	buf.Truncate(buf.Len() - 1) // encoding/json always adds a newline
+
+	names := make([]string, 0, len(s.privateParams))
+	for name := range s.privateParams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&buf, `,%s:`, strconv.Quote(name))
+		if err := enc.Encode(s.privateParams[name]); err != nil {
+			return nil, errors.Wrapf(err, `failed to encode JWK Set member %q`, name)
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
 // Parse parses JWK from the incoming io.Reader. This function can handle
-// both single-key and multi-key formats. If you know before hand which
-// format the incoming data is in, you might want to consider using
-// "encoding/json" directly
+// both single-key and multi-key formats: if the top level JSON object
+// contains a "keys" field, it is parsed as a JWK Set. Otherwise it is
+// parsed as a single key, and the returned Set contains only that key.
+// If you know before hand which format the incoming data is in, you
+// might want to consider using "encoding/json" directly
+//
+// If the `jwk.WithPEM(true)` option is given, the input is parsed as
+// PEM instead, and each PEM block becomes a key in the Set.
 //
 // Note that a successful parsing does NOT guarantee a valid key
-func Parse(in io.Reader) (*Set, error) {
+func Parse(in io.Reader, options ...Option) (*Set, error) {
+	if isPEM(options) {
+		buf, err := ioutil.ReadAll(in)
+		if err != nil {
+			return nil, errors.Wrap(err, `failed to read PEM encoded data`)
+		}
+
+		keys, err := parsePEM(buf)
+		if err != nil {
+			return nil, errors.Wrap(err, `failed to parse PEM encoded keys`)
+		}
+		return &Set{Keys: keys}, nil
+	}
+
 	var s Set
 	if err := json.NewDecoder(in).Decode(&s); err != nil {
 		return nil, errors.Wrap(err, "failed to unmarshal JWK")
 	}
+
+	if isStrict(options) {
+		for i, key := range s.Keys {
+			if err := validateStrict(key); err != nil {
+				return nil, errors.Wrapf(err, `failed to validate key #%d`, i+1)
+			}
+		}
+	}
+	if isValidate(options) {
+		for i, key := range s.Keys {
+			if err := validateKeyParameters(key); err != nil {
+				return nil, errors.Wrapf(err, `failed to validate key #%d`, i+1)
+			}
+		}
+	}
 	return &s, nil
 }
 
 // ParseBytes parses JWK from the incoming byte buffer.
 //
 // Note that a successful parsing does NOT guarantee a valid key
-func ParseBytes(buf []byte) (*Set, error) {
-	return Parse(bytes.NewReader(buf))
+func ParseBytes(buf []byte, options ...Option) (*Set, error) {
+	return Parse(bytes.NewReader(buf), options...)
 }
 
 // ParseString parses JWK from the incoming string.
 //
 // Note that a successful parsing does NOT guarantee a valid key
-func ParseString(s string) (*Set, error) {
-	return Parse(strings.NewReader(s))
+func ParseString(s string, options ...Option) (*Set, error) {
+	return Parse(strings.NewReader(s), options...)
+}
+
+func isPEM(options []Option) bool {
+	var usePEM bool
+	for _, option := range options {
+		switch option.Name() {
+		case optkeyPEM:
+			usePEM = option.Value().(bool)
+		}
+	}
+	return usePEM
+}
+
+func isStrict(options []Option) bool {
+	var strict bool
+	for _, option := range options {
+		switch option.Name() {
+		case optkeyStrictValidation:
+			strict = option.Value().(bool)
+		}
+	}
+	return strict
+}
+
+func isValidate(options []Option) bool {
+	var validate bool
+	for _, option := range options {
+		switch option.Name() {
+		case optkeyValidate:
+			validate = option.Value().(bool)
+		}
+	}
+	return validate
 }
 
 // LookupKeyID looks for keys matching the given key id. Note that the
-// Set *may* contain multiple keys with the same key id
+// Set *may* contain multiple keys with the same key id (e.g. during
+// key rotation), in which case all of them are returned in the order
+// that they appear in the Set, so that the caller can try each candidate
 func (s Set) LookupKeyID(kid string) []Key {
 	var keys []Key
 	for iter := s.Iterate(context.TODO()); iter.Next(context.TODO()); {
@@ -293,10 +708,106 @@ func (s Set) LookupKeyID(kid string) []Key {
 	return keys
 }
 
+// Filter returns a new Set containing the keys for which `match` returns
+// true, in the order that they appear in the Set. The keys themselves
+// are not copied, so they are shared between the two Sets. The top-level
+// members of the Set other than "keys" are carried over as well
+func (s *Set) Filter(match func(Key) bool) *Set {
+	var result Set
+	for _, key := range s.Keys {
+		if match(key) {
+			result.Keys = append(result.Keys, key)
+		}
+	}
+	if len(s.privateParams) > 0 {
+		result.privateParams = make(map[string]interface{}, len(s.privateParams))
+		for name, v := range s.privateParams {
+			result.privateParams[name] = v
+		}
+	}
+	return &result
+}
+
+// FilterUse returns a new Set containing the keys whose "use" field is
+// exactly `use` (e.g. "sig"). Keys that do not specify a "use" are
+// not included. See Filter for details
+func (s *Set) FilterUse(use string) *Set {
+	return s.Filter(func(key Key) bool {
+		return key.KeyUsage() == use
+	})
+}
+
+// FilterAlgorithm returns a new Set containing the keys that can be used
+// to verify signatures created using `alg`: the type of the key (and its
+// curve, for ECDSA keys) must be compatible with the algorithm, its "use"
+// field must not be "enc", and its "alg" field, if present, must be `alg`.
+// See Filter for details
+func (s *Set) FilterAlgorithm(alg jwa.SignatureAlgorithm) *Set {
+	return s.Filter(func(key Key) bool {
+		return canSignWith(key, alg)
+	})
+}
+
+// RemoveKey removes the given key from the Set. Keys are compared by
+// identity, not by their contents. If the same key appears in the Set
+// multiple times, all of its occurrences are removed. An error is
+// returned if the key could not be found.
+//
+// The Set is given a newly allocated list of keys, so iterators that
+// were created before calling this method are not affected
+func (s *Set) RemoveKey(key Key) error {
+	return s.removeKeys(func(k Key) bool { return k == key })
+}
+
+// RemoveKeyByID removes the keys with the given key id from the Set.
+// As with LookupKeyID, the Set may contain multiple keys with the same
+// key id, in which case all of them are removed. An error is returned
+// if no such key could be found.
+//
+// The Set is given a newly allocated list of keys, so iterators that
+// were created before calling this method are not affected
+func (s *Set) RemoveKeyByID(kid string) error {
+	return s.removeKeys(func(k Key) bool { return k.KeyID() == kid })
+}
+
+func (s *Set) removeKeys(match func(Key) bool) error {
+	keys := make([]Key, 0, len(s.Keys))
+	for _, k := range s.Keys {
+		if !match(k) {
+			keys = append(keys, k)
+		}
+	}
+
+	if len(keys) == len(s.Keys) {
+		return errors.New(`key not found in set`)
+	}
+	s.Keys = keys
+	return nil
+}
+
+// Clear removes all keys from the Set. As with RemoveKey, iterators
+// that were created before calling this method are not affected
+func (s *Set) Clear() {
+	s.Keys = nil
+}
+
+// Len returns the number of keys in the Set
 func (s *Set) Len() int {
 	return len(s.Keys)
 }
 
+// Get returns the key at the given index, and true. If the index
+// is out of range, it returns nil and false
+func (s *Set) Get(idx int) (Key, bool) {
+	if idx < 0 || idx >= len(s.Keys) {
+		return nil, false
+	}
+	return s.Keys[idx], true
+}
+
+// Iterate returns an iterator that walks the keys in the Set in the
+// order that they appear in the Set. If the context is canceled, the
+// iteration stops
 func (s *Set) Iterate(ctx context.Context) KeyIterator {
 	ch := make(chan *KeyPair, s.Len())
 	go iterate(ctx, s.Keys, ch)
@@ -389,3 +900,59 @@ func AssignKeyID(key Key, options ...Option) error {
 
 	return nil
 }
+
+// thumbprintHashNames maps hash functions to their names in the IANA
+// "Named Information Hash Algorithm" registry, as used by RFC9278
+var thumbprintHashNames = map[crypto.Hash]string{
+	crypto.SHA256:   "sha-256",
+	crypto.SHA384:   "sha-384",
+	crypto.SHA512:   "sha-512",
+	crypto.SHA3_224: "sha3-224",
+	crypto.SHA3_256: "sha3-256",
+	crypto.SHA3_384: "sha3-384",
+	crypto.SHA3_512: "sha3-512",
+}
+
+// ThumbprintURI returns the JWK thumbprint URI of the key, as described
+// in https://tools.ietf.org/html/rfc9278, e.g.
+// "urn:ietf:params:oauth:jwk-thumbprint:sha-256:NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"
+func ThumbprintURI(key Key, hash crypto.Hash) (string, error) {
+	if key == nil {
+		return "", errors.New(`jwk.ThumbprintURI requires a non-nil key`)
+	}
+
+	name, ok := thumbprintHashNames[hash]
+	if !ok {
+		return "", errors.Errorf(`unsupported hash for thumbprint URI: %s`, hash)
+	}
+
+	tp, err := key.Thumbprint(hash)
+	if err != nil {
+		return "", errors.Wrap(err, `failed to generate thumbprint`)
+	}
+
+	return "urn:ietf:params:oauth:jwk-thumbprint:" + name + ":" + base64.EncodeToString(tp), nil
+}
+
+// ThumbprintEqual computes the thumbprints of the two keys using the
+// given hash, and reports whether they are the same. The comparison
+// is done in constant time, so this function is safe to use when the
+// thumbprints are used as authentication material (e.g. when matching
+// a key against a set of trusted keys)
+func ThumbprintEqual(a, b Key, hash crypto.Hash) (bool, error) {
+	if a == nil || b == nil {
+		return false, errors.New(`jwk.ThumbprintEqual requires non-nil keys`)
+	}
+
+	atp, err := a.Thumbprint(hash)
+	if err != nil {
+		return false, errors.Wrap(err, `failed to generate thumbprint for first key`)
+	}
+
+	btp, err := b.Thumbprint(hash)
+	if err != nil {
+		return false, errors.Wrap(err, `failed to generate thumbprint for second key`)
+	}
+
+	return subtle.ConstantTimeCompare(atp, btp) == 1, nil
+}
@@ -4,16 +4,22 @@ import (
 	"context"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/lestrrat-go/jwx/internal/base64"
+	"github.com/lestrrat-go/jwx/jwa"
 	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jws"
+	"github.com/lestrrat-go/jwx/x25519"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
@@ -26,6 +32,80 @@ func TestNew(t *testing.T) {
 	if !assert.Error(t, err, "nil key should cause an error") {
 		return
 	}
+
+	rsakey, err := generateRawRSAPrivateKey()
+	if !assert.NoError(t, err, `generateRawRSAPrivateKey should succeed`) {
+		return
+	}
+	ecdsakey, err := generateRawECDSAPrivateKey()
+	if !assert.NoError(t, err, `generateRawECDSAPrivateKey should succeed`) {
+		return
+	}
+	edpub, edpriv, err := ed25519.GenerateKey(rand.Reader)
+	if !assert.NoError(t, err, `ed25519.GenerateKey should succeed`) {
+		return
+	}
+	xpub, xpriv, err := x25519.GenerateKey(rand.Reader)
+	if !assert.NoError(t, err, `x25519.GenerateKey should succeed`) {
+		return
+	}
+
+	testcases := []struct {
+		raw      interface{}
+		expected reflect.Type
+		keyType  jwa.KeyType
+	}{
+		{raw: rsakey, expected: reflect.TypeOf((*jwk.RSAPrivateKey)(nil)).Elem(), keyType: jwa.RSA},
+		{raw: &rsakey.PublicKey, expected: reflect.TypeOf((*jwk.RSAPublicKey)(nil)).Elem(), keyType: jwa.RSA},
+		{raw: ecdsakey, expected: reflect.TypeOf((*jwk.ECDSAPrivateKey)(nil)).Elem(), keyType: jwa.EC},
+		{raw: &ecdsakey.PublicKey, expected: reflect.TypeOf((*jwk.ECDSAPublicKey)(nil)).Elem(), keyType: jwa.EC},
+		{raw: edpriv, expected: reflect.TypeOf((*jwk.OKPPrivateKey)(nil)).Elem(), keyType: jwa.OKP},
+		{raw: edpub, expected: reflect.TypeOf((*jwk.OKPPublicKey)(nil)).Elem(), keyType: jwa.OKP},
+		{raw: xpriv, expected: reflect.TypeOf((*jwk.OKPPrivateKey)(nil)).Elem(), keyType: jwa.OKP},
+		{raw: xpub, expected: reflect.TypeOf((*jwk.OKPPublicKey)(nil)).Elem(), keyType: jwa.OKP},
+		{raw: generateRawSymmetricKey(), expected: reflect.TypeOf((*jwk.SymmetricKey)(nil)).Elem(), keyType: jwa.OctetSeq},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(fmt.Sprintf("%T", tc.raw), func(t *testing.T) {
+			k, err := jwk.New(tc.raw)
+			if !assert.NoError(t, err, `jwk.New should succeed`) {
+				return
+			}
+			if !assert.True(t, reflect.TypeOf(k).Implements(tc.expected), `key should be a %s`, tc.expected) {
+				return
+			}
+			if !assert.Equal(t, tc.keyType, k.KeyType(), `KeyType should match`) {
+				return
+			}
+
+			// The key type survives a roundtrip through JSON
+			buf, err := json.Marshal(k)
+			if !assert.NoError(t, err, `json.Marshal should succeed`) {
+				return
+			}
+			parsed, err := jwk.ParseKey(buf)
+			if !assert.NoError(t, err, `jwk.ParseKey should succeed`) {
+				return
+			}
+			if !assert.Equal(t, tc.keyType, parsed.KeyType(), `KeyType of the parsed key should match`) {
+				return
+			}
+		})
+	}
+
+	t.Run("Unknown key type", func(t *testing.T) {
+		var kty jwa.KeyType
+		if !assert.Error(t, kty.Accept("FOO"), `Accept should fail`) {
+			return
+		}
+		if !assert.NoError(t, kty.Accept("RSA"), `Accept should succeed`) {
+			return
+		}
+		if !assert.Equal(t, jwa.RSA, kty, `Accept should set the value`) {
+			return
+		}
+	})
 }
 
 func TestParse(t *testing.T) {
@@ -116,6 +196,59 @@ func TestParse(t *testing.T) {
 			return
 		}
 	})
+	t.Run("Auto-detect format", func(t *testing.T) {
+		const src = `{"kty":"oct","k":"AyM1SysPpbyDfgZld3umj1qzKObwVMkoqQ-EstJQLr_T-1qS0gZH75aKtMN3Yj0iPS4hcgUuTwjAzZr1Z9CAow"}`
+
+		single, err := jwk.ParseString(src)
+		if !assert.NoError(t, err, `jwk.ParseString should succeed for a single key`) {
+			return
+		}
+		if !assert.Equal(t, 1, single.Len(), `set should contain the single key`) {
+			return
+		}
+
+		multi, err := jwk.ParseString(`{"keys":[` + src + `,` + src + `]}`)
+		if !assert.NoError(t, err, `jwk.ParseString should succeed for a set`) {
+			return
+		}
+		if !assert.Equal(t, 2, multi.Len(), `set should contain both keys`) {
+			return
+		}
+
+		empty, err := jwk.ParseString(`{"keys":[]}`)
+		if !assert.NoError(t, err, `jwk.ParseString should succeed for an empty set`) {
+			return
+		}
+		if !assert.Equal(t, 0, empty.Len(), `set should be empty`) {
+			return
+		}
+	})
+	t.Run("Invalid format", func(t *testing.T) {
+		testcases := map[string]string{
+			"not an object":       `["kty"]`,
+			"no keys or kty":      `{"alg":"RS256"}`,
+			"keys is not a list":  `{"keys":{"kty":"oct"}}`,
+			"invalid key in keys": `{"keys":[{"kty":"foo"}]}`,
+		}
+		for name, src := range testcases {
+			src := src
+			t.Run(name, func(t *testing.T) {
+				_, err := jwk.ParseString(src)
+				if !assert.Error(t, err, `jwk.ParseString should fail`) {
+					return
+				}
+			})
+		}
+
+		_, err := jwk.ParseString(`{"alg":"RS256"}`)
+		if !assert.Contains(t, err.Error(), `"kty"`, `error should name the missing field`) {
+			return
+		}
+		_, err = jwk.ParseString(`{"keys":"foo"}`)
+		if !assert.Contains(t, err.Error(), `"keys"`, `error should name the offending field`) {
+			return
+		}
+	})
 }
 
 func generateRawRSAPrivateKey() (*rsa.PrivateKey, error) {
@@ -188,6 +321,110 @@ func generateSymmetricKey() (jwk.Key, error) {
 	return key, nil
 }
 
+func TestStrictValidation(t *testing.T) {
+	const rsaParams = `"kty":"RSA","e":"AQAB","n":"0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"`
+	const ecParams = `"kty":"EC","crv":"P-256","x":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4","y":"4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM"`
+
+	testcases := []struct {
+		Name  string
+		Input string
+		Error bool
+	}{
+		{Name: "RSA without alg", Input: `{` + rsaParams + `}`},
+		{Name: "RSA with RS256", Input: `{` + rsaParams + `,"alg":"RS256","use":"sig"}`},
+		{Name: "RSA with RSA-OAEP", Input: `{` + rsaParams + `,"alg":"RSA-OAEP","use":"enc"}`},
+		{Name: "RSA with ES256", Input: `{` + rsaParams + `,"alg":"ES256"}`, Error: true},
+		{Name: "RSA with RS256 for enc", Input: `{` + rsaParams + `,"alg":"RS256","use":"enc"}`, Error: true},
+		{Name: "RSA with invalid use", Input: `{` + rsaParams + `,"use":"foo"}`, Error: true},
+		{Name: "EC with ES256", Input: `{` + ecParams + `,"alg":"ES256"}`},
+		{Name: "EC with ES384", Input: `{` + ecParams + `,"alg":"ES384"}`, Error: true},
+		{Name: "EC with ECDH-ES for sig", Input: `{` + ecParams + `,"alg":"ECDH-ES","use":"sig"}`, Error: true},
+		{Name: "oct with HS256", Input: `{"kty":"oct","k":"c2VjcmV0","alg":"HS256"}`},
+		{Name: "oct with RS256", Input: `{"kty":"oct","k":"c2VjcmV0","alg":"RS256"}`, Error: true},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			// the default is lenient
+			if _, err := jwk.ParseKey([]byte(tc.Input)); !assert.NoError(t, err, `jwk.ParseKey should succeed`) {
+				return
+			}
+
+			_, err := jwk.ParseKey([]byte(tc.Input), jwk.WithStrictValidation(true))
+			if tc.Error {
+				if !assert.Error(t, err, `jwk.ParseKey should fail`) {
+					return
+				}
+			} else {
+				if !assert.NoError(t, err, `jwk.ParseKey should succeed`) {
+					return
+				}
+			}
+
+			_, err = jwk.ParseString(`{"keys":[`+tc.Input+`]}`, jwk.WithStrictValidation(true))
+			if tc.Error {
+				if !assert.Error(t, err, `jwk.ParseString should fail`) {
+					return
+				}
+			} else {
+				if !assert.NoError(t, err, `jwk.ParseString should succeed`) {
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	rawkey, err := generateRawRSAPrivateKey()
+	if !assert.NoError(t, err, `generateRawRSAPrivateKey should succeed`) {
+		return
+	}
+	other, err := generateRawRSAPrivateKey()
+	if !assert.NoError(t, err, `generateRawRSAPrivateKey should succeed`) {
+		return
+	}
+
+	key, err := jwk.New(rawkey)
+	if !assert.NoError(t, err, `jwk.New should succeed`) {
+		return
+	}
+	valid, err := json.Marshal(key)
+	if !assert.NoError(t, err, `json.Marshal should succeed`) {
+		return
+	}
+
+	// Replace "d" with the private exponent of another key
+	var m map[string]interface{}
+	if !assert.NoError(t, json.Unmarshal(valid, &m), `json.Unmarshal should succeed`) {
+		return
+	}
+	m[jwk.RSADKey] = base64.EncodeToString(other.D.Bytes())
+	tampered, err := json.Marshal(m)
+	if !assert.NoError(t, err, `json.Marshal should succeed`) {
+		return
+	}
+
+	// the default does not validate
+	if _, err := jwk.ParseKey(tampered); !assert.NoError(t, err, `jwk.ParseKey should succeed`) {
+		return
+	}
+
+	if _, err := jwk.ParseKey(valid, jwk.WithValidate(true)); !assert.NoError(t, err, `jwk.ParseKey should succeed`) {
+		return
+	}
+	if _, err := jwk.ParseKey(tampered, jwk.WithValidate(true)); !assert.Error(t, err, `jwk.ParseKey should fail`) {
+		return
+	}
+	if _, err := jwk.ParseString(`{"keys":[`+string(valid)+`]}`, jwk.WithValidate(true)); !assert.NoError(t, err, `jwk.ParseString should succeed`) {
+		return
+	}
+	if _, err := jwk.ParseString(`{"keys":[`+string(tampered)+`]}`, jwk.WithValidate(true)); !assert.Error(t, err, `jwk.ParseString should fail`) {
+		return
+	}
+}
+
 func TestRoundtrip(t *testing.T) {
 	generateRSA := func(use string, keyID string) (jwk.Key, error) {
 		k, err := generateRSAPrivateKey()
@@ -316,6 +553,37 @@ func TestRoundtrip(t *testing.T) {
 	}
 }
 
+func TestMarshalOrder(t *testing.T) {
+	key, err := jwk.New([]byte("0123456789abcdef"))
+	if !assert.NoError(t, err, `jwk.New should succeed`) {
+		return
+	}
+
+	for k, v := range map[string]interface{}{
+		"zzz":            "private",
+		"aaa":            "private",
+		jwk.KeyOpsKey:    jwk.KeyOperationList{jwk.KeyOpSign},
+		jwk.KeyIDKey:     "my-key",
+		jwk.KeyUsageKey:  "sig",
+		jwk.AlgorithmKey: "HS256",
+	} {
+		if !assert.NoError(t, key.Set(k, v), `key.Set should succeed`) {
+			return
+		}
+	}
+
+	const expected = `{"kty":"oct","alg":"HS256","use":"sig","kid":"my-key","k":"MDEyMzQ1Njc4OWFiY2RlZg","key_ops":["sign"],"aaa":"private","zzz":"private"}`
+	for i := 0; i < 10; i++ {
+		buf, err := json.Marshal(key)
+		if !assert.NoError(t, err, `json.Marshal should succeed`) {
+			return
+		}
+		if !assert.Equal(t, expected, string(buf), `fields should be marshaled in canonical order`) {
+			return
+		}
+	}
+}
+
 func TestKeyOperation(t *testing.T) {
 	testcases := []struct {
 		Args  interface{}
@@ -354,6 +622,30 @@ func TestKeyOperation(t *testing.T) {
 			}
 		}
 	}
+
+	t.Run("CanPerform", func(t *testing.T) {
+		k, err := generateSymmetricKey()
+		if !assert.NoError(t, err, `jwk generation should be successful`) {
+			return
+		}
+
+		if !assert.True(t, k.CanPerform(jwk.KeyOpSign), `keys without key_ops should be allowed to sign`) {
+			return
+		}
+
+		if !assert.NoError(t, k.Set(jwk.KeyOpsKey, []string{"verify"}), `k.Set should succeed`) {
+			return
+		}
+		if !assert.Equal(t, jwk.KeyOperationList{jwk.KeyOpVerify}, k.KeyOps(), `k.KeyOps should match`) {
+			return
+		}
+		if !assert.True(t, k.CanPerform(jwk.KeyOpVerify), `key should be allowed to verify`) {
+			return
+		}
+		if !assert.False(t, k.CanPerform(jwk.KeyOpSign), `key should not be allowed to sign`) {
+			return
+		}
+	})
 }
 
 func TestAssignKeyID(t *testing.T) {
@@ -381,99 +673,787 @@ func TestAssignKeyID(t *testing.T) {
 		if !assert.NotEmpty(t, k.KeyID(), `k.KeyID should be non-empty`) {
 			return
 		}
-	}
-}
-
-func TestPublicKeyOf(t *testing.T) {
-	rsakey, err := generateRawRSAPrivateKey()
-	if !assert.NoError(t, err, `generating raw RSA key should succeed`) {
-		return
-	}
-
-	ecdsakey, err := generateRawECDSAPrivateKey()
-	if !assert.NoError(t, err, `generating raw ECDSA key should succeed`) {
-		return
-	}
-
-	octets := generateRawSymmetricKey()
-
-	keys := []struct {
-		Key           interface{}
-		PublicKeyType reflect.Type
-	}{
-		{
-			Key:           rsakey,
-			PublicKeyType: reflect.PtrTo(reflect.TypeOf(rsakey.PublicKey)),
-		},
-		{
-			Key:           *rsakey,
-			PublicKeyType: reflect.PtrTo(reflect.TypeOf(rsakey.PublicKey)),
-		},
-		{
-			Key:           rsakey.PublicKey,
-			PublicKeyType: reflect.PtrTo(reflect.TypeOf(rsakey.PublicKey)),
-		},
-		{
-			Key:           &rsakey.PublicKey,
-			PublicKeyType: reflect.PtrTo(reflect.TypeOf(rsakey.PublicKey)),
-		},
-		{
-			Key:           ecdsakey,
-			PublicKeyType: reflect.PtrTo(reflect.TypeOf(ecdsakey.PublicKey)),
-		},
-		{
-			Key:           *ecdsakey,
-			PublicKeyType: reflect.PtrTo(reflect.TypeOf(ecdsakey.PublicKey)),
-		},
-		{
-			Key:           ecdsakey.PublicKey,
-			PublicKeyType: reflect.PtrTo(reflect.TypeOf(ecdsakey.PublicKey)),
-		},
-		{
-			Key:           &ecdsakey.PublicKey,
-			PublicKeyType: reflect.PtrTo(reflect.TypeOf(ecdsakey.PublicKey)),
-		},
-		{
-			Key:           octets,
-			PublicKeyType: reflect.TypeOf(octets),
-		},
-	}
-
-	for _, key := range keys {
-		key := key
-		t.Run(fmt.Sprintf("%T", key.Key), func(t *testing.T) {
-			t.Parallel()
-
-			pubkey, err := jwk.PublicKeyOf(key.Key)
-			if !assert.NoError(t, err, `jwk.PublicKeyOf(%T) should succeed`) {
-				return
-			}
 
-			if !assert.Equal(t, key.PublicKeyType, reflect.TypeOf(pubkey), `public key types should match`) {
-				return
-			}
-		})
+		tp, err := k.Thumbprint(crypto.SHA256)
+		if !assert.NoError(t, err, `k.Thumbprint should succeed`) {
+			return
+		}
+		if !assert.Equal(t, base64.EncodeToString(tp), k.KeyID(), `k.KeyID should be the base64 encoded thumbprint`) {
+			return
+		}
 	}
-}
-
-func TestIssue207(t *testing.T) {
-	const src = `{"kty":"EC","alg":"ECMR","crv":"P-521","key_ops":["deriveKey"],"x":"AJwCS845x9VljR-fcrN2WMzIJHDYuLmFShhyu8ci14rmi2DMFp8txIvaxG8n7ZcODeKIs1EO4E_Bldm_pxxs8cUn","y":"ASjz754cIQHPJObihPV8D7vVNfjp_nuwP76PtbLwUkqTk9J1mzCDKM3VADEk-Z1tP-DHiwib6If8jxnb_FjNkiLJ"}`
 
-	// Using a loop here because we're using sync.Pool
-	// just for sanity.
-	for i := 0; i < 10; i++ {
-		k, err := jwk.ParseKey([]byte(src))
-		if !assert.NoError(t, err, `jwk.ParseKey should succeed`) {
+	t.Run("Existing kid", func(t *testing.T) {
+		k, err := generateSymmetricKey()
+		if !assert.NoError(t, err, `jwk generation should be successful`) {
+			return
+		}
+		if !assert.NoError(t, k.Set(jwk.KeyIDKey, "my-key"), `k.Set should succeed`) {
+			return
+		}
+		if !assert.NoError(t, jwk.AssignKeyID(k), `AssignKeyID shuld be successful`) {
+			return
+		}
+		if !assert.Equal(t, "my-key", k.KeyID(), `existing kid should be preserved`) {
+			return
+		}
+	})
+	t.Run("WithThumbprintHash", func(t *testing.T) {
+		k, err := generateSymmetricKey()
+		if !assert.NoError(t, err, `jwk generation should be successful`) {
+			return
+		}
+		if !assert.NoError(t, jwk.AssignKeyID(k, jwk.WithThumbprintHash(crypto.SHA512)), `AssignKeyID shuld be successful`) {
 			return
 		}
 
-		thumb, err := k.Thumbprint(crypto.SHA1)
+		tp, err := k.Thumbprint(crypto.SHA512)
 		if !assert.NoError(t, err, `k.Thumbprint should succeed`) {
 			return
 		}
-
-		if !assert.Equal(t, `2Mc_43O_BOrOJTNrGX7uJ6JsIYE`, base64.EncodeToString(thumb), `thumbprints should match`) {
+		if !assert.Equal(t, base64.EncodeToString(tp), k.KeyID(), `k.KeyID should be the base64 encoded SHA512 thumbprint`) {
 			return
 		}
+	})
+}
+
+func TestClone(t *testing.T) {
+	generators := []func() (jwk.Key, error){
+		generateRSAPrivateKey,
+		generateRSAPublicKey,
+		generateECDSAPrivateKey,
+		generateECDSAPublicKey,
+		generateSymmetricKey,
+	}
+
+	for _, generator := range generators {
+		k, err := generator()
+		if !assert.NoError(t, err, `jwk generation should be successful`) {
+			return
+		}
+		if !assert.NoError(t, k.Set(jwk.KeyIDKey, "original"), `k.Set should succeed`) {
+			return
+		}
+		if !assert.NoError(t, k.Set(jwk.KeyOpsKey, jwk.KeyOperationList{jwk.KeyOpSign}), `k.Set should succeed`) {
+			return
+		}
+		if !assert.NoError(t, k.Set("private", "original"), `k.Set should succeed`) {
+			return
+		}
+		nested := map[string]interface{}{
+			"list": []interface{}{"original"},
+		}
+		if !assert.NoError(t, k.Set("nested", nested), `k.Set should succeed`) {
+			return
+		}
+
+		expected, err := json.Marshal(k)
+		if !assert.NoError(t, err, `json.Marshal should succeed`) {
+			return
+		}
+
+		cloned, err := k.Clone()
+		if !assert.NoError(t, err, `k.Clone should succeed`) {
+			return
+		}
+		if !assert.IsType(t, k, cloned, `cloned key should be of the same type`) {
+			return
+		}
+
+		got, err := json.Marshal(cloned)
+		if !assert.NoError(t, err, `json.Marshal should succeed`) {
+			return
+		}
+		if !assert.Equal(t, expected, got, `cloned key should be the same as the original`) {
+			return
+		}
+
+		if !assert.NoError(t, cloned.Set(jwk.KeyIDKey, "cloned"), `cloned.Set should succeed`) {
+			return
+		}
+		if !assert.NoError(t, cloned.Set("private", "cloned"), `cloned.Set should succeed`) {
+			return
+		}
+		cloned.KeyOps()[0] = jwk.KeyOpVerify
+		v, ok := cloned.Get("nested")
+		if !assert.True(t, ok, `cloned.Get should succeed`) {
+			return
+		}
+		clonedNested := v.(map[string]interface{})
+		clonedNested["list"].([]interface{})[0] = "cloned"
+		clonedNested["added"] = "cloned"
+
+		got, err = json.Marshal(k)
+		if !assert.NoError(t, err, `json.Marshal should succeed`) {
+			return
+		}
+		if !assert.Equal(t, expected, got, `modifying the clone should not affect the original`) {
+			return
+		}
+	}
+}
+
+func TestLookupKeyID(t *testing.T) {
+	var set jwk.Set
+	for _, kid := range []string{"foo", "bar", "foo"} {
+		k, err := generateSymmetricKey()
+		if !assert.NoError(t, err, `jwk generation should be successful`) {
+			return
+		}
+		if !assert.NoError(t, k.Set(jwk.KeyIDKey, kid), `k.Set should succeed`) {
+			return
+		}
+		set.Keys = append(set.Keys, k)
+	}
+
+	keys := set.LookupKeyID("foo")
+	if !assert.Len(t, keys, 2, `should return all keys with the same key id`) {
+		return
+	}
+	if !assert.Equal(t, set.Keys[0], keys[0], `first match should be the first key in the set`) {
+		return
+	}
+	if !assert.Equal(t, set.Keys[2], keys[1], `second match should be the third key in the set`) {
+		return
+	}
+
+	if !assert.Empty(t, set.LookupKeyID("baz"), `should return no keys for unknown key ids`) {
+		return
+	}
+}
+
+func TestSetFilter(t *testing.T) {
+	type keySpec struct {
+		generate func() (jwk.Key, error)
+		use      string
+		alg      string
+	}
+	specs := []keySpec{
+		{generate: generateRSAPublicKey, use: "sig", alg: "RS256"},
+		{generate: generateRSAPublicKey, use: "enc"},
+		{generate: generateRSAPublicKey},
+		{generate: generateECDSAPublicKey, use: "sig"},
+		{generate: generateSymmetricKey, use: "sig", alg: "HS256"},
+	}
+
+	var set jwk.Set
+	for i, spec := range specs {
+		k, err := spec.generate()
+		if !assert.NoError(t, err, `jwk generation should be successful`) {
+			return
+		}
+		if !assert.NoError(t, k.Set(jwk.KeyIDKey, strconv.Itoa(i)), `k.Set should succeed`) {
+			return
+		}
+		if spec.use != "" {
+			if !assert.NoError(t, k.Set(jwk.KeyUsageKey, spec.use), `k.Set should succeed`) {
+				return
+			}
+		}
+		if spec.alg != "" {
+			if !assert.NoError(t, k.Set(jwk.AlgorithmKey, spec.alg), `k.Set should succeed`) {
+				return
+			}
+		}
+		set.Keys = append(set.Keys, k)
+	}
+
+	keyIDs := func(s *jwk.Set) []string {
+		var ids []string
+		for _, k := range s.Keys {
+			ids = append(ids, k.KeyID())
+		}
+		return ids
+	}
+
+	t.Run("Filter", func(t *testing.T) {
+		filtered := set.Filter(func(k jwk.Key) bool { return k.KeyType() == jwa.RSA })
+		if !assert.Equal(t, []string{"0", "1", "2"}, keyIDs(filtered), `Filter should return the matching keys in order`) {
+			return
+		}
+		if !assert.Equal(t, 5, set.Len(), `original set should not be modified`) {
+			return
+		}
+	})
+	t.Run("FilterUse", func(t *testing.T) {
+		if !assert.Equal(t, []string{"0", "3", "4"}, keyIDs(set.FilterUse("sig")), `FilterUse("sig") should return the signature keys`) {
+			return
+		}
+		if !assert.Equal(t, []string{"1"}, keyIDs(set.FilterUse("enc")), `FilterUse("enc") should return the encryption keys`) {
+			return
+		}
+	})
+	t.Run("FilterAlgorithm", func(t *testing.T) {
+		testcases := []struct {
+			alg      jwa.SignatureAlgorithm
+			expected []string
+		}{
+			{alg: jwa.RS256, expected: []string{"0", "2"}},
+			{alg: jwa.RS384, expected: []string{"2"}},
+			{alg: jwa.ES512, expected: []string{"3"}},
+			{alg: jwa.ES256},
+			{alg: jwa.HS256, expected: []string{"4"}},
+			{alg: jwa.EdDSA},
+		}
+		for _, tc := range testcases {
+			if !assert.Equal(t, tc.expected, keyIDs(set.FilterAlgorithm(tc.alg)), `FilterAlgorithm(%s) should return the compatible keys`, tc.alg) {
+				return
+			}
+		}
+	})
+	t.Run("Verify", func(t *testing.T) {
+		// The same key is registered for encryption and for signing,
+		// but only the latter may be used to verify signatures
+		rawkey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if !assert.NoError(t, err, `rsa.GenerateKey should succeed`) {
+			return
+		}
+		var keys jwk.Set
+		for _, use := range []string{"enc", "sig"} {
+			k, err := jwk.New(&rawkey.PublicKey)
+			if !assert.NoError(t, err, `jwk.New should succeed`) {
+				return
+			}
+			for name, v := range map[string]interface{}{jwk.KeyIDKey: "mykey", jwk.KeyUsageKey: use, jwk.AlgorithmKey: jwa.RS256.String()} {
+				if !assert.NoError(t, k.Set(name, v), `k.Set should succeed`) {
+					return
+				}
+			}
+			keys.Keys = append(keys.Keys, k)
+		}
+
+		hdrs := jws.NewHeaders()
+		if !assert.NoError(t, hdrs.Set(jws.KeyIDKey, "mykey"), `hdrs.Set should succeed`) {
+			return
+		}
+		signed, err := jws.Sign([]byte("Lorem ipsum"), jwa.RS256, rawkey, jws.WithHeaders(hdrs))
+		if !assert.NoError(t, err, `jws.Sign should succeed`) {
+			return
+		}
+
+		if !assert.Equal(t, 1, keys.FilterUse("sig").Len(), `FilterUse("sig") should return one key`) {
+			return
+		}
+		if _, err := jws.Verify(signed, "", nil, jws.WithKeySet(keys.FilterUse("sig"))); !assert.NoError(t, err, `jws.Verify should succeed`) {
+			return
+		}
+		if _, err := jws.Verify(signed, "", nil, jws.WithKeySet(keys.FilterAlgorithm(jwa.RS256).FilterUse("sig"))); !assert.NoError(t, err, `jws.Verify should succeed`) {
+			return
+		}
+	})
+}
+
+func TestSetGet(t *testing.T) {
+	var set jwk.Set
+	for i := 0; i < 3; i++ {
+		k, err := generateSymmetricKey()
+		if !assert.NoError(t, err, `jwk generation should be successful`) {
+			return
+		}
+		set.Keys = append(set.Keys, k)
+	}
+
+	if !assert.Equal(t, 3, set.Len(), `set should contain 3 keys`) {
+		return
+	}
+
+	var i int
+	for iter := set.Iterate(context.TODO()); iter.Next(context.TODO()); i++ {
+		pair := iter.Pair()
+		if !assert.Equal(t, i, pair.Index, `index should match`) {
+			return
+		}
+		key, ok := set.Get(i)
+		if !assert.True(t, ok, `set.Get should succeed`) {
+			return
+		}
+		if !assert.Equal(t, key, pair.Value, `keys should be returned in order`) {
+			return
+		}
+	}
+
+	for _, idx := range []int{-1, 3} {
+		key, ok := set.Get(idx)
+		if !assert.False(t, ok, `set.Get should fail for index %d`, idx) {
+			return
+		}
+		if !assert.Nil(t, key, `set.Get should return nil for index %d`, idx) {
+			return
+		}
+	}
+}
+
+func TestSetRemoveKey(t *testing.T) {
+	var set jwk.Set
+	for _, kid := range []string{"foo", "bar", "foo"} {
+		k, err := generateSymmetricKey()
+		if !assert.NoError(t, err, `jwk generation should be successful`) {
+			return
+		}
+		if !assert.NoError(t, k.Set(jwk.KeyIDKey, kid), `k.Set should succeed`) {
+			return
+		}
+		set.Keys = append(set.Keys, k)
+	}
+	bar := set.Keys[1]
+
+	// an iterator created before the removal should see all keys
+	iter := set.Iterate(context.TODO())
+
+	if !assert.NoError(t, set.RemoveKeyByID("foo"), `RemoveKeyByID should succeed`) {
+		return
+	}
+	if !assert.Equal(t, []jwk.Key{bar}, set.Keys, `all keys with the key id should be removed`) {
+		return
+	}
+	if !assert.Error(t, set.RemoveKeyByID("foo"), `RemoveKeyByID should fail for unknown key ids`) {
+		return
+	}
+
+	var count int
+	for iter.Next(context.TODO()) {
+		count++
+	}
+	if !assert.Equal(t, 3, count, `iterator should not be affected by the removal`) {
+		return
+	}
+
+	other, err := generateSymmetricKey()
+	if !assert.NoError(t, err, `jwk generation should be successful`) {
+		return
+	}
+	if !assert.Error(t, set.RemoveKey(other), `RemoveKey should fail for keys not in the set`) {
+		return
+	}
+	if !assert.NoError(t, set.RemoveKey(bar), `RemoveKey should succeed`) {
+		return
+	}
+	if !assert.Equal(t, 0, set.Len(), `set should be empty`) {
+		return
+	}
+
+	set.Keys = append(set.Keys, bar, other)
+	set.Clear()
+	if !assert.Equal(t, 0, set.Len(), `set should be empty`) {
+		return
+	}
+}
+
+func TestSetMarshalJSON(t *testing.T) {
+	t.Run("Single key", func(t *testing.T) {
+		k, err := generateSymmetricKey()
+		if !assert.NoError(t, err, `jwk generation should be successful`) {
+			return
+		}
+		set := jwk.Set{Keys: []jwk.Key{k}}
+
+		buf, err := json.Marshal(set)
+		if !assert.NoError(t, err, `json.Marshal should succeed`) {
+			return
+		}
+
+		var proxy map[string]json.RawMessage
+		if !assert.NoError(t, json.Unmarshal(buf, &proxy), `json.Unmarshal should succeed`) {
+			return
+		}
+		if !assert.Contains(t, proxy, "keys", `"keys" field should exist`) {
+			return
+		}
+
+		parsed, err := jwk.ParseBytes(buf)
+		if !assert.NoError(t, err, `jwk.ParseBytes should succeed`) {
+			return
+		}
+		if !assert.Equal(t, 1, parsed.Len(), `set should contain 1 key`) {
+			return
+		}
+	})
+	t.Run("Empty set", func(t *testing.T) {
+		buf, err := json.Marshal(&jwk.Set{})
+		if !assert.NoError(t, err, `json.Marshal should succeed`) {
+			return
+		}
+		if !assert.Equal(t, `{"keys":[]}`, string(buf), `empty set should have an empty "keys" array`) {
+			return
+		}
+	})
+	t.Run("Custom members", func(t *testing.T) {
+		const src = `{"keys":[{"kty":"oct","k":"c2VjcmV0"}],"provider":"example","version":2}`
+		set, err := jwk.ParseString(src)
+		if !assert.NoError(t, err, `jwk.ParseString should succeed`) {
+			return
+		}
+
+		buf, err := json.Marshal(set)
+		if !assert.NoError(t, err, `json.Marshal should succeed`) {
+			return
+		}
+		if !assert.Equal(t, src, string(buf), `custom members should be preserved`) {
+			return
+		}
+	})
+}
+
+func TestThumbprintURI(t *testing.T) {
+	// https://tools.ietf.org/html/rfc9278#section-3.3
+	const src = `{"kty":"RSA","e":"AQAB","n":"0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"}`
+	key, err := jwk.ParseKey([]byte(src))
+	if !assert.NoError(t, err, `jwk.ParseKey should succeed`) {
+		return
+	}
+
+	uri, err := jwk.ThumbprintURI(key, crypto.SHA256)
+	if !assert.NoError(t, err, `jwk.ThumbprintURI should succeed`) {
+		return
+	}
+	if !assert.Equal(t, `urn:ietf:params:oauth:jwk-thumbprint:sha-256:NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs`, uri, `thumbprint URI should match`) {
+		return
+	}
+
+	uri, err = jwk.ThumbprintURI(key, crypto.SHA512)
+	if !assert.NoError(t, err, `jwk.ThumbprintURI should succeed`) {
+		return
+	}
+	if !assert.True(t, strings.HasPrefix(uri, `urn:ietf:params:oauth:jwk-thumbprint:sha-512:`), `thumbprint URI should use the hash name`) {
+		return
+	}
+
+	if _, err := jwk.ThumbprintURI(key, crypto.SHA1); !assert.Error(t, err, `jwk.ThumbprintURI should fail for unregistered hashes`) {
+		return
+	}
+	if _, err := jwk.ThumbprintURI(nil, crypto.SHA256); !assert.Error(t, err, `jwk.ThumbprintURI should fail for nil keys`) {
+		return
+	}
+}
+
+func TestThumbprintEqual(t *testing.T) {
+	generators := []func() (jwk.Key, error){
+		generateRSAPrivateKey,
+		generateRSAPublicKey,
+		generateECDSAPrivateKey,
+		generateECDSAPublicKey,
+		generateSymmetricKey,
+	}
+
+	for _, generator := range generators {
+		k1, err := generator()
+		if !assert.NoError(t, err, `jwk generation should be successful`) {
+			return
+		}
+
+		k2, err := generator()
+		if !assert.NoError(t, err, `jwk generation should be successful`) {
+			return
+		}
+
+		ok, err := jwk.ThumbprintEqual(k1, k1, crypto.SHA256)
+		if !assert.NoError(t, err, `ThumbprintEqual should succeed`) {
+			return
+		}
+		if !assert.True(t, ok, `thumbprints of the same key should match`) {
+			return
+		}
+
+		ok, err = jwk.ThumbprintEqual(k1, k2, crypto.SHA256)
+		if !assert.NoError(t, err, `ThumbprintEqual should succeed`) {
+			return
+		}
+		if !assert.False(t, ok, `thumbprints of different keys should not match`) {
+			return
+		}
+	}
+
+	_, err := jwk.ThumbprintEqual(nil, nil, crypto.SHA256)
+	if !assert.Error(t, err, `ThumbprintEqual should fail for nil keys`) {
+		return
+	}
+}
+
+func TestPublicKeyOf(t *testing.T) {
+	rsakey, err := generateRawRSAPrivateKey()
+	if !assert.NoError(t, err, `generating raw RSA key should succeed`) {
+		return
+	}
+
+	ecdsakey, err := generateRawECDSAPrivateKey()
+	if !assert.NoError(t, err, `generating raw ECDSA key should succeed`) {
+		return
+	}
+
+	octets := generateRawSymmetricKey()
+
+	keys := []struct {
+		Key           interface{}
+		PublicKeyType reflect.Type
+	}{
+		{
+			Key:           rsakey,
+			PublicKeyType: reflect.PtrTo(reflect.TypeOf(rsakey.PublicKey)),
+		},
+		{
+			Key:           *rsakey,
+			PublicKeyType: reflect.PtrTo(reflect.TypeOf(rsakey.PublicKey)),
+		},
+		{
+			Key:           rsakey.PublicKey,
+			PublicKeyType: reflect.PtrTo(reflect.TypeOf(rsakey.PublicKey)),
+		},
+		{
+			Key:           &rsakey.PublicKey,
+			PublicKeyType: reflect.PtrTo(reflect.TypeOf(rsakey.PublicKey)),
+		},
+		{
+			Key:           ecdsakey,
+			PublicKeyType: reflect.PtrTo(reflect.TypeOf(ecdsakey.PublicKey)),
+		},
+		{
+			Key:           *ecdsakey,
+			PublicKeyType: reflect.PtrTo(reflect.TypeOf(ecdsakey.PublicKey)),
+		},
+		{
+			Key:           ecdsakey.PublicKey,
+			PublicKeyType: reflect.PtrTo(reflect.TypeOf(ecdsakey.PublicKey)),
+		},
+		{
+			Key:           &ecdsakey.PublicKey,
+			PublicKeyType: reflect.PtrTo(reflect.TypeOf(ecdsakey.PublicKey)),
+		},
+		{
+			Key:           octets,
+			PublicKeyType: reflect.TypeOf(octets),
+		},
+	}
+
+	for _, key := range keys {
+		key := key
+		t.Run(fmt.Sprintf("%T", key.Key), func(t *testing.T) {
+			t.Parallel()
+
+			pubkey, err := jwk.PublicKeyOf(key.Key)
+			if !assert.NoError(t, err, `jwk.PublicKeyOf(%T) should succeed`) {
+				return
+			}
+
+			if !assert.Equal(t, key.PublicKeyType, reflect.TypeOf(pubkey), `public key types should match`) {
+				return
+			}
+		})
+	}
+}
+
+func TestPublicSetOf(t *testing.T) {
+	rsakey, err := generateRSAPrivateKey()
+	if !assert.NoError(t, err, `generating RSA key should succeed`) {
+		return
+	}
+	ecdsakey, err := generateECDSAPrivateKey()
+	if !assert.NoError(t, err, `generating ECDSA key should succeed`) {
+		return
+	}
+	ecdsapub, err := generateECDSAPublicKey()
+	if !assert.NoError(t, err, `generating ECDSA public key should succeed`) {
+		return
+	}
+	symkey, err := generateSymmetricKey()
+	if !assert.NoError(t, err, `generating symmetric key should succeed`) {
+		return
+	}
+	if !assert.NoError(t, rsakey.Set(jwk.KeyIDKey, "rsa"), `rsakey.Set should succeed`) {
+		return
+	}
+
+	set := &jwk.Set{Keys: []jwk.Key{rsakey, symkey, ecdsakey, ecdsapub}}
+
+	t.Run("Drop symmetric keys", func(t *testing.T) {
+		pubset, err := jwk.PublicSetOf(set)
+		if !assert.NoError(t, err, `jwk.PublicSetOf should succeed`) {
+			return
+		}
+		if !assert.Equal(t, 3, pubset.Len(), `symmetric key should be dropped`) {
+			return
+		}
+		if !assert.Implements(t, (*jwk.RSAPublicKey)(nil), pubset.Keys[0], `key #1 should be an RSA public key`) {
+			return
+		}
+		if !assert.Equal(t, "rsa", pubset.Keys[0].KeyID(), `"kid" should be preserved`) {
+			return
+		}
+		if !assert.Implements(t, (*jwk.ECDSAPublicKey)(nil), pubset.Keys[1], `key #2 should be an ECDSA public key`) {
+			return
+		}
+		if !assert.Equal(t, ecdsapub, pubset.Keys[2], `public keys should be included as is`) {
+			return
+		}
+		if !assert.Equal(t, 4, set.Len(), `original set should not be modified`) {
+			return
+		}
+
+		buf, err := json.Marshal(pubset)
+		if !assert.NoError(t, err, `json.Marshal should succeed`) {
+			return
+		}
+		if !assert.NotContains(t, string(buf), `"d"`, `private parameters should not be present`) {
+			return
+		}
+	})
+	t.Run("Reject symmetric keys", func(t *testing.T) {
+		_, err := jwk.PublicSetOf(set, jwk.WithRejectSymmetricKeys(true))
+		if !assert.Error(t, err, `jwk.PublicSetOf should fail`) {
+			return
+		}
+	})
+}
+
+func TestPublicKeyEqual(t *testing.T) {
+	rsakey1, err := generateRawRSAPrivateKey()
+	if !assert.NoError(t, err, `generating raw RSA key should succeed`) {
+		return
+	}
+	rsakey2, err := generateRawRSAPrivateKey()
+	if !assert.NoError(t, err, `generating raw RSA key should succeed`) {
+		return
+	}
+	ecdsakey, err := generateRawECDSAPrivateKey()
+	if !assert.NoError(t, err, `generating raw ECDSA key should succeed`) {
+		return
+	}
+	edpub, edpriv, err := ed25519.GenerateKey(rand.Reader)
+	if !assert.NoError(t, err, `ed25519.GenerateKey should succeed`) {
+		return
+	}
+	octets := generateRawSymmetricKey()
+
+	newKey := func(t *testing.T, raw interface{}, kid string) jwk.Key {
+		t.Helper()
+		key, err := jwk.New(raw)
+		if !assert.NoError(t, err, `jwk.New should succeed`) {
+			t.FailNow()
+		}
+		if !assert.NoError(t, key.Set(jwk.KeyIDKey, kid), `key.Set should succeed`) {
+			t.FailNow()
+		}
+		return key
+	}
+
+	testcases := []struct {
+		Name     string
+		A        interface{}
+		B        interface{}
+		Expected bool
+	}{
+		{Name: "RSA private and public", A: rsakey1, B: &rsakey1.PublicKey, Expected: true},
+		{Name: "Different RSA keys", A: rsakey1, B: &rsakey2.PublicKey, Expected: false},
+		{Name: "ECDSA private and public", A: ecdsakey, B: &ecdsakey.PublicKey, Expected: true},
+		{Name: "Ed25519 private and public", A: edpriv, B: edpub, Expected: true},
+		{Name: "Symmetric", A: octets, B: append([]byte(nil), octets...), Expected: true},
+		{Name: "Different symmetric keys", A: octets, B: generateRawSymmetricKey(), Expected: false},
+		{Name: "Different key types", A: rsakey1, B: ecdsakey, Expected: false},
+		{Name: "Symmetric and asymmetric", A: octets, B: edpub, Expected: false},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			// metadata differences are ignored
+			a := newKey(t, tc.A, "a")
+			b := newKey(t, tc.B, "b")
+
+			for _, pair := range [][2]jwk.Key{{a, b}, {b, a}} {
+				equal, err := jwk.PublicKeyEqual(pair[0], pair[1])
+				if !assert.NoError(t, err, `jwk.PublicKeyEqual should succeed`) {
+					return
+				}
+				if !assert.Equal(t, tc.Expected, equal, `jwk.PublicKeyEqual should return %t`, tc.Expected) {
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestIssue207(t *testing.T) {
+	const src = `{"kty":"EC","alg":"ECMR","crv":"P-521","key_ops":["deriveKey"],"x":"AJwCS845x9VljR-fcrN2WMzIJHDYuLmFShhyu8ci14rmi2DMFp8txIvaxG8n7ZcODeKIs1EO4E_Bldm_pxxs8cUn","y":"ASjz754cIQHPJObihPV8D7vVNfjp_nuwP76PtbLwUkqTk9J1mzCDKM3VADEk-Z1tP-DHiwib6If8jxnb_FjNkiLJ"}`
+
+	// Using a loop here because we're using sync.Pool
+	// just for sanity.
+	for i := 0; i < 10; i++ {
+		k, err := jwk.ParseKey([]byte(src))
+		if !assert.NoError(t, err, `jwk.ParseKey should succeed`) {
+			return
+		}
+
+		thumb, err := k.Thumbprint(crypto.SHA1)
+		if !assert.NoError(t, err, `k.Thumbprint should succeed`) {
+			return
+		}
+
+		if !assert.Equal(t, `2Mc_43O_BOrOJTNrGX7uJ6JsIYE`, base64.EncodeToString(thumb), `thumbprints should match`) {
+			return
+		}
+	}
+}
+
+func TestMergeSets(t *testing.T) {
+	rsakey, err := generateRSAPrivateKey()
+	if !assert.NoError(t, err, `generating RSA key should succeed`) {
+		return
+	}
+	if !assert.NoError(t, rsakey.Set(jwk.KeyIDKey, "primary"), `rsakey.Set should succeed`) {
+		return
+	}
+	rsapub, err := rsakey.(jwk.RSAPrivateKey).PublicKey()
+	if !assert.NoError(t, err, `PublicKey should succeed`) {
+		return
+	}
+	if !assert.NoError(t, rsapub.Set(jwk.KeyIDKey, "partner"), `rsapub.Set should succeed`) {
+		return
+	}
+	ecdsapub, err := generateECDSAPublicKey()
+	if !assert.NoError(t, err, `generating ECDSA public key should succeed`) {
+		return
+	}
+
+	primary, err := jwk.ParseString(`{"keys":[],"issuer":"primary"}`)
+	if !assert.NoError(t, err, `jwk.ParseString should succeed`) {
+		return
+	}
+	primary.Keys = append(primary.Keys, rsakey)
+
+	partner, err := jwk.ParseString(`{"keys":[],"issuer":"partner","updated":"yesterday"}`)
+	if !assert.NoError(t, err, `jwk.ParseString should succeed`) {
+		return
+	}
+	partner.Keys = append(partner.Keys, rsapub, ecdsapub)
+
+	merged, err := jwk.MergeSets(primary, nil, partner)
+	if !assert.NoError(t, err, `jwk.MergeSets should succeed`) {
+		return
+	}
+	if !assert.Equal(t, 2, merged.Len(), `duplicate key should be dropped`) {
+		return
+	}
+	if !assert.Equal(t, "primary", merged.Keys[0].KeyID(), `first occurrence should be kept`) {
+		return
+	}
+	if !assert.Equal(t, ecdsapub, merged.Keys[1], `unique keys should be included as is`) {
+		return
+	}
+	if !assert.Equal(t, 1, primary.Len(), `source sets should not be modified`) {
+		return
+	}
+
+	buf, err := json.Marshal(merged)
+	if !assert.NoError(t, err, `json.Marshal should succeed`) {
+		return
+	}
+	var members map[string]interface{}
+	if !assert.NoError(t, json.Unmarshal(buf, &members), `json.Unmarshal should succeed`) {
+		return
+	}
+	if !assert.Equal(t, "primary", members["issuer"], `first occurrence of a member should be kept`) {
+		return
+	}
+	if !assert.Equal(t, "yesterday", members["updated"], `members should be merged`) {
+		return
 	}
 }
@@ -9,6 +9,21 @@ func (ops *KeyOperationList) Get() KeyOperationList {
 	return *ops
 }
 
+// canPerform reports whether op is included in the list. A nil list
+// means that the "key_ops" field was not specified, in which case
+// all operations are allowed
+func (ops *KeyOperationList) canPerform(op KeyOperation) bool {
+	if ops == nil {
+		return true
+	}
+	for _, v := range *ops {
+		if v == op {
+			return true
+		}
+	}
+	return false
+}
+
 func (ops *KeyOperationList) Accept(v interface{}) error {
 	switch x := v.(type) {
 	case string:
@@ -0,0 +1,208 @@
+package jwk
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"fmt"
+
+	"github.com/lestrrat-go/jwx/internal/base64"
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/x25519"
+	"github.com/pkg/errors"
+)
+
+func NewOKPPublicKey() OKPPublicKey {
+	return newOKPPublicKey()
+}
+
+func newOKPPublicKey() *okpPublicKey {
+	return &okpPublicKey{
+		privateParams: make(map[string]interface{}),
+	}
+}
+
+func NewOKPPrivateKey() OKPPrivateKey {
+	return newOKPPrivateKey()
+}
+
+func newOKPPrivateKey() *okpPrivateKey {
+	return &okpPrivateKey{
+		privateParams: make(map[string]interface{}),
+	}
+}
+
+// FromRaw initializes the key using an ed25519.PublicKey or x25519.PublicKey
+func (k *okpPublicKey) FromRaw(rawKeyIf interface{}) error {
+	switch rawKey := rawKeyIf.(type) {
+	case ed25519.PublicKey:
+		if len(rawKey) != ed25519.PublicKeySize {
+			return errors.Errorf(`invalid ed25519.PublicKey length %d`, len(rawKey))
+		}
+		k.x = append([]byte(nil), rawKey...)
+		if err := k.Set(OKPCrvKey, jwa.Ed25519); err != nil {
+			return errors.Wrap(err, `failed to set header`)
+		}
+	case x25519.PublicKey:
+		if len(rawKey) != x25519.PublicKeySize {
+			return errors.Errorf(`invalid x25519.PublicKey length %d`, len(rawKey))
+		}
+		k.x = append([]byte(nil), rawKey...)
+		if err := k.Set(OKPCrvKey, jwa.X25519); err != nil {
+			return errors.Wrap(err, `failed to set header`)
+		}
+	default:
+		return errors.Errorf(`unknown key type %T`, rawKeyIf)
+	}
+
+	return nil
+}
+
+// FromRaw initializes the key using an ed25519.PrivateKey or x25519.PrivateKey
+func (k *okpPrivateKey) FromRaw(rawKeyIf interface{}) error {
+	switch rawKey := rawKeyIf.(type) {
+	case ed25519.PrivateKey:
+		if len(rawKey) != ed25519.PrivateKeySize {
+			return errors.Errorf(`invalid ed25519.PrivateKey length %d`, len(rawKey))
+		}
+		k.d = rawKey.Seed()
+		k.x = append([]byte(nil), rawKey.Public().(ed25519.PublicKey)...)
+		if err := k.Set(OKPCrvKey, jwa.Ed25519); err != nil {
+			return errors.Wrap(err, `failed to set header`)
+		}
+	case x25519.PrivateKey:
+		if len(rawKey) != x25519.PrivateKeySize {
+			return errors.Errorf(`invalid x25519.PrivateKey length %d`, len(rawKey))
+		}
+		k.d = rawKey.Seed()
+		k.x = append([]byte(nil), rawKey.Public().(x25519.PublicKey)...)
+		if err := k.Set(OKPCrvKey, jwa.X25519); err != nil {
+			return errors.Wrap(err, `failed to set header`)
+		}
+	default:
+		return errors.Errorf(`unknown key type %T`, rawKeyIf)
+	}
+
+	return nil
+}
+
+func buildOKPPublicKey(alg jwa.EllipticCurveAlgorithm, xbuf []byte) (interface{}, error) {
+	switch alg {
+	case jwa.Ed25519:
+		if len(xbuf) != ed25519.PublicKeySize {
+			return nil, errors.Errorf(`invalid Ed25519 public key length %d`, len(xbuf))
+		}
+		return ed25519.PublicKey(append([]byte(nil), xbuf...)), nil
+	case jwa.X25519:
+		if len(xbuf) != x25519.PublicKeySize {
+			return nil, errors.Errorf(`invalid X25519 public key length %d`, len(xbuf))
+		}
+		return x25519.PublicKey(append([]byte(nil), xbuf...)), nil
+	default:
+		return nil, errors.Errorf(`invalid curve algorithm %s`, alg)
+	}
+}
+
+// Raw returns the public key represented by this JWK
+func (k *okpPublicKey) Raw(v interface{}) error {
+	pubk, err := buildOKPPublicKey(k.Crv(), k.x)
+	if err != nil {
+		return errors.Wrap(err, `failed to build public key`)
+	}
+
+	return assignRawResult(v, pubk)
+}
+
+func buildOKPPrivateKey(alg jwa.EllipticCurveAlgorithm, xbuf []byte, dbuf []byte) (interface{}, error) {
+	switch alg {
+	case jwa.Ed25519:
+		if len(dbuf) != ed25519.SeedSize {
+			return nil, errors.Errorf(`invalid Ed25519 private key length %d`, len(dbuf))
+		}
+		ret := ed25519.NewKeyFromSeed(dbuf)
+		if len(xbuf) > 0 && !bytes.Equal(xbuf, ret.Public().(ed25519.PublicKey)) {
+			return nil, errors.New(`invalid Ed25519 private key: public key (x) does not match private key (d)`)
+		}
+		return ret, nil
+	case jwa.X25519:
+		if len(dbuf) != x25519.SeedSize {
+			return nil, errors.Errorf(`invalid X25519 private key length %d`, len(dbuf))
+		}
+		ret, err := x25519.NewKeyFromSeed(dbuf)
+		if err != nil {
+			return nil, errors.Wrap(err, `failed to create X25519 private key`)
+		}
+		if len(xbuf) > 0 && !bytes.Equal(xbuf, ret.Public().(x25519.PublicKey)) {
+			return nil, errors.New(`invalid X25519 private key: public key (x) does not match private key (d)`)
+		}
+		return ret, nil
+	default:
+		return nil, errors.Errorf(`invalid curve algorithm %s`, alg)
+	}
+}
+
+// Raw returns the private key represented by this JWK
+func (k *okpPrivateKey) Raw(v interface{}) error {
+	privk, err := buildOKPPrivateKey(k.Crv(), k.x, k.d)
+	if err != nil {
+		return errors.Wrap(err, `failed to build private key`)
+	}
+
+	return assignRawResult(v, privk)
+}
+
+func (k *okpPrivateKey) PublicKey() (OKPPublicKey, error) {
+	var privk interface{}
+	if err := k.Raw(&privk); err != nil {
+		return nil, errors.Wrap(err, `failed to materialize OKP private key`)
+	}
+
+	var pubk interface{}
+	switch privk := privk.(type) {
+	case ed25519.PrivateKey:
+		pubk = privk.Public()
+	case x25519.PrivateKey:
+		pubk = privk.Public()
+	default:
+		return nil, errors.Errorf(`unknown private key type %T`, privk)
+	}
+
+	newKey := NewOKPPublicKey()
+	if err := newKey.FromRaw(pubk); err != nil {
+		return nil, errors.Wrap(err, `failed to initialize OKPPublicKey`)
+	}
+	if err := copyPublicKeyFields(newKey, k); err != nil {
+		return nil, errors.Wrap(err, `failed to copy fields to OKPPublicKey`)
+	}
+	return newKey, nil
+}
+
+func okpThumbprint(hash crypto.Hash, crv, x string) []byte {
+	h := hash.New()
+	fmt.Fprint(h, `{"crv":"`)
+	fmt.Fprint(h, crv)
+	fmt.Fprint(h, `","kty":"OKP","x":"`)
+	fmt.Fprint(h, x)
+	fmt.Fprint(h, `"}`)
+	return h.Sum(nil)
+}
+
+// Thumbprint returns the JWK thumbprint using the indicated
+// hashing algorithm, according to RFC 7638 and RFC 8037
+func (k okpPublicKey) Thumbprint(hash crypto.Hash) ([]byte, error) {
+	return okpThumbprint(
+		hash,
+		k.Crv().String(),
+		base64.EncodeToString(k.x),
+	), nil
+}
+
+// Thumbprint returns the JWK thumbprint using the indicated
+// hashing algorithm, according to RFC 7638 and RFC 8037
+func (k okpPrivateKey) Thumbprint(hash crypto.Hash) ([]byte, error) {
+	return okpThumbprint(
+		hash,
+		k.Crv().String(),
+		base64.EncodeToString(k.x),
+	), nil
+}
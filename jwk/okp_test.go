@@ -0,0 +1,152 @@
+package jwk_test
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/internal/base64"
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/x25519"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOKP(t *testing.T) {
+	// Test vectors from https://tools.ietf.org/html/rfc8037#appendix-A
+	const privKeySrc = `{"kty":"OKP","crv":"Ed25519",
+   "d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A",
+   "x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`
+	const pubKeySrc = `{"kty":"OKP","crv":"Ed25519",
+   "x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`
+
+	t.Run("Parse Private Key", func(t *testing.T) {
+		set, err := jwk.ParseString(privKeySrc)
+		if !assert.NoError(t, err, `jwk.ParseString should succeed`) {
+			return
+		}
+
+		privKey, ok := set.Keys[0].(jwk.OKPPrivateKey)
+		if !assert.True(t, ok, `should be jwk.OKPPrivateKey`) {
+			return
+		}
+
+		if !assert.Equal(t, jwa.OKP, privKey.KeyType(), `kty should be OKP`) {
+			return
+		}
+
+		if !assert.Equal(t, jwa.Ed25519, privKey.Crv(), `crv should be Ed25519`) {
+			return
+		}
+
+		var rawPrivKey ed25519.PrivateKey
+		if !assert.NoError(t, privKey.Raw(&rawPrivKey), `Raw should succeed`) {
+			return
+		}
+
+		pubKey, err := privKey.PublicKey()
+		if !assert.NoError(t, err, `PublicKey should succeed`) {
+			return
+		}
+
+		if !assert.Equal(t, privKey.X(), pubKey.X(), `X should match`) {
+			return
+		}
+
+		var rawPubKey ed25519.PublicKey
+		if !assert.NoError(t, pubKey.Raw(&rawPubKey), `Raw should succeed`) {
+			return
+		}
+
+		if !assert.Equal(t, rawPrivKey.Public(), rawPubKey, `public keys should match`) {
+			return
+		}
+	})
+	t.Run("Thumbprint", func(t *testing.T) {
+		const expected = `kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k`
+
+		for _, src := range []string{privKeySrc, pubKeySrc} {
+			key, err := jwk.ParseKey([]byte(src))
+			if !assert.NoError(t, err, `jwk.ParseKey should succeed`) {
+				return
+			}
+
+			tp, err := key.Thumbprint(crypto.SHA256)
+			if !assert.NoError(t, err, `Thumbprint should succeed`) {
+				return
+			}
+
+			if !assert.Equal(t, expected, base64.EncodeToString(tp), `thumbprint should match`) {
+				return
+			}
+		}
+	})
+	t.Run("FromRaw", func(t *testing.T) {
+		rawPubKey, rawPrivKey, err := ed25519.GenerateKey(rand.Reader)
+		if !assert.NoError(t, err, `ed25519.GenerateKey should succeed`) {
+			return
+		}
+
+		privKey := jwk.NewOKPPrivateKey()
+		if !assert.NoError(t, privKey.FromRaw(rawPrivKey), `FromRaw should succeed`) {
+			return
+		}
+
+		var gotPrivKey ed25519.PrivateKey
+		if !assert.NoError(t, privKey.Raw(&gotPrivKey), `Raw should succeed`) {
+			return
+		}
+
+		if !assert.Equal(t, rawPrivKey, gotPrivKey, `private keys should match`) {
+			return
+		}
+
+		pubKey := jwk.NewOKPPublicKey()
+		if !assert.NoError(t, pubKey.FromRaw(rawPubKey), `FromRaw should succeed`) {
+			return
+		}
+
+		var gotPubKey ed25519.PublicKey
+		if !assert.NoError(t, pubKey.Raw(&gotPubKey), `Raw should succeed`) {
+			return
+		}
+
+		if !assert.Equal(t, rawPubKey, gotPubKey, `public keys should match`) {
+			return
+		}
+
+		if !assert.Error(t, pubKey.FromRaw(rawPrivKey), `FromRaw with a private key should fail`) {
+			return
+		}
+	})
+	t.Run("FromRaw (X25519)", func(t *testing.T) {
+		rawPubKey, rawPrivKey, err := x25519.GenerateKey(rand.Reader)
+		if !assert.NoError(t, err, `x25519.GenerateKey should succeed`) {
+			return
+		}
+
+		privKey := jwk.NewOKPPrivateKey()
+		if !assert.NoError(t, privKey.FromRaw(rawPrivKey), `FromRaw should succeed`) {
+			return
+		}
+
+		if !assert.Equal(t, jwa.X25519, privKey.Crv(), `crv should be X25519`) {
+			return
+		}
+
+		pubKey, err := privKey.PublicKey()
+		if !assert.NoError(t, err, `PublicKey should succeed`) {
+			return
+		}
+
+		var gotPubKey x25519.PublicKey
+		if !assert.NoError(t, pubKey.Raw(&gotPubKey), `Raw should succeed`) {
+			return
+		}
+
+		if !assert.Equal(t, rawPubKey, gotPubKey, `public keys should match`) {
+			return
+		}
+	})
+}
@@ -3,6 +3,7 @@ package jwk
 import (
 	"crypto"
 	"net/http"
+	"time"
 
 	"github.com/lestrrat-go/jwx/internal/option"
 )
@@ -10,14 +11,94 @@ import (
 type Option = option.Interface
 
 const (
-	optkeyHTTPClient     = `http-client`
-	optkeyThumbprintHash = `thumbprint-hash`
+	optkeyHTTPClient          = `http-client`
+	optkeyThumbprintHash      = `thumbprint-hash`
+	optkeyPEM                 = `pem`
+	optkeyStrictValidation    = `strict-validation`
+	optkeyMinRefreshInterval  = `min-refresh-interval`
+	optkeyMaxRefreshInterval  = `max-refresh-interval`
+	optkeyRefreshErrorHandler = `refresh-error-handler`
+	optkeyHTTPMaxBodySize     = `http-max-body-size`
+	optkeyValidate            = `validate`
+	optkeyRejectSymmetricKeys = `reject-symmetric-keys`
 )
 
 func WithHTTPClient(cl *http.Client) Option {
 	return option.New(optkeyHTTPClient, cl)
 }
 
+// WithThumbprintHash specifies the hashing algorithm to be used when
+// computing the thumbprint in `jwk.AssignKeyID`. The default is crypto.SHA256
 func WithThumbprintHash(h crypto.Hash) Option {
 	return option.New(optkeyThumbprintHash, h)
 }
+
+// WithPEM specifies that the input to `jwk.Parse` and `jwk.ParseKey`
+// (and their variants) is PEM encoded, instead of JSON. PEM blocks
+// containing PKCS#1, PKCS#8, SEC 1 (EC) and PKIX (SubjectPublicKeyInfo)
+// encoded keys, as well as certificates, are understood
+func WithPEM(b bool) Option {
+	return option.New(optkeyPEM, b)
+}
+
+// WithStrictValidation specifies that `jwk.Parse` and `jwk.ParseKey`
+// (and their variants) should verify that the "use" and "alg" fields
+// of each key are consistent with each other and with the key type.
+// For example, an RSA key with "alg" set to "ES256", or a key with "use"
+// set to "enc" and "alg" set to a signature algorithm are rejected.
+//
+// By default no such validation is performed
+func WithStrictValidation(b bool) Option {
+	return option.New(optkeyStrictValidation, b)
+}
+
+// WithValidate specifies that `jwk.Parse` and `jwk.ParseKey` (and
+// their variants) should check that the parameters of each RSA private
+// key are consistent with each other, by materializing the key and
+// calling `rsa.PrivateKey.Validate`. This detects malformed or tampered
+// keys whose "d", "p" and "q" fields do not correspond, which would
+// otherwise silently produce invalid signatures.
+//
+// By default no such validation is performed, as it is relatively expensive
+func WithValidate(b bool) Option {
+	return option.New(optkeyValidate, b)
+}
+
+// WithRejectSymmetricKeys specifies that `jwk.PublicSetOf` should
+// return an error when it encounters a symmetric key, instead of
+// silently dropping it from the resulting Set
+func WithRejectSymmetricKeys(b bool) Option {
+	return option.New(optkeyRejectSymmetricKeys, b)
+}
+
+// WithMinRefreshInterval specifies the minimum interval between
+// refreshes of a JWKS configured in `jwk.AutoRefresh`. This value
+// is also used when the server does not specify "max-age", and as the
+// initial interval when retrying after a failed refresh. Values smaller
+// than one second are rounded up to one second
+func WithMinRefreshInterval(d time.Duration) Option {
+	return option.New(optkeyMinRefreshInterval, d)
+}
+
+// WithMaxRefreshInterval specifies the maximum interval between
+// refreshes of a JWKS configured in `jwk.AutoRefresh`, regardless
+// of the "max-age" specified by the server. This value also bounds
+// the interval between retries after failed refreshes
+func WithMaxRefreshInterval(d time.Duration) Option {
+	return option.New(optkeyMaxRefreshInterval, d)
+}
+
+// WithRefreshErrorHandler specifies the function that is called when
+// `jwk.AutoRefresh` fails to refresh a JWKS in the background
+func WithRefreshErrorHandler(fn func(url string, err error)) Option {
+	return option.New(optkeyRefreshErrorHandler, fn)
+}
+
+// WithHTTPMaxBodySize specifies the maximum size in bytes of the
+// response body when fetching a remote JWKS using `jwk.FetchHTTP`,
+// `jwk.Cache` or `jwk.AutoRefresh` (and their variants). Responses
+// exceeding this size are rejected, which protects against misbehaving
+// servers. The default is 10MB. Specifying a value <= 0 disables the check
+func WithHTTPMaxBodySize(n int64) Option {
+	return option.New(optkeyHTTPMaxBodySize, n)
+}
@@ -0,0 +1,197 @@
+package jwk
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"strings"
+
+	"github.com/lestrrat-go/jwx/x25519"
+	"github.com/pkg/errors"
+)
+
+// oidX25519 is the object identifier for X25519 keys (RFC 8410).
+// "crypto/x509" did not support these until Go 1.20, so we handle
+// them by ourselves
+var oidX25519 = asn1.ObjectIdentifier{1, 3, 101, 110}
+
+type pkixPublicKey struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+type pkcs8PrivateKey struct {
+	Version    int
+	Algorithm  pkix.AlgorithmIdentifier
+	PrivateKey []byte
+	// optional attributes omitted
+}
+
+// parsePEM parses all of the PEM blocks in the given data and creates
+// a jwk.Key for each of them. "EC PARAMETERS" blocks, which are emitted
+// by OpenSSL along with EC private keys, are ignored
+func parsePEM(data []byte) ([]Key, error) {
+	var keys []Key
+	for i := 0; ; i++ {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		if block.Type == "EC PARAMETERS" {
+			continue
+		}
+
+		key, err := parsePEMBlock(block)
+		if err != nil {
+			return nil, errors.Wrapf(err, `failed to parse PEM block #%d (%s)`, i+1, block.Type)
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New(`failed to find any keys in PEM encoded data`)
+	}
+	return keys, nil
+}
+
+func parsePEMBlock(block *pem.Block) (Key, error) {
+	// We can't transparently decrypt these, as we don't know the password
+	if block.Type == "ENCRYPTED PRIVATE KEY" || strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED") {
+		return nil, errors.New(`encrypted PEM blocks are not supported`)
+	}
+
+	var raw interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		raw, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		raw, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		raw, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		raw, err = parsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		raw, err = parsePKIXPublicKey(block.Bytes)
+	case "CERTIFICATE":
+		raw, err = x509.ParseCertificate(block.Bytes)
+	default:
+		return nil, errors.Errorf(`unsupported PEM block type %s`, block.Type)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to parse key`)
+	}
+
+	return newFromPEMRawKey(raw)
+}
+
+func newFromPEMRawKey(raw interface{}) (Key, error) {
+	switch raw := raw.(type) {
+	case ed25519.PrivateKey, x25519.PrivateKey:
+		k := NewOKPPrivateKey()
+		if err := k.FromRaw(raw); err != nil {
+			return nil, errors.Wrapf(err, `failed to initialize %T from %T`, k, raw)
+		}
+		return k, nil
+	case ed25519.PublicKey, x25519.PublicKey:
+		k := NewOKPPublicKey()
+		if err := k.FromRaw(raw); err != nil {
+			return nil, errors.Wrapf(err, `failed to initialize %T from %T`, k, raw)
+		}
+		return k, nil
+	default:
+		return New(raw)
+	}
+}
+
+func parsePKCS8PrivateKey(der []byte) (interface{}, error) {
+	var v pkcs8PrivateKey
+	if _, err := asn1.Unmarshal(der, &v); err == nil && v.Algorithm.Algorithm.Equal(oidX25519) {
+		var seed []byte
+		if _, err := asn1.Unmarshal(v.PrivateKey, &seed); err != nil {
+			return nil, errors.Wrap(err, `failed to parse X25519 private key`)
+		}
+		return x25519.NewKeyFromSeed(seed)
+	}
+	return x509.ParsePKCS8PrivateKey(der)
+}
+
+func parsePKIXPublicKey(der []byte) (interface{}, error) {
+	var v pkixPublicKey
+	if _, err := asn1.Unmarshal(der, &v); err == nil && v.Algorithm.Algorithm.Equal(oidX25519) {
+		pub := v.PublicKey.RightAlign()
+		if len(pub) != x25519.PublicKeySize {
+			return nil, errors.Errorf(`invalid X25519 public key length %d`, len(pub))
+		}
+		return x25519.PublicKey(pub), nil
+	}
+	return x509.ParsePKIXPublicKey(der)
+}
+
+// EncodePEM encodes the key in PEM format. RSA and EC private keys
+// are encoded in PKCS#1 and SEC 1 formats ("RSA PRIVATE KEY" and
+// "EC PRIVATE KEY"), OKP private keys are encoded in PKCS#8 format
+// ("PRIVATE KEY"), and all public keys are encoded in PKIX format
+// ("PUBLIC KEY"). Symmetric keys cannot be encoded in PEM format
+func EncodePEM(key Key) ([]byte, error) {
+	var raw interface{}
+	if err := key.Raw(&raw); err != nil {
+		return nil, errors.Wrap(err, `failed to get raw key`)
+	}
+
+	var block pem.Block
+	var err error
+	switch raw := raw.(type) {
+	case *rsa.PrivateKey:
+		block.Type = "RSA PRIVATE KEY"
+		block.Bytes = x509.MarshalPKCS1PrivateKey(raw)
+	case *ecdsa.PrivateKey:
+		block.Type = "EC PRIVATE KEY"
+		block.Bytes, err = x509.MarshalECPrivateKey(raw)
+	case ed25519.PrivateKey:
+		block.Type = "PRIVATE KEY"
+		block.Bytes, err = x509.MarshalPKCS8PrivateKey(raw)
+	case x25519.PrivateKey:
+		block.Type = "PRIVATE KEY"
+		block.Bytes, err = marshalX25519PrivateKey(raw)
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		block.Type = "PUBLIC KEY"
+		block.Bytes, err = x509.MarshalPKIXPublicKey(raw)
+	case x25519.PublicKey:
+		block.Type = "PUBLIC KEY"
+		block.Bytes, err = asn1.Marshal(pkixPublicKey{
+			Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidX25519},
+			PublicKey: asn1.BitString{Bytes: raw, BitLength: 8 * len(raw)},
+		})
+	default:
+		return nil, errors.Errorf(`unsupported key type for PEM encoding (%T)`, raw)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, `failed to marshal %s`, block.Type)
+	}
+
+	var buf bytes.Buffer
+	if err := pem.Encode(&buf, &block); err != nil {
+		return nil, errors.Wrap(err, `failed to encode PEM block`)
+	}
+	return buf.Bytes(), nil
+}
+
+func marshalX25519PrivateKey(key x25519.PrivateKey) ([]byte, error) {
+	seed, err := asn1.Marshal(key.Seed())
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to marshal X25519 private key`)
+	}
+
+	return asn1.Marshal(pkcs8PrivateKey{
+		Algorithm:  pkix.AlgorithmIdentifier{Algorithm: oidX25519},
+		PrivateKey: seed,
+	})
+}
@@ -41,6 +41,14 @@ func (k *rsaPrivateKey) FromRaw(rawKey *rsa.PrivateKey) error {
 	k.p = rawKey.Primes[0].Bytes()
 	k.q = rawKey.Primes[1].Bytes()
 
+	// Always include the CRT parameters, computing them on a copy of
+	// the key if needed, so that the caller's key is not modified
+	if rawKey.Precomputed.Dp == nil {
+		cp := *rawKey
+		cp.Precompute()
+		rawKey = &cp
+	}
+
 	if v := rawKey.Precomputed.Dp; v != nil {
 		k.dp = v.Bytes()
 	}
@@ -126,6 +134,12 @@ func (k *rsaPrivateKey) Raw(v interface{}) error {
 		key.Precomputed.Qinv = qi
 	}
 
+	// Precompute fills in the CRT values that were not present in the
+	// JWK, and prepares the key for fast private key operations
+	if len(k.p) > 0 && len(k.q) > 0 {
+		key.Precompute()
+	}
+
 	return assignRawResult(v, &key)
 }
 
@@ -157,6 +171,9 @@ func (k rsaPrivateKey) PublicKey() (RSAPublicKey, error) {
 	if err := newKey.FromRaw(&key.PublicKey); err != nil {
 		return nil, errors.Wrap(err, `failed to initialize RSAPublicKey`)
 	}
+	if err := copyPublicKeyFields(newKey, &k); err != nil {
+		return nil, errors.Wrap(err, `failed to copy fields to RSAPublicKey`)
+	}
 	return newKey, nil
 }
 
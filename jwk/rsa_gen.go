@@ -67,17 +67,17 @@ type rsaPrivateKey struct {
 type rsaPrivateKeyMarshalProxy struct {
 	XkeyType                jwa.KeyType       `json:"kty"`
 	Xalgorithm              *string           `json:"alg,omitempty"`
+	XkeyUsage               *string           `json:"use,omitempty"`
+	XkeyID                  *string           `json:"kid,omitempty"`
 	Xd                      *string           `json:"d,omitempty"`
 	Xdp                     *string           `json:"dp,omitempty"`
 	Xdq                     *string           `json:"dq,omitempty"`
 	Xe                      *string           `json:"e,omitempty"`
-	XkeyID                  *string           `json:"kid,omitempty"`
-	XkeyUsage               *string           `json:"use,omitempty"`
-	Xkeyops                 *KeyOperationList `json:"key_ops,omitempty"`
 	Xn                      *string           `json:"n,omitempty"`
 	Xp                      *string           `json:"p,omitempty"`
 	Xq                      *string           `json:"q,omitempty"`
 	Xqi                     *string           `json:"qi,omitempty"`
+	Xkeyops                 *KeyOperationList `json:"key_ops,omitempty"`
 	Xx509CertChain          *CertificateChain `json:"x5c,omitempty"`
 	Xx509CertThumbprint     *string           `json:"x5t,omitempty"`
 	Xx509CertThumbprintS256 *string           `json:"x5t#S256,omitempty"`
@@ -343,6 +343,18 @@ func (h *rsaPrivateKey) Set(name string, value interface{}) error {
 		switch v := value.(type) {
 		case string:
 			h.algorithm = &v
+		case jwa.SignatureAlgorithm:
+			if !isSignatureAlgorithm(h.KeyType(), v.String()) {
+				return errors.Errorf(`algorithm %q cannot be used with key type %q`, v, h.KeyType())
+			}
+			tmp := v.String()
+			h.algorithm = &tmp
+		case jwa.KeyEncryptionAlgorithm:
+			if !isKeyEncryptionAlgorithm(h.KeyType(), v.String()) {
+				return errors.Errorf(`algorithm %q cannot be used with key type %q`, v, h.KeyType())
+			}
+			tmp := v.String()
+			h.algorithm = &tmp
 		case fmt.Stringer:
 			tmp := v.String()
 			h.algorithm = &tmp
@@ -442,6 +454,8 @@ func (h *rsaPrivateKey) Set(name string, value interface{}) error {
 			return nil
 		}
 		return errors.Errorf(`invalid value for %s key: %T`, X509URLKey, value)
+	case "crv":
+		return errors.Errorf(`"crv" cannot be used with key type %q`, h.KeyType())
 	default:
 		if h.privateParams == nil {
 			h.privateParams = map[string]interface{}{}
@@ -559,6 +573,9 @@ func (h *rsaPrivateKey) UnmarshalJSON(buf []byte) error {
 	delete(m, X509CertThumbprintKey)
 	delete(m, X509CertThumbprintS256Key)
 	delete(m, X509URLKey)
+	if _, ok := m["crv"]; ok {
+		return errors.Errorf(`"crv" cannot be used with key type %q`, jwa.RSA)
+	}
 	h.privateParams = m
 	return nil
 }
@@ -647,6 +664,103 @@ func (h *rsaPrivateKey) AsMap(ctx context.Context) (map[string]interface{}, erro
 	return iter.AsMap(ctx, h)
 }
 
+func (h *rsaPrivateKey) ValidateX509() error {
+	return validateX509(h)
+}
+
+func (h *rsaPrivateKey) SetX509CertChain(chain []*x509.Certificate) error {
+	return h.Set(X509CertChainKey, chain)
+}
+
+func (h *rsaPrivateKey) CanPerform(op KeyOperation) bool {
+	return h.keyops.canPerform(op)
+}
+
+func (h *rsaPrivateKey) SignatureAlgorithm() jwa.SignatureAlgorithm {
+	return signatureAlgorithmOf(h)
+}
+
+func (h *rsaPrivateKey) KeyEncryptionAlgorithm() jwa.KeyEncryptionAlgorithm {
+	return keyEncryptionAlgorithmOf(h)
+}
+
+func (h *rsaPrivateKey) Clone() (Key, error) {
+	dst := &rsaPrivateKey{}
+	if h.algorithm != nil {
+		v := *h.algorithm
+		dst.algorithm = &v
+	}
+	if h.d != nil {
+		dst.d = make([]byte, len(h.d))
+		copy(dst.d, h.d)
+	}
+	if h.dp != nil {
+		dst.dp = make([]byte, len(h.dp))
+		copy(dst.dp, h.dp)
+	}
+	if h.dq != nil {
+		dst.dq = make([]byte, len(h.dq))
+		copy(dst.dq, h.dq)
+	}
+	if h.e != nil {
+		dst.e = make([]byte, len(h.e))
+		copy(dst.e, h.e)
+	}
+	if h.keyID != nil {
+		v := *h.keyID
+		dst.keyID = &v
+	}
+	if h.keyUsage != nil {
+		v := *h.keyUsage
+		dst.keyUsage = &v
+	}
+	if h.keyops != nil {
+		v := make(KeyOperationList, len(*h.keyops))
+		copy(v, *h.keyops)
+		dst.keyops = &v
+	}
+	if h.n != nil {
+		dst.n = make([]byte, len(h.n))
+		copy(dst.n, h.n)
+	}
+	if h.p != nil {
+		dst.p = make([]byte, len(h.p))
+		copy(dst.p, h.p)
+	}
+	if h.q != nil {
+		dst.q = make([]byte, len(h.q))
+		copy(dst.q, h.q)
+	}
+	if h.qi != nil {
+		dst.qi = make([]byte, len(h.qi))
+		copy(dst.qi, h.qi)
+	}
+	if h.x509CertChain != nil {
+		v := CertificateChain{certs: make([]*x509.Certificate, len(h.x509CertChain.certs))}
+		copy(v.certs, h.x509CertChain.certs)
+		dst.x509CertChain = &v
+	}
+	if h.x509CertThumbprint != nil {
+		v := *h.x509CertThumbprint
+		dst.x509CertThumbprint = &v
+	}
+	if h.x509CertThumbprintS256 != nil {
+		v := *h.x509CertThumbprintS256
+		dst.x509CertThumbprintS256 = &v
+	}
+	if h.x509URL != nil {
+		v := *h.x509URL
+		dst.x509URL = &v
+	}
+	if h.privateParams != nil {
+		dst.privateParams = make(map[string]interface{}, len(h.privateParams))
+		for k, v := range h.privateParams {
+			dst.privateParams[k] = cloneParam(v)
+		}
+	}
+	return dst, nil
+}
+
 type RSAPublicKey interface {
 	Key
 	FromRaw(*rsa.PublicKey) error
@@ -671,11 +785,11 @@ type rsaPublicKey struct {
 type rsaPublicKeyMarshalProxy struct {
 	XkeyType                jwa.KeyType       `json:"kty"`
 	Xalgorithm              *string           `json:"alg,omitempty"`
-	Xe                      *string           `json:"e,omitempty"`
-	XkeyID                  *string           `json:"kid,omitempty"`
 	XkeyUsage               *string           `json:"use,omitempty"`
-	Xkeyops                 *KeyOperationList `json:"key_ops,omitempty"`
+	XkeyID                  *string           `json:"kid,omitempty"`
+	Xe                      *string           `json:"e,omitempty"`
 	Xn                      *string           `json:"n,omitempty"`
+	Xkeyops                 *KeyOperationList `json:"key_ops,omitempty"`
 	Xx509CertChain          *CertificateChain `json:"x5c,omitempty"`
 	Xx509CertThumbprint     *string           `json:"x5t,omitempty"`
 	Xx509CertThumbprintS256 *string           `json:"x5t#S256,omitempty"`
@@ -869,6 +983,18 @@ func (h *rsaPublicKey) Set(name string, value interface{}) error {
 		switch v := value.(type) {
 		case string:
 			h.algorithm = &v
+		case jwa.SignatureAlgorithm:
+			if !isSignatureAlgorithm(h.KeyType(), v.String()) {
+				return errors.Errorf(`algorithm %q cannot be used with key type %q`, v, h.KeyType())
+			}
+			tmp := v.String()
+			h.algorithm = &tmp
+		case jwa.KeyEncryptionAlgorithm:
+			if !isKeyEncryptionAlgorithm(h.KeyType(), v.String()) {
+				return errors.Errorf(`algorithm %q cannot be used with key type %q`, v, h.KeyType())
+			}
+			tmp := v.String()
+			h.algorithm = &tmp
 		case fmt.Stringer:
 			tmp := v.String()
 			h.algorithm = &tmp
@@ -932,6 +1058,8 @@ func (h *rsaPublicKey) Set(name string, value interface{}) error {
 			return nil
 		}
 		return errors.Errorf(`invalid value for %s key: %T`, X509URLKey, value)
+	case "crv":
+		return errors.Errorf(`"crv" cannot be used with key type %q`, h.KeyType())
 	default:
 		if h.privateParams == nil {
 			h.privateParams = map[string]interface{}{}
@@ -992,6 +1120,9 @@ func (h *rsaPublicKey) UnmarshalJSON(buf []byte) error {
 	delete(m, X509CertThumbprintKey)
 	delete(m, X509CertThumbprintS256Key)
 	delete(m, X509URLKey)
+	if _, ok := m["crv"]; ok {
+		return errors.Errorf(`"crv" cannot be used with key type %q`, jwa.RSA)
+	}
 	h.privateParams = m
 	return nil
 }
@@ -1055,3 +1186,76 @@ func (h *rsaPublicKey) Walk(ctx context.Context, visitor HeaderVisitor) error {
 func (h *rsaPublicKey) AsMap(ctx context.Context) (map[string]interface{}, error) {
 	return iter.AsMap(ctx, h)
 }
+
+func (h *rsaPublicKey) ValidateX509() error {
+	return validateX509(h)
+}
+
+func (h *rsaPublicKey) SetX509CertChain(chain []*x509.Certificate) error {
+	return h.Set(X509CertChainKey, chain)
+}
+
+func (h *rsaPublicKey) CanPerform(op KeyOperation) bool {
+	return h.keyops.canPerform(op)
+}
+
+func (h *rsaPublicKey) SignatureAlgorithm() jwa.SignatureAlgorithm {
+	return signatureAlgorithmOf(h)
+}
+
+func (h *rsaPublicKey) KeyEncryptionAlgorithm() jwa.KeyEncryptionAlgorithm {
+	return keyEncryptionAlgorithmOf(h)
+}
+
+func (h *rsaPublicKey) Clone() (Key, error) {
+	dst := &rsaPublicKey{}
+	if h.algorithm != nil {
+		v := *h.algorithm
+		dst.algorithm = &v
+	}
+	if h.e != nil {
+		dst.e = make([]byte, len(h.e))
+		copy(dst.e, h.e)
+	}
+	if h.keyID != nil {
+		v := *h.keyID
+		dst.keyID = &v
+	}
+	if h.keyUsage != nil {
+		v := *h.keyUsage
+		dst.keyUsage = &v
+	}
+	if h.keyops != nil {
+		v := make(KeyOperationList, len(*h.keyops))
+		copy(v, *h.keyops)
+		dst.keyops = &v
+	}
+	if h.n != nil {
+		dst.n = make([]byte, len(h.n))
+		copy(dst.n, h.n)
+	}
+	if h.x509CertChain != nil {
+		v := CertificateChain{certs: make([]*x509.Certificate, len(h.x509CertChain.certs))}
+		copy(v.certs, h.x509CertChain.certs)
+		dst.x509CertChain = &v
+	}
+	if h.x509CertThumbprint != nil {
+		v := *h.x509CertThumbprint
+		dst.x509CertThumbprint = &v
+	}
+	if h.x509CertThumbprintS256 != nil {
+		v := *h.x509CertThumbprintS256
+		dst.x509CertThumbprintS256 = &v
+	}
+	if h.x509URL != nil {
+		v := *h.x509URL
+		dst.x509URL = &v
+	}
+	if h.privateParams != nil {
+		dst.privateParams = make(map[string]interface{}, len(h.privateParams))
+		for k, v := range h.privateParams {
+			dst.privateParams[k] = cloneParam(v)
+		}
+	}
+	return dst, nil
+}
@@ -3,8 +3,10 @@ package jwk_test
 import (
 	"context"
 	"crypto"
+	"crypto/rand"
 	"crypto/rsa"
 	"encoding/json"
+	"math/big"
 	"testing"
 
 	"github.com/lestrrat-go/jwx/jwk"
@@ -142,6 +144,71 @@ func TestRSA(t *testing.T) {
 			return
 		}
 	})
+	t.Run("CRT parameters", func(t *testing.T) {
+		rawkey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if !assert.NoError(t, err, `rsa.GenerateKey should succeed`) {
+			return
+		}
+		expected := rawkey.Precomputed
+		rawkey.Precomputed = rsa.PrecomputedValues{}
+
+		key, err := jwk.New(rawkey)
+		if !assert.NoError(t, err, `jwk.New should succeed`) {
+			return
+		}
+		if !assert.Nil(t, rawkey.Precomputed.Dp, `jwk.New should not modify the raw key`) {
+			return
+		}
+
+		rsakey := key.(jwk.RSAPrivateKey)
+		for name, pair := range map[string][2]*big.Int{
+			"dp": {expected.Dp, new(big.Int).SetBytes(rsakey.DP())},
+			"dq": {expected.Dq, new(big.Int).SetBytes(rsakey.DQ())},
+			"qi": {expected.Qinv, new(big.Int).SetBytes(rsakey.QI())},
+		} {
+			if !assert.Equal(t, 0, pair[0].Cmp(pair[1]), `%s should match`, name) {
+				return
+			}
+		}
+
+		// Keys without the CRT values are completed when materialized
+		buf, err := json.Marshal(key)
+		if !assert.NoError(t, err, `json.Marshal should succeed`) {
+			return
+		}
+		var m map[string]interface{}
+		if !assert.NoError(t, json.Unmarshal(buf, &m), `json.Unmarshal should succeed`) {
+			return
+		}
+		for _, name := range []string{jwk.RSADPKey, jwk.RSADQKey, jwk.RSAQIKey} {
+			delete(m, name)
+		}
+		buf, err = json.Marshal(m)
+		if !assert.NoError(t, err, `json.Marshal should succeed`) {
+			return
+		}
+		parsed, err := jwk.ParseKey(buf)
+		if !assert.NoError(t, err, `jwk.ParseKey should succeed`) {
+			return
+		}
+
+		var materialized rsa.PrivateKey
+		if !assert.NoError(t, parsed.Raw(&materialized), `parsed.Raw should succeed`) {
+			return
+		}
+		if !assert.NoError(t, materialized.Validate(), `materialized key should be valid`) {
+			return
+		}
+		if !assert.Equal(t, 0, expected.Dp.Cmp(materialized.Precomputed.Dp), `Dp should match`) {
+			return
+		}
+		if !assert.Equal(t, 0, expected.Dq.Cmp(materialized.Precomputed.Dq), `Dq should match`) {
+			return
+		}
+		if !assert.Equal(t, 0, expected.Qinv.Cmp(materialized.Precomputed.Qinv), `Qinv should match`) {
+			return
+		}
+	})
 	t.Run("Thumbprint", func(t *testing.T) {
 		expected := []byte{55, 54, 203, 177, 120, 124, 184, 48, 156, 119, 238,
 			140, 55, 5, 197, 225, 111, 251, 158, 133, 151, 21, 144, 31, 30, 76, 89,
@@ -167,4 +234,46 @@ func TestRSA(t *testing.T) {
 			return
 		}
 	})
+	t.Run("PublicKey", func(t *testing.T) {
+		rawKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if !assert.NoError(t, err, `rsa.GenerateKey should succeed`) {
+			return
+		}
+
+		privkey := jwk.NewRSAPrivateKey()
+		if !assert.NoError(t, privkey.FromRaw(rawKey), `FromRaw should succeed`) {
+			return
+		}
+		for k, v := range map[string]interface{}{
+			jwk.KeyIDKey:     "my-key",
+			jwk.AlgorithmKey: "RS256",
+			jwk.KeyUsageKey:  "sig",
+		} {
+			if !assert.NoError(t, privkey.Set(k, v), `Set should succeed`) {
+				return
+			}
+		}
+
+		pubkey, err := privkey.PublicKey()
+		if !assert.NoError(t, err, `PublicKey should succeed`) {
+			return
+		}
+
+		var rawPubKey rsa.PublicKey
+		if !assert.NoError(t, pubkey.Raw(&rawPubKey), `Raw should succeed`) {
+			return
+		}
+		if !assert.Equal(t, rawKey.PublicKey, rawPubKey, `public keys should match`) {
+			return
+		}
+		if !assert.Equal(t, "my-key", pubkey.KeyID(), `kid should be preserved`) {
+			return
+		}
+		if !assert.Equal(t, "RS256", pubkey.Algorithm(), `alg should be preserved`) {
+			return
+		}
+		if !assert.Equal(t, "sig", pubkey.KeyUsage(), `use should be preserved`) {
+			return
+		}
+	})
 }
@@ -8,6 +8,8 @@ import (
 	"github.com/pkg/errors"
 )
 
+// NewSymmetricKey creates a new symmetric ("oct") key. Use FromRaw
+// to initialize it with the raw octets
 func NewSymmetricKey() SymmetricKey {
 	return newSymmetricKey()
 }
@@ -18,12 +20,14 @@ func newSymmetricKey() *symmetricKey {
 	}
 }
 
+// FromRaw initializes the key using the given octets. The octets
+// are copied, so the caller may modify rawKey afterwards
 func (k *symmetricKey) FromRaw(rawKey []byte) error {
 	if len(rawKey) == 0 {
 		return errors.New(`non-empty []byte key required`)
 	}
 
-	k.octets = rawKey
+	k.octets = append([]byte(nil), rawKey...)
 
 	return nil
 }
@@ -34,6 +38,12 @@ func (k symmetricKey) Raw(v interface{}) error {
 	return assignRawResult(v, k.octets)
 }
 
+// PublicKey always returns an error, as symmetric keys do not have
+// a public half that can be safely published
+func (k *symmetricKey) PublicKey() (Key, error) {
+	return nil, errors.New(`symmetric keys do not have a public key`)
+}
+
 // Thumbprint returns the JWK thumbprint using the indicated
 // hakhing algorithm, according to RFC 7638
 func (k symmetricKey) Thumbprint(hash crypto.Hash) ([]byte, error) {
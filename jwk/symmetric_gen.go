@@ -26,6 +26,7 @@ type SymmetricKey interface {
 	Key
 	FromRaw([]byte) error
 	Octets() []byte
+	PublicKey() (Key, error)
 }
 
 type symmetricKey struct {
@@ -44,10 +45,10 @@ type symmetricKey struct {
 type symmetricSymmetricKeyMarshalProxy struct {
 	XkeyType                jwa.KeyType       `json:"kty"`
 	Xalgorithm              *string           `json:"alg,omitempty"`
-	XkeyID                  *string           `json:"kid,omitempty"`
 	XkeyUsage               *string           `json:"use,omitempty"`
-	Xkeyops                 *KeyOperationList `json:"key_ops,omitempty"`
+	XkeyID                  *string           `json:"kid,omitempty"`
 	Xoctets                 *string           `json:"k,omitempty"`
+	Xkeyops                 *KeyOperationList `json:"key_ops,omitempty"`
 	Xx509CertChain          *CertificateChain `json:"x5c,omitempty"`
 	Xx509CertThumbprint     *string           `json:"x5t,omitempty"`
 	Xx509CertThumbprintS256 *string           `json:"x5t#S256,omitempty"`
@@ -229,6 +230,18 @@ func (h *symmetricKey) Set(name string, value interface{}) error {
 		switch v := value.(type) {
 		case string:
 			h.algorithm = &v
+		case jwa.SignatureAlgorithm:
+			if !isSignatureAlgorithm(h.KeyType(), v.String()) {
+				return errors.Errorf(`algorithm %q cannot be used with key type %q`, v, h.KeyType())
+			}
+			tmp := v.String()
+			h.algorithm = &tmp
+		case jwa.KeyEncryptionAlgorithm:
+			if !isKeyEncryptionAlgorithm(h.KeyType(), v.String()) {
+				return errors.Errorf(`algorithm %q cannot be used with key type %q`, v, h.KeyType())
+			}
+			tmp := v.String()
+			h.algorithm = &tmp
 		case fmt.Stringer:
 			tmp := v.String()
 			h.algorithm = &tmp
@@ -286,6 +299,8 @@ func (h *symmetricKey) Set(name string, value interface{}) error {
 			return nil
 		}
 		return errors.Errorf(`invalid value for %s key: %T`, X509URLKey, value)
+	case "crv":
+		return errors.Errorf(`"crv" cannot be used with key type %q`, h.KeyType())
 	default:
 		if h.privateParams == nil {
 			h.privateParams = map[string]interface{}{}
@@ -335,6 +350,9 @@ func (h *symmetricKey) UnmarshalJSON(buf []byte) error {
 	delete(m, X509CertThumbprintKey)
 	delete(m, X509CertThumbprintS256Key)
 	delete(m, X509URLKey)
+	if _, ok := m["crv"]; ok {
+		return errors.Errorf(`"crv" cannot be used with key type %q`, jwa.OctetSeq)
+	}
 	h.privateParams = m
 	return nil
 }
@@ -394,3 +412,72 @@ func (h *symmetricKey) Walk(ctx context.Context, visitor HeaderVisitor) error {
 func (h *symmetricKey) AsMap(ctx context.Context) (map[string]interface{}, error) {
 	return iter.AsMap(ctx, h)
 }
+
+func (h *symmetricKey) ValidateX509() error {
+	return validateX509(h)
+}
+
+func (h *symmetricKey) SetX509CertChain(chain []*x509.Certificate) error {
+	return h.Set(X509CertChainKey, chain)
+}
+
+func (h *symmetricKey) CanPerform(op KeyOperation) bool {
+	return h.keyops.canPerform(op)
+}
+
+func (h *symmetricKey) SignatureAlgorithm() jwa.SignatureAlgorithm {
+	return signatureAlgorithmOf(h)
+}
+
+func (h *symmetricKey) KeyEncryptionAlgorithm() jwa.KeyEncryptionAlgorithm {
+	return keyEncryptionAlgorithmOf(h)
+}
+
+func (h *symmetricKey) Clone() (Key, error) {
+	dst := &symmetricKey{}
+	if h.algorithm != nil {
+		v := *h.algorithm
+		dst.algorithm = &v
+	}
+	if h.keyID != nil {
+		v := *h.keyID
+		dst.keyID = &v
+	}
+	if h.keyUsage != nil {
+		v := *h.keyUsage
+		dst.keyUsage = &v
+	}
+	if h.keyops != nil {
+		v := make(KeyOperationList, len(*h.keyops))
+		copy(v, *h.keyops)
+		dst.keyops = &v
+	}
+	if h.octets != nil {
+		dst.octets = make([]byte, len(h.octets))
+		copy(dst.octets, h.octets)
+	}
+	if h.x509CertChain != nil {
+		v := CertificateChain{certs: make([]*x509.Certificate, len(h.x509CertChain.certs))}
+		copy(v.certs, h.x509CertChain.certs)
+		dst.x509CertChain = &v
+	}
+	if h.x509CertThumbprint != nil {
+		v := *h.x509CertThumbprint
+		dst.x509CertThumbprint = &v
+	}
+	if h.x509CertThumbprintS256 != nil {
+		v := *h.x509CertThumbprintS256
+		dst.x509CertThumbprintS256 = &v
+	}
+	if h.x509URL != nil {
+		v := *h.x509URL
+		dst.x509URL = &v
+	}
+	if h.privateParams != nil {
+		dst.privateParams = make(map[string]interface{}, len(h.privateParams))
+		for k, v := range h.privateParams {
+			dst.privateParams[k] = cloneParam(v)
+		}
+	}
+	return dst, nil
+}
@@ -0,0 +1,72 @@
+package jwk_test
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymmetric(t *testing.T) {
+	const src = `{"kty":"oct","k":"AyM1SysPpbyDfgZld3umj1qzKObwVMkoqQ-EstJQLr_T-1qS0gZH75aKtMN3Yj0iPS4hcgUuTwjAzZr1Z9CAow"}`
+
+	key, err := jwk.ParseKey([]byte(src))
+	if !assert.NoError(t, err, `jwk.ParseKey should succeed`) {
+		return
+	}
+
+	symkey, ok := key.(jwk.SymmetricKey)
+	if !assert.True(t, ok, `should be jwk.SymmetricKey`) {
+		return
+	}
+
+	if !assert.Equal(t, jwa.OctetSeq, symkey.KeyType(), `kty should be oct`) {
+		return
+	}
+
+	t.Run("Thumbprint", func(t *testing.T) {
+		// RFC 7638 section 3.2: the required members for "oct" keys are "k" and "kty"
+		expected := sha256.Sum256([]byte(`{"k":"AyM1SysPpbyDfgZld3umj1qzKObwVMkoqQ-EstJQLr_T-1qS0gZH75aKtMN3Yj0iPS4hcgUuTwjAzZr1Z9CAow","kty":"oct"}`))
+
+		tp, err := key.Thumbprint(crypto.SHA256)
+		if !assert.NoError(t, err, `Thumbprint should succeed`) {
+			return
+		}
+
+		if !assert.Equal(t, expected[:], tp, `thumbprint should match`) {
+			return
+		}
+	})
+	t.Run("FromRaw", func(t *testing.T) {
+		raw := []byte("secret")
+
+		key := jwk.NewSymmetricKey()
+		if !assert.NoError(t, key.FromRaw(raw), `FromRaw should succeed`) {
+			return
+		}
+
+		// modifying the input should not affect the key
+		raw[0] = 'S'
+
+		var got []byte
+		if !assert.NoError(t, key.Raw(&got), `Raw should succeed`) {
+			return
+		}
+
+		if !assert.Equal(t, []byte("secret"), got, `raw key should match`) {
+			return
+		}
+
+		if !assert.Error(t, key.FromRaw(nil), `FromRaw should fail for empty keys`) {
+			return
+		}
+	})
+	t.Run("PublicKey", func(t *testing.T) {
+		if _, err := symkey.PublicKey(); !assert.Error(t, err, `PublicKey should fail`) {
+			return
+		}
+	})
+}
@@ -0,0 +1,168 @@
+package jwk
+
+import (
+	"crypto/rsa"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/pkg/errors"
+)
+
+// signatureAlgorithms lists the signature algorithms that may be
+// used with each key type
+var signatureAlgorithms = map[jwa.KeyType][]jwa.SignatureAlgorithm{
+	jwa.RSA:      {jwa.RS256, jwa.RS384, jwa.RS512, jwa.PS256, jwa.PS384, jwa.PS512},
+	jwa.EC:       {jwa.ES256, jwa.ES256K, jwa.ES384, jwa.ES512},
+	jwa.OKP:      {jwa.EdDSA},
+	jwa.OctetSeq: {jwa.HS256, jwa.HS384, jwa.HS512},
+}
+
+// keyEncryptionAlgorithms lists the key encryption algorithms that may
+// be used with each key type
+var keyEncryptionAlgorithms = map[jwa.KeyType][]jwa.KeyEncryptionAlgorithm{
+	jwa.RSA: {jwa.RSA1_5, jwa.RSA_OAEP, jwa.RSA_OAEP_256, jwa.RSA_OAEP_384, jwa.RSA_OAEP_512},
+	jwa.EC:  {jwa.ECDH_ES, jwa.ECDH_ES_A128KW, jwa.ECDH_ES_A192KW, jwa.ECDH_ES_A256KW, jwa.ECMR},
+	jwa.OKP: {jwa.ECDH_ES, jwa.ECDH_ES_A128KW, jwa.ECDH_ES_A192KW, jwa.ECDH_ES_A256KW},
+	jwa.OctetSeq: {
+		jwa.A128KW, jwa.A192KW, jwa.A256KW, jwa.A128GCMKW, jwa.A192GCMKW, jwa.A256GCMKW,
+		jwa.DIRECT, jwa.PBES2_HS256_A128KW, jwa.PBES2_HS384_A192KW, jwa.PBES2_HS512_A256KW,
+	},
+}
+
+// ecdsaSignatureCurves lists the curves that must be used with each
+// of the ECDSA signature algorithms (RFC 7518 section 3.4)
+var ecdsaSignatureCurves = map[jwa.SignatureAlgorithm]jwa.EllipticCurveAlgorithm{
+	jwa.ES256:  jwa.P256,
+	jwa.ES256K: jwa.Secp256k1,
+	jwa.ES384:  jwa.P384,
+	jwa.ES512:  jwa.P521,
+}
+
+// ellipticCurves lists the curves that may be used with each key type
+var ellipticCurves = map[jwa.KeyType][]jwa.EllipticCurveAlgorithm{
+	jwa.EC:  {jwa.P256, jwa.P384, jwa.P521, jwa.Secp256k1},
+	jwa.OKP: {jwa.Ed25519, jwa.Ed448, jwa.X25519, jwa.X448},
+}
+
+func isValidCurve(kty jwa.KeyType, crv jwa.EllipticCurveAlgorithm) bool {
+	for _, v := range ellipticCurves[kty] {
+		if v == crv {
+			return true
+		}
+	}
+	return false
+}
+
+func isSignatureAlgorithm(kty jwa.KeyType, alg string) bool {
+	for _, v := range signatureAlgorithms[kty] {
+		if string(v) == alg {
+			return true
+		}
+	}
+	return false
+}
+
+func isKeyEncryptionAlgorithm(kty jwa.KeyType, alg string) bool {
+	for _, v := range keyEncryptionAlgorithms[kty] {
+		if string(v) == alg {
+			return true
+		}
+	}
+	return false
+}
+
+// signatureAlgorithmOf returns the "alg" field of the key if it is a
+// signature algorithm that is compatible with the key
+func signatureAlgorithmOf(key Key) jwa.SignatureAlgorithm {
+	alg := key.Algorithm()
+	if key.KeyUsage() == string(ForEncryption) || !isSignatureAlgorithm(key.KeyType(), alg) {
+		return ""
+	}
+	return jwa.SignatureAlgorithm(alg)
+}
+
+// keyEncryptionAlgorithmOf returns the "alg" field of the key if it is
+// a key encryption algorithm that is compatible with the key
+func keyEncryptionAlgorithmOf(key Key) jwa.KeyEncryptionAlgorithm {
+	alg := key.Algorithm()
+	if key.KeyUsage() == string(ForSignature) || !isKeyEncryptionAlgorithm(key.KeyType(), alg) {
+		return ""
+	}
+	return jwa.KeyEncryptionAlgorithm(alg)
+}
+
+// canSignWith returns true if the key may be used to create or verify
+// signatures using the given algorithm, taking the "use" and "alg"
+// fields of the key into account when they are present
+func canSignWith(key Key, alg jwa.SignatureAlgorithm) bool {
+	if key.KeyUsage() == string(ForEncryption) || !isSignatureAlgorithm(key.KeyType(), string(alg)) {
+		return false
+	}
+	if v := key.Algorithm(); v != "" && v != string(alg) {
+		return false
+	}
+	if ecdsakey, ok := key.(interface {
+		Crv() jwa.EllipticCurveAlgorithm
+	}); ok && key.KeyType() == jwa.EC {
+		if crv, ok := ecdsaSignatureCurves[alg]; ok && crv != ecdsakey.Crv() {
+			return false
+		}
+	}
+	return true
+}
+
+// validateStrict checks that the "use" and "alg" fields of the key
+// are consistent with each other, and with the type of the key
+func validateStrict(key Key) error {
+	kty := key.KeyType()
+	alg := key.Algorithm()
+	use := key.KeyUsage()
+
+	switch use {
+	case "", "sig", "enc":
+	default:
+		return errors.Errorf(`invalid "use" value %q`, use)
+	}
+
+	if alg == "" {
+		return nil
+	}
+
+	isSig := isSignatureAlgorithm(kty, alg)
+	isEnc := isKeyEncryptionAlgorithm(kty, alg)
+	if !isSig && !isEnc {
+		return errors.Errorf(`algorithm %q cannot be used with key type %q`, alg, kty)
+	}
+
+	switch {
+	case use == "sig" && !isSig:
+		return errors.Errorf(`algorithm %q cannot be used with "use" value "sig"`, alg)
+	case use == "enc" && !isEnc:
+		return errors.Errorf(`algorithm %q cannot be used with "use" value "enc"`, alg)
+	}
+
+	if ecdsakey, ok := key.(interface {
+		Crv() jwa.EllipticCurveAlgorithm
+	}); ok && kty == jwa.EC {
+		if crv, ok := ecdsaSignatureCurves[jwa.SignatureAlgorithm(alg)]; ok && crv != ecdsakey.Crv() {
+			return errors.Errorf(`algorithm %q requires curve %q (got %q)`, alg, crv, ecdsakey.Crv())
+		}
+	}
+	return nil
+}
+
+// validateKeyParameters checks that the parameters of private keys
+// are consistent with each other. Currently only RSA private keys
+// are checked
+func validateKeyParameters(key Key) error {
+	switch key.(type) {
+	case RSAPrivateKey:
+		var raw rsa.PrivateKey
+		if err := key.Raw(&raw); err != nil {
+			return errors.Wrap(err, `failed to materialize RSA private key`)
+		}
+		if err := raw.Validate(); err != nil {
+			return errors.Wrap(err, `invalid RSA private key`)
+		}
+	}
+	return nil
+}
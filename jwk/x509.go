@@ -0,0 +1,114 @@
+package jwk
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+
+	"github.com/lestrrat-go/jwx/internal/base64"
+	"github.com/lestrrat-go/jwx/x25519"
+	"github.com/pkg/errors"
+)
+
+func validateX509(key Key) error {
+	chain := key.X509CertChain()
+	if len(chain) == 0 {
+		return nil
+	}
+	leaf := chain[0]
+
+	if v := key.X509CertThumbprint(); v != "" {
+		expected := sha1.Sum(leaf.Raw)
+		if err := compareCertThumbprint(X509CertThumbprintKey, v, expected[:]); err != nil {
+			return err
+		}
+	}
+
+	if v := key.X509CertThumbprintS256(); v != "" {
+		expected := sha256.Sum256(leaf.Raw)
+		if err := compareCertThumbprint(X509CertThumbprintS256Key, v, expected[:]); err != nil {
+			return err
+		}
+	}
+
+	var raw interface{}
+	if err := key.Raw(&raw); err != nil {
+		return errors.Wrap(err, `failed to get raw key`)
+	}
+
+	pubkey, err := rawPublicKeyOf(raw)
+	if err != nil {
+		return errors.Wrap(err, `failed to get public key`)
+	}
+
+	if !rawPublicKeyEqual(pubkey, leaf.PublicKey) {
+		return errors.New(`public key of the leaf certificate in x5c does not match the key`)
+	}
+	return nil
+}
+
+func compareCertThumbprint(name, encoded string, expected []byte) error {
+	tp, err := base64.DecodeString(encoded)
+	if err != nil {
+		return errors.Wrapf(err, `failed to decode %s`, name)
+	}
+
+	if subtle.ConstantTimeCompare(tp, expected) != 1 {
+		return errors.Errorf(`%s does not match the thumbprint of the leaf certificate in x5c`, name)
+	}
+	return nil
+}
+
+// rawPublicKeyOf is like PublicKeyOf, but handles all of the key
+// types that may be stored in a jwk.Key, and rejects symmetric keys
+func rawPublicKeyOf(v interface{}) (interface{}, error) {
+	switch x := v.(type) {
+	case ed25519.PrivateKey:
+		return x.Public(), nil
+	case ed25519.PublicKey:
+		return x, nil
+	case x25519.PrivateKey:
+		return x.Public(), nil
+	case x25519.PublicKey:
+		return x, nil
+	case []byte:
+		return nil, errors.New(`symmetric keys do not have a public key`)
+	default:
+		return PublicKeyOf(v)
+	}
+}
+
+func rawPublicKeyEqual(a, b interface{}) bool {
+	switch a := a.(type) {
+	case *rsa.PublicKey:
+		b, ok := b.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+		return a.N.Cmp(b.N) == 0 && a.E == b.E
+	case *ecdsa.PublicKey:
+		b, ok := b.(*ecdsa.PublicKey)
+		if !ok {
+			return false
+		}
+		return a.Curve == b.Curve && a.X.Cmp(b.X) == 0 && a.Y.Cmp(b.Y) == 0
+	case ed25519.PublicKey:
+		b, ok := b.(ed25519.PublicKey)
+		if !ok {
+			return false
+		}
+		return bytes.Equal(a, b)
+	case x25519.PublicKey:
+		b, ok := b.(x25519.PublicKey)
+		if !ok {
+			return false
+		}
+		return bytes.Equal(a, b)
+	default:
+		return false
+	}
+}
@@ -1,8 +1,20 @@
 package jwk_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"math/big"
 	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/internal/base64"
 
 	"github.com/lestrrat-go/jwx/jwk"
 	"github.com/stretchr/testify/assert"
@@ -102,6 +114,44 @@ func Test_X5CHeader(t *testing.T) {
 					return
 				}
 			})
+			t.Run("[]*x509.Certificate", func(t *testing.T) {
+				var c jwk.CertificateChain
+				if !assert.NoError(t, c.Accept(certs), `Accept should succeed`) {
+					return
+				}
+
+				if !assert.NoError(t, key.Set(jwk.X509CertChainKey, c.Get()), "Set for x5c should succeed") {
+					return
+				}
+
+				if !assert.Equal(t, c.Get(), key.X509CertChain(), `X509CertChain should match`) {
+					return
+				}
+			})
+			t.Run("SetX509CertChain", func(t *testing.T) {
+				var c jwk.CertificateChain
+				if !assert.NoError(t, c.Accept(certs), `Accept should succeed`) {
+					return
+				}
+
+				if !assert.NoError(t, key.SetX509CertChain(c.Get()[:2]), "SetX509CertChain should succeed") {
+					return
+				}
+
+				if !assert.Equal(t, c.Get()[:2], key.X509CertChain(), `X509CertChain should match`) {
+					return
+				}
+			})
+			t.Run("invalid element", func(t *testing.T) {
+				err := key.Set(jwk.X509CertChainKey, []string{certs[0], "!!!"})
+				if !assert.Error(t, err, "Set for x5c should fail") {
+					return
+				}
+
+				if !assert.Contains(t, err.Error(), `element 1`, `error should identify the failed element`) {
+					return
+				}
+			})
 			t.Run("[]interface{} with string elements", func(t *testing.T) {
 				tmp := make([]interface{}, len(certs))
 				for i := 0; i < len(certs); i++ {
@@ -124,3 +174,224 @@ func Test_X5CHeader(t *testing.T) {
 		})
 	}
 }
+
+func TestNewFromCertificate(t *testing.T) {
+	privkey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if !assert.NoError(t, err, `ecdsa.GenerateKey should succeed`) {
+		return
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "jwx test"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privkey.PublicKey, privkey)
+	if !assert.NoError(t, err, `x509.CreateCertificate should succeed`) {
+		return
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if !assert.NoError(t, err, `x509.ParseCertificate should succeed`) {
+		return
+	}
+
+	key, err := jwk.New(cert)
+	if !assert.NoError(t, err, `jwk.New should succeed`) {
+		return
+	}
+
+	if !assert.Implements(t, (*jwk.ECDSAPublicKey)(nil), key, `key should be a jwk.ECDSAPublicKey`) {
+		return
+	}
+
+	var rawKey ecdsa.PublicKey
+	if !assert.NoError(t, key.Raw(&rawKey), `Raw should succeed`) {
+		return
+	}
+
+	if !assert.Equal(t, privkey.PublicKey.X, rawKey.X, `X should match`) {
+		return
+	}
+
+	if !assert.Equal(t, []*x509.Certificate{cert}, key.X509CertChain(), `x5c should contain the certificate`) {
+		return
+	}
+
+	expected := sha256.Sum256(der)
+	if !assert.Equal(t, base64.EncodeToString(expected[:]), key.X509CertThumbprintS256(), `x5t#S256 should match`) {
+		return
+	}
+
+	if !assert.NotEmpty(t, key.X509CertThumbprint(), `x5t should be populated`) {
+		return
+	}
+
+	t.Run("ValidateX509", func(t *testing.T) {
+		if !assert.NoError(t, key.ValidateX509(), `ValidateX509 should succeed`) {
+			return
+		}
+
+		privkey2, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if !assert.NoError(t, err, `ecdsa.GenerateKey should succeed`) {
+			return
+		}
+
+		t.Run("matching private key", func(t *testing.T) {
+			key, err := jwk.New(privkey)
+			if !assert.NoError(t, err, `jwk.New should succeed`) {
+				return
+			}
+
+			if !assert.NoError(t, key.Set(jwk.X509CertChainKey, cert), `Set should succeed`) {
+				return
+			}
+
+			if !assert.NoError(t, key.ValidateX509(), `ValidateX509 should succeed`) {
+				return
+			}
+		})
+		t.Run("mismatched key", func(t *testing.T) {
+			key, err := jwk.New(&privkey2.PublicKey)
+			if !assert.NoError(t, err, `jwk.New should succeed`) {
+				return
+			}
+
+			if !assert.NoError(t, key.Set(jwk.X509CertChainKey, cert), `Set should succeed`) {
+				return
+			}
+
+			if !assert.Error(t, key.ValidateX509(), `ValidateX509 should fail`) {
+				return
+			}
+		})
+		t.Run("mismatched x5t#S256", func(t *testing.T) {
+			key, err := jwk.New(cert)
+			if !assert.NoError(t, err, `jwk.New should succeed`) {
+				return
+			}
+
+			bogus := sha256.Sum256([]byte("bogus"))
+			if !assert.NoError(t, key.Set(jwk.X509CertThumbprintS256Key, base64.EncodeToString(bogus[:])), `Set should succeed`) {
+				return
+			}
+
+			if !assert.Error(t, key.ValidateX509(), `ValidateX509 should fail`) {
+				return
+			}
+		})
+		t.Run("no x5c", func(t *testing.T) {
+			key, err := jwk.New(&privkey2.PublicKey)
+			if !assert.NoError(t, err, `jwk.New should succeed`) {
+				return
+			}
+
+			if !assert.NoError(t, key.ValidateX509(), `ValidateX509 should succeed`) {
+				return
+			}
+		})
+	})
+}
+
+func TestFromCertificate(t *testing.T) {
+	cakey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if !assert.NoError(t, err, `ecdsa.GenerateKey should succeed`) {
+		return
+	}
+	leafkey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if !assert.NoError(t, err, `rsa.GenerateKey should succeed`) {
+		return
+	}
+
+	catemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "jwx test ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	cader, err := x509.CreateCertificate(rand.Reader, catemplate, catemplate, &cakey.PublicKey, cakey)
+	if !assert.NoError(t, err, `x509.CreateCertificate should succeed`) {
+		return
+	}
+	cacert, err := x509.ParseCertificate(cader)
+	if !assert.NoError(t, err, `x509.ParseCertificate should succeed`) {
+		return
+	}
+
+	leaftemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "jwx test leaf"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	leafder, err := x509.CreateCertificate(rand.Reader, leaftemplate, cacert, &leafkey.PublicKey, cakey)
+	if !assert.NoError(t, err, `x509.CreateCertificate should succeed`) {
+		return
+	}
+	leafcert, err := x509.ParseCertificate(leafder)
+	if !assert.NoError(t, err, `x509.ParseCertificate should succeed`) {
+		return
+	}
+
+	t.Run("FromCertificate", func(t *testing.T) {
+		key, err := jwk.FromCertificate(leafcert)
+		if !assert.NoError(t, err, `jwk.FromCertificate should succeed`) {
+			return
+		}
+		if !assert.Implements(t, (*jwk.RSAPublicKey)(nil), key, `key should be a jwk.RSAPublicKey`) {
+			return
+		}
+		if !assert.Equal(t, []*x509.Certificate{leafcert}, key.X509CertChain(), `x5c should contain the certificate`) {
+			return
+		}
+		if !assert.NoError(t, key.ValidateX509(), `ValidateX509 should succeed`) {
+			return
+		}
+	})
+	t.Run("FromTLSCertificate", func(t *testing.T) {
+		key, err := jwk.FromTLSCertificate(tls.Certificate{
+			Certificate: [][]byte{leafder, cader},
+			PrivateKey:  leafkey,
+		})
+		if !assert.NoError(t, err, `jwk.FromTLSCertificate should succeed`) {
+			return
+		}
+		if !assert.Implements(t, (*jwk.RSAPublicKey)(nil), key, `key should be a jwk.RSAPublicKey`) {
+			return
+		}
+		if !assert.Equal(t, []*x509.Certificate{leafcert, cacert}, key.X509CertChain(), `x5c should contain the whole chain`) {
+			return
+		}
+
+		expected := sha256.Sum256(leafder)
+		if !assert.Equal(t, base64.EncodeToString(expected[:]), key.X509CertThumbprintS256(), `x5t#S256 should be that of the leaf certificate`) {
+			return
+		}
+		if !assert.NoError(t, key.ValidateX509(), `ValidateX509 should succeed`) {
+			return
+		}
+	})
+	t.Run("Errors", func(t *testing.T) {
+		_, err := jwk.FromCertificate(nil)
+		if !assert.Error(t, err, `jwk.FromCertificate should fail for nil certificate`) {
+			return
+		}
+
+		_, err = jwk.FromCertificate(&x509.Certificate{PublicKey: struct{}{}})
+		if !assert.Error(t, err, `jwk.FromCertificate should fail for unsupported public key`) {
+			return
+		}
+		if !assert.Contains(t, err.Error(), `unsupported public key type`, `error should mention the unsupported key`) {
+			return
+		}
+
+		_, err = jwk.FromTLSCertificate(tls.Certificate{})
+		if !assert.Error(t, err, `jwk.FromTLSCertificate should fail for empty certificate`) {
+			return
+		}
+	})
+}
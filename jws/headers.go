@@ -2,11 +2,19 @@ package jws
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 
 	"github.com/lestrrat-go/iter/mapiter"
 	"github.com/lestrrat-go/jwx/internal/iter"
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/pkg/errors"
 )
 
+// b64Key is the name of the header parameter defined in RFC7797,
+// which controls if the payload is base64 encoded or not
+const b64Key = "b64"
+
 // Iterate returns a channel that successively returns all the
 // header name and values.
 func (h *stdHeaders) Iterate(ctx context.Context) Iterator {
@@ -22,3 +30,184 @@ func (h *stdHeaders) Walk(ctx context.Context, visitor Visitor) error {
 func (h *stdHeaders) AsMap(ctx context.Context) (map[string]interface{}, error) {
 	return iter.AsMap(ctx, h)
 }
+
+// getB64Value returns the value of the "b64" header parameter
+// (https://tools.ietf.org/html/rfc7797#section-3). If the parameter
+// is not present, the default value of true is returned.
+//
+// If the value is false, "b64" must also be listed in the "crit"
+// header parameter, as required by RFC7797
+func getB64Value(hdrs Headers) (bool, error) {
+	if hdrs == nil {
+		return true, nil
+	}
+
+	v, ok := hdrs.Get(b64Key)
+	if !ok {
+		return true, nil
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return false, errors.Errorf(`invalid value for "b64" header: %T`, v)
+	}
+
+	if !b && !isCritical(hdrs, b64Key) {
+		return false, errors.New(`"b64" header must be listed in "crit" header`)
+	}
+	return b, nil
+}
+
+// setB64Critical adds "b64" to the "crit" header parameter if the "b64"
+// header parameter is set to false and is not already listed
+func setB64Critical(hdrs Headers) error {
+	v, ok := hdrs.Get(b64Key)
+	if !ok {
+		return nil
+	}
+
+	if b, ok := v.(bool); !ok || b || isCritical(hdrs, b64Key) {
+		return nil
+	}
+
+	return hdrs.Set(CriticalKey, append(hdrs.Critical(), b64Key))
+}
+
+func isCritical(hdrs Headers, name string) bool {
+	for _, v := range hdrs.Critical() {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// getProtectedB64Value decodes the base64 encoded protected header, and
+// returns the value of its "b64" header parameter
+func getProtectedB64Value(protected string) (bool, error) {
+	if len(protected) == 0 {
+		return true, nil
+	}
+
+	hdrbuf, err := base64.RawURLEncoding.DecodeString(protected)
+	if err != nil {
+		return false, &ParseError{Segment: SegmentHeader, Cause: err}
+	}
+
+	hdrs := NewHeaders()
+	if err := json.Unmarshal(hdrbuf, hdrs); err != nil {
+		return false, &ParseError{Segment: SegmentHeader, Cause: err}
+	}
+	return getB64Value(hdrs)
+}
+
+// getProtectedAlgorithm returns the value of the "alg" header
+// parameter in the base64 encoded protected header
+func getProtectedAlgorithm(protected string) (jwa.SignatureAlgorithm, error) {
+	if len(protected) == 0 {
+		return "", nil
+	}
+
+	hdrbuf, err := base64.RawURLEncoding.DecodeString(protected)
+	if err != nil {
+		return "", &ParseError{Segment: SegmentHeader, Cause: err}
+	}
+
+	var hdrs struct {
+		Algorithm string `json:"alg"`
+	}
+	if err := json.Unmarshal(hdrbuf, &hdrs); err != nil {
+		return "", &ParseError{Segment: SegmentHeader, Cause: err}
+	}
+	return jwa.SignatureAlgorithm(hdrs.Algorithm), nil
+}
+
+// keyHint holds the header parameters that are used to look up the
+// verification key for a signature
+type keyHint struct {
+	algorithm jwa.SignatureAlgorithm
+	keyID     string
+}
+
+// getProtectedKeyHint returns the values of the "alg" and "kid" header
+// parameters in the base64 encoded protected header
+func getProtectedKeyHint(protected string) (keyHint, error) {
+	var hint keyHint
+	if len(protected) == 0 {
+		return hint, nil
+	}
+
+	hdrbuf, err := base64.RawURLEncoding.DecodeString(protected)
+	if err != nil {
+		return hint, errors.Wrap(err, `failed to decode protected header`)
+	}
+
+	var hdrs struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+	if err := json.Unmarshal(hdrbuf, &hdrs); err != nil {
+		return hint, errors.Wrap(err, `failed to unmarshal protected header`)
+	}
+	hint.algorithm = jwa.SignatureAlgorithm(hdrs.Algorithm)
+	hint.keyID = hdrs.KeyID
+	return hint, nil
+}
+
+func isValidAlgorithm(alg jwa.SignatureAlgorithm, validAlgs []jwa.SignatureAlgorithm) bool {
+	for _, v := range validAlgs {
+		if v == alg {
+			return true
+		}
+	}
+	return false
+}
+
+// checkProtectedAlgorithm makes sure that messages declaring the "none"
+// algorithm in their protected header are only accepted when the caller
+// explicitly allowed them, and only when verifying using jwa.NoSignature.
+// If validAlgs is non-nil, the algorithm declared in the protected header
+// must also be one of them
+func checkProtectedAlgorithm(protected string, alg jwa.SignatureAlgorithm, insecure bool, validAlgs []jwa.SignatureAlgorithm) error {
+	hdralg, err := getProtectedAlgorithm(protected)
+	if err != nil {
+		return errors.Wrap(err, `failed to get "alg" header`)
+	}
+
+	if validAlgs != nil && !isValidAlgorithm(hdralg, validAlgs) {
+		return errors.Errorf(`message is signed using %q, which is not in the list of valid algorithms`, hdralg)
+	}
+
+	if hdralg == jwa.NoSignature && !insecure {
+		return errors.Errorf(`refusing to verify message using unsafe algorithm %q (use jws.WithInsecureNoSignature to allow it)`, hdralg)
+	}
+
+	if alg == jwa.NoSignature && hdralg != jwa.NoSignature {
+		return errors.Errorf(`message is signed using %q, but verification was requested using %q`, hdralg, alg)
+	}
+	return nil
+}
+
+// checkProtectedKeyID makes sure that the protected header contains
+// a "kid" header parameter
+func checkProtectedKeyID(protected string) error {
+	hint, err := getProtectedKeyHint(protected)
+	if err != nil {
+		return errors.Wrap(err, `failed to get "kid" header`)
+	}
+	if hint.keyID == "" {
+		return errors.New(`required "kid" header is missing from the protected header`)
+	}
+	return nil
+}
+
+// noSignatureVerifier verifies unsecured messages, which must not
+// carry any signature
+type noSignatureVerifier struct{}
+
+func (noSignatureVerifier) Verify(_ []byte, signature []byte, _ interface{}) error {
+	if len(signature) > 0 {
+		return errors.Errorf(`unexpected signature for algorithm %q`, jwa.NoSignature)
+	}
+	return nil
+}
@@ -46,6 +46,7 @@ type Headers interface {
 	AsMap(ctx context.Context) (map[string]interface{}, error)
 	Get(string) (interface{}, bool)
 	Set(string, interface{}) error
+	SetX509CertChain([]string) error
 	PrivateParams() map[string]interface{}
 }
 
@@ -265,6 +266,12 @@ func (h *stdHeaders) Get(name string) (interface{}, bool) {
 	}
 }
 
+// SetX509CertChain sets the "x5c" header. It is equivalent to
+// calling Set(X509CertChainKey, chain)
+func (h *stdHeaders) SetX509CertChain(chain []string) error {
+	return h.Set(X509CertChainKey, chain)
+}
+
 func (h *stdHeaders) Set(name string, value interface{}) error {
 	switch name {
 	case AlgorithmKey:
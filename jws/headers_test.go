@@ -54,6 +54,15 @@ func TestHeader(t *testing.T) {
 			return
 		}
 	})
+	t.Run("SetX509CertChain", func(t *testing.T) {
+		h := jws.NewHeaders()
+		if !assert.NoError(t, h.SetX509CertChain(certChain), `SetX509CertChain should succeed`) {
+			return
+		}
+		if !assert.Equal(t, certChain, h.X509CertChain(), `X509CertChain should match`) {
+			return
+		}
+	})
 	t.Run("Private parameters", func(t *testing.T) {
 		t.Run("Without standard headers", func(t *testing.T) {
 			t.Parallel()
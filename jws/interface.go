@@ -3,7 +3,6 @@ package jws
 import (
 	"github.com/lestrrat-go/iter/mapiter"
 	"github.com/lestrrat-go/jwx/internal/iter"
-	"github.com/lestrrat-go/jwx/jwa"
 	"github.com/lestrrat-go/jwx/jwk"
 )
 
@@ -18,10 +17,20 @@ type encodedMessage struct {
 	Signatures []*encodedSignature `json:"signatures,omitempty"`
 }
 
-// PayloadSigner generates signature for the given payload
+// encodedFlattenedMessage is the flattened JWS JSON serialization
+// (https://tools.ietf.org/html/rfc7515#section-7.2.2), where the single
+// signature is hoisted to the top level
+type encodedFlattenedMessage struct {
+	Payload   string  `json:"payload"`
+	Protected string  `json:"protected,omitempty"`
+	Headers   Headers `json:"header,omitempty"`
+	Signature string  `json:"signature,omitempty"`
+}
+
+// PayloadSigner generates signature for the given payload, along with
+// the headers that are associated with the signature
 type PayloadSigner interface {
-	Sign([]byte) ([]byte, error)
-	Algorithm() jwa.SignatureAlgorithm
+	Signer
 	ProtectedHeader() Headers
 	PublicHeader() Headers
 }
@@ -38,12 +47,15 @@ type PayloadSigner interface {
 type Message struct {
 	payload    []byte
 	signatures []*Signature
+	raw        []byte // The message as it was passed to Parse
+	rawPayload string // The payload as it was encoded in the message
 }
 
 type Signature struct {
-	headers   Headers // Unprotected Headers
-	protected Headers // Protected Headers
-	signature []byte  // Signature
+	headers      Headers // Unprotected Headers
+	protected    Headers // Protected Headers
+	signature    []byte  // Signature
+	rawProtected string  // Protected Headers as they were encoded in the message
 }
 
 // JWKAcceptor decides which keys can be accepted
@@ -75,3 +87,33 @@ type Visitor = iter.MapVisitor
 type VisitorFunc = iter.MapVisitorFunc
 type HeaderPair = mapiter.Pair
 type Iterator = mapiter.Iterator
+
+// Segments of a JWS message, as reported by ParseError
+const (
+	SegmentHeader    = "header"
+	SegmentPayload   = "payload"
+	SegmentSignature = "signature"
+)
+
+// ParseError is returned when a JWS message could not be parsed. It
+// tells which segment of the message was malformed, and the underlying
+// error tells why: for example a base64.CorruptInputError when the
+// segment is not properly base64 encoded, or a *json.SyntaxError when
+// the decoded header is not valid JSON. Use `errors.As` to obtain it
+type ParseError struct {
+	// Segment is one of SegmentHeader, SegmentPayload or SegmentSignature.
+	// It is empty when the message could not be split into segments
+	Segment string
+	Cause   error
+}
+
+func (e *ParseError) Error() string {
+	if e.Segment == "" {
+		return e.Cause.Error()
+	}
+	return `invalid ` + e.Segment + ` segment: ` + e.Cause.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Cause
+}
@@ -208,6 +208,7 @@ func generateHeaders() error {
 	// These are used to access a single element by key name
 	fmt.Fprintf(&buf, "\nGet(string) (interface{}, bool)")
 	fmt.Fprintf(&buf, "\nSet(string, interface{}) error")
+	fmt.Fprintf(&buf, "\nSetX509CertChain([]string) error")
 
 	fmt.Fprintf(&buf, "\nPrivateParams() map[string]interface{}")
 
@@ -299,6 +300,12 @@ func generateHeaders() error {
 	fmt.Fprintf(&buf, "\n}") // end switch name
 	fmt.Fprintf(&buf, "\n}") // func (h *stdHeaders) Get(name string) (interface{}, bool)
 
+	fmt.Fprintf(&buf, "\n\n// SetX509CertChain sets the \"x5c\" header. It is equivalent to")
+	fmt.Fprintf(&buf, "\n// calling Set(X509CertChainKey, chain)")
+	fmt.Fprintf(&buf, "\nfunc (h *stdHeaders) SetX509CertChain(chain []string) error {")
+	fmt.Fprintf(&buf, "\nreturn h.Set(X509CertChainKey, chain)")
+	fmt.Fprintf(&buf, "\n}")
+
 	fmt.Fprintf(&buf, "\n\nfunc (h *stdHeaders) Set(name string, value interface{}) error {")
 	fmt.Fprintf(&buf, "\nswitch name {")
 	for _, f := range fields {
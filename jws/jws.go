@@ -28,9 +28,11 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"io"
+	"io/ioutil"
 	"strings"
 	"unicode"
 
+	"github.com/lestrrat-go/jwx/internal/option"
 	"github.com/lestrrat-go/jwx/internal/pool"
 	"github.com/lestrrat-go/jwx/jwa"
 	"github.com/lestrrat-go/jwx/jwk"
@@ -40,14 +42,13 @@ import (
 )
 
 type payloadSigner struct {
-	signer    sign.Signer
-	key       interface{}
+	signer    Signer
 	protected Headers
 	public    Headers
 }
 
 func (s *payloadSigner) Sign(payload []byte) ([]byte, error) {
-	return s.signer.Sign(payload, s.key)
+	return s.signer.Sign(payload)
 }
 
 func (s *payloadSigner) Algorithm() jwa.SignatureAlgorithm {
@@ -77,21 +78,45 @@ func (s *payloadSigner) PublicHeader() Headers {
 // the type of key you provided, otherwise an error is returned.
 //
 // If you would like to pass custom headers, use the WithHeaders option.
+//
+// Options for the built-in signers, such as `sign.WithPSSSaltLength`,
+// may be passed as well.
+//
+// If one or more WithSigner options are specified, the payload is signed
+// using each of them in addition to `alg` and `key`, and the result is
+// serialized in the general JSON serialization format (see SignMulti).
+// In this case `alg` may be left empty to only use the provided signers.
+//
+// If the WithDetachedPayload option is specified, the `payload` parameter
+// must be empty. The detached payload is used to compute the signature,
+// but the payload segment of the resulting message is left empty
+// (https://tools.ietf.org/html/rfc7515#appendix-F)
 func Sign(payload []byte, alg jwa.SignatureAlgorithm, key interface{}, options ...Option) ([]byte, error) {
 	var hdrs Headers
+	var hasSigners bool
 	for _, o := range options {
 		switch o.Name() {
 		case optkeyHeaders:
 			hdrs = o.Value().(Headers)
+		case optkeyPayloadSigner:
+			hasSigners = true
 		}
 	}
 
+	if hasSigners && alg == "" {
+		return SignMulti(payload, options...)
+	}
+
 	if hdrs == nil {
 		hdrs = NewHeaders()
 	}
 
 	// If the key is a jwk.Key instance, obtain the raw key
 	if jwkKey, ok := key.(jwk.Key); ok {
+		if !jwkKey.CanPerform(jwk.KeyOpSign) {
+			return nil, errors.Errorf(`key_ops of the jwk.Key does not allow "%s"`, jwk.KeyOpSign)
+		}
+
 		var tmp interface{}
 		if err := jwkKey.Raw(&tmp); err != nil {
 			return nil, errors.Wrap(err, `failed to get raw key from jwk.Key instance`)
@@ -106,15 +131,84 @@ func Sign(payload []byte, alg jwa.SignatureAlgorithm, key interface{}, options .
 		}
 	}
 
-	signer, err := sign.New(alg)
+	signer, err := NewSigner(alg, key, options...)
 	if err != nil {
 		return nil, errors.Wrap(err, `failed to create signer`)
 	}
 
+	return signWithSigner(payload, signer, hdrs, options)
+}
+
+// SignWithSigner is the same as `jws.Sign`, but the signature is
+// generated by the given Signer instead of one of the algorithms that
+// are built into this library. This allows keys that are managed by a
+// HSM or a KMS to be used for signing, without exposing the keys.
+//
+// The same options as `jws.Sign` are accepted. In particular, the
+// WithSigner and WithExternalSigner options may be used to create a
+// message with multiple signatures.
+func SignWithSigner(payload []byte, signer Signer, options ...Option) ([]byte, error) {
+	var hdrs Headers
+	for _, o := range options {
+		switch o.Name() {
+		case optkeyHeaders:
+			hdrs = o.Value().(Headers)
+		}
+	}
+
+	if hdrs == nil {
+		hdrs = NewHeaders()
+	}
+	return signWithSigner(payload, signer, hdrs, options)
+}
+
+func signWithSigner(payload []byte, signer Signer, hdrs Headers, options []Option) ([]byte, error) {
+	var hasSigners bool
+	var detached bool
+	for _, o := range options {
+		switch o.Name() {
+		case optkeyPayloadSigner:
+			hasSigners = true
+		case optkeyDetachedPayload:
+			if len(payload) > 0 {
+				return nil, errors.New(`payload must be empty when using a detached payload`)
+			}
+			detached = true
+			payload = o.Value().([]byte)
+		}
+	}
+
+	if detached && hasSigners {
+		return nil, errors.New(`detached payloads cannot be used with multiple signers`)
+	}
+
+	if hasSigners {
+		primary := &payloadSigner{
+			signer:    signer,
+			protected: hdrs,
+		}
+		return SignMulti(payload, append([]Option{option.New(optkeyPayloadSigner, primary)}, options...)...)
+	}
+
 	if err := hdrs.Set(AlgorithmKey, signer.Algorithm()); err != nil {
 		return nil, errors.Wrap(err, `failed to set header`)
 	}
 
+	if err := setB64Critical(hdrs); err != nil {
+		return nil, errors.Wrap(err, `failed to set "crit" header`)
+	}
+
+	b64, err := getB64Value(hdrs)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to get "b64" header`)
+	}
+
+	// RFC7797 Section 5.2: when using the compact serialization, the
+	// unencoded payload must not contain any '.' characters
+	if !b64 && !detached && bytes.IndexByte(payload, '.') > -1 {
+		return nil, errors.New(`unencoded payload must not contain '.' when using compact serialization`)
+	}
+
 	hdrbuf, err := json.Marshal(hdrs)
 	if err != nil {
 		return nil, errors.Wrap(err, `failed to marshal headers`)
@@ -131,19 +225,28 @@ func Sign(payload []byte, alg jwa.SignatureAlgorithm, key interface{}, options .
 	}
 
 	buf.WriteByte('.')
-	enc = base64.NewEncoder(base64.RawURLEncoding, buf)
-	if _, err := enc.Write(payload); err != nil {
-		return nil, errors.Wrap(err, `failed to write payload as base64`)
-	}
-	if err := enc.Close(); err != nil {
-		return nil, errors.Wrap(err, `failed to finalize writing payload as base64`)
+	hdrlen := buf.Len()
+	if b64 {
+		enc = base64.NewEncoder(base64.RawURLEncoding, buf)
+		if _, err := enc.Write(payload); err != nil {
+			return nil, errors.Wrap(err, `failed to write payload as base64`)
+		}
+		if err := enc.Close(); err != nil {
+			return nil, errors.Wrap(err, `failed to finalize writing payload as base64`)
+		}
+	} else {
+		buf.Write(payload)
 	}
 
-	signature, err := signer.Sign(buf.Bytes(), key)
+	signature, err := signer.Sign(buf.Bytes())
 	if err != nil {
 		return nil, errors.Wrap(err, `failed to sign payload`)
 	}
 
+	if detached {
+		buf.Truncate(hdrlen)
+	}
+
 	buf.WriteByte('.')
 	enc = base64.NewEncoder(base64.RawURLEncoding, buf)
 	if _, err := enc.Write(signature); err != nil {
@@ -162,60 +265,83 @@ func Sign(payload []byte, alg jwa.SignatureAlgorithm, key interface{}, options .
 // it in compact serialization format. In this format you may NOT use
 // multiple signers.
 //
-func SignLiteral(payload []byte, alg jwa.SignatureAlgorithm, key interface{}, headers []byte) ([]byte, error) {
-	signer, err := sign.New(alg)
+// The options are passed to `sign.New`, e.g. `sign.WithPSSSaltLength`
+func SignLiteral(payload []byte, alg jwa.SignatureAlgorithm, key interface{}, headers []byte, options ...Option) ([]byte, error) {
+	signer, err := sign.New(alg, options...)
 	if err != nil {
 		return nil, errors.Wrap(err, `failed to create signer`)
 	}
 
-	buf := pool.GetBytesBuffer()
-	defer pool.ReleaseBytesBuffer(buf)
-
-	enc := base64.NewEncoder(base64.RawURLEncoding, buf)
-	if _, err := enc.Write(headers); err != nil {
-		return nil, errors.Wrap(err, `failed to write headers as base64`)
-	}
-	if err := enc.Close(); err != nil {
-		return nil, errors.Wrap(err, `failed to finalize writing headers as base64`)
-	}
-
-	buf.WriteByte('.')
-	enc = base64.NewEncoder(base64.RawURLEncoding, buf)
-	if _, err := enc.Write(payload); err != nil {
-		return nil, errors.Wrap(err, `failed to write payload as base64`)
-	}
-	if err := enc.Close(); err != nil {
-		return nil, errors.Wrap(err, `failed to finalize writing payload as base64`)
-	}
-
-	signature, err := signer.Sign(buf.Bytes(), key)
+	input := SigningInput(headers, payload)
+	signature, err := signer.Sign(input, key)
 	if err != nil {
 		return nil, errors.Wrap(err, `failed to sign payload`)
 	}
 
-	buf.WriteByte('.')
-	enc = base64.NewEncoder(base64.RawURLEncoding, buf)
-	if _, err := enc.Write(signature); err != nil {
-		return nil, errors.Wrap(err, `failed to write signature as base64`)
-	}
-	if err := enc.Close(); err != nil {
-		return nil, errors.Wrap(err, `failed to finalize writing signature as base64`)
-	}
-
-	result := make([]byte, buf.Len())
-	copy(result, buf.Bytes())
+	result := make([]byte, len(input)+1+base64.RawURLEncoding.EncodedLen(len(signature)))
+	copy(result, input)
+	result[len(input)] = '.'
+	base64.RawURLEncoding.Encode(result[len(input)+1:], signature)
 	return result, nil
 }
 
+// SigningInput returns the bytes that are signed to create a JWS message
+// in compact serialization format, i.e. the base64url encoded protected
+// headers and the base64url encoded payload, separated by a ".".
+// `protected` is the JSON representation of the protected headers.
+//
+// This allows the signature to be computed by an external party, such
+// as a HSM or a KMS. The resulting message can then be created using
+// `jws.AssembleCompact`. Note that the payload is always base64url
+// encoded, so this cannot be used with the "b64" header parameter
+func SigningInput(protected, payload []byte) []byte {
+	enc := base64.RawURLEncoding
+	buf := make([]byte, enc.EncodedLen(len(protected))+1+enc.EncodedLen(len(payload)))
+	enc.Encode(buf, protected)
+	buf[enc.EncodedLen(len(protected))] = '.'
+	enc.Encode(buf[enc.EncodedLen(len(protected))+1:], payload)
+	return buf
+}
+
+// AssembleCompact creates a JWS message in compact serialization format
+// from its parts. `protected` is the JSON representation of the
+// protected headers, and `signature` is the signature computed over the
+// value returned by `jws.SigningInput` for the same protected headers
+// and payload
+func AssembleCompact(protected, payload, signature []byte) string {
+	var sb strings.Builder
+	enc := base64.RawURLEncoding
+	sb.Grow(enc.EncodedLen(len(protected)) + enc.EncodedLen(len(payload)) + enc.EncodedLen(len(signature)) + 2)
+	sb.Write(SigningInput(protected, payload))
+	sb.WriteByte('.')
+	sb.WriteString(enc.EncodeToString(signature))
+	return sb.String()
+}
+
 // SignMulti accepts multiple signers via the options parameter,
 // and creates a JWS in JSON serialization format that contains
 // signatures from applying aforementioned signers.
+//
+// If the WithFlattenedJSON(true) option is specified, the flattened
+// JSON serialization format is used instead. In this case exactly one
+// signer must be provided.
+//
+// If the protected headers contain the "b64" header parameter set to false
+// (https://tools.ietf.org/html/rfc7797), the payload is included as is,
+// without base64 encoding it. All signers must agree on the value of "b64".
+//
+// The WithDetachedPayload option cannot be used with SignMulti.
 func SignMulti(payload []byte, options ...Option) ([]byte, error) {
 	var signers []PayloadSigner
+	var flattened bool
 	for _, o := range options {
 		switch o.Name() {
 		case optkeyPayloadSigner:
 			signers = append(signers, o.Value().(PayloadSigner))
+		case optkeyFlattenedJSON:
+			flattened = o.Value().(bool)
+		case optkeyDetachedPayload:
+			return nil, errors.New(`detached payloads cannot be used with multiple signers`)
 		}
 	}
 
@@ -223,13 +349,16 @@ func SignMulti(payload []byte, options ...Option) ([]byte, error) {
 		return nil, errors.New(`no signers provided`)
 	}
 
-	var result encodedMessage
+	if flattened && len(signers) > 1 {
+		return nil, errors.New(`flattened JSON serialization requires exactly one signer`)
+	}
 
-	result.Payload = base64.RawURLEncoding.EncodeToString(payload)
+	var result encodedMessage
+	var payloadB64 bool
 
 	buf := pool.GetBytesBuffer()
 	defer pool.ReleaseBytesBuffer(buf)
-	for _, signer := range signers {
+	for i, signer := range signers {
 		protected := signer.ProtectedHeader()
 		if protected == nil {
 			protected = NewHeaders()
@@ -239,6 +368,26 @@ func SignMulti(payload []byte, options ...Option) ([]byte, error) {
 			return nil, errors.Wrap(err, `failed to set header`)
 		}
 
+		if err := setB64Critical(protected); err != nil {
+			return nil, errors.Wrap(err, `failed to set "crit" header`)
+		}
+
+		b64, err := getB64Value(protected)
+		if err != nil {
+			return nil, errors.Wrap(err, `failed to get "b64" header`)
+		}
+
+		if i == 0 {
+			payloadB64 = b64
+			if b64 {
+				result.Payload = base64.RawURLEncoding.EncodeToString(payload)
+			} else {
+				result.Payload = string(payload)
+			}
+		} else if b64 != payloadB64 {
+			return nil, errors.New(`all signers must use the same value for the "b64" header`)
+		}
+
 		hdrbuf, err := json.Marshal(protected)
 		if err != nil {
 			return nil, errors.Wrap(err, `failed to marshal headers`)
@@ -261,6 +410,16 @@ func SignMulti(payload []byte, options ...Option) ([]byte, error) {
 		})
 	}
 
+	if flattened {
+		sig := result.Signatures[0]
+		return json.Marshal(encodedFlattenedMessage{
+			Payload:   result.Payload,
+			Protected: sig.Protected,
+			Headers:   sig.Headers,
+			Signature: sig.Signature,
+		})
+	}
+
 	return json.Marshal(result)
 }
 
@@ -269,10 +428,132 @@ func SignMulti(payload []byte, options ...Option) ([]byte, error) {
 // payload that was signed is returned. If you need more fine-grained
 // control of the verification process, manually call `Parse`, generate a
 // verifier, and call `Verify` on the parsed JWS message object.
-func Verify(buf []byte, alg jwa.SignatureAlgorithm, key interface{}) (ret []byte, err error) {
-	verifier, err := verify.New(alg)
+//
+// For messages in JSON serialization format, verification succeeds if
+// any one of the signatures can be verified. Use the WithSignatureIndex
+// option to find out which signature was used.
+//
+// If the message was created with a detached payload, the payload must be
+// provided using the WithDetachedPayload option. In this case the payload
+// segment of the message must be empty.
+//
+// If the WithKeySet option is given, the verification key is looked up
+// in the key set using the "kid" header of the message, and `key` is ignored.
+//
+// When verifying many messages using the same algorithm and key, use
+// a `jws.Verifier` created by `jws.NewVerifier` instead.
+func Verify(buf []byte, alg jwa.SignatureAlgorithm, key interface{}, options ...Option) (ret []byte, err error) {
+	for _, o := range options {
+		switch o.Name() {
+		case optkeyKeySet:
+			return verifyWithKeySet(buf, alg, o.Value().(*jwk.Set), options)
+		}
+	}
+
+	v, err := NewVerifier(alg, key, options...)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to create verifier")
+		return nil, err
+	}
+	return v.Verify(buf, options...)
+}
+
+// Verifier verifies JWS messages using a fixed algorithm and key. The
+// work that does not depend on the message, such as looking up the
+// verification function for the algorithm and materializing the key,
+// is only done once in `jws.NewVerifier`, so a Verifier should be
+// reused when verifying many messages signed by the same key.
+//
+// A Verifier is safe for concurrent use.
+type Verifier struct {
+	alg          jwa.SignatureAlgorithm
+	key          interface{}
+	verifier     verify.Verifier
+	insecure     bool
+	validAlgs    []jwa.SignatureAlgorithm
+	requireKeyID bool
+}
+
+// NewVerifier creates a Verifier for the given algorithm and key. The
+// key may also be a jwk.Key, in which case the raw key is extracted
+// from it once.
+//
+// The `jws.WithInsecureNoSignature`, `jws.WithValidAlgorithms` and
+// `jws.WithRequireKeyID` options are applied to all messages verified by the Verifier. The
+// `jws.WithKeySet` option is not supported.
+func NewVerifier(alg jwa.SignatureAlgorithm, key interface{}, options ...Option) (*Verifier, error) {
+	var insecure bool
+	var requireKeyID bool
+	var validAlgs []jwa.SignatureAlgorithm
+	for _, o := range options {
+		switch o.Name() {
+		case optkeyKeySet:
+			return nil, errors.New(`jws.WithKeySet cannot be used with jws.NewVerifier`)
+		case optkeyInsecureNoSignature:
+			insecure = o.Value().(bool)
+		case optkeyValidAlgorithms:
+			validAlgs = o.Value().([]jwa.SignatureAlgorithm)
+		case optkeyRequireKeyID:
+			requireKeyID = o.Value().(bool)
+		}
+	}
+
+	if validAlgs != nil && !isValidAlgorithm(alg, validAlgs) {
+		return nil, errors.Errorf(`algorithm %q is not in the list of valid algorithms`, alg)
+	}
+
+	var verifier verify.Verifier
+	if alg == jwa.NoSignature {
+		if !insecure {
+			return nil, errors.Errorf(`refusing to verify using unsafe algorithm %q (use jws.WithInsecureNoSignature to allow it)`, alg)
+		}
+		verifier = noSignatureVerifier{}
+	} else {
+		v, err := verify.New(alg)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create verifier")
+		}
+		verifier = v
+	}
+
+	if jwkKey, ok := key.(jwk.Key); ok {
+		if !jwkKey.CanPerform(jwk.KeyOpVerify) {
+			return nil, errors.Errorf(`key_ops of the jwk.Key does not allow "%s"`, jwk.KeyOpVerify)
+		}
+
+		var rawkey interface{}
+		if err := jwkKey.Raw(&rawkey); err != nil {
+			return nil, errors.Wrap(err, `failed to materialize jwk.Key`)
+		}
+		key = rawkey
+	}
+
+	return &Verifier{
+		alg:          alg,
+		key:          key,
+		verifier:     verifier,
+		insecure:     insecure,
+		validAlgs:    validAlgs,
+		requireKeyID: requireKeyID,
+	}, nil
+}
+
+// Verify verifies the JWS message in `buf`, and returns the payload
+// if the verification is successful. It behaves exactly like
+// `jws.Verify` called with the algorithm, key and options given to
+// `jws.NewVerifier`. The `jws.WithSignatureIndex` and
+// `jws.WithDetachedPayload` options may be specified for each message.
+func (v *Verifier) Verify(buf []byte, options ...Option) ([]byte, error) {
+	var sigIndex *int
+	var detached []byte
+	var hasDetached bool
+	for _, o := range options {
+		switch o.Name() {
+		case optkeySignatureIndex:
+			sigIndex = o.Value().(*int)
+		case optkeyDetachedPayload:
+			hasDetached = true
+			detached = o.Value().([]byte)
+		}
 	}
 
 	buf = bytes.TrimSpace(buf)
@@ -287,11 +568,6 @@ func Verify(buf []byte, alg jwa.SignatureAlgorithm, key interface{}) (ret []byte
 			return nil, errors.Wrap(err, `failed to unmarshal JWS message`)
 		}
 
-		// There's something wrong if the Message part is not initialized
-		if len(proxy.Payload) == 0 {
-			return nil, errors.New(`invalid JWS message format (missing payload)`)
-		}
-
 		// if we're using the compact serialization format, then m.Signature
 		// will be non-nil
 		if len(proxy.Signature) > 0 {
@@ -300,15 +576,48 @@ func Verify(buf []byte, alg jwa.SignatureAlgorithm, key interface{}) (ret []byte
 			}
 			encodedSig, err := proxy.encodedSignature()
 			if err != nil {
-				return nil, err // don't think we need to wrap this one
+				return nil, errors.Wrap(err, `failed to parse flattened signature`)
 			}
 
 			proxy.Signatures = append(proxy.Signatures, encodedSig)
 		}
 
+		b64, err := getMessageB64Value(proxy.Signatures)
+		if err != nil {
+			return nil, errors.Wrap(err, `failed to determine the payload encoding`)
+		}
+
+		if hasDetached {
+			if len(proxy.Payload) > 0 {
+				return nil, errors.New(`invalid JWS message format (payload must be empty when using a detached payload)`)
+			}
+			if b64 {
+				proxy.Payload = base64.RawURLEncoding.EncodeToString(detached)
+			} else {
+				proxy.Payload = string(detached)
+			}
+		}
+
+		// There's something wrong if the Message part is not initialized
+		if len(proxy.Payload) == 0 {
+			return nil, errors.New(`invalid JWS message format (missing payload)`)
+		}
+
+		var refused error
 		buf := pool.GetBytesBuffer()
 		defer pool.ReleaseBytesBuffer(buf)
-		for _, sig := range proxy.Signatures {
+		for i, sig := range proxy.Signatures {
+			if err := checkProtectedAlgorithm(sig.Protected, v.alg, v.insecure, v.validAlgs); err != nil {
+				refused = err
+				continue
+			}
+			if v.requireKeyID {
+				if err := checkProtectedKeyID(sig.Protected); err != nil {
+					refused = err
+					continue
+				}
+			}
+
 			buf.Reset()
 			buf.WriteString(sig.Protected)
 			buf.WriteByte('.')
@@ -318,8 +627,14 @@ func Verify(buf []byte, alg jwa.SignatureAlgorithm, key interface{}) (ret []byte
 				continue
 			}
 
-			if err := verifier.Verify(buf.Bytes(), decodedSignature, key); err == nil {
+			if err := v.verifier.Verify(buf.Bytes(), decodedSignature, v.key); err == nil {
 				// verified!
+				if sigIndex != nil {
+					*sigIndex = i
+				}
+				if !b64 {
+					return []byte(proxy.Payload), nil
+				}
 				decodedPayload, err := base64.RawURLEncoding.DecodeString(proxy.Payload)
 				if err != nil {
 					return nil, errors.Wrap(err, `message verified, failed to decode payload`)
@@ -327,12 +642,40 @@ func Verify(buf []byte, alg jwa.SignatureAlgorithm, key interface{}) (ret []byte
 				return decodedPayload, nil
 			}
 		}
+		if refused != nil {
+			return nil, errors.Wrap(refused, `could not verify with any of the signatures`)
+		}
 		return nil, errors.New(`could not verify with any of the signatures`)
 	}
 
 	protected, payload, signature, err := SplitCompact(bytes.NewReader(buf))
 	if err != nil {
-		return nil, errors.Wrap(err, `failed extract from compact serialization format`)
+		return nil, &ParseError{Cause: errors.Wrap(err, `failed extract from compact serialization format`)}
+	}
+
+	if err := checkProtectedAlgorithm(string(protected), v.alg, v.insecure, v.validAlgs); err != nil {
+		return nil, errors.Wrap(err, `failed to verify "alg" header`)
+	}
+	if v.requireKeyID {
+		if err := checkProtectedKeyID(string(protected)); err != nil {
+			return nil, errors.Wrap(err, `failed to verify "kid" header`)
+		}
+	}
+
+	b64, err := getProtectedB64Value(string(protected))
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to get "b64" header`)
+	}
+
+	if hasDetached {
+		if len(payload) > 0 {
+			return nil, errors.New(`invalid JWS message format (payload must be empty when using a detached payload)`)
+		}
+		if b64 {
+			payload = []byte(base64.RawURLEncoding.EncodeToString(detached))
+		} else {
+			payload = detached
+		}
 	}
 
 	verifyBuf := pool.GetBytesBuffer()
@@ -344,19 +687,146 @@ func Verify(buf []byte, alg jwa.SignatureAlgorithm, key interface{}) (ret []byte
 
 	decodedSignature := make([]byte, base64.RawURLEncoding.DecodedLen(len(signature)))
 	if _, err := base64.RawURLEncoding.Decode(decodedSignature, signature); err != nil {
-		return nil, errors.Wrap(err, `failed to decode signature`)
+		return nil, &ParseError{Segment: SegmentSignature, Cause: err}
 	}
-	if err := verifier.Verify(verifyBuf.Bytes(), decodedSignature, key); err != nil {
+	if err := v.verifier.Verify(verifyBuf.Bytes(), decodedSignature, v.key); err != nil {
 		return nil, errors.Wrap(err, `failed to verify message`)
 	}
 
+	if sigIndex != nil {
+		*sigIndex = 0
+	}
+
+	if !b64 {
+		ret := make([]byte, len(payload))
+		copy(ret, payload)
+		return ret, nil
+	}
+
 	decodedPayload := make([]byte, base64.RawURLEncoding.DecodedLen(len(payload)))
 	if _, err := base64.RawURLEncoding.Decode(decodedPayload, payload); err != nil {
-		return nil, errors.Wrap(err, `message verified, failed to decode payload`)
+		return nil, errors.Wrap(&ParseError{Segment: SegmentPayload, Cause: err}, `message verified, failed to decode payload`)
 	}
 	return decodedPayload, nil
 }
 
+// verifyWithKeySet verifies the message using the keys in the key set
+// that match the "kid" and "alg" header parameters of its signatures.
+//
+// The "alg" header of the message is never trusted on its own: it must
+// match either `alg`, the "alg" of the key, or one of the algorithms
+// specified by WithValidAlgorithms. Otherwise the key is skipped.
+func verifyWithKeySet(buf []byte, alg jwa.SignatureAlgorithm, keyset *jwk.Set, options []Option) ([]byte, error) {
+	hints, err := getMessageKeyHints(buf)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to get key hints from message`)
+	}
+
+	// Remove the key set from the options, so that the calls to
+	// Verify below use the keys that we pick
+	var validAlgs []jwa.SignatureAlgorithm
+	verifyOptions := make([]Option, 0, len(options))
+	for _, o := range options {
+		switch o.Name() {
+		case optkeyKeySet:
+			continue
+		case optkeyValidAlgorithms:
+			validAlgs = o.Value().([]jwa.SignatureAlgorithm)
+		}
+		verifyOptions = append(verifyOptions, o)
+	}
+
+	var lastError error
+	for _, hint := range hints {
+		if hint.algorithm == "" || (alg != "" && hint.algorithm != alg) {
+			continue
+		}
+
+		keys := keyset.Keys
+		if hint.keyID != "" {
+			keys = keyset.LookupKeyID(hint.keyID)
+		}
+
+		for _, key := range keys {
+			if !DefaultJWKAcceptor(key) || !key.CanPerform(jwk.KeyOpVerify) {
+				continue
+			}
+			if v := key.Algorithm(); v != "" {
+				if v != hint.algorithm.String() {
+					continue
+				}
+			} else if alg == "" && (validAlgs == nil || !isValidAlgorithm(hint.algorithm, validAlgs)) {
+				// Neither the caller nor the key specify the algorithm
+				continue
+			}
+
+			var rawkey interface{}
+			if err := key.Raw(&rawkey); err != nil {
+				lastError = errors.Wrap(err, `failed to materialize jwk.Key`)
+				continue
+			}
+
+			payload, err := Verify(buf, hint.algorithm, rawkey, verifyOptions...)
+			if err == nil {
+				return payload, nil
+			}
+			lastError = err
+		}
+	}
+
+	if lastError != nil {
+		return nil, errors.Wrap(lastError, `failed to verify with any of the keys in the key set`)
+	}
+	return nil, errors.New(`failed to verify with any of the keys in the key set`)
+}
+
+// getMessageKeyHints returns the "alg" and "kid" header parameters of
+// all signatures in the message. For messages in JSON serialization
+// format, the "kid" may also be specified in the unprotected header
+func getMessageKeyHints(buf []byte) ([]keyHint, error) {
+	buf = bytes.TrimSpace(buf)
+	if len(buf) == 0 {
+		return nil, errors.New(`attempt to verify empty buffer`)
+	}
+
+	if buf[0] != '{' {
+		protected, _, _, err := SplitCompact(bytes.NewReader(buf))
+		if err != nil {
+			return nil, errors.Wrap(err, `failed extract from compact serialization format`)
+		}
+		hint, err := getProtectedKeyHint(string(protected))
+		if err != nil {
+			return nil, err
+		}
+		return []keyHint{hint}, nil
+	}
+
+	var proxy fullMessageProxy
+	if err := json.Unmarshal(buf, &proxy); err != nil {
+		return nil, errors.Wrap(err, `failed to unmarshal JWS message`)
+	}
+	if len(proxy.Signature) > 0 {
+		encodedSig, err := proxy.encodedSignature()
+		if err != nil {
+			return nil, err
+		}
+		proxy.Signatures = append(proxy.Signatures, encodedSig)
+	}
+
+	hints := make([]keyHint, 0, len(proxy.Signatures))
+	for _, sig := range proxy.Signatures {
+		hint, err := getProtectedKeyHint(sig.Protected)
+		if err != nil {
+			return nil, err
+		}
+		if hint.keyID == "" && sig.Headers != nil {
+			hint.keyID = sig.Headers.KeyID()
+		}
+		hints = append(hints, hint)
+	}
+	return hints, nil
+}
+
 // VerifyWithJKU wraps VerifyWithJKUAndContext using the background context.
 func VerifyWithJKU(buf []byte, jwkurl string, options ...Option) ([]byte, error) {
 	return VerifyWithJKUAndContext(context.Background(), buf, jwkurl, options...)
@@ -375,12 +845,16 @@ func VerifyWithJKUAndContext(ctx context.Context, buf []byte, jwkurl string, opt
 
 // VerifyWithJWK verifies the JWS message using the specified JWK
 func VerifyWithJWK(buf []byte, key jwk.Key) (payload []byte, err error) {
+	if !key.CanPerform(jwk.KeyOpVerify) {
+		return nil, errors.Errorf(`key_ops of the jwk.Key does not allow "%s"`, jwk.KeyOpVerify)
+	}
+
 	var rawkey interface{}
 	if err := key.Raw(&rawkey); err != nil {
 		return nil, errors.Wrap(err, `failed to materialize jwk.Key`)
 	}
 
-	payload, err = Verify(buf, jwa.SignatureAlgorithm(key.Algorithm()), rawkey)
+	payload, err = Verify(buf, key.SignatureAlgorithm(), rawkey)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to verify message")
 	}
@@ -446,10 +920,18 @@ func Parse(src io.Reader) (m *Message, err error) {
 		parser = parseCompact
 	}
 
-	m, err = parser(rdr)
+	// Keep the original message around, so that Message.Verify
+	// can compute the signing input from the exact encoded values
+	raw, err := ioutil.ReadAll(rdr)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to read jws message`)
+	}
+
+	m, err = parser(bytes.NewReader(raw))
 	if err != nil {
 		return nil, errors.Wrap(err, `failed to parse jws message`)
 	}
+	m.raw = raw
 
 	return m, nil
 }
@@ -472,20 +954,43 @@ type fullMessageProxy struct {
 
 func (proxy *fullMessageProxy) encodedSignature() (*encodedSignature, error) {
 	var encodedSig encodedSignature
-	if err := json.Unmarshal(proxy.Protected, &encodedSig.Protected); err != nil {
-		return nil, errors.Wrap(err, `failed to unmarshal 'protected' field`)
+	if len(proxy.Protected) > 0 {
+		if err := json.Unmarshal(proxy.Protected, &encodedSig.Protected); err != nil {
+			return nil, errors.Wrap(err, `failed to unmarshal 'protected' field`)
+		}
 	}
 	if err := json.Unmarshal(proxy.Signature, &encodedSig.Signature); err != nil {
 		return nil, errors.Wrap(err, `failed to unmarshal 'signature' field`)
 	}
-	h := NewHeaders()
-	if err := json.Unmarshal(proxy.Headers, h); err != nil {
-		return nil, errors.Wrap(err, `failed to unmarshal 'header' field`)
+	if len(proxy.Headers) > 0 {
+		h := NewHeaders()
+		if err := json.Unmarshal(proxy.Headers, h); err != nil {
+			return nil, errors.Wrap(err, `failed to unmarshal 'header' field`)
+		}
+		encodedSig.Headers = h
 	}
 
 	return &encodedSig, nil
 }
 
+// getMessageB64Value returns the value of the "b64" header parameter
+// for a message in JSON serialization format. RFC7797 requires that all
+// signatures use the same value, so messages with mixed values are rejected
+func getMessageB64Value(sigs []*encodedSignature) (bool, error) {
+	b64 := true
+	for i, sig := range sigs {
+		v, err := getProtectedB64Value(sig.Protected)
+		if err != nil {
+			return false, errors.Wrapf(err, `failed to get "b64" header for signature #%d`, i+1)
+		}
+		if i > 0 && v != b64 {
+			return false, errors.New(`invalid JWS message format (signatures use different values for "b64" header)`)
+		}
+		b64 = v
+	}
+	return b64, nil
+}
+
 func parseJSON(src io.Reader) (result *Message, err error) {
 	var proxy fullMessageProxy
 	if err := json.NewDecoder(src).Decode(&proxy); err != nil {
@@ -499,36 +1004,47 @@ func parseJSON(src io.Reader) (result *Message, err error) {
 
 		encodedSig, err := proxy.encodedSignature()
 		if err != nil {
-			return nil, err // don't think we need to wrap this one
+			return nil, errors.Wrap(err, `failed to parse flattened signature`)
 		}
 		proxy.Signatures = append(proxy.Signatures, encodedSig)
 	}
 
-	var plain Message
-	plain.payload, err = base64.RawURLEncoding.DecodeString(proxy.Payload)
+	b64, err := getMessageB64Value(proxy.Signatures)
 	if err != nil {
-		return nil, errors.Wrap(err, `failed to decode payload`)
+		return nil, errors.Wrap(err, `failed to determine the payload encoding`)
+	}
+
+	var plain Message
+	plain.rawPayload = proxy.Payload
+	if b64 {
+		plain.payload, err = base64.RawURLEncoding.DecodeString(proxy.Payload)
+		if err != nil {
+			return nil, &ParseError{Segment: SegmentPayload, Cause: err}
+		}
+	} else {
+		plain.payload = []byte(proxy.Payload)
 	}
 
 	for i, sig := range proxy.Signatures {
 		var plainSig Signature
 
 		plainSig.headers = sig.Headers
+		plainSig.rawProtected = sig.Protected
 
 		if l := len(sig.Protected); l > 0 {
 			plainSig.protected = NewHeaders()
 			hdrbuf, err := base64.RawURLEncoding.DecodeString(sig.Protected)
 			if err != nil {
-				return nil, errors.Wrapf(err, `failed to base64 decode protected header for signature #%d`, i+1)
+				return nil, errors.Wrapf(&ParseError{Segment: SegmentHeader, Cause: err}, `failed to base64 decode protected header for signature #%d`, i+1)
 			}
 			if err := json.Unmarshal(hdrbuf, &plainSig.protected); err != nil {
-				return nil, errors.Wrapf(err, `failed to unmarshal protected header for signature #%d`, i+1)
+				return nil, errors.Wrapf(&ParseError{Segment: SegmentHeader, Cause: err}, `failed to unmarshal protected header for signature #%d`, i+1)
 			}
 		}
 
 		plainSig.signature, err = base64.RawURLEncoding.DecodeString(sig.Signature)
 		if err != nil {
-			return nil, errors.Wrapf(err, `failed to decode signature #%d`, i)
+			return nil, errors.Wrapf(&ParseError{Segment: SegmentSignature, Cause: err}, `failed to decode signature #%d`, i+1)
 		}
 
 		plain.signatures = append(plain.signatures, &plainSig)
@@ -607,33 +1123,45 @@ func SplitCompact(rdr io.Reader) ([]byte, []byte, []byte, error) {
 func parseCompact(rdr io.Reader) (m *Message, err error) {
 	protected, payload, signature, err := SplitCompact(rdr)
 	if err != nil {
-		return nil, errors.Wrap(err, `invalid compact serialization format`)
+		return nil, &ParseError{Cause: errors.Wrap(err, `invalid compact serialization format`)}
 	}
 
 	decodedHeader := make([]byte, base64.RawURLEncoding.DecodedLen(len(protected)))
 	if _, err := base64.RawURLEncoding.Decode(decodedHeader, protected); err != nil {
-		return nil, errors.Wrap(err, `failed to decode headers`)
+		return nil, &ParseError{Segment: SegmentHeader, Cause: err}
 	}
 	var hdr stdHeaders
 	if err := json.Unmarshal(decodedHeader, &hdr); err != nil {
-		return nil, errors.Wrap(err, `failed to parse JOSE headers`)
+		return nil, &ParseError{Segment: SegmentHeader, Cause: err}
 	}
 
-	decodedPayload := make([]byte, base64.RawURLEncoding.DecodedLen(len(payload)))
-	if _, err = base64.RawURLEncoding.Decode(decodedPayload, payload); err != nil {
-		return nil, errors.Wrap(err, `failed to decode payload`)
+	b64, err := getB64Value(&hdr)
+	if err != nil {
+		return nil, &ParseError{Segment: SegmentHeader, Cause: errors.Wrap(err, `failed to get "b64" header`)}
+	}
+
+	var decodedPayload []byte
+	if b64 {
+		decodedPayload = make([]byte, base64.RawURLEncoding.DecodedLen(len(payload)))
+		if _, err = base64.RawURLEncoding.Decode(decodedPayload, payload); err != nil {
+			return nil, &ParseError{Segment: SegmentPayload, Cause: err}
+		}
+	} else {
+		decodedPayload = payload
 	}
 
 	decodedSignature := make([]byte, base64.RawURLEncoding.DecodedLen(len(signature)))
 	if _, err := base64.RawURLEncoding.Decode(decodedSignature, signature); err != nil {
-		return nil, errors.Wrap(err, `failed to decode signature`)
+		return nil, &ParseError{Segment: SegmentSignature, Cause: err}
 	}
 
 	var msg Message
 	msg.payload = decodedPayload
+	msg.rawPayload = string(payload)
 	msg.signatures = append(msg.signatures, &Signature{
-		protected: &hdr,
-		signature: decodedSignature,
+		protected:    &hdr,
+		signature:    decodedSignature,
+		rawProtected: string(protected),
 	})
 	return &msg, nil
 }
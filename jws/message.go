@@ -1,25 +1,52 @@
 package jws
 
+import (
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/pkg/errors"
+)
+
+// PublicHeaders returns the unprotected headers of the signature.
+// Signatures of messages in compact serialization format have none
 func (s Signature) PublicHeaders() Headers {
 	return s.headers
 }
 
+// ProtectedHeaders returns the protected headers of the signature
 func (s Signature) ProtectedHeaders() Headers {
 	return s.protected
 }
 
+// Signature returns the decoded signature
 func (s Signature) Signature() []byte {
 	return s.signature
 }
 
+// Payload returns the decoded payload of the message. Note that the
+// payload of a message returned by Parse has NOT been verified: use
+// Verify before trusting its contents
 func (m Message) Payload() []byte {
 	return m.payload
 }
 
+// Signatures returns the signatures of the message
 func (m Message) Signatures() []*Signature {
 	return m.signatures
 }
 
+// Verify verifies the message using `alg` and `key`, and returns the
+// verified payload. It is equivalent to calling `jws.Verify` on the
+// data that was passed to Parse, so it accepts the same options.
+// This allows callers to inspect the headers of a message (e.g. to look
+// up the key by its "kid") before verifying it, without keeping the
+// original data around. Note that the message is parsed again in order
+// to be verified
+func (m Message) Verify(alg jwa.SignatureAlgorithm, key interface{}, options ...Option) ([]byte, error) {
+	if m.raw == nil {
+		return nil, errors.New(`only messages created by jws.Parse can be verified`)
+	}
+	return Verify(m.raw, alg, key, options...)
+}
+
 // LookupSignature looks up a particular signature entry using
 // the `kid` value
 func (m Message) LookupSignature(kid string) []*Signature {
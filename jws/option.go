@@ -2,20 +2,39 @@ package jws
 
 import (
 	"github.com/lestrrat-go/jwx/internal/option"
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
 	"github.com/lestrrat-go/jwx/jws/sign"
 )
 
 type Option = option.Interface
 
 const (
-	optkeyPayloadSigner = `payload-signer`
-	optkeyHeaders       = `headers`
+	optkeyPayloadSigner   = `payload-signer`
+	optkeyHeaders         = `headers`
+	optkeyFlattenedJSON   = `flattened-json`
+	optkeySignatureIndex  = `signature-index`
+	optkeyDetachedPayload = `detached-payload`
+
+	optkeyInsecureNoSignature = `insecure-no-signature`
+	optkeyValidAlgorithms     = `valid-algorithms`
+	optkeyKeySet              = `key-set`
+	optkeyRequireKeyID        = `require-key-id`
 )
 
 func WithSigner(signer sign.Signer, key interface{}, public, protected Headers) Option {
+	return option.New(optkeyPayloadSigner, &payloadSigner{
+		signer:    &keySigner{signer: signer, key: key},
+		protected: protected,
+		public:    public,
+	})
+}
+
+// WithExternalSigner is the same as WithSigner, but the signature is
+// generated by a Signer that manages its own key, such as a HSM or a KMS
+func WithExternalSigner(signer Signer, public, protected Headers) Option {
 	return option.New(optkeyPayloadSigner, &payloadSigner{
 		signer:    signer,
-		key:       key,
 		protected: protected,
 		public:    public,
 	})
@@ -24,3 +43,74 @@ func WithSigner(signer sign.Signer, key interface{}, public, protected Headers)
 func WithHeaders(h Headers) Option {
 	return option.New(optkeyHeaders, h)
 }
+
+// WithFlattenedJSON specifies that SignMulti should use the flattened
+// JSON serialization format (https://tools.ietf.org/html/rfc7515#section-7.2.2)
+// instead of the general JSON serialization format. The flattened format
+// can only be used when there is exactly one signer.
+func WithFlattenedJSON(b bool) Option {
+	return option.New(optkeyFlattenedJSON, b)
+}
+
+// WithSignatureIndex specifies a location where Verify stores the
+// index of the signature that was successfully verified. For messages
+// in compact serialization format, the index is always 0.
+func WithSignatureIndex(idx *int) Option {
+	return option.New(optkeySignatureIndex, idx)
+}
+
+// WithDetachedPayload specifies the payload to be used with a JWS message
+// that does not carry its own payload (https://tools.ietf.org/html/rfc7515#appendix-F).
+//
+// When used with Sign, the payload is signed but omitted from the
+// resulting message. When used with Verify, the payload is used to
+// reconstruct the signing input of a message whose payload segment is empty.
+func WithDetachedPayload(payload []byte) Option {
+	return option.New(optkeyDetachedPayload, payload)
+}
+
+// WithInsecureNoSignature allows Verify to accept unsecured messages,
+// i.e. messages whose protected header declares the "none" algorithm
+// (https://tools.ietf.org/html/rfc7518#section-3.6). By default such
+// messages are rejected, as accepting them allows anybody to forge
+// messages. The algorithm passed to Verify must be jwa.NoSignature
+// as well for these messages to be accepted.
+func WithInsecureNoSignature() Option {
+	return option.New(optkeyInsecureNoSignature, true)
+}
+
+// WithValidAlgorithms specifies the list of signature algorithms that
+// Verify may use. Messages whose protected header declares an algorithm
+// that is not in the list are rejected before the key is used, which
+// prevents algorithm confusion attacks (e.g. verifying an RS256 signed
+// message using the RSA public key as an HMAC secret). The algorithm
+// passed to Verify must be in the list as well.
+func WithValidAlgorithms(algs ...jwa.SignatureAlgorithm) Option {
+	if algs == nil {
+		algs = []jwa.SignatureAlgorithm{}
+	}
+	return option.New(optkeyValidAlgorithms, algs)
+}
+
+// WithKeySet specifies a JWK key set that Verify uses to look up the
+// verification key, instead of the key passed as its argument (which
+// should be nil). For each signature, the keys whose "kid" matches the
+// "kid" header of the message are tried; if the message does not specify
+// a "kid", all keys in the set are tried. Keys that specify an "alg"
+// different from the algorithm declared in the message are skipped.
+//
+// The algorithm passed to Verify may be left empty, in which case the
+// algorithm declared in the protected header is used, but only with keys
+// that specify the same "alg", or that is one of the algorithms given
+// by WithValidAlgorithms. Keys without an "alg" are skipped otherwise.
+func WithKeySet(set *jwk.Set) Option {
+	return option.New(optkeyKeySet, set)
+}
+
+// WithRequireKeyID specifies that Verify must reject messages whose
+// protected header does not contain a "kid" header parameter. For
+// messages in JSON serialization format, signatures without one are
+// skipped. By default the "kid" header parameter is optional
+func WithRequireKeyID(b bool) Option {
+	return option.New(optkeyRequireKeyID, b)
+}
@@ -0,0 +1,95 @@
+package jws
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Compact encodes the message into a JWS compact serialization format.
+// The message must have exactly one signature, without any unprotected
+// headers.
+//
+// The protected header and the payload are written exactly as they
+// were encoded in the message that was passed to `jws.Parse`, so header
+// parameters that this library does not know about are preserved, and
+// the signature remains valid
+func Compact(m *Message) ([]byte, error) {
+	if len(m.signatures) != 1 {
+		return nil, errors.New(`wrong number of signatures for compact serialization`)
+	}
+
+	sig := m.signatures[0]
+	if hdrs := sig.headers; hdrs != nil {
+		v, err := hdrs.AsMap(context.TODO())
+		if err != nil {
+			return nil, errors.Wrap(err, `failed to inspect unprotected headers`)
+		}
+		if len(v) > 0 {
+			return nil, errors.New(`unprotected headers cannot be used in compact serialization`)
+		}
+	}
+
+	if strings.IndexByte(m.rawPayload, '.') >= 0 {
+		return nil, errors.New(`unencoded payload containing "." cannot be used in compact serialization`)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(sig.rawProtected)
+	buf.WriteByte('.')
+	buf.WriteString(m.rawPayload)
+	buf.WriteByte('.')
+	buf.WriteString(base64.RawURLEncoding.EncodeToString(sig.signature))
+	return buf.Bytes(), nil
+}
+
+// JSON encodes the message into a JWS JSON serialization format. By
+// default the general JSON serialization format is used. Pass
+// `jws.WithFlattenedJSON(true)` to use the flattened format instead,
+// which requires the message to have exactly one signature.
+//
+// As with `jws.Compact`, the protected headers and the payload are
+// written exactly as they were encoded in the original message. The
+// unprotected headers, including parameters that this library does not
+// know about, are re-encoded from their current values
+func JSON(m *Message, options ...Option) ([]byte, error) {
+	var flattened bool
+	for _, o := range options {
+		switch o.Name() {
+		case optkeyFlattenedJSON:
+			flattened = o.Value().(bool)
+		}
+	}
+
+	if len(m.signatures) == 0 {
+		return nil, errors.New(`message has no signatures`)
+	}
+	if flattened && len(m.signatures) > 1 {
+		return nil, errors.New(`flattened JSON serialization requires exactly one signature`)
+	}
+
+	var result encodedMessage
+	result.Payload = m.rawPayload
+	for _, sig := range m.signatures {
+		result.Signatures = append(result.Signatures, &encodedSignature{
+			Headers:   sig.headers,
+			Protected: sig.rawProtected,
+			Signature: base64.RawURLEncoding.EncodeToString(sig.signature),
+		})
+	}
+
+	if flattened {
+		sig := result.Signatures[0]
+		return json.Marshal(encodedFlattenedMessage{
+			Payload:   result.Payload,
+			Protected: sig.Protected,
+			Headers:   sig.Headers,
+			Signature: sig.Signature,
+		})
+	}
+	return json.Marshal(result)
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/ecdsa"
 	"crypto/rand"
 
+	"github.com/lestrrat-go/jwx/internal/secp256k1"
 	"github.com/lestrrat-go/jwx/jwa"
 	"github.com/pkg/errors"
 )
@@ -13,9 +14,9 @@ var ecdsaSignFuncs = map[jwa.SignatureAlgorithm]ecdsaSignFunc{}
 
 func init() {
 	algs := map[jwa.SignatureAlgorithm]crypto.Hash{
-		jwa.ES256: crypto.SHA256,
-		jwa.ES384: crypto.SHA384,
-		jwa.ES512: crypto.SHA512,
+		jwa.ES256:  crypto.SHA256,
+		jwa.ES384:  crypto.SHA384,
+		jwa.ES512:  crypto.SHA512,
 	}
 
 	for alg, h := range algs {
@@ -84,5 +85,10 @@ func (s ECDSASigner) Sign(payload []byte, key interface{}) ([]byte, error) {
 		return nil, errors.Errorf(`invalid key type %T. *ecdsa.PrivateKey is required`, key)
 	}
 
+	// See New for why secp256k1 keys cannot be used for signing
+	if pubkey.Curve == secp256k1.Curve() {
+		return nil, errors.Errorf(`signing using %s keys is not supported (verification only)`, secp256k1.Name)
+	}
+
 	return s.sign(payload, pubkey)
 }
@@ -0,0 +1,41 @@
+package sign
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/pkg/errors"
+)
+
+func newEdDSA() (*EdDSASigner, error) {
+	return &EdDSASigner{}, nil
+}
+
+func (s EdDSASigner) Algorithm() jwa.SignatureAlgorithm {
+	return jwa.EdDSA
+}
+
+// Sign creates a signature using an Ed25519 private key, as described
+// in https://tools.ietf.org/html/rfc8037#section-3.1
+func (s EdDSASigner) Sign(payload []byte, key interface{}) ([]byte, error) {
+	if key == nil {
+		return nil, errors.New(`missing private key while signing payload`)
+	}
+
+	var privkey ed25519.PrivateKey
+	switch v := key.(type) {
+	case ed25519.PrivateKey:
+		privkey = v
+	case *ecdsa.PrivateKey:
+		return nil, errors.Errorf(`invalid key: EdDSA requires an Ed25519 key, but an ECDSA key on curve %s was given`, v.Curve.Params().Name)
+	default:
+		return nil, errors.Errorf(`invalid key type %T. ed25519.PrivateKey is required`, key)
+	}
+
+	if len(privkey) != ed25519.PrivateKeySize {
+		return nil, errors.Errorf(`invalid ed25519.PrivateKey length %d`, len(privkey))
+	}
+
+	return ed25519.Sign(privkey, payload), nil
+}
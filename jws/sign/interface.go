@@ -35,6 +35,9 @@ type ECDSASigner struct {
 	sign ecdsaSignFunc
 }
 
+// EdDSASigner uses crypto/ed25519 to sign the payloads.
+type EdDSASigner struct{}
+
 type hmacSignFunc func([]byte, []byte) ([]byte, error)
 
 // HMACSigner uses crypto/hmac to sign the payloads.
@@ -0,0 +1,25 @@
+package sign
+
+import (
+	"github.com/lestrrat-go/jwx/internal/option"
+)
+
+type Option = option.Interface
+
+const (
+	optkeyPSSSaltLength = `pss-salt-length`
+)
+
+// WithPSSSaltLength specifies the length of the salt used by the
+// PS256, PS384 and PS512 signers, which maps to rsa.PSSOptions.SaltLength.
+// The special values rsa.PSSSaltLengthAuto and rsa.PSSSaltLengthEqualsHash
+// are accepted as well. By default the salt is as long as the hash, as
+// required by RFC7518. This option is ignored by the other signers.
+//
+// Besides `sign.New`, the option may be passed to `jws.Sign`,
+// `jws.SignLiteral` and `jws.NewSigner`.
+//
+// Note that the verifiers accept signatures using any salt length.
+func WithPSSSaltLength(n int) Option {
+	return option.New(optkeyPSSSaltLength, n)
+}
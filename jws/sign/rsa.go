@@ -11,38 +11,38 @@ import (
 
 var rsaSignFuncs = map[jwa.SignatureAlgorithm]rsaSignFunc{}
 
-func init() {
-	algs := map[jwa.SignatureAlgorithm]struct {
-		Hash     crypto.Hash
-		SignFunc func(crypto.Hash) rsaSignFunc
-	}{
-		jwa.RS256: {
-			Hash:     crypto.SHA256,
-			SignFunc: makeSignPKCS1v15,
-		},
-		jwa.RS384: {
-			Hash:     crypto.SHA384,
-			SignFunc: makeSignPKCS1v15,
-		},
-		jwa.RS512: {
-			Hash:     crypto.SHA512,
-			SignFunc: makeSignPKCS1v15,
-		},
-		jwa.PS256: {
-			Hash:     crypto.SHA256,
-			SignFunc: makeSignPSS,
-		},
-		jwa.PS384: {
-			Hash:     crypto.SHA384,
-			SignFunc: makeSignPSS,
-		},
-		jwa.PS512: {
-			Hash:     crypto.SHA512,
-			SignFunc: makeSignPSS,
-		},
-	}
+var rsaAlgorithms = map[jwa.SignatureAlgorithm]struct {
+	Hash     crypto.Hash
+	SignFunc func(crypto.Hash) rsaSignFunc
+}{
+	jwa.RS256: {
+		Hash:     crypto.SHA256,
+		SignFunc: makeSignPKCS1v15,
+	},
+	jwa.RS384: {
+		Hash:     crypto.SHA384,
+		SignFunc: makeSignPKCS1v15,
+	},
+	jwa.RS512: {
+		Hash:     crypto.SHA512,
+		SignFunc: makeSignPKCS1v15,
+	},
+	jwa.PS256: {
+		Hash:     crypto.SHA256,
+		SignFunc: makeSignPSS,
+	},
+	jwa.PS384: {
+		Hash:     crypto.SHA384,
+		SignFunc: makeSignPSS,
+	},
+	jwa.PS512: {
+		Hash:     crypto.SHA512,
+		SignFunc: makeSignPSS,
+	},
+}
 
-	for alg, item := range algs {
+func init() {
+	for alg, item := range rsaAlgorithms {
 		rsaSignFuncs[alg] = item.SignFunc(item.Hash)
 	}
 }
@@ -57,23 +57,46 @@ func makeSignPKCS1v15(hash crypto.Hash) rsaSignFunc {
 	}
 }
 
+// makeSignPSS creates signatures using a salt whose length is equal to
+// the size of the hash, as required by RFC7518 section 3.5
 func makeSignPSS(hash crypto.Hash) rsaSignFunc {
+	return makeSignPSSWithSaltLength(hash, rsa.PSSSaltLengthEqualsHash)
+}
+
+func makeSignPSSWithSaltLength(hash crypto.Hash, saltLength int) rsaSignFunc {
 	return func(payload []byte, key *rsa.PrivateKey) ([]byte, error) {
 		h := hash.New()
 		if _, err := h.Write(payload); err != nil {
 			return nil, errors.Wrap(err, "failed to write payload using SignPSS")
 		}
 		return rsa.SignPSS(rand.Reader, key, hash, h.Sum(nil), &rsa.PSSOptions{
-			SaltLength: rsa.PSSSaltLengthAuto,
+			SaltLength: saltLength,
 		})
 	}
 }
 
-func newRSA(alg jwa.SignatureAlgorithm) (*RSASigner, error) {
+func newRSA(alg jwa.SignatureAlgorithm, options ...Option) (*RSASigner, error) {
 	signfn, ok := rsaSignFuncs[alg]
 	if !ok {
 		return nil, errors.Errorf(`unsupported algorithm while trying to create RSA signer: %s`, alg)
 	}
+
+	for _, option := range options {
+		switch option.Name() {
+		case optkeyPSSSaltLength:
+			switch alg {
+			case jwa.PS256, jwa.PS384, jwa.PS512:
+			default:
+				// not a PSS algorithm
+				continue
+			}
+			saltLength := option.Value().(int)
+			if saltLength < rsa.PSSSaltLengthEqualsHash {
+				return nil, errors.Errorf(`invalid salt length for %s: %d`, alg, saltLength)
+			}
+			signfn = makeSignPSSWithSaltLength(rsaAlgorithms[alg].Hash, saltLength)
+		}
+	}
 	return &RSASigner{
 		alg:  alg,
 		sign: signfn,
@@ -6,12 +6,19 @@ import (
 )
 
 // New creates a signer that signs payloads using the given signature algorithm.
-func New(alg jwa.SignatureAlgorithm) (Signer, error) {
+func New(alg jwa.SignatureAlgorithm, options ...Option) (Signer, error) {
 	switch alg {
 	case jwa.RS256, jwa.RS384, jwa.RS512, jwa.PS256, jwa.PS384, jwa.PS512:
-		return newRSA(alg)
+		return newRSA(alg, options...)
 	case jwa.ES256, jwa.ES384, jwa.ES512:
 		return newECDSA(alg)
+	case jwa.ES256K:
+		// The secp256k1 implementation is not constant time, and
+		// signing with it would leak the nonce and the private key
+		// through timing. ES256K signatures can still be verified
+		return nil, errors.Errorf(`signing using %s is not supported (verification only)`, alg)
+	case jwa.EdDSA:
+		return newEdDSA()
 	case jwa.HS256, jwa.HS384, jwa.HS512:
 		return newHMAC(alg)
 	default:
@@ -1 +1,48 @@
 package jws
+
+import (
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jws/sign"
+	"github.com/pkg/errors"
+)
+
+// Signer generates signatures using a key that it holds itself. Unlike
+// `sign.Signer`, the key is not passed to the Sign method, so Signers
+// may be backed by keys that never leave a HSM or a KMS.
+//
+// Use `jws.SignWithSigner` or the `jws.WithExternalSigner` option to
+// sign payloads using a Signer
+type Signer interface {
+	// Sign creates a signature for the given signing input
+	Sign(payload []byte) ([]byte, error)
+
+	// Algorithm returns the algorithm that the signatures are created
+	// with. It is used as the value of the "alg" header
+	Algorithm() jwa.SignatureAlgorithm
+}
+
+// keySigner binds one of the built-in signers to a key
+type keySigner struct {
+	signer sign.Signer
+	key    interface{}
+}
+
+func (s *keySigner) Sign(payload []byte) ([]byte, error) {
+	return s.signer.Sign(payload, s.key)
+}
+
+func (s *keySigner) Algorithm() jwa.SignatureAlgorithm {
+	return s.signer.Algorithm()
+}
+
+// NewSigner creates a Signer that signs payloads with `key`, using the
+// built-in implementation of `alg`. The type of `key` must be suitable
+// for the algorithm, as described in `jws.Sign`. The options are passed
+// to `sign.New`
+func NewSigner(alg jwa.SignatureAlgorithm, key interface{}, options ...sign.Option) (Signer, error) {
+	signer, err := sign.New(alg, options...)
+	if err != nil {
+		return nil, errors.Wrapf(err, `failed to create signer for %s`, alg)
+	}
+	return &keySigner{signer: signer, key: key}, nil
+}
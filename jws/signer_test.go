@@ -98,3 +98,99 @@ func TestSignMulti(t *testing.T) {
 
 	t.Logf("%s", m)
 }
+
+// custodianSigner mimics a signer backed by a HSM or a KMS: the key is
+// never handed out, and the signer reports how often it was used
+type custodianSigner struct {
+	key   *ecdsa.PrivateKey
+	calls int
+}
+
+func (s *custodianSigner) Sign(payload []byte) ([]byte, error) {
+	s.calls++
+	signer, err := sign.New(jwa.ES256)
+	if err != nil {
+		return nil, err
+	}
+	return signer.Sign(payload, s.key)
+}
+
+func (s *custodianSigner) Algorithm() jwa.SignatureAlgorithm {
+	return jwa.ES256
+}
+
+func TestSignWithSigner(t *testing.T) {
+	eckey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if !assert.NoError(t, err, "ECDSA key generated") {
+		return
+	}
+	payload := []byte("Lorem ipsum")
+
+	t.Run("Compact", func(t *testing.T) {
+		signer := &custodianSigner{key: eckey}
+		signed, err := jws.SignWithSigner(payload, signer)
+		if !assert.NoError(t, err, "jws.SignWithSigner should succeed") {
+			return
+		}
+		if !assert.Equal(t, 1, signer.calls, "signer should be called once") {
+			return
+		}
+
+		verified, err := jws.Verify(signed, jwa.ES256, &eckey.PublicKey)
+		if !assert.NoError(t, err, "jws.Verify should succeed") {
+			return
+		}
+		if !assert.Equal(t, payload, verified, "payload should match") {
+			return
+		}
+	})
+	t.Run("Multiple signatures", func(t *testing.T) {
+		hmackey := []byte("very secret key")
+		hmacsigner, err := jws.NewSigner(jwa.HS256, hmackey)
+		if !assert.NoError(t, err, "jws.NewSigner should succeed") {
+			return
+		}
+
+		signer := &custodianSigner{key: eckey}
+		protected := jws.NewHeaders()
+		if !assert.NoError(t, protected.Set(jws.KeyIDKey, "custodian"), "protected.Set should succeed") {
+			return
+		}
+		signed, err := jws.SignWithSigner(payload, hmacsigner, jws.WithExternalSigner(signer, nil, protected))
+		if !assert.NoError(t, err, "jws.SignWithSigner should succeed") {
+			return
+		}
+		if !assert.Equal(t, 1, signer.calls, "signer should be called once") {
+			return
+		}
+
+		msg, err := jws.Parse(strings.NewReader(string(signed)))
+		if !assert.NoError(t, err, "jws.Parse should succeed") {
+			return
+		}
+		if !assert.Len(t, msg.Signatures(), 2, "message should have two signatures") {
+			return
+		}
+		if !assert.Len(t, msg.LookupSignature("custodian"), 1, "external signature should have the kid") {
+			return
+		}
+
+		for _, tc := range []struct {
+			alg jwa.SignatureAlgorithm
+			key interface{}
+		}{
+			{alg: jwa.HS256, key: hmackey},
+			{alg: jwa.ES256, key: &eckey.PublicKey},
+		} {
+			if _, err := jws.Verify(signed, tc.alg, tc.key); !assert.NoError(t, err, "jws.Verify should succeed for %s", tc.alg) {
+				return
+			}
+		}
+	})
+	t.Run("Unknown algorithm", func(t *testing.T) {
+		_, err := jws.NewSigner(jwa.SignatureAlgorithm("FooBar"), nil)
+		if !assert.Error(t, err, "jws.NewSigner should fail") {
+			return
+		}
+	})
+}
@@ -0,0 +1,47 @@
+package jws_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jws"
+)
+
+func BenchmarkVerifyRS256(b *testing.B) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		b.Fatal(err)
+	}
+	signed, err := jws.Sign([]byte("Lorem ipsum"), jwa.RS256, key)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("jws.Verify", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := jws.Verify(signed, jwa.RS256, &key.PublicKey); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	})
+	b.Run("jws.Verifier", func(b *testing.B) {
+		v, err := jws.NewVerifier(jwa.RS256, &key.PublicKey)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := v.Verify(signed); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	})
+}
@@ -0,0 +1,39 @@
+package verify
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+
+	"github.com/pkg/errors"
+)
+
+func newEdDSA() (*EdDSAVerifier, error) {
+	return &EdDSAVerifier{}, nil
+}
+
+// Verify checks a signature created using an Ed25519 private key,
+// as described in https://tools.ietf.org/html/rfc8037#section-3.1
+func (v EdDSAVerifier) Verify(payload []byte, signature []byte, key interface{}) error {
+	if key == nil {
+		return errors.New(`missing public key while verifying payload`)
+	}
+
+	var pubkey ed25519.PublicKey
+	switch v := key.(type) {
+	case ed25519.PublicKey:
+		pubkey = v
+	case *ecdsa.PublicKey:
+		return errors.Errorf(`invalid key: EdDSA requires an Ed25519 key, but an ECDSA key on curve %s was given`, v.Curve.Params().Name)
+	default:
+		return errors.Errorf(`invalid key type %T. ed25519.PublicKey is required`, key)
+	}
+
+	if len(pubkey) != ed25519.PublicKeySize {
+		return errors.Errorf(`invalid ed25519.PublicKey length %d`, len(pubkey))
+	}
+
+	if !ed25519.Verify(pubkey, payload, signature) {
+		return errors.New(`failed to verify signature using ed25519`)
+	}
+	return nil
+}
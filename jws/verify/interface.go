@@ -30,6 +30,8 @@ type ECDSAVerifier struct {
 	verify ecdsaVerifyFunc
 }
 
+type EdDSAVerifier struct{}
+
 type HMACVerifier struct {
 	signer sign.Signer
 }
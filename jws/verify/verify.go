@@ -11,8 +11,10 @@ func New(alg jwa.SignatureAlgorithm) (Verifier, error) {
 	switch alg {
 	case jwa.RS256, jwa.RS384, jwa.RS512, jwa.PS256, jwa.PS384, jwa.PS512:
 		return newRSA(alg)
-	case jwa.ES256, jwa.ES384, jwa.ES512:
+	case jwa.ES256, jwa.ES256K, jwa.ES384, jwa.ES512:
 		return newECDSA(alg)
+	case jwa.EdDSA:
+		return newEdDSA()
 	case jwa.HS256, jwa.HS384, jwa.HS512:
 		return newHMAC(alg)
 	default:
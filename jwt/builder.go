@@ -0,0 +1,83 @@
+package jwt
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type claimPair struct {
+	key   string
+	value interface{}
+}
+
+// Builder is a convenience wrapper around the `New()` constructor and
+// the `Set()` methods to assign values to Token claims. Users can
+// successively call Claim() or one of the typed methods on the Builder,
+// and when they are done, call Build() to obtain the final Token.
+//
+// The Builder is not safe for concurrent use.
+type Builder struct {
+	claims []claimPair
+}
+
+// NewBuilder creates a new Builder instance.
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Claim sets the value of an arbitrary claim. The value of registered
+// claims are validated when Build() is called, just as they would if
+// they were assigned using `Token.Set()`
+func (b *Builder) Claim(name string, value interface{}) *Builder {
+	b.claims = append(b.claims, claimPair{key: name, value: value})
+	return b
+}
+
+// Audience sets the "aud" claim.
+func (b *Builder) Audience(v []string) *Builder {
+	return b.Claim(AudienceKey, v)
+}
+
+// Expiration sets the "exp" claim. The value is serialized as a NumericDate.
+func (b *Builder) Expiration(v time.Time) *Builder {
+	return b.Claim(ExpirationKey, v)
+}
+
+// IssuedAt sets the "iat" claim. The value is serialized as a NumericDate.
+func (b *Builder) IssuedAt(v time.Time) *Builder {
+	return b.Claim(IssuedAtKey, v)
+}
+
+// Issuer sets the "iss" claim.
+func (b *Builder) Issuer(v string) *Builder {
+	return b.Claim(IssuerKey, v)
+}
+
+// JwtID sets the "jti" claim.
+func (b *Builder) JwtID(v string) *Builder {
+	return b.Claim(JwtIDKey, v)
+}
+
+// NotBefore sets the "nbf" claim. The value is serialized as a NumericDate.
+func (b *Builder) NotBefore(v time.Time) *Builder {
+	return b.Claim(NotBeforeKey, v)
+}
+
+// Subject sets the "sub" claim.
+func (b *Builder) Subject(v string) *Builder {
+	return b.Claim(SubjectKey, v)
+}
+
+// Build creates a new Token using the claims that were assigned to
+// the Builder. An error is returned if any of the values is not valid
+// for its claim.
+func (b *Builder) Build() (Token, error) {
+	t := New()
+	for _, claim := range b.claims {
+		if err := t.Set(claim.key, claim.value); err != nil {
+			return nil, errors.Wrapf(err, `failed to set claim %q`, claim.key)
+		}
+	}
+	return t, nil
+}
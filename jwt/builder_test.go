@@ -0,0 +1,95 @@
+package jwt_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder(t *testing.T) {
+	t.Run("Build", func(t *testing.T) {
+		token, err := jwt.NewBuilder().
+			Audience([]string{"developers"}).
+			Expiration(expectedTokenTime).
+			IssuedAt(expectedTokenTime).
+			Issuer("x").
+			JwtID("jti").
+			NotBefore(expectedTokenTime).
+			Subject("y").
+			Claim("role", "admin").
+			Build()
+		if !assert.NoError(t, err, `Build should succeed`) {
+			return
+		}
+
+		if !assert.Equal(t, []string{"developers"}, token.Audience(), `aud should match`) {
+			return
+		}
+		if !assert.Equal(t, expectedTokenTime, token.Expiration(), `exp should match`) {
+			return
+		}
+		if !assert.Equal(t, "x", token.Issuer(), `iss should match`) {
+			return
+		}
+		if !assert.Equal(t, "y", token.Subject(), `sub should match`) {
+			return
+		}
+		v, ok := token.Get("role")
+		if !assert.True(t, ok, `role should exist`) {
+			return
+		}
+		if !assert.Equal(t, "admin", v, `role should match`) {
+			return
+		}
+
+		buf, err := json.Marshal(token)
+		if !assert.NoError(t, err, `json.Marshal should succeed`) {
+			return
+		}
+		var m map[string]interface{}
+		if !assert.NoError(t, json.Unmarshal(buf, &m), `json.Unmarshal should succeed`) {
+			return
+		}
+		if !assert.Equal(t, float64(tokenTime), m[jwt.ExpirationKey], `exp should be serialized as a NumericDate`) {
+			return
+		}
+	})
+	t.Run("Invalid claim value", func(t *testing.T) {
+		_, err := jwt.NewBuilder().
+			Issuer("x").
+			Claim(jwt.ExpirationKey, "tomorrow").
+			Build()
+		if !assert.Error(t, err, `Build should fail`) {
+			return
+		}
+	})
+	t.Run("Sign", func(t *testing.T) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if !assert.NoError(t, err, `rsa.GenerateKey should succeed`) {
+			return
+		}
+
+		token, err := jwt.NewBuilder().Issuer("x").Subject("y").Build()
+		if !assert.NoError(t, err, `Build should succeed`) {
+			return
+		}
+
+		signed, err := jwt.Sign(token, jwa.RS256, key)
+		if !assert.NoError(t, err, `jwt.Sign should succeed`) {
+			return
+		}
+
+		parsed, err := jwt.ParseBytes(signed, jwt.WithVerify(jwa.RS256, &key.PublicKey))
+		if !assert.NoError(t, err, `jwt.ParseBytes should succeed`) {
+			return
+		}
+		if !assert.Equal(t, "y", parsed.Subject(), `sub should match`) {
+			return
+		}
+	})
+}
@@ -375,6 +375,7 @@ func generateToken(tt tokenType) error {
 	fmt.Fprintf(&buf, "\nPrivateClaims() map[string]interface{}")
 	fmt.Fprintf(&buf, "\nGet(string) (interface{}, bool)")
 	fmt.Fprintf(&buf, "\nSet(string, interface{}) error")
+	fmt.Fprintf(&buf, "\nRemove(string) error")
 	fmt.Fprintf(&buf, "\nIterate(context.Context) Iterator")
 	fmt.Fprintf(&buf, "\nWalk(context.Context, Visitor) error")
 	fmt.Fprintf(&buf, "\nAsMap(context.Context) (map[string]interface{}, error)")
@@ -508,6 +509,18 @@ func generateToken(tt tokenType) error {
 	fmt.Fprintf(&buf, "\nreturn nil")
 	fmt.Fprintf(&buf, "\n}") // end func (t *%s) Set(name string, value interface{})
 
+	fmt.Fprintf(&buf, "\n\nfunc (t *%s) Remove(name string) error {", tt.structName)
+	fmt.Fprintf(&buf, "\nswitch name {")
+	for _, f := range fields {
+		fmt.Fprintf(&buf, "\ncase %sKey:", f.method)
+		fmt.Fprintf(&buf, "\nt.%s = nil", f.name)
+	}
+	fmt.Fprintf(&buf, "\ndefault:")
+	fmt.Fprintf(&buf, "\ndelete(t.privateClaims, name)")
+	fmt.Fprintf(&buf, "\n}") // end switch name
+	fmt.Fprintf(&buf, "\nreturn nil")
+	fmt.Fprintf(&buf, "\n}") // end func (t *%s) Remove(name string)
+
 	for _, f := range fields {
 		fmt.Fprintf(&buf, "\n\nfunc (t *%s) %s() ", tt.structName, f.method)
 		if f.returnType != "" {
@@ -658,6 +671,11 @@ func generateToken(tt tokenType) error {
 		fmt.Fprintf(&buf, "\ndelete(m, %s)", keyName)
 	}
 
+	if tt.pkg == "jwt" {
+		fmt.Fprintf(&buf, "\nif err := decodeCustomFields(buf, m); err != nil {")
+		fmt.Fprintf(&buf, "\nreturn errors.Wrap(err, `failed to decode custom fields`)")
+		fmt.Fprintf(&buf, "\n}")
+	}
 	fmt.Fprintf(&buf, "\nt.privateClaims = m")
 	fmt.Fprintf(&buf, "\nreturn nil")
 	fmt.Fprintf(&buf, "\n}")
@@ -715,8 +733,38 @@ func generateToken(tt tokenType) error {
 	fmt.Fprintf(&buf, "\nreturn iter.WalkMap(ctx, t, visitor)")
 	fmt.Fprintf(&buf, "\n}")
 
-	fmt.Fprintf(&buf, "\n\nfunc (t *%s) AsMap(ctx context.Context) (map[string]interface{}, error) {", tt.structName)
-	fmt.Fprintf(&buf, "\nreturn iter.AsMap(ctx, t)")
+	fmt.Fprintf(&buf, "\n\n// AsMap returns all registered and private claims as a single map.")
+	fmt.Fprintf(&buf, "\n// Time based claims are stored in their NumericDate form (number of")
+	fmt.Fprintf(&buf, "\n// seconds since the Unix epoch), so that the map can be serialized")
+	fmt.Fprintf(&buf, "\n// and parsed back into a token. The map contains exactly the claims")
+	fmt.Fprintf(&buf, "\n// visited by Iterate and Walk")
+	fmt.Fprintf(&buf, "\nfunc (t *%s) AsMap(ctx context.Context) (map[string]interface{}, error) {", tt.structName)
+	fmt.Fprintf(&buf, "\nm := make(map[string]interface{})")
+	fmt.Fprintf(&buf, "\nfor iter := t.Iterate(ctx); iter.Next(ctx); {")
+	fmt.Fprintf(&buf, "\npair := iter.Pair()")
+	fmt.Fprintf(&buf, "\nkey := pair.Key.(string)")
+	fmt.Fprintf(&buf, "\nswitch key {")
+	fmt.Fprintf(&buf, "\ncase ")
+	var i int
+	for _, f := range fields {
+		if f.typ != "types.NumericDate" {
+			continue
+		}
+		if i > 0 {
+			fmt.Fprintf(&buf, ", ")
+		}
+		fmt.Fprintf(&buf, "%sKey", f.method)
+		i++
+	}
+	fmt.Fprintf(&buf, ":")
+	fmt.Fprintf(&buf, "\nif tv, ok := pair.Value.(time.Time); ok {")
+	fmt.Fprintf(&buf, "\nm[key] = tv.Unix()")
+	fmt.Fprintf(&buf, "\ncontinue")
+	fmt.Fprintf(&buf, "\n}")
+	fmt.Fprintf(&buf, "\n}")
+	fmt.Fprintf(&buf, "\nm[key] = pair.Value")
+	fmt.Fprintf(&buf, "\n}")
+	fmt.Fprintf(&buf, "\nreturn m, nil")
 	fmt.Fprintf(&buf, "\n}")
 
 	return codegen.WriteFormattedCodeToFile(tt.filename, &buf)
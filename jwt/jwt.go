@@ -5,6 +5,7 @@ package jwt
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"io"
 	"io/ioutil"
@@ -16,6 +17,10 @@ import (
 	"github.com/pkg/errors"
 )
 
+// jwsOptkeyKeySet is the name of the jws.WithKeySet option, which is
+// accepted by Parse in place of jwt.WithKeySet
+var jwsOptkeyKeySet = jws.WithKeySet(nil).Name()
+
 // ParseString calls Parse with the given string
 func ParseString(s string, options ...Option) (Token, error) {
 	return Parse(strings.NewReader(s), options...)
@@ -32,24 +37,47 @@ func ParseBytes(s []byte, options ...Option) (Token, error) {
 // If the token is signed and you want to verify the payload, you must
 // pass the jwt.WithVerify(alg, key) or jwt.WithVerifyKeySet(jwk.Set) option.
 // If you do not specify these parameters, no verification will be performed.
+//
+// Options that are specific to `jws.Verify`, such as
+// `jws.WithValidAlgorithms`, may be passed as well, and are used
+// when verifying the token. As they have no effect otherwise, an error
+// is returned if they are given without requesting verification.
+// `jws.WithKeySet` is treated the same as jwt.WithKeySet.
 func Parse(src io.Reader, options ...Option) (Token, error) {
 	var params VerifyParameters
 	var keyset *jwk.Set
 	var useDefault bool
+	var insecure bool
 	var token Token
+	var verifyOptions []jws.Option
+	var forwarded []string
 	for _, o := range options {
 		switch o.Name() {
 		case optkeyVerify:
 			params = o.Value().(VerifyParameters)
-		case optkeyKeySet:
+		case optkeyKeySet, jwsOptkeyKeySet:
 			keyset = o.Value().(*jwk.Set)
 		case optkeyToken:
 			token = o.Value().(Token)
 		case optkeyDefault:
 			useDefault = o.Value().(bool)
+		case optkeyInsecureNoSignature:
+			insecure = o.Value().(bool)
+			if insecure {
+				verifyOptions = append(verifyOptions, jws.WithInsecureNoSignature())
+			}
+		default:
+			// Options that are not known to this package, such as
+			// jws.WithValidAlgorithms, are passed to jws.Verify
+			verifyOptions = append(verifyOptions, o)
+			forwarded = append(forwarded, o.Name())
 		}
 	}
 
+	if keyset == nil && params == nil && len(forwarded) > 0 {
+		return nil, errors.Errorf(`options %q require jwt.WithVerify or jwt.WithKeySet`, forwarded)
+	}
+
 	// We're going to need the raw bytes regardless. Read it.
 	data, err := ioutil.ReadAll(src)
 	if err != nil {
@@ -63,22 +91,71 @@ func Parse(src io.Reader, options ...Option) (Token, error) {
 		if err != nil {
 			return nil, errors.Wrap(err, `failed to find matching key for verification`)
 		}
-		return parse(token, data, true, alg, key)
+		return parse(token, data, true, alg, key, insecure, verifyOptions)
 	}
 
 	if params != nil {
-		return parse(token, data, true, params.Algorithm(), params.Key())
+		return parse(token, data, true, params.Algorithm(), params.Key(), insecure, verifyOptions)
+	}
+
+	return parse(token, data, false, "", nil, insecure, nil)
+}
+
+// ParseInsecure decodes the claims of a JWT in compact serialization
+// format WITHOUT VERIFYING ITS SIGNATURE. The claims of the returned
+// token can therefore not be trusted: only use this function for
+// logging, debugging, or to decide how to verify the token (e.g. by
+// looking up the key for its issuer), and then verify the token using
+// `jwt.Parse` with the `jwt.WithVerify` option.
+//
+// The structure of the token is still validated: it must consist of
+// three base64url encoded segments, and its header and claims must be
+// valid JSON. The `jwt.WithToken` option may be used to specify the
+// object that the claims are decoded into
+func ParseInsecure(data []byte, options ...Option) (Token, error) {
+	var token Token
+	for _, o := range options {
+		switch o.Name() {
+		case optkeyToken:
+			token = o.Value().(Token)
+		}
 	}
 
-	return parse(token, data, false, "", nil)
+	parts := bytes.Split(bytes.TrimSpace(data), []byte{'.'})
+	if len(parts) != 3 {
+		return nil, errors.Errorf(`invalid token: expected 3 segments (got %d)`, len(parts))
+	}
+
+	names := [3]string{"header", "claims", "signature"}
+	decoded := make([][]byte, len(parts))
+	for i, part := range parts {
+		v, err := base64.RawURLEncoding.DecodeString(string(part))
+		if err != nil {
+			return nil, errors.Wrapf(err, `invalid token: failed to decode %s`, names[i])
+		}
+		decoded[i] = v
+	}
+
+	var hdr map[string]interface{}
+	if err := json.Unmarshal(decoded[0], &hdr); err != nil {
+		return nil, errors.Wrap(err, `invalid token: failed to parse header`)
+	}
+
+	if token == nil {
+		token = New()
+	}
+	if err := json.Unmarshal(decoded[1], token); err != nil {
+		return nil, errors.Wrap(err, `invalid token: failed to parse claims`)
+	}
+	return token, nil
 }
 
 // verify parameter exists to make sure that we don't accidentally skip
 // over verification just because alg == ""  or key == nil or something.
-func parse(token Token, data []byte, verify bool, alg jwa.SignatureAlgorithm, key interface{}) (Token, error) {
+func parse(token Token, data []byte, verify bool, alg jwa.SignatureAlgorithm, key interface{}, insecure bool, options []jws.Option) (Token, error) {
 	var payload []byte
 	if verify {
-		v, err := jws.Verify(data, alg, key)
+		v, err := jws.Verify(data, alg, key, options...)
 		if err != nil {
 			return nil, errors.Wrap(err, `failed to verify jws signature`)
 		}
@@ -91,6 +168,14 @@ func parse(token Token, data []byte, verify bool, alg jwa.SignatureAlgorithm, ke
 		if err != nil {
 			return nil, errors.Wrap(err, `invalid jws message`)
 		}
+
+		if !insecure {
+			for _, sig := range m.Signatures() {
+				if alg := sig.ProtectedHeaders().Algorithm(); alg == jwa.NoSignature {
+					return nil, errors.Errorf(`refusing to parse token using unsafe algorithm %q (use jwt.WithInsecureNoSignature to allow it)`, alg)
+				}
+			}
+		}
 		payload = m.Payload()
 	}
 
@@ -98,7 +183,7 @@ func parse(token Token, data []byte, verify bool, alg jwa.SignatureAlgorithm, ke
 		token = New()
 	}
 	if err := json.Unmarshal(payload, token); err != nil {
-		return nil, errors.Wrap(err, `failed to parse token`)
+		return nil, errors.Wrap(&jws.ParseError{Segment: jws.SegmentPayload, Cause: err}, `failed to parse token`)
 	}
 	return token, nil
 }
@@ -9,6 +9,7 @@ import (
 	"crypto/rsa"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -85,6 +86,186 @@ func TestJWTParse(t *testing.T) {
 	})
 }
 
+func TestParse_NoSignature(t *testing.T) {
+	// {"alg":"none"}.{"iss":"joe"}.
+	const src = `eyJhbGciOiJub25lIn0.eyJpc3MiOiJqb2UifQ.`
+
+	t.Run("rejected by default", func(t *testing.T) {
+		_, err := jwt.ParseString(src)
+		if !assert.Error(t, err, `jwt.ParseString should fail`) {
+			return
+		}
+		if !assert.Contains(t, err.Error(), `"none"`, `error should name the algorithm`) {
+			return
+		}
+
+		_, err = jwt.ParseString(src, jwt.WithVerify(jwa.NoSignature, nil))
+		if !assert.Error(t, err, `jwt.ParseString should fail`) {
+			return
+		}
+
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if !assert.NoError(t, err, `rsa.GenerateKey should succeed`) {
+			return
+		}
+		_, err = jwt.ParseString(src, jwt.WithVerify(jwa.RS256, &key.PublicKey), jwt.WithInsecureNoSignature())
+		if !assert.Error(t, err, `jwt.ParseString should fail`) {
+			return
+		}
+	})
+	t.Run("explicitly allowed", func(t *testing.T) {
+		for _, options := range [][]jwt.Option{
+			{jwt.WithInsecureNoSignature()},
+			{jwt.WithInsecureNoSignature(), jwt.WithVerify(jwa.NoSignature, nil)},
+		} {
+			token, err := jwt.ParseString(src, options...)
+			if !assert.NoError(t, err, `jwt.ParseString should succeed`) {
+				return
+			}
+			if !assert.Equal(t, "joe", token.Issuer(), `iss should match`) {
+				return
+			}
+		}
+	})
+}
+
+func TestParse_ParseError(t *testing.T) {
+	// {"alg":"none"}.{"iss":.
+	const src = `eyJhbGciOiJub25lIn0.eyJpc3MiOg.`
+
+	_, err := jwt.ParseString(src, jwt.WithInsecureNoSignature())
+	var perr *jws.ParseError
+	if !assert.True(t, errors.As(err, &perr), `error should be a jws.ParseError`) {
+		return
+	}
+	if !assert.Equal(t, jws.SegmentPayload, perr.Segment, `segment should be the payload`) {
+		return
+	}
+}
+
+func TestParse_ValidAlgorithms(t *testing.T) {
+	key := []byte("abracadabra")
+	token := jwt.New()
+	if !assert.NoError(t, token.Set(jwt.IssuerKey, "joe"), `token.Set should succeed`) {
+		return
+	}
+	signed, err := jwt.Sign(token, jwa.HS256, key)
+	if !assert.NoError(t, err, `jwt.Sign should succeed`) {
+		return
+	}
+
+	if _, err := jwt.ParseBytes(signed, jwt.WithVerify(jwa.HS256, key), jws.WithValidAlgorithms(jwa.RS256, jwa.ES256)); !assert.Error(t, err, `jwt.ParseBytes should fail`) {
+		return
+	}
+
+	parsed, err := jwt.ParseBytes(signed, jwt.WithVerify(jwa.HS256, key), jws.WithValidAlgorithms(jwa.HS256))
+	if !assert.NoError(t, err, `jwt.ParseBytes should succeed`) {
+		return
+	}
+	if !assert.Equal(t, "joe", parsed.Issuer(), `iss should match`) {
+		return
+	}
+}
+
+func TestParse_JWSOptions(t *testing.T) {
+	token := jwt.New()
+	if !assert.NoError(t, token.Set(jwt.IssuerKey, "joe"), `token.Set should succeed`) {
+		return
+	}
+	hdrs := jws.NewHeaders()
+	if !assert.NoError(t, hdrs.Set(jws.KeyIDKey, "mykey"), `hdrs.Set should succeed`) {
+		return
+	}
+	signed, err := jwt.Sign(token, jwa.HS256, []byte("abracadabra"), jwt.WithHeaders(hdrs))
+	if !assert.NoError(t, err, `jwt.Sign should succeed`) {
+		return
+	}
+
+	keyset := func(t *testing.T, secret string) *jwk.Set {
+		t.Helper()
+		key, err := jwk.New([]byte(secret))
+		if !assert.NoError(t, err, `jwk.New should succeed`) {
+			return nil
+		}
+		if !assert.NoError(t, key.Set(jwk.KeyIDKey, "mykey"), `key.Set should succeed`) {
+			return nil
+		}
+		return &jwk.Set{Keys: []jwk.Key{key}}
+	}
+
+	t.Run("jws.WithKeySet", func(t *testing.T) {
+		// jws.WithKeySet must not be ignored, as that would return
+		// an unverified token
+		if _, err := jwt.ParseBytes(signed, jws.WithKeySet(keyset(t, "wrong key"))); !assert.Error(t, err, `jwt.ParseBytes should fail`) {
+			return
+		}
+		parsed, err := jwt.ParseBytes(signed, jws.WithKeySet(keyset(t, "abracadabra")))
+		if !assert.NoError(t, err, `jwt.ParseBytes should succeed`) {
+			return
+		}
+		if !assert.Equal(t, "joe", parsed.Issuer(), `iss should match`) {
+			return
+		}
+	})
+	t.Run("Without verification", func(t *testing.T) {
+		if _, err := jwt.ParseBytes(signed, jws.WithValidAlgorithms(jwa.HS256)); !assert.Error(t, err, `jwt.ParseBytes should fail`) {
+			return
+		}
+	})
+}
+
+func TestParseInsecure(t *testing.T) {
+	token := jwt.New()
+	if !assert.NoError(t, token.Set(jwt.IssuerKey, "joe"), `token.Set should succeed`) {
+		return
+	}
+	signed, err := jwt.Sign(token, jwa.HS256, []byte("abracadabra"))
+	if !assert.NoError(t, err, `jwt.Sign should succeed`) {
+		return
+	}
+
+	parsed, err := jwt.ParseInsecure(signed)
+	if !assert.NoError(t, err, `jwt.ParseInsecure should succeed`) {
+		return
+	}
+	if !assert.Equal(t, "joe", parsed.Issuer(), `iss should match`) {
+		return
+	}
+
+	// {"alg":"none"}.{"iss":"joe"}.
+	parsed, err = jwt.ParseInsecure([]byte(`eyJhbGciOiJub25lIn0.eyJpc3MiOiJqb2UifQ.`))
+	if !assert.NoError(t, err, `jwt.ParseInsecure should succeed for unsecured tokens`) {
+		return
+	}
+	if !assert.Equal(t, "joe", parsed.Issuer(), `iss should match`) {
+		return
+	}
+
+	custom := jwt.New()
+	if _, err := jwt.ParseInsecure(signed, jwt.WithToken(custom)); !assert.NoError(t, err, `jwt.ParseInsecure should succeed`) {
+		return
+	}
+	if !assert.Equal(t, "joe", custom.Issuer(), `claims should be decoded into the given token`) {
+		return
+	}
+
+	parts := strings.Split(string(signed), ".")
+	invalid := map[string]string{
+		"two segments":      parts[0] + "." + parts[1],
+		"four segments":     string(signed) + ".",
+		"invalid base64":    parts[0] + ".!!!." + parts[2],
+		"padded base64":     parts[0] + "." + base64.URLEncoding.EncodeToString([]byte(`{"iss":"joe"}`)) + "." + parts[2],
+		"invalid header":    base64.RawURLEncoding.EncodeToString([]byte(`{"alg"`)) + "." + parts[1] + "." + parts[2],
+		"invalid claims":    parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`["joe"]`)) + "." + parts[2],
+		"invalid signature": parts[0] + "." + parts[1] + ".***",
+	}
+	for name, src := range invalid {
+		if _, err := jwt.ParseInsecure([]byte(src)); !assert.Error(t, err, `jwt.ParseInsecure should fail (%s)`, name) {
+			return
+		}
+	}
+}
+
 func TestJWTParseVerify(t *testing.T) {
 	alg := jwa.RS256
 	key, err := rsa.GenerateKey(rand.Reader, 2048)
@@ -273,6 +454,50 @@ func TestVerifyClaims(t *testing.T) {
 			return
 		}
 	})
+	t.Run("skew boundaries", func(t *testing.T) {
+		now := time.Unix(aLongLongTimeAgo, 0).UTC()
+		const skew = 10 * time.Second
+		clockAt := func(tm time.Time) jwt.Option {
+			return jwt.WithClock(jwt.ClockFunc(func() time.Time { return tm }))
+		}
+
+		testcases := []struct {
+			Name    string
+			Claim   string
+			Value   time.Time
+			Clock   time.Time
+			Skew    time.Duration
+			IsValid bool
+		}{
+			{Name: "exp == now", Claim: jwt.ExpirationKey, Value: now, Clock: now, IsValid: false},
+			{Name: "exp == now + 1s", Claim: jwt.ExpirationKey, Value: now.Add(time.Second), Clock: now, IsValid: true},
+			{Name: "exp == now - skew + 1s", Claim: jwt.ExpirationKey, Value: now.Add(-skew + time.Second), Clock: now, Skew: skew, IsValid: true},
+			{Name: "exp == now - skew", Claim: jwt.ExpirationKey, Value: now.Add(-skew), Clock: now, Skew: skew, IsValid: false},
+			{Name: "nbf == now", Claim: jwt.NotBeforeKey, Value: now, Clock: now, IsValid: true},
+			{Name: "nbf == now + 1s", Claim: jwt.NotBeforeKey, Value: now.Add(time.Second), Clock: now, IsValid: false},
+			{Name: "nbf == now + skew", Claim: jwt.NotBeforeKey, Value: now.Add(skew), Clock: now, Skew: skew, IsValid: true},
+			{Name: "nbf == now + skew + 1s", Claim: jwt.NotBeforeKey, Value: now.Add(skew + time.Second), Clock: now, Skew: skew, IsValid: false},
+			{Name: "iat == now + skew", Claim: jwt.IssuedAtKey, Value: now.Add(skew), Clock: now, Skew: skew, IsValid: true},
+			{Name: "iat == now + skew + 1s", Claim: jwt.IssuedAtKey, Value: now.Add(skew + time.Second), Clock: now, Skew: skew, IsValid: false},
+		}
+
+		for _, tc := range testcases {
+			tc := tc
+			t.Run(tc.Name, func(t *testing.T) {
+				token := jwt.New()
+				if !assert.NoError(t, token.Set(tc.Claim, tc.Value), `token.Set should succeed`) {
+					return
+				}
+
+				err := jwt.Verify(token, clockAt(tc.Clock), jwt.WithAcceptableSkew(tc.Skew))
+				if tc.IsValid {
+					assert.NoError(t, err, `jwt.Verify should succeed`)
+				} else {
+					assert.Error(t, err, `jwt.Verify should fail`)
+				}
+			})
+		}
+	})
 }
 
 const aLongLongTimeAgo = 233431200
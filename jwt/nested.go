@@ -0,0 +1,99 @@
+package jwt
+
+import (
+	"bytes"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwe"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrDecryptFailed is returned by `jwt.DecryptVerify` when the
+	// outer JWE message could not be parsed or decrypted. Use
+	// `errors.Is` to check for it
+	ErrDecryptFailed = errors.New(`nested jwt: failed to decrypt`)
+
+	// ErrVerifyFailed is returned by `jwt.DecryptVerify` when the
+	// inner JWS message could not be verified, or when its payload
+	// could not be parsed as a JWT. Use `errors.Is` to check for it
+	ErrVerifyFailed = errors.New(`nested jwt: failed to verify`)
+)
+
+// nestedError associates an error with the stage of
+// `jwt.DecryptVerify` that it occurred in
+type nestedError struct {
+	stage error
+	err   error
+}
+
+func (e *nestedError) Error() string {
+	return e.stage.Error() + `: ` + e.err.Error()
+}
+
+func (e *nestedError) Is(target error) bool {
+	return target == e.stage
+}
+
+func (e *nestedError) Unwrap() error {
+	return e.err
+}
+
+// SignEncrypt creates a nested JWT: the token is first signed as
+// described in `jwt.Sign`, and the resulting JWS message in compact
+// serialization format is then encrypted as a JWE message, also in
+// compact serialization format. The protected header of the JWE
+// message has its "cty" field set to the literal value `JWT`.
+//
+// Options are passed to `jwt.Sign`.
+func SignEncrypt(t Token, signAlg jwa.SignatureAlgorithm, signKey interface{}, encAlg jwa.KeyEncryptionAlgorithm, contentAlg jwa.ContentEncryptionAlgorithm, encKey interface{}, options ...Option) ([]byte, error) {
+	signed, err := Sign(t, signAlg, signKey, options...)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to sign token`)
+	}
+
+	hdrs := jwe.NewHeaders()
+	if err := hdrs.Set(jwe.ContentTypeKey, `JWT`); err != nil {
+		return nil, errors.Wrap(err, `failed to set "cty" header`)
+	}
+
+	encrypted, err := jwe.Encrypt(signed, encAlg, encKey, contentAlg, jwa.NoCompress, jwe.WithProtectedHeaders(hdrs))
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to encrypt signed token`)
+	}
+	return encrypted, nil
+}
+
+// DecryptVerify parses a nested JWT created by `jwt.SignEncrypt`. The
+// outer JWE message is decrypted using the given key, its "cty"
+// header must be `JWT`, and the inner JWS message is then verified
+// using the verification key before the claims are parsed.
+//
+// Errors that occur while decrypting can be detected using
+// `errors.Is(err, jwt.ErrDecryptFailed)`, and errors that occur while
+// verifying can be detected using `errors.Is(err, jwt.ErrVerifyFailed)`.
+//
+// Options are passed to `jwt.Parse` when parsing the inner token,
+// e.g. `jwt.WithToken` or `jws.WithValidAlgorithms`.
+func DecryptVerify(data []byte, encAlg jwa.KeyEncryptionAlgorithm, decKey interface{}, signAlg jwa.SignatureAlgorithm, verifyKey interface{}, options ...Option) (Token, error) {
+	msg, err := jwe.Parse(data)
+	if err != nil {
+		return nil, &nestedError{stage: ErrDecryptFailed, err: errors.Wrap(err, `failed to parse jwe message`)}
+	}
+
+	if !msg.IsNestedJWT() {
+		return nil, &nestedError{stage: ErrDecryptFailed, err: errors.Errorf(`invalid "cty" header for nested jwt: %q`, msg.ProtectedHeaders().ContentType())}
+	}
+
+	payload, err := msg.Decrypt(encAlg, decKey)
+	if err != nil {
+		return nil, &nestedError{stage: ErrDecryptFailed, err: err}
+	}
+
+	parseOptions := append(append([]Option(nil), options...), WithVerify(signAlg, verifyKey))
+	t, err := Parse(bytes.NewReader(payload), parseOptions...)
+	if err != nil {
+		return nil, &nestedError{stage: ErrVerifyFailed, err: err}
+	}
+	return t, nil
+}
@@ -0,0 +1,84 @@
+package jwt_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwe"
+	"github.com/lestrrat-go/jwx/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignEncrypt(t *testing.T) {
+	signKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if !assert.NoError(t, err, `rsa.GenerateKey should succeed`) {
+		return
+	}
+	encKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if !assert.NoError(t, err, `rsa.GenerateKey should succeed`) {
+		return
+	}
+
+	t1 := jwt.New()
+	if !assert.NoError(t, t1.Set(jwt.SubjectKey, "lestrrat"), `t1.Set should succeed`) {
+		return
+	}
+
+	nested, err := jwt.SignEncrypt(t1, jwa.RS256, signKey, jwa.RSA_OAEP, jwa.A128GCM, &encKey.PublicKey)
+	if !assert.NoError(t, err, `jwt.SignEncrypt should succeed`) {
+		return
+	}
+
+	msg, err := jwe.Parse(nested)
+	if !assert.NoError(t, err, `jwe.Parse should succeed`) {
+		return
+	}
+	if !assert.Equal(t, "JWT", msg.ProtectedHeaders().ContentType(), `"cty" should be JWT`) {
+		return
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		t2, err := jwt.DecryptVerify(nested, jwa.RSA_OAEP, encKey, jwa.RS256, &signKey.PublicKey)
+		if !assert.NoError(t, err, `jwt.DecryptVerify should succeed`) {
+			return
+		}
+		if !assert.Equal(t, "lestrrat", t2.Subject(), `"sub" should match`) {
+			return
+		}
+	})
+	t.Run("Wrong decryption key", func(t *testing.T) {
+		_, err := jwt.DecryptVerify(nested, jwa.RSA_OAEP, signKey, jwa.RS256, &signKey.PublicKey)
+		if !assert.True(t, errors.Is(err, jwt.ErrDecryptFailed), `error should be ErrDecryptFailed`) {
+			return
+		}
+		if !assert.False(t, errors.Is(err, jwt.ErrVerifyFailed), `error should not be ErrVerifyFailed`) {
+			return
+		}
+	})
+	t.Run("Wrong verification key", func(t *testing.T) {
+		_, err := jwt.DecryptVerify(nested, jwa.RSA_OAEP, encKey, jwa.RS256, &encKey.PublicKey)
+		if !assert.True(t, errors.Is(err, jwt.ErrVerifyFailed), `error should be ErrVerifyFailed`) {
+			return
+		}
+		if !assert.False(t, errors.Is(err, jwt.ErrDecryptFailed), `error should not be ErrDecryptFailed`) {
+			return
+		}
+	})
+	t.Run("Missing cty", func(t *testing.T) {
+		signed, err := jwt.Sign(t1, jwa.RS256, signKey)
+		if !assert.NoError(t, err, `jwt.Sign should succeed`) {
+			return
+		}
+		encrypted, err := jwe.Encrypt(signed, jwa.RSA_OAEP, &encKey.PublicKey, jwa.A128GCM, jwa.NoCompress)
+		if !assert.NoError(t, err, `jwe.Encrypt should succeed`) {
+			return
+		}
+		_, err = jwt.DecryptVerify(encrypted, jwa.RSA_OAEP, encKey, jwa.RS256, &signKey.PublicKey)
+		if !assert.True(t, errors.Is(err, jwt.ErrDecryptFailed), `error should be ErrDecryptFailed`) {
+			return
+		}
+	})
+}
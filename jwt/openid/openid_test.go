@@ -525,7 +525,17 @@ func TestOpenIDClaims(t *testing.T) {
 			if !assert.NoError(t, err, `v.AsMap should succeed`) {
 				return
 			}
-			if !assert.Equal(t, expected, seen, `values should match`) {
+			// AsMap stores time based claims as NumericDate values
+			expectedMap := make(map[string]interface{})
+			for k, v := range expected {
+				expectedMap[k] = v
+			}
+			for _, k := range []string{openid.ExpirationKey, openid.IssuedAtKey, openid.NotBeforeKey, openid.UpdatedAtKey} {
+				if tv, ok := expectedMap[k].(time.Time); ok {
+					expectedMap[k] = tv.Unix()
+				}
+			}
+			if !assert.Equal(t, expectedMap, seen, `values should match`) {
 				return
 			}
 		})
@@ -75,6 +75,7 @@ type Token interface {
 	PrivateClaims() map[string]interface{}
 	Get(string) (interface{}, bool)
 	Set(string, interface{}) error
+	Remove(string) error
 	Iterate(context.Context) Iterator
 	Walk(context.Context, Visitor) error
 	AsMap(context.Context) (map[string]interface{}, error)
@@ -501,6 +502,66 @@ func (t *stdToken) Set(name string, value interface{}) error {
 	return nil
 }
 
+func (t *stdToken) Remove(name string) error {
+	switch name {
+	case AudienceKey:
+		t.audience = nil
+	case ExpirationKey:
+		t.expiration = nil
+	case IssuedAtKey:
+		t.issuedAt = nil
+	case IssuerKey:
+		t.issuer = nil
+	case JwtIDKey:
+		t.jwtID = nil
+	case NotBeforeKey:
+		t.notBefore = nil
+	case SubjectKey:
+		t.subject = nil
+	case NameKey:
+		t.name = nil
+	case GivenNameKey:
+		t.givenName = nil
+	case MiddleNameKey:
+		t.middleName = nil
+	case FamilyNameKey:
+		t.familyName = nil
+	case NicknameKey:
+		t.nickname = nil
+	case PreferredUsernameKey:
+		t.preferredUsername = nil
+	case ProfileKey:
+		t.profile = nil
+	case PictureKey:
+		t.picture = nil
+	case WebsiteKey:
+		t.website = nil
+	case EmailKey:
+		t.email = nil
+	case EmailVerifiedKey:
+		t.emailVerified = nil
+	case GenderKey:
+		t.gender = nil
+	case BirthdateKey:
+		t.birthdate = nil
+	case ZoneinfoKey:
+		t.zoneinfo = nil
+	case LocaleKey:
+		t.locale = nil
+	case PhoneNumberKey:
+		t.phoneNumber = nil
+	case PhoneNumberVerifiedKey:
+		t.phoneNumberVerified = nil
+	case AddressKey:
+		t.address = nil
+	case UpdatedAtKey:
+		t.updatedAt = nil
+	default:
+		delete(t.privateClaims, name)
+	}
+	return nil
+}
+
 func (t *stdToken) Audience() []string {
 	if t.audience != nil {
 		return t.audience.Get()
@@ -944,6 +1005,24 @@ func (t *stdToken) Walk(ctx context.Context, visitor Visitor) error {
 	return iter.WalkMap(ctx, t, visitor)
 }
 
+// AsMap returns all registered and private claims as a single map.
+// Time based claims are stored in their NumericDate form (number of
+// seconds since the Unix epoch), so that the map can be serialized
+// and parsed back into a token. The map contains exactly the claims
+// visited by Iterate and Walk
 func (t *stdToken) AsMap(ctx context.Context) (map[string]interface{}, error) {
-	return iter.AsMap(ctx, t)
+	m := make(map[string]interface{})
+	for iter := t.Iterate(ctx); iter.Next(ctx); {
+		pair := iter.Pair()
+		key := pair.Key.(string)
+		switch key {
+		case ExpirationKey, IssuedAtKey, NotBeforeKey, UpdatedAtKey:
+			if tv, ok := pair.Value.(time.Time); ok {
+				m[key] = tv.Unix()
+				continue
+			}
+		}
+		m[key] = pair.Value
+	}
+	return m, nil
 }
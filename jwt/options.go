@@ -16,6 +16,8 @@ const (
 	optkeyKeySet  = `keySet`
 	optkeyHeaders = `headers`
 	optkeyDefault = `defaultKey`
+
+	optkeyInsecureNoSignature = `insecureNoSignature`
 )
 
 type VerifyParameters interface {
@@ -82,3 +84,16 @@ func WithOpenIDClaims() Option {
 func WithHeaders(hdrs jws.Headers) Option {
 	return option.New(optkeyHeaders, hdrs)
 }
+
+// WithInsecureNoSignature allows the Parse method to accept unsecured
+// JWTs, i.e. tokens whose protected header declares the "none"
+// algorithm. By default such tokens are rejected, regardless of
+// whether verification was requested or not, as accepting them allows
+// anybody to forge tokens. This option should only be used if you
+// know that the token has been obtained from a trusted source.
+//
+// To verify such tokens, pass `jwt.WithVerify(jwa.NoSignature, nil)`
+// along with this option.
+func WithInsecureNoSignature() Option {
+	return option.New(optkeyInsecureNoSignature, true)
+}
@@ -1 +1,68 @@
 package jwt
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var customFieldsMu sync.RWMutex
+var customFields = map[string]reflect.Type{}
+
+// RegisterCustomField allows users to specify that a private claim
+// should be decoded into an object of the same type as `object`,
+// instead of the generic types used by encoding/json. For example,
+// after the following call
+//
+//	jwt.RegisterCustomField("roles", []string{})
+//
+// `token.Get("roles")` returns a []string for tokens parsed thereafter.
+//
+// The registration is global to the process, and affects all tokens
+// created by this package. It is safe to call this function from
+// multiple goroutines, but it should preferably be called during
+// program initialization. Passing a nil object removes the registration
+func RegisterCustomField(name string, object interface{}) {
+	customFieldsMu.Lock()
+	defer customFieldsMu.Unlock()
+
+	if object == nil {
+		delete(customFields, name)
+		return
+	}
+	customFields[name] = reflect.TypeOf(object)
+}
+
+// decodeCustomFields replaces the values of the private claims in m
+// that have been registered using RegisterCustomField, with the result
+// of decoding them from buf into the registered types
+func decodeCustomFields(buf []byte, m map[string]interface{}) error {
+	customFieldsMu.RLock()
+	defer customFieldsMu.RUnlock()
+
+	if len(customFields) == 0 {
+		return nil
+	}
+
+	var raw map[string]json.RawMessage
+	for name, typ := range customFields {
+		if _, ok := m[name]; !ok {
+			continue
+		}
+
+		if raw == nil {
+			if err := json.Unmarshal(buf, &raw); err != nil {
+				return errors.Wrap(err, `failed to parse private claims`)
+			}
+		}
+
+		rv := reflect.New(typ)
+		if err := json.Unmarshal(raw[name], rv.Interface()); err != nil {
+			return errors.Wrapf(err, `failed to decode value for %s`, name)
+		}
+		m[name] = rv.Elem().Interface()
+	}
+	return nil
+}
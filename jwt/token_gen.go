@@ -49,6 +49,7 @@ type Token interface {
 	PrivateClaims() map[string]interface{}
 	Get(string) (interface{}, bool)
 	Set(string, interface{}) error
+	Remove(string) error
 	Iterate(context.Context) Iterator
 	Walk(context.Context, Visitor) error
 	AsMap(context.Context) (map[string]interface{}, error)
@@ -200,6 +201,28 @@ func (t *stdToken) Set(name string, value interface{}) error {
 	return nil
 }
 
+func (t *stdToken) Remove(name string) error {
+	switch name {
+	case AudienceKey:
+		t.audience = nil
+	case ExpirationKey:
+		t.expiration = nil
+	case IssuedAtKey:
+		t.issuedAt = nil
+	case IssuerKey:
+		t.issuer = nil
+	case JwtIDKey:
+		t.jwtID = nil
+	case NotBeforeKey:
+		t.notBefore = nil
+	case SubjectKey:
+		t.subject = nil
+	default:
+		delete(t.privateClaims, name)
+	}
+	return nil
+}
+
 func (t *stdToken) Audience() []string {
 	if t.audience != nil {
 		return t.audience.Get()
@@ -333,6 +356,9 @@ func (t *stdToken) UnmarshalJSON(buf []byte) error {
 	delete(m, JwtIDKey)
 	delete(m, NotBeforeKey)
 	delete(m, SubjectKey)
+	if err := decodeCustomFields(buf, m); err != nil {
+		return errors.Wrap(err, `failed to decode custom fields`)
+	}
 	t.privateClaims = m
 	return nil
 }
@@ -383,6 +409,24 @@ func (t *stdToken) Walk(ctx context.Context, visitor Visitor) error {
 	return iter.WalkMap(ctx, t, visitor)
 }
 
+// AsMap returns all registered and private claims as a single map.
+// Time based claims are stored in their NumericDate form (number of
+// seconds since the Unix epoch), so that the map can be serialized
+// and parsed back into a token. The map contains exactly the claims
+// visited by Iterate and Walk
 func (t *stdToken) AsMap(ctx context.Context) (map[string]interface{}, error) {
-	return iter.AsMap(ctx, t)
+	m := make(map[string]interface{})
+	for iter := t.Iterate(ctx); iter.Next(ctx); {
+		pair := iter.Pair()
+		key := pair.Key.(string)
+		switch key {
+		case ExpirationKey, IssuedAtKey, NotBeforeKey:
+			if tv, ok := pair.Value.(time.Time); ok {
+				m[key] = tv.Unix()
+				continue
+			}
+		}
+		m[key] = pair.Value
+	}
+	return m, nil
 }
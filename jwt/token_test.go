@@ -182,7 +182,245 @@ func TestToken(t *testing.T) {
 		return
 	}
 
-	if !assert.Equal(t, m, claims, "hash should match") {
+	expected := make(map[string]interface{})
+	for k, v := range claims {
+		expected[k] = v
+	}
+	expected[jwt.ExpirationKey] = int64(tokenTime)
+	expected[jwt.IssuedAtKey] = int64(tokenTime)
+	expected[jwt.NotBeforeKey] = int64(tokenTime)
+	if !assert.Equal(t, expected, m, "hash should match") {
+		return
+	}
+
+	buf, err := json.Marshal(m)
+	if !assert.NoError(t, err, `json.Marshal should succeed`) {
+		return
+	}
+
+	parsed := jwt.New()
+	if !assert.NoError(t, json.Unmarshal(buf, parsed), `json.Unmarshal should succeed`) {
+		return
+	}
+
+	if !assert.Equal(t, tok, parsed, `tokens should match`) {
+		return
+	}
+
+	fromMap := jwt.New()
+	for k, v := range m {
+		if !assert.NoError(t, fromMap.Set(k, v), `fromMap.Set should succeed`) {
+			return
+		}
+	}
+
+	if !assert.Equal(t, tok, fromMap, `tokens should match`) {
+		return
+	}
+}
+
+func TestToken_AsMap(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	empty := jwt.New()
+
+	private := jwt.New()
+	private.Set("myClaim", "hello, world")
+
+	removed := jwt.New()
+	removed.Set(jwt.IssuerKey, "http://www.example.com")
+	removed.Set(jwt.ExpirationKey, expectedTokenTime)
+	removed.Set("myClaim", "hello, world")
+	removed.Remove(jwt.ExpirationKey)
+	removed.Remove("myClaim")
+
+	for name, tok := range map[string]jwt.Token{"empty": empty, "private claims only": private, "removed claims": removed} {
+		tok := tok
+		t.Run(name, func(t *testing.T) {
+			walked := make(map[string]interface{})
+			err := tok.Walk(ctx, jwt.VisitorFunc(func(k string, v interface{}) error {
+				walked[k] = v
+				return nil
+			}))
+			if !assert.NoError(t, err, `Walk should succeed`) {
+				return
+			}
+
+			m, err := tok.AsMap(ctx)
+			if !assert.NoError(t, err, `AsMap should succeed`) {
+				return
+			}
+			if !assert.NotNil(t, m, `AsMap should return a non-nil map`) {
+				return
+			}
+			if !assert.Equal(t, walked, m, `AsMap should contain the same claims as Walk`) {
+				return
+			}
+		})
+	}
+}
+
+func TestToken_TypedClaims(t *testing.T) {
+	const src = `{
+		"aud": "developers",
+		"exp": 233431200,
+		"iat": 233431200.0,
+		"iss": "http://www.example.com",
+		"jti": "e9bc097a-ce51-4036-9562-d2ade882db0d",
+		"nbf": 233431200,
+		"sub": "unit test"
+	}`
+
+	tok := jwt.New()
+	if !assert.NoError(t, json.Unmarshal([]byte(src), tok), `json.Unmarshal should succeed`) {
+		return
+	}
+
+	if !assert.Equal(t, []string{"developers"}, tok.Audience(), `a single "aud" should be returned as a list`) {
+		return
+	}
+	for name, v := range map[string]time.Time{"exp": tok.Expiration(), "iat": tok.IssuedAt(), "nbf": tok.NotBefore()} {
+		if !assert.Equal(t, expectedTokenTime, v, `%q should be converted to time.Time`, name) {
+			return
+		}
+	}
+	if !assert.Equal(t, "http://www.example.com", tok.Issuer(), `"iss" should match`) {
+		return
+	}
+	if !assert.Equal(t, "e9bc097a-ce51-4036-9562-d2ade882db0d", tok.JwtID(), `"jti" should match`) {
+		return
+	}
+	if !assert.Equal(t, "unit test", tok.Subject(), `"sub" should match`) {
+		return
+	}
+
+	empty := jwt.New()
+	if !assert.Empty(t, empty.Audience(), `missing "aud" should be empty`) {
+		return
+	}
+	if !assert.True(t, empty.Expiration().IsZero(), `missing "exp" should be the zero time`) {
+		return
+	}
+	if !assert.Empty(t, empty.Issuer(), `missing "iss" should be empty`) {
+		return
+	}
+}
+
+func TestTokenSetRemove(t *testing.T) {
+	tok := jwt.New()
+	if !assert.NoError(t, tok.Set(jwt.AudienceKey, []string{"developers"}), `tok.Set should succeed`) {
+		return
+	}
+	if !assert.NoError(t, tok.Set(jwt.ExpirationKey, expectedTokenTime), `tok.Set should succeed`) {
+		return
+	}
+	if !assert.NoError(t, tok.Set("secret", "hello, world"), `tok.Set should succeed`) {
+		return
+	}
+
+	t.Run("Invalid types", func(t *testing.T) {
+		if !assert.Error(t, tok.Set(jwt.ExpirationKey, "tomorrow"), `tok.Set should fail for non time-like "exp"`) {
+			return
+		}
+		if !assert.Error(t, tok.Set(jwt.SubjectKey, 42), `tok.Set should fail for non-string "sub"`) {
+			return
+		}
+		if !assert.Equal(t, expectedTokenTime, tok.Expiration(), `"exp" should not change on failure`) {
+			return
+		}
+	})
+	t.Run("Overwrite", func(t *testing.T) {
+		if !assert.NoError(t, tok.Set(jwt.AudienceKey, "secops"), `tok.Set should succeed`) {
+			return
+		}
+		if !assert.Equal(t, []string{"secops"}, tok.Audience(), `"aud" should be overwritten`) {
+			return
+		}
+	})
+	t.Run("Remove", func(t *testing.T) {
+		for _, name := range []string{jwt.AudienceKey, jwt.ExpirationKey, "secret", "nonexistent"} {
+			if !assert.NoError(t, tok.Remove(name), `tok.Remove should succeed`) {
+				return
+			}
+			if _, ok := tok.Get(name); !assert.False(t, ok, `%s should be removed`, name) {
+				return
+			}
+		}
+
+		buf, err := json.Marshal(tok)
+		if !assert.NoError(t, err, `json.Marshal should succeed`) {
+			return
+		}
+		if !assert.Equal(t, `{}`, string(buf), `token should be empty`) {
+			return
+		}
+	})
+}
+
+func TestRegisterCustomField(t *testing.T) {
+	type tenant struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+
+	jwt.RegisterCustomField("roles", []string{})
+	jwt.RegisterCustomField("tenant", &tenant{})
+	defer jwt.RegisterCustomField("roles", nil)
+	defer jwt.RegisterCustomField("tenant", nil)
+
+	const src = `{"iss":"joe","roles":["admin","user"],"tenant":{"id":"t1","name":"Tenant"},"other":["a"]}`
+
+	token := jwt.New()
+	if !assert.NoError(t, json.Unmarshal([]byte(src), token), `json.Unmarshal should succeed`) {
+		return
+	}
+
+	v, ok := token.Get("roles")
+	if !assert.True(t, ok, `token.Get should succeed`) {
+		return
+	}
+	if !assert.Equal(t, []string{"admin", "user"}, v, `roles should be decoded as []string`) {
+		return
+	}
+
+	v, ok = token.Get("tenant")
+	if !assert.True(t, ok, `token.Get should succeed`) {
+		return
+	}
+	if !assert.Equal(t, &tenant{ID: "t1", Name: "Tenant"}, v, `tenant should be decoded as *tenant`) {
+		return
+	}
+
+	v, ok = token.Get("other")
+	if !assert.True(t, ok, `token.Get should succeed`) {
+		return
+	}
+	if !assert.Equal(t, []interface{}{"a"}, v, `unregistered claims should use the generic types`) {
+		return
+	}
+
+	// The registered type must be respected
+	if !assert.Error(t, json.Unmarshal([]byte(`{"roles":"admin"}`), jwt.New()), `json.Unmarshal should fail for values of the wrong type`) {
+		return
+	}
+
+	// Round trip
+	buf, err := json.Marshal(token)
+	if !assert.NoError(t, err, `json.Marshal should succeed`) {
+		return
+	}
+	if !assert.JSONEq(t, src, string(buf), `JSON should match`) {
+		return
+	}
+
+	jwt.RegisterCustomField("roles", nil)
+	token = jwt.New()
+	if !assert.NoError(t, json.Unmarshal([]byte(src), token), `json.Unmarshal should succeed`) {
+		return
+	}
+	v, _ = token.Get("roles")
+	if !assert.Equal(t, []interface{}{"admin", "user"}, v, `roles should use the generic types after unregistering`) {
 		return
 	}
 }
@@ -3,6 +3,7 @@ package jwt
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/lestrrat-go/jwx/internal/option"
@@ -15,37 +16,63 @@ const (
 	optkeySubject        = "subject"
 	optkeyAudience       = "audience"
 	optkeyJwtid          = "jwtid"
+	optkeyClaimValue     = "claimValue"
+	optkeyRequiredClaim  = "requiredClaim"
+	optkeyTokenSeenFunc  = "tokenSeenFunc"
 )
 
+type claimValue struct {
+	name  string
+	value interface{}
+}
+
+// Clock is the interface used to obtain the current time when
+// verifying the time based claims (exp, iat and nbf). The default
+// is the system clock, but users may specify their own Clock via
+// `jwt.WithClock` (e.g. to make tests deterministic)
 type Clock interface {
 	Now() time.Time
 }
+
+// ClockFunc is a function that satisfies the Clock interface
 type ClockFunc func() time.Time
 
+// Now returns the current time, as reported by the function
 func (f ClockFunc) Now() time.Time {
 	return f()
 }
 
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
 // WithClock specifies the `Clock` to be used when verifying
-// claims exp and nbf.
+// claims exp, iat and nbf.
 func WithClock(c Clock) Option {
 	return option.New(optkeyClock, c)
 }
 
-// WithAcceptableSkew specifies the duration in which exp and nbf
-// claims may differ by. This value should be positive
+// WithAcceptableSkew specifies the duration in which exp, iat and nbf
+// claims may differ by. That is, a token is accepted if it expired
+// less than `dur` ago, or if it becomes valid (or was issued) at most
+// `dur` in the future. This value should be positive, and is zero by
+// default
 func WithAcceptableSkew(dur time.Duration) Option {
 	return option.New(optkeyAcceptableSkew, dur)
 }
 
-// WithIssuer specifies that expected issuer value. If not specified,
-// the value of issuer is not verified at all.
+// WithIssuer specifies that expected issuer value. Verify fails if the
+// token does not contain the iss claim, or if its value is not exactly
+// the same. If not specified, the value of issuer is not verified at all.
 func WithIssuer(s string) Option {
 	return option.New(optkeyIssuer, s)
 }
 
-// WithSubject specifies that expected subject value. If not specified,
-// the value of subject is not verified at all.
+// WithSubject specifies that expected subject value. Verify fails if the
+// token does not contain the sub claim, or if its value is not exactly
+// the same. If not specified, the value of subject is not verified at all.
 func WithSubject(s string) Option {
 	return option.New(optkeySubject, s)
 }
@@ -56,17 +83,38 @@ func WithJwtID(s string) Option {
 	return option.New(optkeyJwtid, s)
 }
 
-// WithAudience specifies that expected audience value.
+// WithAudience specifies the acceptable audience values.
 // Verify will return true if one of the values in the `aud` element
-// matches this value.  If not specified, the value of issuer is not
-// verified at all.
-func WithAudience(s string) Option {
+// matches one of these values. If not specified, the value of audience
+// is not verified at all.
+func WithAudience(s ...string) Option {
 	return option.New(optkeyAudience, s)
 }
 
-// WithClaimValue specifies that expected any claim value.
+// WithClaimValue specifies the expected value for an arbitrary claim.
+// Verify fails if the claim is missing, or if its value is not
+// deep-equal (as in `reflect.DeepEqual`) to v
 func WithClaimValue(name string, v interface{}) Option {
-	return option.New(name, v)
+	return option.New(optkeyClaimValue, claimValue{name: name, value: v})
+}
+
+// WithRequiredClaim specifies that the claim identified by `name`
+// must be present in the token. Its value is not verified
+func WithRequiredClaim(name string) Option {
+	return option.New(optkeyRequiredClaim, name)
+}
+
+// WithTokenSeenFunc specifies a function that is used to detect replayed
+// tokens. After all other claims have been verified, the function is
+// called with the value of the jti claim, and must report whether a token
+// with the same ID has already been seen. If it returns true, Verify
+// fails. Tokens without a jti claim are rejected when this option is used.
+//
+// This library does not keep track of the IDs itself: the function is
+// responsible for storing them (e.g. in memory or in a database), and
+// for expiring them once the tokens are no longer valid
+func WithTokenSeenFunc(f func(jti string) bool) Option {
+	return option.New(optkeyTokenSeenFunc, f)
 }
 
 // Verify makes sure that the essential claims stand.
@@ -76,34 +124,43 @@ func WithClaimValue(name string, v interface{}) Option {
 func Verify(t Token, options ...Option) error {
 	var issuer string
 	var subject string
-	var audience string
+	var audiences []string
 	var jwtid string
-	var clock Clock = ClockFunc(time.Now)
+	var clock Clock = systemClock{}
 	var skew time.Duration
-	claimValues := make(map[string]interface{})
+	var claimValues []claimValue
+	var requiredClaims []string
+	var tokenSeen func(string) bool
 	for _, o := range options {
 		switch o.Name() {
 		case optkeyClock:
 			clock = o.Value().(Clock)
 		case optkeyAcceptableSkew:
 			skew = o.Value().(time.Duration)
+			if skew < 0 {
+				skew = -skew
+			}
 		case optkeyIssuer:
 			issuer = o.Value().(string)
 		case optkeySubject:
 			subject = o.Value().(string)
 		case optkeyAudience:
-			audience = o.Value().(string)
+			audiences = append(audiences, o.Value().([]string)...)
 		case optkeyJwtid:
 			jwtid = o.Value().(string)
-		default:
-			claimValues[o.Name()] = o.Value()
+		case optkeyClaimValue:
+			claimValues = append(claimValues, o.Value().(claimValue))
+		case optkeyRequiredClaim:
+			requiredClaims = append(requiredClaims, o.Value().(string))
+		case optkeyTokenSeenFunc:
+			tokenSeen = o.Value().(func(string) bool)
 		}
 	}
 
 	// check for iss
 	if len(issuer) > 0 {
-		if v := t.Issuer(); v != "" && v != issuer {
-			return errors.New(`iss not satisfied`)
+		if err := verifyStringClaim(IssuerKey, t.Issuer(), issuer); err != nil {
+			return err
 		}
 	}
 
@@ -116,18 +173,21 @@ func Verify(t Token, options ...Option) error {
 
 	// check for sub
 	if len(subject) > 0 {
-		if v := t.Subject(); v != "" && v != subject {
-			return errors.New(`sub not satisfied`)
+		if err := verifyStringClaim(SubjectKey, t.Subject(), subject); err != nil {
+			return err
 		}
 	}
 
 	// check for aud
-	if len(audience) > 0 {
+	if len(audiences) > 0 {
 		var found bool
+	OUTER:
 		for _, v := range t.Audience() {
-			if v == audience {
-				found = true
-				break
+			for _, audience := range audiences {
+				if v == audience {
+					found = true
+					break OUTER
+				}
 			}
 		}
 		if !found {
@@ -135,9 +195,11 @@ func Verify(t Token, options ...Option) error {
 		}
 	}
 
+	// All time based claims are compared against the same instant
+	now := clock.Now().Truncate(time.Second)
+
 	// check for exp
 	if tv := t.Expiration(); !tv.IsZero() {
-		now := clock.Now().Truncate(time.Second)
 		ttv := tv.Truncate(time.Second)
 		if !now.Before(ttv.Add(skew)) {
 			return errors.New(`exp not satisfied`)
@@ -146,7 +208,6 @@ func Verify(t Token, options ...Option) error {
 
 	// check for iat
 	if tv := t.IssuedAt(); !tv.IsZero() {
-		now := clock.Now().Truncate(time.Second)
 		ttv := tv.Truncate(time.Second)
 		if now.Before(ttv.Add(-1 * skew)) {
 			return errors.New(`iat not satisfied`)
@@ -155,19 +216,52 @@ func Verify(t Token, options ...Option) error {
 
 	// check for nbf
 	if tv := t.NotBefore(); !tv.IsZero() {
-		now := clock.Now().Truncate(time.Second)
 		ttv := tv.Truncate(time.Second)
-		// now cannot be before t, so we check for now > t - skew
-		if !now.After(ttv.Add(-1 * skew)) {
+		// now cannot be before t, so we check for now >= t - skew
+		if now.Before(ttv.Add(-1 * skew)) {
 			return errors.New(`nbf not satisfied`)
 		}
 	}
 
-	for name, expectedValue := range claimValues {
-		if v, ok := t.Get(name); !ok || v != expectedValue {
-			return fmt.Errorf(`%v not satisfied`, name)
+	for _, name := range requiredClaims {
+		if _, ok := t.Get(name); !ok {
+			return fmt.Errorf(`%v not satisfied: required claim is missing`, name)
 		}
 	}
 
+	for _, cv := range claimValues {
+		v, ok := t.Get(cv.name)
+		if !ok {
+			return fmt.Errorf(`%v not satisfied: claim is missing`, cv.name)
+		}
+		if !reflect.DeepEqual(v, cv.value) {
+			return fmt.Errorf(`%v not satisfied: values do not match`, cv.name)
+		}
+	}
+
+	// check for replays last, so that tokens that fail any of the
+	// other checks are not reported as seen
+	if tokenSeen != nil {
+		jti := t.JwtID()
+		if jti == "" {
+			return errors.New(`jti not satisfied: claim is missing`)
+		}
+		if tokenSeen(jti) {
+			return fmt.Errorf(`jti not satisfied: token %q has already been seen`, jti)
+		}
+	}
+
+	return nil
+}
+
+// verifyStringClaim checks that the claim identified by name is
+// present, and that its value is exactly the expected value
+func verifyStringClaim(name, v, expected string) error {
+	if v == "" {
+		return fmt.Errorf(`%v not satisfied: claim is missing (expected %q)`, name, expected)
+	}
+	if v != expected {
+		return fmt.Errorf(`%v not satisfied: expected %q, got %q`, name, expected, v)
+	}
 	return nil
 }
@@ -1,6 +1,7 @@
 package jwt_test
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -24,7 +25,16 @@ func TestGHIssue10(t *testing.T) {
 			return
 		}
 
-		if !assert.Error(t, jwt.Verify(t1, jwt.WithIssuer("poop")), "t1.Verify should fail") {
+		err := jwt.Verify(t1, jwt.WithIssuer("poop"))
+		if !assert.Error(t, err, "t1.Verify should fail") {
+			return
+		}
+		if !assert.Contains(t, err.Error(), `expected "poop", got "github.com/lestrrat-go/jwx"`, "error should report expected and actual values") {
+			return
+		}
+
+		// This should fail, because the token does not have an iss claim
+		if !assert.Error(t, jwt.Verify(jwt.New(), jwt.WithIssuer("github.com/lestrrat-go/jwx")), "token.Verify should fail") {
 			return
 		}
 	})
@@ -51,6 +61,34 @@ func TestGHIssue10(t *testing.T) {
 		if !assert.Error(t, jwt.Verify(t1, jwt.WithAudience("poop")), "token.Verify should fail") {
 			return
 		}
+
+		// This should succeed, because one of the acceptable values
+		// matches one of the audience values
+		if !assert.NoError(t, jwt.Verify(t1, jwt.WithAudience("poop", "bar")), "token.Verify should succeed") {
+			return
+		}
+
+		if !assert.Error(t, jwt.Verify(t1, jwt.WithAudience("poop", "quux")), "token.Verify should fail") {
+			return
+		}
+	})
+	t.Run(jwt.AudienceKey+" (string)", func(t *testing.T) {
+		t1 := jwt.New()
+		if !assert.NoError(t, json.Unmarshal([]byte(`{"aud":"foo"}`), t1), "json.Unmarshal should succeed") {
+			return
+		}
+
+		if !assert.NoError(t, jwt.Verify(t1, jwt.WithAudience("foo")), "token.Verify should succeed") {
+			return
+		}
+
+		if !assert.NoError(t, jwt.Verify(t1, jwt.WithAudience("bar", "foo")), "token.Verify should succeed") {
+			return
+		}
+
+		if !assert.Error(t, jwt.Verify(t1, jwt.WithAudience("bar")), "token.Verify should fail") {
+			return
+		}
 	})
 	t.Run(jwt.SubjectKey, func(t *testing.T) {
 		t1 := jwt.New()
@@ -67,7 +105,16 @@ func TestGHIssue10(t *testing.T) {
 			return
 		}
 
-		if !assert.Error(t, jwt.Verify(t1, jwt.WithSubject("poop")), "token.Verify should fail") {
+		err := jwt.Verify(t1, jwt.WithSubject("poop"))
+		if !assert.Error(t, err, "token.Verify should fail") {
+			return
+		}
+		if !assert.Contains(t, err.Error(), `expected "poop", got "github.com/lestrrat-go/jwx"`, "error should report expected and actual values") {
+			return
+		}
+
+		// This should fail, because the token does not have a sub claim
+		if !assert.Error(t, jwt.Verify(jwt.New(), jwt.WithSubject("github.com/lestrrat-go/jwx")), "token.Verify should fail") {
 			return
 		}
 	})
@@ -150,4 +197,100 @@ func TestGHIssue10(t *testing.T) {
 			return
 		}
 	})
+	t.Run("required claim", func(t *testing.T) {
+		t1 := jwt.New()
+		t1.Set(jwt.IssuerKey, "github.com/lestrrat-go/jwx")
+		t1.Set("tenant_id", "tenant-1")
+
+		if !assert.NoError(t, jwt.Verify(t1, jwt.WithRequiredClaim("tenant_id"), jwt.WithRequiredClaim(jwt.IssuerKey)), "t1.Verify should succeed") {
+			return
+		}
+
+		err := jwt.Verify(t1, jwt.WithRequiredClaim("tenant_id"), jwt.WithRequiredClaim(jwt.SubjectKey))
+		if !assert.Error(t, err, "t1.Verify should fail") {
+			return
+		}
+		if !assert.Contains(t, err.Error(), jwt.SubjectKey, "error should mention the missing claim") {
+			return
+		}
+	})
+	t.Run("claim value with non-comparable values", func(t *testing.T) {
+		t1 := jwt.New()
+		t1.Set("roles", []interface{}{"admin", "user"})
+
+		if !assert.NoError(t, jwt.Verify(t1, jwt.WithClaimValue("roles", []interface{}{"admin", "user"})), "t1.Verify should succeed") {
+			return
+		}
+
+		if !assert.Error(t, jwt.Verify(t1, jwt.WithClaimValue("roles", []interface{}{"user"})), "t1.Verify should fail") {
+			return
+		}
+
+		// claim names that happen to be the same as other option names
+		// should not interfere with them
+		t1.Set("issuer", "foo")
+		if !assert.NoError(t, jwt.Verify(t1, jwt.WithClaimValue("issuer", "foo")), "t1.Verify should succeed") {
+			return
+		}
+	})
+	t.Run("clock", func(t *testing.T) {
+		tm := time.Unix(233431200, 0)
+		t1 := jwt.New()
+		t1.Set(jwt.IssuedAtKey, tm)
+		t1.Set(jwt.NotBeforeKey, tm)
+		t1.Set(jwt.ExpirationKey, tm.Add(time.Hour))
+
+		// The clock is consulted exactly once, so that all time
+		// based claims are compared against the same instant
+		var calls int
+		clock := jwt.ClockFunc(func() time.Time {
+			calls++
+			return tm.Add(time.Minute)
+		})
+		if !assert.NoError(t, jwt.Verify(t1, jwt.WithClock(clock)), "token.Verify should succeed") {
+			return
+		}
+		if !assert.Equal(t, 1, calls, "clock should be called once") {
+			return
+		}
+
+		if !assert.Error(t, jwt.Verify(t1, jwt.WithClock(jwt.ClockFunc(func() time.Time { return tm.Add(2 * time.Hour) }))), "token.Verify should fail") {
+			return
+		}
+	})
+	t.Run("token seen", func(t *testing.T) {
+		seen := map[string]struct{}{}
+		tokenSeen := jwt.WithTokenSeenFunc(func(jti string) bool {
+			if _, ok := seen[jti]; ok {
+				return true
+			}
+			seen[jti] = struct{}{}
+			return false
+		})
+
+		t1 := jwt.New()
+		t1.Set(jwt.JwtIDKey, "deadbeef")
+
+		if !assert.NoError(t, jwt.Verify(t1, tokenSeen), "token.Verify should succeed") {
+			return
+		}
+		if !assert.Error(t, jwt.Verify(t1, tokenSeen), "token.Verify should fail for a replayed token") {
+			return
+		}
+
+		// Tokens that fail other checks must not be recorded
+		t2 := jwt.New()
+		t2.Set(jwt.JwtIDKey, "cafebabe")
+		t2.Set(jwt.ExpirationKey, time.Now().Add(-time.Hour))
+		if !assert.Error(t, jwt.Verify(t2, tokenSeen), "token.Verify should fail for an expired token") {
+			return
+		}
+		if !assert.NotContains(t, seen, "cafebabe", "expired token should not be recorded") {
+			return
+		}
+
+		if !assert.Error(t, jwt.Verify(jwt.New(), tokenSeen), "token.Verify should fail without jti") {
+			return
+		}
+	})
 }
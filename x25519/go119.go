@@ -0,0 +1,17 @@
+// +build !go1.20
+
+package x25519
+
+import (
+	"github.com/pkg/errors"
+)
+
+var errUnsupported = errors.New(`X25519 requires Go 1.20 or later`)
+
+func scalarBaseMult(_ []byte) ([]byte, error) {
+	return nil, errUnsupported
+}
+
+func scalarMult(_, _ []byte) ([]byte, error) {
+	return nil, errUnsupported
+}
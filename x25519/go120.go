@@ -0,0 +1,27 @@
+// +build go1.20
+
+package x25519
+
+import (
+	"crypto/ecdh"
+)
+
+func scalarBaseMult(seed []byte) ([]byte, error) {
+	priv, err := ecdh.X25519().NewPrivateKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	return priv.PublicKey().Bytes(), nil
+}
+
+func scalarMult(seed, point []byte) ([]byte, error) {
+	priv, err := ecdh.X25519().NewPrivateKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := ecdh.X25519().NewPublicKey(point)
+	if err != nil {
+		return nil, err
+	}
+	return priv.ECDH(pub)
+}
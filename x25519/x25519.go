@@ -0,0 +1,124 @@
+// Package x25519 provides the key types used for X25519 (RFC 7748)
+// key agreement, in a form that mirrors "crypto/ed25519".
+//
+// The actual scalar multiplication is delegated to "crypto/ecdh",
+// which is only available in Go 1.20 and later. On older versions
+// of Go all operations that require it return an error.
+package x25519
+
+import (
+	"bytes"
+	"crypto"
+	cryptorand "crypto/rand"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// PublicKeySize is the size, in bytes, of public keys as used in this package.
+	PublicKeySize = 32
+	// PrivateKeySize is the size, in bytes, of private keys as used in this package.
+	PrivateKeySize = 64
+	// SeedSize is the size, in bytes, of private key seeds. These are the private key representations used by RFC 8037.
+	SeedSize = 32
+)
+
+// PublicKey is the type of X25519 public keys
+type PublicKey []byte
+
+// Equal reports whether pub and x have the same value.
+func (pub PublicKey) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(PublicKey)
+	if !ok {
+		return false
+	}
+	return bytes.Equal(pub, xx)
+}
+
+// PrivateKey is the type of X25519 private key. It consists of the
+// 32 byte seed followed by the 32 byte public key
+type PrivateKey []byte
+
+// Public returns the PublicKey corresponding to priv.
+func (priv PrivateKey) Public() crypto.PublicKey {
+	publicKey := make([]byte, PublicKeySize)
+	copy(publicKey, priv[SeedSize:])
+	return PublicKey(publicKey)
+}
+
+// Equal reports whether priv and x have the same value.
+func (priv PrivateKey) Equal(x crypto.PrivateKey) bool {
+	xx, ok := x.(PrivateKey)
+	if !ok {
+		return false
+	}
+	return bytes.Equal(priv, xx)
+}
+
+// Seed returns the private key seed corresponding to priv. It is provided for
+// interoperability with RFC 7748. RFC 7748's private keys correspond to seeds
+// in this package.
+func (priv PrivateKey) Seed() []byte {
+	seed := make([]byte, SeedSize)
+	copy(seed, priv[:SeedSize])
+	return seed
+}
+
+// NewKeyFromSeed calculates a private key from a seed. It will return
+// an error if len(seed) is not SeedSize. This function is provided for
+// interoperability with RFC 7748. RFC 7748's private keys correspond to
+// seeds in this package.
+func NewKeyFromSeed(seed []byte) (PrivateKey, error) {
+	if l := len(seed); l != SeedSize {
+		return nil, errors.Errorf(`x25519: bad seed length: %d`, l)
+	}
+
+	pub, err := scalarBaseMult(seed)
+	if err != nil {
+		return nil, errors.Wrap(err, `x25519: failed to compute public key`)
+	}
+
+	privateKey := make([]byte, PrivateKeySize)
+	copy(privateKey, seed)
+	copy(privateKey[SeedSize:], pub)
+	return privateKey, nil
+}
+
+// GenerateKey generates a public/private key pair using entropy from rand.
+// If rand is nil, "crypto/rand".Reader will be used.
+func GenerateKey(rand io.Reader) (PublicKey, PrivateKey, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+
+	seed := make([]byte, SeedSize)
+	if _, err := io.ReadFull(rand, seed); err != nil {
+		return nil, nil, errors.Wrap(err, `x25519: failed to read seed`)
+	}
+
+	privateKey, err := NewKeyFromSeed(seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return privateKey.Public().(PublicKey), privateKey, nil
+}
+
+// SharedSecret computes the X25519 shared secret between the private
+// key priv and the peer's public key pub. An error is returned if pub
+// is not a valid X25519 public key, or if the result is the all-zero
+// value (i.e. pub is a low order point)
+func SharedSecret(priv PrivateKey, pub PublicKey) ([]byte, error) {
+	if l := len(priv); l != PrivateKeySize {
+		return nil, errors.Errorf(`x25519: bad private key length: %d`, l)
+	}
+	if l := len(pub); l != PublicKeySize {
+		return nil, errors.Errorf(`x25519: bad public key length: %d`, l)
+	}
+
+	z, err := scalarMult(priv[:SeedSize], pub)
+	if err != nil {
+		return nil, errors.Wrap(err, `x25519: failed to compute shared secret`)
+	}
+	return z, nil
+}
@@ -0,0 +1,64 @@
+package x25519_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/x25519"
+	"github.com/stretchr/testify/assert"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("failed to decode hex: %s", err)
+	}
+	return b
+}
+
+func TestX25519(t *testing.T) {
+	// Test vectors from https://tools.ietf.org/html/rfc7748#section-6.1
+	alicePriv, err := x25519.NewKeyFromSeed(mustHex(t, `77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a`))
+	if !assert.NoError(t, err, `NewKeyFromSeed should succeed`) {
+		return
+	}
+	if !assert.Equal(t, x25519.PublicKey(mustHex(t, `8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a`)), alicePriv.Public(), `public key should match`) {
+		return
+	}
+
+	bobPriv, err := x25519.NewKeyFromSeed(mustHex(t, `5dab087e624a8a4b79e17f8b83800ee66f3bb1292618b6fd1c2f8b27ff88e0eb`))
+	if !assert.NoError(t, err, `NewKeyFromSeed should succeed`) {
+		return
+	}
+	if !assert.Equal(t, x25519.PublicKey(mustHex(t, `de9edb7d7b7dc1b4d35b61c2ece435373f8343c85b78674dadfc7e146f882b4f`)), bobPriv.Public(), `public key should match`) {
+		return
+	}
+
+	expected := mustHex(t, `4a5d9d5ba4ce2de1728e3bf480350f25e07e21c947d19e3376f09b3c1e161742`)
+	z1, err := x25519.SharedSecret(alicePriv, bobPriv.Public().(x25519.PublicKey))
+	if !assert.NoError(t, err, `SharedSecret should succeed`) {
+		return
+	}
+	z2, err := x25519.SharedSecret(bobPriv, alicePriv.Public().(x25519.PublicKey))
+	if !assert.NoError(t, err, `SharedSecret should succeed`) {
+		return
+	}
+	if !assert.Equal(t, expected, z1, `shared secret should match`) {
+		return
+	}
+	if !assert.Equal(t, expected, z2, `shared secret should match`) {
+		return
+	}
+
+	t.Run("invalid public key", func(t *testing.T) {
+		_, err := x25519.SharedSecret(alicePriv, x25519.PublicKey([]byte{0x1, 0x2}))
+		if !assert.Error(t, err, `SharedSecret should fail for short public keys`) {
+			return
+		}
+		_, err = x25519.SharedSecret(alicePriv, make(x25519.PublicKey, x25519.PublicKeySize))
+		if !assert.Error(t, err, `SharedSecret should fail for low order points`) {
+			return
+		}
+	})
+}